@@ -40,8 +40,11 @@ func makebox(s tcell.Screen) {
 	lh := rand.Int() % (h - ly)
 	st := tcell.StyleDefault
 	gl := ' '
-	if s.Colors() > 1 {
-		st = st.Background(tcell.Color(rand.Int() % s.Colors()))
+	if n := s.Colors(); n > 1 {
+		if n > 256 {
+			n = 256
+		}
+		st = st.Background(tcell.Color(rand.Int() % n))
 	} else {
 		st = st.Reverse(rand.Int()%2 == 0)
 		gl = glyphs[rand.Int()%len(glyphs)]
@@ -186,6 +186,9 @@ func main() {
 			if button&tcell.WheelRight != 0 {
 				bstr += " WheelRight"
 			}
+			if ev.Clicks() > 1 {
+				bstr += fmt.Sprintf(" (x%d)", ev.Clicks())
+			}
 			// Only buttons, not wheel events
 			button &= tcell.ButtonMask(0xff)
 			ch := '*'
@@ -0,0 +1,86 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// stress fills the screen with reproducible pseudo-random content, as
+// fast as it can, and reports the frame rate it achieved.  An optional
+// argument gives the seed to use.  Press ESC to exit the program.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+func main() {
+	seed := int64(1)
+	if len(os.Args) > 1 {
+		if n, e := strconv.ParseInt(os.Args[1], 0, 64); e == nil {
+			seed = n
+		}
+	}
+
+	s, e := tcell.NewScreen()
+	if e != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", e)
+		os.Exit(1)
+	}
+	if e = s.Init(); e != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", e)
+		os.Exit(1)
+	}
+	s.Clear()
+
+	quit := make(chan struct{})
+	go func() {
+		for {
+			ev := s.PollEvent()
+			switch ev := ev.(type) {
+			case *tcell.EventKey:
+				switch ev.Key() {
+				case tcell.KeyEscape, tcell.KeyEnter:
+					close(quit)
+					return
+				case tcell.KeyCtrlL:
+					s.Sync()
+				}
+			case *tcell.EventResize:
+				s.Sync()
+			}
+		}
+	}()
+
+	sp := tcell.NewStressPattern(seed)
+	cnt := 0
+	start := time.Now()
+loop:
+	for {
+		select {
+		case <-quit:
+			break loop
+		default:
+		}
+		sp.Draw(s)
+		s.Show()
+		cnt++
+	}
+	dur := time.Now().Sub(start)
+
+	s.Fini()
+	fmt.Printf("Drew %d frames in %s (seed %d)\n", cnt, dur, seed)
+	fmt.Printf("Average is %0.1f frames / sec\n", float64(cnt)/dur.Seconds())
+}
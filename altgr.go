@@ -0,0 +1,100 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AltGrMode selects how characters composed with the right Alt key
+// (AltGr) are told apart from keys pressed with Alt as a modifier.  On
+// many European layouts, characters such as @, { or € are typed with
+// AltGr, and terminals that report Alt with an ESC prefix (or with a
+// modifier parameter, as in CSI u) often report AltGr the same way.
+// Those characters should reach the application as plain runes, and not
+// as Alt shortcuts.
+//
+// The mode used by terminals can be set with the TCELL_ALTGR environment
+// variable, using the names "auto", "off" and "symbols".
+type AltGrMode int
+
+const (
+	// AltGrAuto treats a character as composed with AltGr if it could
+	// not have been typed with Alt on a US layout: that is any character
+	// outside of ASCII, or a printable character other than a letter or
+	// digit that is reported with both Ctrl and Alt (which is how some
+	// terminals report AltGr).  This is the default.
+	AltGrAuto AltGrMode = iota
+
+	// AltGrOff reports the modifiers exactly as the terminal sent them.
+	AltGrOff
+
+	// AltGrSymbols treats every printable character other than ASCII
+	// letters and digits as composed with AltGr, so Alt is only ever
+	// reported with letters and digits.  This suits terminals that send
+	// an ESC prefix for AltGr, for layouts where AltGr produces ASCII
+	// symbols (such as @ on German keyboards).
+	AltGrSymbols
+)
+
+// parseAltGrMode returns the mode with the given name, as used for
+// TCELL_ALTGR.  Unknown names select AltGrAuto.
+func parseAltGrMode(name string) AltGrMode {
+	switch strings.ToLower(name) {
+	case "off":
+		return AltGrOff
+	case "symbols":
+		return AltGrSymbols
+	}
+	return AltGrAuto
+}
+
+// String returns the name of the mode, as used for TCELL_ALTGR.
+func (m AltGrMode) String() string {
+	switch m {
+	case AltGrOff:
+		return "off"
+	case AltGrSymbols:
+		return "symbols"
+	}
+	return "auto"
+}
+
+// composed returns the modifiers to report for the printable rune r,
+// which the terminal reported with mod.  If the rune appears to have
+// been composed with AltGr, then the Alt (and Ctrl) that stand for AltGr
+// are removed.
+func (m AltGrMode) composed(r rune, mod ModMask) ModMask {
+	if mod&ModAlt == 0 || !unicode.IsPrint(r) || r == ' ' {
+		return mod
+	}
+	ascii := r < 0x80
+	alnum := ascii && (unicode.IsLetter(r) || unicode.IsDigit(r))
+	switch m {
+	case AltGrAuto:
+		if !ascii {
+			return mod &^ (ModAlt | ModCtrl)
+		}
+		if mod&ModCtrl != 0 && !alnum {
+			return mod &^ (ModAlt | ModCtrl)
+		}
+	case AltGrSymbols:
+		if !alnum {
+			return mod &^ (ModAlt | ModCtrl)
+		}
+	}
+	return mod
+}
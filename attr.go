@@ -27,6 +27,31 @@ const (
 	AttrUnderline
 	AttrDim
 
+	// AttrInvisible hides the text, as for a password, while still
+	// showing its background (and underline, if any).  Every screen
+	// draws such cells as blanks, rather than relying on the terminal,
+	// so the text cannot be revealed by copying it.
+	AttrInvisible
+
 	// AttrNone is just normal text.
 	AttrNone AttrMask = 0
+
+	// attrMask covers the bits that hold attributes proper; the rest
+	// of the attribute field is used for the underline style.
+	attrMask AttrMask = 0xfff
 )
+
+// UnderlineStyle is the style of an underline.  The values correspond
+// to the parameter of the SGR 4:x sequence.
+type UnderlineStyle int
+
+const (
+	UnderlineSingle UnderlineStyle = iota + 1
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
+// ulStyleShift is where the underline style is kept in a Style.
+const ulStyleShift = 32 + 12
@@ -0,0 +1,77 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// DefaultBlinkRate is the interval used for software blinking when
+// no other rate is specified.  Cells are shown for one interval, and
+// hidden for the next.
+const DefaultBlinkRate = 500 * time.Millisecond
+
+// BlinkScreen is implemented by Screens that can blink cells themselves.
+// All of those in this package do.
+type BlinkScreen interface {
+	// EnableBlink enables software blinking of cells that have the
+	// AttrBlink attribute.  Such cells are alternately shown and hidden
+	// at the given rate, and all of them share the same phase, so that
+	// they blink in unison.  A rate of zero selects DefaultBlinkRate.
+	// Software blinking is enabled automatically on terminals (and
+	// consoles) that lack native blink support, but it can also be
+	// used for terminals whose native blink is absent or unreliable.
+	EnableBlink(rate time.Duration)
+
+	// DisableBlink disables software blinking.  Cells with AttrBlink
+	// will be displayed steadily, unless the terminal supports blinking
+	// natively.
+	DisableBlink()
+
+	Screen
+}
+
+// blinkLoop drives software blinking.  It calls fn once per interval,
+// until stopq is closed, or fn returns false, as it does once nothing is
+// left to blink.  The screen implementations use a separate stopq for
+// each call to EnableBlink, so that a stale loop can detect that it has
+// been superseded.  They start the loop only when they draw a blinking
+// cell, so that screens without any are not woken for nothing.
+func blinkLoop(rate time.Duration, stopq chan struct{}, fn func() bool) {
+	tick := time.NewTicker(rate)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stopq:
+			return
+		case <-tick.C:
+			if !fn() {
+				return
+			}
+		}
+	}
+}
+
+// isBlink returns true if the style has the blink attribute set.
+func isBlink(style Style) bool {
+	_, _, attrs := style.Decompose()
+	return attrs&AttrBlink != 0
+}
+
+// isInvisible returns true if the style has the invisible attribute set.
+func isInvisible(style Style) bool {
+	_, _, attrs := style.Decompose()
+	return attrs&AttrInvisible != 0
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// CapabilityScreen is implemented by Screens that can say which keys the
+// user has, and whether there is a mouse.  All of the screens in this
+// package do so.  Applications can use this to adapt their key bindings
+// and help text, for example to offer letters where the function keys
+// are missing.
+type CapabilityScreen interface {
+	// HasMouse reports whether the display can report mouse events,
+	// once they are enabled.
+	HasMouse() bool
+
+	// HasKey reports whether the display can report the key.  Runes,
+	// and the control keys of ASCII (such as KeyEnter and KeyCtrlA),
+	// can always be typed.  For terminals, the other keys are those
+	// that the terminal's description lists.  A key that has a
+	// sequence need not really be on the user's keyboard, of course.
+	HasKey(Key) bool
+
+	Screen
+}
+
+// hasKey reports whether any of the key sequences known to the parser
+// is for the key.
+func (ip *InputParser) hasKey(k Key) bool {
+	if k <= KeyRune {
+		return true
+	}
+	for _, kc := range ip.keycodes {
+		if kc.key == k {
+			return true
+		}
+	}
+	return false
+}
@@ -14,10 +14,6 @@
 
 package tcell
 
-import (
-	"github.com/mattn/go-runewidth"
-)
-
 // Cell represents a single character cell.  This is primarily intended for
 // use by Screen implementors.
 type Cell struct {
@@ -97,7 +93,7 @@ func (c *Cell) PutChars(ch []rune) {
 			// skip over non-printable control characters
 			continue
 		}
-		switch runewidth.RuneWidth(r) {
+		switch runeWidth(r) {
 		case 1:
 			mainc = r
 			width = 1
@@ -0,0 +1,204 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// CellBuffer is a two dimensional array of Cells, with the same contents
+// and dirty tracking that a Screen keeps, but which is not attached to any
+// display.  It is intended for composing content off-screen (for example
+// by widget libraries), which can then be transferred to a Screen with
+// Blit.  A CellBuffer is not safe for concurrent use.
+type CellBuffer struct {
+	w     int
+	h     int
+	cells []Cell
+}
+
+// NewCellBuffer returns a CellBuffer of the given size, filled with
+// spaces in the default style.  All cells are initially dirty.
+func NewCellBuffer(width, height int) *CellBuffer {
+	cb := &CellBuffer{}
+	cb.Resize(width, height)
+	return cb
+}
+
+// Size returns the width and height of the buffer.
+func (cb *CellBuffer) Size() (int, int) {
+	return cb.w, cb.h
+}
+
+// Resize changes the size of the buffer.  Content that is within both
+// the old and new sizes is preserved, and new cells are spaces in the
+// default style.  All cells are marked dirty.
+func (cb *CellBuffer) Resize(width, height int) {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	if cb.cells == nil || width != cb.w || height != cb.h {
+		cb.cells = ResizeCells(cb.cells, cb.w, cb.h, width, height)
+		for row := 0; row < height; row++ {
+			for col := 0; col < width; col++ {
+				if row >= cb.h || col >= cb.w {
+					c := &cb.cells[(row*width)+col]
+					c.Ch = nil
+					c.Width = 1
+					c.Style = StyleDefault
+				}
+			}
+		}
+		cb.w, cb.h = width, height
+	}
+	InvalidateCells(cb.cells)
+}
+
+// SetContent sets the cell at the given location, just as Screen.SetCell
+// does.  The cell is marked dirty if its contents change.  Locations
+// outside of the buffer are ignored.
+func (cb *CellBuffer) SetContent(x, y int, style Style, ch ...rune) {
+	if c := cb.cell(x, y); c != nil {
+		c.SetCell(ch, style)
+	}
+}
+
+// PutContent stores the contents of the given cell at the given location.
+// The cell is marked dirty if its contents change.
+func (cb *CellBuffer) PutContent(x, y int, cell *Cell) {
+	if c := cb.cell(x, y); c != nil {
+		c.PutStyle(cell.Style)
+		c.PutChars(cell.Ch)
+	}
+}
+
+// GetContent returns a copy of the cell at the given location, or nil if
+// the location is outside of the buffer.
+func (cb *CellBuffer) GetContent(x, y int) *Cell {
+	if c := cb.cell(x, y); c != nil {
+		cell := *c
+		return &cell
+	}
+	return nil
+}
+
+// Fill sets every cell in the buffer to the given rune and style.
+func (cb *CellBuffer) Fill(r rune, style Style) {
+	ch := []rune{r}
+	for i := range cb.cells {
+		cb.cells[i].SetCell(ch, style)
+	}
+}
+
+// Dirty returns true if the cell at the given location has changed since
+// it was last cleaned (see SetDirty and Blit).
+func (cb *CellBuffer) Dirty(x, y int) bool {
+	if c := cb.cell(x, y); c != nil {
+		return c.Dirty
+	}
+	return false
+}
+
+// SetDirty sets or clears the dirty flag on the cell at the given location.
+func (cb *CellBuffer) SetDirty(x, y int, dirty bool) {
+	if c := cb.cell(x, y); c != nil {
+		c.Dirty = dirty
+	}
+}
+
+// Invalidate marks every cell in the buffer dirty.
+func (cb *CellBuffer) Invalidate() {
+	InvalidateCells(cb.cells)
+}
+
+// Blit copies the buffer onto the Screen, with its upper left corner at
+// the given location, and marks every cell in the buffer clean.  Cells
+// that fall outside of the Screen are clipped.  The Screen does its own
+// change tracking, so only cells that actually differ from what it
+// already holds will be redrawn by the next Show.  Screens that implement
+// RegionScreen are given the whole buffer at once.
+func (cb *CellBuffer) Blit(s Screen, x, y int) {
+	if rs, ok := s.(RegionScreen); ok {
+		rs.PutCells(x, y, cb.w, cb.h, cb.cells)
+		cb.clean()
+		return
+	}
+	for row := 0; row < cb.h; row++ {
+		for col := 0; col < cb.w; col++ {
+			c := &cb.cells[(row*cb.w)+col]
+			s.PutCell(x+col, y+row, c)
+			c.Dirty = false
+		}
+	}
+}
+
+// CellUpdate is the new content of one cell, as reported by Diff.
+type CellUpdate struct {
+	X     int
+	Y     int
+	Ch    []rune
+	Style Style
+}
+
+// Diff returns the cells of next that differ from those of prev, in
+// row order.  It allows a producer to keep a CellBuffer, and send only
+// the changes to a renderer elsewhere (which applies them with Apply).
+// If prev is nil, or a different size from next, every cell of next is
+// reported; the renderer should Resize its own buffer to match first.
+// Dirty flags are ignored, and neither buffer is modified.
+func Diff(prev, next *CellBuffer) []CellUpdate {
+	var updates []CellUpdate
+	same := prev != nil && prev.w == next.w && prev.h == next.h
+	for row := 0; row < next.h; row++ {
+		for col := 0; col < next.w; col++ {
+			i := (row * next.w) + col
+			c := &next.cells[i]
+			if same && sameCell(&prev.cells[i], c) {
+				continue
+			}
+			ch := make([]rune, len(c.Ch))
+			copy(ch, c.Ch)
+			updates = append(updates, CellUpdate{
+				X:     col,
+				Y:     row,
+				Ch:    ch,
+				Style: c.Style,
+			})
+		}
+	}
+	return updates
+}
+
+// Apply stores the updates from Diff in the buffer, marking the cells
+// that change dirty.  Updates outside of the buffer are ignored.
+func (cb *CellBuffer) Apply(updates []CellUpdate) {
+	for i := range updates {
+		u := &updates[i]
+		cb.SetContent(u.X, u.Y, u.Style, u.Ch...)
+	}
+}
+
+// clean marks every cell clean.
+func (cb *CellBuffer) clean() {
+	for i := range cb.cells {
+		cb.cells[i].Dirty = false
+	}
+}
+
+func (cb *CellBuffer) cell(x, y int) *Cell {
+	if x < 0 || y < 0 || x >= cb.w || y >= cb.h {
+		return nil
+	}
+	return &cb.cells[(y*cb.w)+x]
+}
@@ -0,0 +1,150 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCellBuffer(t *testing.T) {
+	Convey("Cell buffer", t, func() {
+		cb := NewCellBuffer(10, 5)
+		w, h := cb.Size()
+		So(w, ShouldEqual, 10)
+		So(h, ShouldEqual, 5)
+		So(cb.Dirty(0, 0), ShouldBeTrue)
+		So(cb.GetContent(10, 0), ShouldBeNil)
+
+		st := StyleDefault.Foreground(ColorRed)
+		cb.SetContent(2, 3, st, 'x')
+		c := cb.GetContent(2, 3)
+		So(c, ShouldNotBeNil)
+		So(c.Ch[0], ShouldEqual, 'x')
+		So(c.Style, ShouldEqual, st)
+
+		Convey("Only changes are dirty", func() {
+			cb.SetDirty(2, 3, false)
+			cb.SetContent(2, 3, st, 'x')
+			So(cb.Dirty(2, 3), ShouldBeFalse)
+			cb.SetContent(2, 3, st, 'y')
+			So(cb.Dirty(2, 3), ShouldBeTrue)
+		})
+
+		Convey("Resize preserves content", func() {
+			cb.Resize(20, 4)
+			w, h = cb.Size()
+			So(w, ShouldEqual, 20)
+			So(h, ShouldEqual, 4)
+			So(cb.GetContent(2, 3).Ch[0], ShouldEqual, 'x')
+			So(cb.GetContent(15, 3).Width, ShouldEqual, 1)
+			cb.Resize(2, 2)
+			So(cb.GetContent(2, 3), ShouldBeNil)
+		})
+
+		Convey("Fill", func() {
+			cb.Fill('#', st)
+			So(cb.GetContent(0, 0).Ch[0], ShouldEqual, '#')
+			So(cb.GetContent(9, 4).Style, ShouldEqual, st)
+		})
+
+		Convey("Blit to a screen", WithScreen(t, "", func(s SimulationScreen) {
+			cb.Blit(s, 5, 1)
+			s.Show()
+			b, sw, _ := s.GetContents()
+			So(b[4*sw+7].Runes[0], ShouldEqual, 'x')
+			So(b[4*sw+7].Style, ShouldEqual, st)
+			So(cb.Dirty(2, 3), ShouldBeFalse)
+		}))
+	})
+}
+
+func TestRegion(t *testing.T) {
+	Convey("Regions drawn concurrently", t, WithScreen(t, "", func(s SimulationScreen) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				r := NewRegion(s, i*10, 2, 10, 3)
+				for n := 0; n < 20; n++ {
+					r.Fill(rune('a'+i), StyleDefault)
+					r.SetContent(0, 0, StyleDefault, rune('A'+i))
+					r.Flush()
+				}
+			}(i)
+		}
+		wg.Wait()
+		s.Show()
+		So(regionText(s, 0, 2, 40), ShouldEqual,
+			"AaaaaaaaaaBbbbbbbbbbCcccccccccDddddddddd")
+		So(regionText(s, 0, 4, 40), ShouldEqual,
+			"aaaaaaaaaabbbbbbbbbbccccccccccdddddddddd")
+
+		Convey("Moving redraws the whole region", func() {
+			r := NewRegion(s, 0, 0, 2, 1)
+			r.Flush()
+			r.Move(78, 24)
+			So(r.Dirty(1, 0), ShouldBeTrue)
+			r.Flush()
+			s.Show()
+			So(regionText(s, 78, 24, 2), ShouldEqual, "  ")
+			x, y := r.Origin()
+			So(x, ShouldEqual, 78)
+			So(y, ShouldEqual, 24)
+		})
+	}))
+}
+
+func TestCellBufferDiff(t *testing.T) {
+	Convey("Cell buffer diffs", t, func() {
+		prev := NewCellBuffer(4, 2)
+		next := NewCellBuffer(4, 2)
+
+		Convey("Identical buffers have no updates", func() {
+			So(Diff(prev, next), ShouldBeEmpty)
+		})
+
+		Convey("Changed cells are reported", func() {
+			style := StyleDefault.Bold(true)
+			next.SetContent(1, 0, StyleDefault, 'a')
+			next.SetContent(3, 1, style, 'b')
+			updates := Diff(prev, next)
+			So(len(updates), ShouldEqual, 2)
+			So(updates[0], ShouldResemble, CellUpdate{X: 1, Y: 0, Ch: []rune{'a'}, Style: StyleDefault})
+			So(updates[1], ShouldResemble, CellUpdate{X: 3, Y: 1, Ch: []rune{'b'}, Style: style})
+
+			Convey("And can be applied", func() {
+				for y := 0; y < 2; y++ {
+					for x := 0; x < 4; x++ {
+						prev.SetDirty(x, y, false)
+					}
+				}
+				prev.Apply(updates)
+				So(Diff(prev, next), ShouldBeEmpty)
+				So(prev.Dirty(1, 0), ShouldBeTrue)
+				So(prev.Dirty(0, 0), ShouldBeFalse)
+			})
+		})
+
+		Convey("Resized buffers report everything", func() {
+			So(len(Diff(nil, next)), ShouldEqual, 8)
+			prev.Resize(2, 2)
+			So(len(Diff(prev, next)), ShouldEqual, 8)
+		})
+	})
+}
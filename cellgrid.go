@@ -0,0 +1,288 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// cellGrid holds the cells of a screen.  Rather than a []Cell, with a
+// slice of runes for every cell, it keeps each field of the cells in a
+// slice of its own, and the combining characters (which few cells have)
+// in a side table.  This takes much less memory, and the loops that look
+// only at the dirty flags, as most of them do, touch much less of it.
+// Cells are handed to and from the rest of tcell as Cells, by load, put
+// and putCells.
+//
+// A cell is dirty when it differs from what was last drawn, rather than
+// when it was last written to.  Applications often clear the screen and
+// draw all of it again for every frame, and then only the cells that
+// really changed are drawn.  What was drawn is remembered as a 32-bit key
+// made from the main character and the style (see drawnKey), rather than
+// as a copy of them, which would take twice the memory.
+type cellGrid struct {
+	w     int
+	h     int
+	mainc []rune // the main character, or zero if never written
+	width []uint8
+	style []Style
+	dirty []bool
+	combc map[int][]rune // combining characters, by index
+	drawk []uint32       // the key of what was drawn, or zero if not known
+}
+
+// resize changes the size of the grid, preserving the contents that are
+// within both the old and new sizes.  Those cells are marked dirty, and
+// the new ones are empty and clean, just as with ResizeCells.
+func (g *cellGrid) resize(w, h int) {
+	if w == g.w && h == g.h && g.mainc != nil {
+		return
+	}
+	n := cellGrid{
+		w:     w,
+		h:     h,
+		mainc: make([]rune, w*h),
+		width: make([]uint8, w*h),
+		style: make([]Style, w*h),
+		dirty: make([]bool, w*h),
+		drawk: make([]uint32, w*h),
+	}
+	for row := 0; row < h && row < g.h; row++ {
+		for col := 0; col < w && col < g.w; col++ {
+			oi, ni := (row*g.w)+col, (row*w)+col
+			n.mainc[ni] = g.mainc[oi]
+			n.width[ni] = g.width[oi]
+			n.style[ni] = g.style[oi]
+			n.dirty[ni] = true
+			if cc, ok := g.combc[oi]; ok {
+				n.setComb(ni, cc)
+			}
+		}
+	}
+	*g = n
+}
+
+// clear makes every cell empty, with the given style.
+func (g *cellGrid) clear(style Style) {
+	g.combc = nil
+	for i := range g.mainc {
+		g.mainc[i] = 0
+		g.width[i] = 1
+		g.style[i] = style
+		g.update(i)
+	}
+}
+
+// invalidate marks every cell dirty, as after the display was cleared.
+func (g *cellGrid) invalidate() {
+	for i := range g.dirty {
+		g.touch(i)
+	}
+}
+
+// touch marks the cell at index i dirty, whatever it holds, because what
+// is shown for it is no longer known.
+func (g *cellGrid) touch(i int) {
+	g.dirty[i] = true
+	g.drawk[i] = 0
+}
+
+// drawn records that the cell at index i has been drawn, and so is clean.
+func (g *cellGrid) drawn(i int) {
+	g.dirty[i] = false
+	g.drawk[i] = drawnKey(g.mainc[i], g.style[i])
+	if _, ok := g.combc[i]; ok {
+		// not worth remembering
+		g.drawk[i] = 0
+	}
+}
+
+// drawnWide records that the wide cell at index i has been drawn.  The cell
+// that it covers, at i+1, no longer shows what was drawn there, so that is
+// touched, to be drawn again once it is uncovered.
+func (g *cellGrid) drawnWide(i int) {
+	g.drawn(i)
+	g.touch(i + 1)
+}
+
+// update sets the dirty flag of the cell at index i, according to whether
+// it differs from what was drawn.
+func (g *cellGrid) update(i int) {
+	k := g.drawk[i]
+	g.dirty[i] = k == 0 || k != drawnKey(g.mainc[i], g.style[i])
+}
+
+// drawnKey returns the key that a cell with the main character and the
+// style is remembered by, once drawn.  It is a hash, which is never zero;
+// two cells that differ get the same key only once in 2^32 or so, and
+// then the second is not drawn until something else about it changes.
+func drawnKey(r rune, style Style) uint32 {
+	h := mix64(mix64(uint64(style)) + uint64(uint32(r)))
+	k := uint32(h) ^ uint32(h>>32)
+	if k == 0 {
+		k = 1
+	}
+	return k
+}
+
+// mix64 is the finalizer of MurmurHash3, which mixes the bits of h.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// invalidateBlink marks every cell with the blink attribute dirty, so that
+// it is redrawn when software blinking is turned on or off.  The default
+// style, def, is used for cells that have StyleDefault.
+func (g *cellGrid) invalidateBlink(def Style) {
+	for i, style := range g.style {
+		if style == StyleDefault {
+			style = def
+		}
+		if isBlink(style) {
+			g.touch(i)
+		}
+	}
+}
+
+// anyBlink returns true if any of the cells has the blink attribute, with
+// def used for cells that have StyleDefault.
+func (g *cellGrid) anyBlink(def Style) bool {
+	for _, style := range g.style {
+		if style == StyleDefault {
+			style = def
+		}
+		if isBlink(style) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyDirty returns true if any of the cells in the row is dirty, or, if
+// row is negative, any cell at all.
+func (g *cellGrid) anyDirty(row int) bool {
+	d := g.dirty
+	if row >= 0 {
+		d = d[row*g.w : (row+1)*g.w]
+	}
+	for _, dirty := range d {
+		if dirty {
+			return true
+		}
+	}
+	return false
+}
+
+// load copies the cell at index i into c.  The runes are written to the
+// existing Ch of c, which is how the drawing loops avoid allocating for
+// every cell, so a Cell given to load must not be shared.
+func (g *cellGrid) load(i int, c *Cell) {
+	c.Ch = c.Ch[:0]
+	if g.mainc[i] != 0 {
+		c.Ch = append(c.Ch, g.mainc[i])
+		c.Ch = append(c.Ch, g.combc[i]...)
+	}
+	c.Width = g.width[i]
+	c.Style = g.style[i]
+	c.Dirty = g.dirty[i]
+}
+
+// cell returns a copy of the cell at index i.
+func (g *cellGrid) cell(i int) *Cell {
+	c := &Cell{}
+	g.load(i, c)
+	return c
+}
+
+// put writes the runes and style to the cell at index i, in the same way
+// as Cell.SetCell, and marks it dirty if it differs from what was drawn.
+func (g *cellGrid) put(i int, ch []rune, style Style) {
+	g.putChars(i, ch)
+	g.style[i] = style
+	g.update(i)
+}
+
+// putChars is Cell.PutChars for the cell at index i, except that it
+// leaves the dirty flag to the caller.
+func (g *cellGrid) putChars(i int, ch []rune) {
+	mainc := ' '
+	width := uint8(1)
+	var compc []rune
+	for _, r := range ch {
+		if r < ' ' {
+			// skip over non-printable control characters
+			continue
+		}
+		switch runeWidth(r) {
+		case 1:
+			mainc = r
+			width = 1
+		case 2:
+			mainc = r
+			width = 2
+		case 0:
+			compc = append(compc, r)
+		}
+	}
+	g.mainc[i] = mainc
+	g.width[i] = width
+	g.setComb(i, compc)
+}
+
+// setComb stores the combining characters of the cell at index i.
+func (g *cellGrid) setComb(i int, compc []rune) {
+	if len(compc) == 0 {
+		delete(g.combc, i)
+		return
+	}
+	if g.combc == nil {
+		g.combc = make(map[int][]rune)
+	}
+	g.combc[i] = compc
+}
+
+// putCells stores the cells of the rectangle into the grid, and calls
+// touch for each row in which a cell changed.  It is for implementations
+// of PutCells, which hold their lock while calling it.
+func (g *cellGrid) putCells(x, y, w, h int, src []Cell, touch func(row int)) {
+	for row := 0; row < h; row++ {
+		sy := y + row
+		if sy < 0 || sy >= g.h {
+			continue
+		}
+		for col := 0; col < w; col++ {
+			sx := x + col
+			if sx < 0 || sx >= g.w {
+				continue
+			}
+			i := (sy * g.w) + sx
+			g.put(i, src[row*w+col].Ch, src[row*w+col].Style)
+			if g.dirty[i] && touch != nil {
+				touch(sy)
+			}
+		}
+	}
+}
+
+// contents returns a snapshot of the grid, with the given cursor.
+func (g *cellGrid) contents(cx, cy int) *Contents {
+	c := newContents(nil, g.w, g.h, cx, cy)
+	for i := range c.Cells {
+		g.load(i, &c.Cells[i])
+		c.Cells[i].Dirty = false
+	}
+	return c
+}
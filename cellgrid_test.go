@@ -0,0 +1,131 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+	"unsafe"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCellGrid(t *testing.T) {
+	Convey("Cell grid", t, func() {
+		var g cellGrid
+		g.resize(10, 5)
+		So(g.anyDirty(-1), ShouldBeFalse)
+		So(g.cell(0).Ch, ShouldBeEmpty)
+
+		st := StyleDefault.Foreground(ColorRed)
+		g.put(12, []rune{'e', '́'}, st)
+		So(g.dirty[12], ShouldBeTrue)
+		So(g.anyDirty(1), ShouldBeTrue)
+		So(g.anyDirty(2), ShouldBeFalse)
+		c := g.cell(12)
+		So(string(c.Ch), ShouldEqual, "é")
+		So(c.Style, ShouldEqual, st)
+		So(c.Width, ShouldEqual, 1)
+
+		Convey("Matches a Cell", func() {
+			var ref Cell
+			for _, ch := range [][]rune{
+				{'x'}, {'世'}, {'́'}, {'\x01', 'y'}, nil,
+			} {
+				ref.SetCell(ch, st)
+				g.put(0, ch, st)
+				c := g.cell(0)
+				So(c.Ch, ShouldResemble, ref.Ch)
+				So(c.Width, ShouldEqual, ref.Width)
+			}
+		})
+
+		Convey("Only changes from what was drawn are dirty", func() {
+			g.put(13, []rune{'x'}, st)
+			g.drawn(13)
+			g.put(13, []rune{'x'}, st)
+			So(g.dirty[13], ShouldBeFalse)
+			g.put(13, []rune{'y'}, st)
+			So(g.dirty[13], ShouldBeTrue)
+			g.put(13, []rune{'x'}, st)
+			So(g.dirty[13], ShouldBeFalse)
+
+			g.clear(StyleDefault)
+			So(g.dirty[13], ShouldBeTrue)
+			g.put(13, []rune{'x'}, st)
+			So(g.dirty[13], ShouldBeFalse)
+
+			g.touch(13)
+			g.put(13, []rune{'x'}, st)
+			So(g.dirty[13], ShouldBeTrue)
+		})
+
+		Convey("Combining characters are always dirty", func() {
+			g.drawn(12)
+			g.put(12, []rune{'e', '́'}, st)
+			So(g.dirty[12], ShouldBeTrue)
+			g.put(12, []rune{'e'}, st)
+			So(g.combc, ShouldBeEmpty)
+		})
+
+		Convey("Resize preserves content", func() {
+			g.resize(3, 2)
+			So(string(g.cell(3*1+2).Ch), ShouldEqual, "é")
+			So(g.dirty[3*1+2], ShouldBeTrue)
+			g.resize(20, 20)
+			So(string(g.cell(20*1+2).Ch), ShouldEqual, "é")
+			So(g.cell(20*10+10).Ch, ShouldBeEmpty)
+		})
+
+		Convey("Clear empties every cell", func() {
+			g.clear(st)
+			So(g.cell(12).Ch, ShouldBeEmpty)
+			So(g.combc, ShouldBeEmpty)
+			So(g.style[49], ShouldEqual, st)
+		})
+
+		Convey("Cells take at most half the memory of []Cell", func() {
+			// (not counting the runes that each Cell points to)
+			size := unsafe.Sizeof(g.mainc[0]) + unsafe.Sizeof(g.width[0]) +
+				unsafe.Sizeof(g.style[0]) + unsafe.Sizeof(g.dirty[0]) +
+				unsafe.Sizeof(g.drawk[0])
+			So(size*2 <= unsafe.Sizeof(Cell{}), ShouldBeTrue)
+		})
+
+		Convey("Loading reuses the runes", func() {
+			var c Cell
+			g.load(12, &c)
+			p := &c.Ch[0]
+			g.put(12, []rune{'z'}, st)
+			g.load(12, &c)
+			So(&c.Ch[0], ShouldEqual, p)
+			So(string(c.Ch), ShouldEqual, "z")
+		})
+	})
+}
+
+func BenchmarkCellGridPut(b *testing.B) {
+	var g cellGrid
+	g.resize(200, 60)
+	st := StyleDefault.Foreground(ColorRed)
+	ch := []rune{'x'}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range g.mainc {
+			g.put(i, ch, st)
+			g.drawn(i)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// CloseReason is why the terminal is going away.  (See EventClose.)
+type CloseReason int
+
+const (
+	CloseWindow   CloseReason = iota // the window is being closed
+	CloseLogoff                      // the user is logging off
+	CloseShutdown                    // the system is shutting down
+)
+
+// EventClose is sent when the terminal is about to go away, with the
+// program, so that the program can save its state.  It must do so
+// promptly, and then Fini the screen; what it does after that may not
+// get done.  Only the Windows console sends it, for the console control
+// events CTRL_CLOSE_EVENT, CTRL_LOGOFF_EVENT and CTRL_SHUTDOWN_EVENT.
+// Windows ends the process soon after Fini, or after five seconds,
+// whichever comes first.
+type EventClose struct {
+	t      time.Time
+	reason CloseReason
+}
+
+// NewEventClose creates an EventClose, for the given reason.
+func NewEventClose(reason CloseReason) *EventClose {
+	return &EventClose{t: time.Now(), reason: reason}
+}
+
+func (ev *EventClose) When() time.Time {
+	return ev.t
+}
+
+// Reason returns why the terminal is going away.
+func (ev *EventClose) Reason() CloseReason {
+	return ev.reason
+}
@@ -14,10 +14,17 @@
 
 package tcell
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
 // Color represents a color.  Colors numeric values are taken from the XTerm
 // 256 color map, except that they are offset by one, to allow 0 to indicate
-// the default (unset) color.
-type Color int16
+// the default (unset) color.  A Color can instead hold red, green and blue
+// values, in which case ColorIsRGB is set (see NewHexColor).
+type Color int32
 
 const (
 	// ColorDefault is used to leave the Color unchanged from whatever
@@ -40,3 +47,144 @@ const (
 	ColorBrightCyan
 	ColorBrightWhite
 )
+
+// ColorIsRGB is set in colors that hold red, green and blue values, in
+// their lower 24 bits, rather than an index into the palette.
+const ColorIsRGB Color = 1 << 24
+
+// ErrBadColor is returned by ParseColor for strings that name no color.
+var ErrBadColor = errors.New("unknown color")
+
+// PaletteColor returns the color with the given index (from 0 to 255)
+// in the 256 color palette, or ColorDefault if there is none.
+func PaletteColor(index int) Color {
+	if index < 0 || index > 255 {
+		return ColorDefault
+	}
+	return Color(index + 1)
+}
+
+// NewHexColor returns the color with the red, green and blue values of
+// v, written as 0xRRGGBB.  Unlike NewRGBColor, the values are kept as
+// they are.
+func NewHexColor(v int32) Color {
+	return ColorIsRGB | Color(v&0xffffff)
+}
+
+// IsDefault reports whether c is ColorDefault.
+func (c Color) IsDefault() bool {
+	return c == ColorDefault
+}
+
+// IsRGB reports whether c holds red, green and blue values.
+func (c Color) IsRGB() bool {
+	return c&ColorIsRGB != 0
+}
+
+// Index returns the index of c in the 256 color palette, or -1 if c is
+// ColorDefault or an RGB color.
+func (c Color) Index() int {
+	if c.IsRGB() || c < ColorBlack || c > ColorBlack+255 {
+		return -1
+	}
+	return int(c - ColorBlack)
+}
+
+// Hex returns the red, green and blue values of c, written as 0xRRGGBB,
+// or -1 for ColorDefault.  Palette colors give the values that xterm
+// shows them with.
+func (c Color) Hex() int32 {
+	if c.IsRGB() {
+		return int32(c & 0xffffff)
+	}
+	if c.Index() < 0 {
+		return -1
+	}
+	r, g, b := paletteRGB(c)
+	return int32(r<<16 | g<<8 | b)
+}
+
+// RGB returns the red, green and blue values of c (from 0 to 255), or
+// -1 for each if c is ColorDefault.
+func (c Color) RGB() (r, g, b int) {
+	v := c.Hex()
+	if v < 0 {
+		return -1, -1, -1
+	}
+	return int(v>>16) & 0xff, int(v>>8) & 0xff, int(v) & 0xff
+}
+
+// TrueColor returns c as an RGB color.  ColorDefault stays as it is.
+func (c Color) TrueColor() Color {
+	if v := c.Hex(); v >= 0 {
+		return NewHexColor(v)
+	}
+	return ColorDefault
+}
+
+// Palette returns the palette color that looks most like c.  Palette
+// colors and ColorDefault stay as they are.
+func (c Color) Palette() Color {
+	if !c.IsRGB() {
+		if c.Index() < 0 {
+			return ColorDefault
+		}
+		return c
+	}
+	return NewRGBColor(c.RGB())
+}
+
+// String returns "default", the palette index in decimal, or the red,
+// green and blue values as "#rrggbb".  ParseColor accepts each of these.
+func (c Color) String() string {
+	if c.IsRGB() {
+		s := strconv.FormatInt(int64(c.Hex())|1<<24, 16)
+		return "#" + s[1:]
+	}
+	if i := c.Index(); i >= 0 {
+		return strconv.Itoa(i)
+	}
+	return "default"
+}
+
+// colorNames are the names ParseColor accepts for the first 16 colors.
+var colorNames = map[string]Color{
+	"black":         ColorBlack,
+	"red":           ColorRed,
+	"green":         ColorGreen,
+	"yellow":        ColorYellow,
+	"blue":          ColorBlue,
+	"magenta":       ColorMagenta,
+	"cyan":          ColorCyan,
+	"white":         ColorWhite,
+	"grey":          ColorGrey,
+	"gray":          ColorGrey,
+	"brightred":     ColorBrightRed,
+	"brightgreen":   ColorBrightGreen,
+	"brightyellow":  ColorBrightYellow,
+	"brightblue":    ColorBrightBlue,
+	"brightmagenta": ColorBrightMagenta,
+	"brightcyan":    ColorBrightCyan,
+	"brightwhite":   ColorBrightWhite,
+}
+
+// ParseColor returns the color that s describes: "default", a palette
+// index from 0 to 255, "#rrggbb", or the name of one of the first 16
+// colors, such as "red" or "brightblue".  Case is ignored.
+func ParseColor(s string) (Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "default" {
+		return ColorDefault, nil
+	}
+	if c, ok := colorNames[s]; ok {
+		return c, nil
+	}
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		if v, e := strconv.ParseUint(s[1:], 16, 32); e == nil {
+			return NewHexColor(int32(v)), nil
+		}
+	} else if i, e := strconv.Atoi(s); e == nil && i >= 0 && i <= 255 {
+		return PaletteColor(i), nil
+	}
+	return ColorDefault, ErrBadColor
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"math"
+	"sync"
+)
+
+// ColorDistance returns a measure of how different two colors look,
+// given their red, green and blue values (from 0 to 255).  Only the
+// order of the results matters, not their scale.
+type ColorDistance func(r1, g1, b1, r2, g2, b2 int) int
+
+// EuclideanDistance is the (squared) distance between the colors in RGB
+// space.  It is simple, but it overstates differences in blue, and
+// understates those in green, as the eye sees them.
+func EuclideanDistance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+// RedmeanDistance weighs the differences in red, green and blue by how
+// much the eye notices them, which depends on how red the colors are.
+// This is a cheap approximation of perceptual distance.
+func RedmeanDistance(r1, g1, b1, r2, g2, b2 int) int {
+	rmean := (r1 + r2) / 2
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return ((512+rmean)*dr*dr)>>8 + 4*dg*dg + ((767-rmean)*db*db)>>8
+}
+
+// CIE76Distance is the (squared) distance between the colors in the CIE
+// L*a*b* color space, which was designed so that equal distances look
+// equally different.  This is the default.
+func CIE76Distance(r1, g1, b1, r2, g2, b2 int) int {
+	l1, a1, bb1 := labColor(r1, g1, b1)
+	l2, a2, bb2 := labColor(r2, g2, b2)
+	dl, da, db := l1-l2, a1-a2, bb1-bb2
+	return int((dl*dl + da*da + db*db) * 100)
+}
+
+// labColor converts sRGB values to CIE L*a*b*, with the D65 white point.
+func labColor(r, g, b int) (float64, float64, float64) {
+	linear := func(v int) float64 {
+		c := float64(v) / 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	lr, lg, lb := linear(r), linear(g), linear(b)
+	x := (0.4124*lr + 0.3576*lg + 0.1805*lb) / 0.95047
+	y := 0.2126*lr + 0.7152*lg + 0.0722*lb
+	z := (0.0193*lr + 0.1192*lg + 0.9505*lb) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// NewRGBColor returns the color of the 256 color palette that looks most
+// like the given red, green and blue values (from 0 to 255), so that
+// colors can be given as RGB.  Screens show colors of the palette that
+// the display lacks as the nearest that it has (see ColorMatchScreen), so
+// the result is shown as well as the display can.
+func NewRGBColor(r, g, b int) Color {
+	paletteLabOnce.Do(func() {
+		for i := range paletteLab {
+			pr, pg, pb := paletteRGB(ColorBlack + Color(i))
+			l, a, b := labColor(pr, pg, pb)
+			paletteLab[i] = [3]float64{l, a, b}
+		}
+	})
+	l, a, bb := labColor(r, g, b)
+	best, bestd := ColorBlack, -1.0
+	for i, lab := range paletteLab {
+		dl, da, db := l-lab[0], a-lab[1], bb-lab[2]
+		if d := dl*dl + da*da + db*db; bestd < 0 || d < bestd {
+			best, bestd = ColorBlack+Color(i), d
+		}
+	}
+	return best
+}
+
+// paletteLab holds the L*a*b* values of the 256 color palette.
+var (
+	paletteLab     [256][3]float64
+	paletteLabOnce sync.Once
+)
+
+// ColorMatchScreen is implemented by Screens that show the palette
+// colors that the display lacks by drawing the nearest color that it
+// has.  The Windows console screen does so, for the 256 color palette
+// on the 16 colors of the console, and terminfo screens do so on
+// terminals with 16 or 8 colors.  Choosing the distance can make a theme
+// degrade more gracefully.
+type ColorMatchScreen interface {
+	// SetColorDistance sets the function used to find the nearest
+	// color.  Nil restores the default, CIE76Distance.
+	SetColorDistance(d ColorDistance)
+
+	Screen
+}
+
+// The levels of the 6x6x6 color cube of the 256 color palette.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the red, green and blue values of a color of the
+// 256 color palette, as xterm shows it.
+func paletteRGB(c Color) (int, int, int) {
+	i := int(c) - 1
+	switch {
+	case i < 0 || i > 255:
+		return 0, 0, 0
+	case i < 16:
+		rgb := ansiRGB[i]
+		return rgb[0], rgb[1], rgb[2]
+	case i < 232:
+		i -= 16
+		return cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6]
+	}
+	v := 8 + (i-232)*10
+	return v, v, v
+}
+
+// ansiRGB are the first 16 colors of the palette, as xterm shows them.
+var ansiRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// matchColors returns a table that maps each color of the palette to
+// the nearest of ColorBlack and those that follow it, of which there are
+// as many as there are entries in have, which holds their RGB values.
+// The table is indexed by Color; colors within have map to themselves.
+func matchColors(have [][3]int, dist ColorDistance) []Color {
+	if dist == nil {
+		dist = CIE76Distance
+	}
+	table := make([]Color, 257)
+	for c := range table {
+		if c <= len(have) {
+			table[c] = Color(c)
+			continue
+		}
+		r, g, b := paletteRGB(Color(c))
+		best, bestd := ColorBlack, -1
+		for i, rgb := range have {
+			d := dist(r, g, b, rgb[0], rgb[1], rgb[2])
+			if bestd < 0 || d < bestd {
+				best, bestd = ColorBlack+Color(i), d
+			}
+		}
+		table[c] = best
+	}
+	return table
+}
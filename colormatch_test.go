@@ -0,0 +1,142 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMatchColors(t *testing.T) {
+	Convey("Palette colors", t, func() {
+		rgb := func(c Color) []int {
+			r, g, b := paletteRGB(c)
+			return []int{r, g, b}
+		}
+		So(rgb(ColorRed), ShouldResemble, []int{205, 0, 0})
+		So(rgb(Color(17)), ShouldResemble, []int{0, 0, 0})
+		So(rgb(Color(197)), ShouldResemble, []int{255, 0, 0})
+		So(rgb(Color(232)), ShouldResemble, []int{255, 255, 255})
+		So(rgb(Color(233)), ShouldResemble, []int{8, 8, 8})
+		So(rgb(Color(256)), ShouldResemble, []int{238, 238, 238})
+	})
+
+	Convey("Colors are matched to the nearest", t, func() {
+		have := ansiRGB[:8]
+		table := matchColors(have, nil)
+		So(table, ShouldHaveLength, 257)
+		So(table[ColorDefault], ShouldEqual, ColorDefault)
+		So(table[ColorBlue], ShouldEqual, ColorBlue)
+		So(table[Color(197)], ShouldEqual, ColorRed)   // bright red
+		So(table[Color(22)], ShouldEqual, ColorBlue)   // blue
+		So(table[Color(233)], ShouldEqual, ColorBlack) // near black
+		So(table[Color(256)], ShouldEqual, ColorWhite) // near white
+		So(table[ColorBrightGreen], ShouldEqual, ColorGreen)
+
+		Convey("The distance can be chosen", func() {
+			// everything is as far from everything else
+			same := func(r1, g1, b1, r2, g2, b2 int) int { return 0 }
+			table := matchColors(have, same)
+			So(table[Color(197)], ShouldEqual, ColorBlack)
+		})
+
+		Convey("The distances agree on the obvious", func() {
+			for _, d := range []ColorDistance{EuclideanDistance,
+				RedmeanDistance, CIE76Distance} {
+				So(d(0, 0, 0, 0, 0, 0), ShouldEqual, 0)
+				So(d(0, 0, 0, 255, 255, 255), ShouldBeGreaterThan,
+					d(0, 0, 0, 128, 128, 128))
+			}
+		})
+	})
+
+	Convey("RGB values are matched to the palette", t, func() {
+		So(NewRGBColor(0, 0, 0), ShouldEqual, ColorBlack)
+		So(NewRGBColor(255, 0, 0), ShouldEqual, ColorBrightRed)
+		So(NewRGBColor(0, 95, 135), ShouldEqual, Color(25))
+		So(NewRGBColor(0, 90, 140), ShouldEqual, Color(25))
+		So(NewRGBColor(128, 128, 128), ShouldEqual, Color(245))
+	})
+}
+
+func TestColorForms(t *testing.T) {
+	Convey("Colors know their form", t, func() {
+		So(ColorDefault.IsDefault(), ShouldBeTrue)
+		So(ColorDefault.Index(), ShouldEqual, -1)
+		So(ColorDefault.Hex(), ShouldEqual, -1)
+		So(ColorRed.IsRGB(), ShouldBeFalse)
+		So(ColorRed.Index(), ShouldEqual, 1)
+		So(PaletteColor(34), ShouldEqual, Color(35))
+		So(PaletteColor(256), ShouldEqual, ColorDefault)
+
+		c := NewHexColor(0x336699)
+		So(c.IsRGB(), ShouldBeTrue)
+		So(c.IsDefault(), ShouldBeFalse)
+		So(c.Index(), ShouldEqual, -1)
+		r, g, b := c.RGB()
+		So([]int{r, g, b}, ShouldResemble, []int{0x33, 0x66, 0x99})
+	})
+
+	Convey("Colors convert between forms", t, func() {
+		So(ColorBrightRed.TrueColor(), ShouldEqual, NewHexColor(0xff0000))
+		So(PaletteColor(24).TrueColor(), ShouldEqual, NewHexColor(0x005f87))
+		So(NewHexColor(0x005a8c).Palette(), ShouldEqual, PaletteColor(24))
+		So(ColorGreen.Palette(), ShouldEqual, ColorGreen)
+		So(ColorDefault.TrueColor(), ShouldEqual, ColorDefault)
+		So(ColorDefault.Palette(), ShouldEqual, ColorDefault)
+	})
+
+	Convey("Styles keep RGB colors", t, func() {
+		s := StyleDefault.Foreground(NewHexColor(0xff0000)).
+			Background(NewHexColor(0x005f87)).
+			UnderlineColor(NewHexColor(0x123456))
+		fg, bg, _ := s.Decompose()
+		_, ul := s.DecomposeUnderline()
+		So(fg, ShouldEqual, NewHexColor(0xff0000))
+		So(bg, ShouldEqual, NewHexColor(0x005f87))
+		So(ul, ShouldEqual, NewHexColor(0x123456))
+		So(s, ShouldNotEqual, s.palette())
+
+		fg, bg, _ = s.palette().Decompose()
+		So(fg, ShouldEqual, ColorBrightRed)
+		So(bg, ShouldEqual, PaletteColor(24))
+	})
+
+	Convey("Colors format and parse", t, func() {
+		for _, c := range []Color{ColorDefault, ColorBlack, PaletteColor(34),
+			PaletteColor(255), NewHexColor(0x336699), NewHexColor(0)} {
+			p, e := ParseColor(c.String())
+			So(e, ShouldBeNil)
+			So(p, ShouldEqual, c)
+		}
+		So(PaletteColor(34).String(), ShouldEqual, "34")
+		So(NewHexColor(0x336699).String(), ShouldEqual, "#336699")
+		So(NewHexColor(0x0a).String(), ShouldEqual, "#00000a")
+		So(ColorDefault.String(), ShouldEqual, "default")
+
+		c, e := ParseColor(" BrightBlue ")
+		So(e, ShouldBeNil)
+		So(c, ShouldEqual, ColorBrightBlue)
+		c, e = ParseColor("#FFAA00")
+		So(e, ShouldBeNil)
+		So(c, ShouldEqual, NewHexColor(0xffaa00))
+
+		for _, s := range []string{"", "256", "-1", "#12345", "#12345g", "pink"} {
+			_, e = ParseColor(s)
+			So(e, ShouldEqual, ErrBadColor)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// ColorModel describes how many colors a Screen can show.
+type ColorModel int
+
+// These are the color models, from the poorest to the richest.
+const (
+	// ColorModelNone is a monochrome display.
+	ColorModelNone ColorModel = iota
+
+	// ColorModel8 offers the eight ANSI colors.
+	ColorModel8
+
+	// ColorModel16 adds bright versions of the first eight.
+	ColorModel16
+
+	// ColorModel256 offers the xterm 256 color palette.
+	ColorModel256
+
+	// ColorModelDirect is a display that can show any RGB color
+	// directly, rather than from a palette.
+	ColorModelDirect
+)
+
+// String returns a short name for the model, such as "256" or "direct".
+func (m ColorModel) String() string {
+	switch m {
+	case ColorModelNone:
+		return "none"
+	case ColorModel8:
+		return "8"
+	case ColorModel16:
+		return "16"
+	case ColorModel256:
+		return "256"
+	case ColorModelDirect:
+		return "direct"
+	}
+	return "unknown"
+}
+
+// ColorModelScreen is implemented by Screens that can say more about their
+// colors than the number that Colors returns.  All of the screens in this
+// package do so.  Theming layers can use this to pick between themes,
+// for example to avoid a theme that relies on the terminal's own
+// background color where there is no such thing.
+type ColorModelScreen interface {
+	// ColorModel returns the color model of the display, as it is
+	// drawn.  Displays with ColorModelDirect show the RGB colors of a
+	// Style as they are, and the others show the nearest colors of
+	// their palette.
+	ColorModel() ColorModel
+
+	// DefaultColors reports whether ColorDefault shows the display's
+	// own default colors, which the user may have chosen, and which
+	// need not match any color of the palette.  If not, ColorDefault
+	// is shown as some fixed color of the palette.
+	DefaultColors() bool
+
+	Screen
+}
+
+// paletteModel returns the color model of a palette of n colors, or of a
+// display that shows RGB colors directly, if n is 1<<24.
+func paletteModel(n int) ColorModel {
+	switch {
+	case n >= 1<<24:
+		return ColorModelDirect
+	case n >= 256:
+		return ColorModel256
+	case n >= 16:
+		return ColorModel16
+	case n >= 8:
+		return ColorModel8
+	}
+	return ColorModelNone
+}
+
+// colorModelOf returns the color model of any Screen, working it out from
+// Colors for those that do not say.
+func colorModelOf(s Screen) ColorModel {
+	if cs, ok := s.(ColorModelScreen); ok {
+		return cs.ColorModel()
+	}
+	return paletteModel(s.Colors())
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// ColorScheme identifies whether the terminal is using a dark or a light
+// color theme.
+type ColorScheme int
+
+const (
+	ColorSchemeUnknown ColorScheme = iota
+	ColorSchemeDark
+	ColorSchemeLight
+)
+
+func (cs ColorScheme) String() string {
+	switch cs {
+	case ColorSchemeDark:
+		return "dark"
+	case ColorSchemeLight:
+		return "light"
+	}
+	return "unknown"
+}
+
+// Color scheme reporting, as introduced by Contour and since adopted by
+// other terminals.  While mode 2031 is set, the terminal reports
+// CSI ? 997 ; 1 n (dark) or CSI ? 997 ; 2 n (light) whenever its theme
+// changes, and it sends the same report when queried with CSI ? 996 n.
+// Terminals that do not know about this just ignore these sequences.
+const (
+	colorSchemeOn    = "\x1b[?2031h"
+	colorSchemeOff   = "\x1b[?2031l"
+	colorSchemeQuery = "\x1b[?996n"
+)
+
+// EventColorsChanged is sent when the terminal switches its color theme,
+// for example when the desktop changes between dark and light modes.
+// Applications that pick their colors based on the theme can use this to
+// adjust them on the fly.  One of these is also sent shortly after Init
+// by terminals that support it, reporting the initial theme.
+type EventColorsChanged struct {
+	t      time.Time
+	scheme ColorScheme
+}
+
+// NewEventColorsChanged creates an EventColorsChanged for the given
+// color scheme.
+func NewEventColorsChanged(scheme ColorScheme) *EventColorsChanged {
+	return &EventColorsChanged{t: time.Now(), scheme: scheme}
+}
+
+func (ev *EventColorsChanged) When() time.Time {
+	return ev.t
+}
+
+// Scheme returns the color scheme now in use by the terminal.
+func (ev *EventColorsChanged) Scheme() ColorScheme {
+	return ev.scheme
+}
+
+// Dark returns true if the terminal is now using a dark theme.
+func (ev *EventColorsChanged) Dark() bool {
+	return ev.scheme == ColorSchemeDark
+}
@@ -0,0 +1,59 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// EventComposition reports on text that is being composed, with an input
+// method (as for Chinese, Japanese or Korean) or with dead keys (as for
+// accented letters on many European keyboards), so that applications can
+// show it in place while it is in progress.  One is sent each time the
+// text being composed changes, and a last one when the composition ends,
+// with the text that was committed, if any.
+//
+// The committed text is also delivered as EventKey events, just as if it
+// had been typed, so applications that ignore these events still get it.
+// Only some platforms let us see composition: the Windows console (for
+// dead keys) and browsers.  Terminals compose text themselves, and send
+// only the result.  (See FeatureComposition.)
+type EventComposition struct {
+	t       time.Time
+	preedit string
+	commit  string
+}
+
+// NewEventComposition creates an EventComposition, with the text being
+// composed, or, once it is done, with the text that was committed.
+func NewEventComposition(preedit, commit string) *EventComposition {
+	return &EventComposition{t: time.Now(), preedit: preedit, commit: commit}
+}
+
+func (ev *EventComposition) When() time.Time {
+	return ev.t
+}
+
+// Preedit returns the text being composed, which is empty once the
+// composition has ended.
+func (ev *EventComposition) Preedit() string {
+	return ev.preedit
+}
+
+// Commit returns the text that was committed, when the composition has
+// ended.  It is empty if the composition was cancelled.
+func (ev *EventComposition) Commit() string {
+	return ev.commit
+}
@@ -1,4 +1,4 @@
-// +build !windows
+// +build !windows,!js windows,tcell_noconsole
 
 // Copyright 2015 The TCell Authors
 //
@@ -21,3 +21,7 @@ import "errors"
 func NewConsoleScreen() (Screen, error) {
 	return nil, errors.New("no platform specific console support")
 }
+
+func NewConsoleScreenWithOptions(opts ScreenOptions) (Screen, error) {
+	return NewConsoleScreen()
+}
@@ -1,4 +1,4 @@
-// +build windows
+// +build windows,!tcell_noconsole
 
 // Copyright 2015 The TCell Authors
 //
@@ -19,109 +19,547 @@ package tcell
 import (
 	"sync"
 	"syscall"
+	"time"
 	"unicode/utf16"
 	"unsafe"
 )
 
+// ConsoleScreen is implemented by the Windows console Screen returned
+// by NewConsoleScreen.  It gives advanced applications access to the
+// underlying console handles, and to the console modes that were saved
+// at Init (and which are restored by Fini), so that they can cooperate
+// with tcell.  Callers must not close the handles.
+type ConsoleScreen interface {
+	// ConsoleHandles returns the console input and output handles.
+	// These are only valid between Init and Fini.
+	ConsoleHandles() (in syscall.Handle, out syscall.Handle)
+
+	// SavedConsoleModes returns the input and output console modes that
+	// were in effect prior to Init.
+	SavedConsoleModes() (in uint32, out uint32)
+
+	// SetOutputTranslation controls processed output on the console.
+	// When on, control characters written to the console (such as a bare
+	// newline) are interpreted the way a normal console program would
+	// expect.  When off, which is the default, output is not processed.
+	// This is the analog of the same method on TtyScreen.
+	SetOutputTranslation(on bool) error
+
+	// SetAllocConsole controls what Init does when the process has no
+	// console, as is the case for programs built for the GUI subsystem
+	// (-H windowsgui) that were not started from a console.  Init
+	// always tries to attach to the console of the parent process; if
+	// there is none, and this is on, it creates a new console instead
+	// of failing.  The default is off.  It must be called before Init.
+	// A console that Init attached or created is released by Fini.
+	SetAllocConsole(on bool)
+
+	Screen
+}
+
 type cScreen struct {
-	in    syscall.Handle
-	out   syscall.Handle
-	mbtns uint32 // debounce mouse buttons
-	evch  chan Event
-	quit  chan struct{}
-	curx  int
-	cury  int
-	style Style
-	clear bool
+	in     syscall.Handle
+	out    syscall.Handle
+	mbtns  uint32 // debounce mouse buttons
+	click  clickTracker
+	evch   chan Event
+	filter eventFilter
+	redraw redrawer
+	quit   chan struct{}
+	curx   int
+	cury   int
+	style  Style
+	clear  bool
 
 	w int
 	h int
 
 	oscreen consoleInfo
 	ocursor cursorInfo
+	obuffer []charInfo // what was in the console before Init
 	oimode  uint32
 	oomode  uint32
-	cells   []Cell
+	cells   cellGrid
+	drawc   Cell // scratch space for drawing cells
+
+	blinkoff  bool
+	blinkq    chan struct{}
+	blinkdur  time.Duration
+	blinkrun  bool
+	processed bool
+	stats     renderStats
+	frames    frameLimiter
+	colordist ColorDistance
+	colormap  []Color
+	fini      bool
+	mouseon   bool
+	nocolor   bool
+	allocon   bool   // may allocate a console
+	owncon    bool   // attached or allocated the console
+	surrogate rune   // high surrogate awaiting its low half
+	heldkey   uint16 // virtual key code of the key held down
+	heldreps  int    // how many times it has been posted
+	pasting   bool   // keys are arriving faster than anyone types
+	composing bool   // a dead key awaits the next character
+	opts      ScreenOptions
 
 	sync.Mutex
 }
 
 // all Windows systems are little endian
 var k32 = syscall.NewLazyDLL("kernel32.dll")
+var u32 = syscall.NewLazyDLL("user32.dll")
 
 // Note that Windows appends some functions with W to indicate that wide
 // characters (Unicode) are in use.  The documentation refers to them
 // without this suffix, as the resolution is made via preprocessor.
 var (
-	procReadConsoleInput           = k32.NewProc("ReadConsoleInputW")
-	procGetConsoleCursorInfo       = k32.NewProc("GetConsoleCursorInfo")
-	procSetConsoleCursorInfo       = k32.NewProc("SetConsoleCursorInfo")
-	procSetConsoleCursorPosition   = k32.NewProc("SetConsoleCursorPosition")
-	procSetConsoleMode             = k32.NewProc("SetConsoleMode")
-	procGetConsoleMode             = k32.NewProc("GetConsoleMode")
-	procGetConsoleScreenBufferInfo = k32.NewProc("GetConsoleScreenBufferInfo")
-	procFillConsoleOutputAttribute = k32.NewProc("FillConsoleOutputAttribute")
-	procFillConsoleOutputCharacter = k32.NewProc("FillConsoleOutputCharacterW")
-	procSetConsoleWindowInfo       = k32.NewProc("SetConsoleWindowInfo")
-	procSetConsoleScreenBufferSize = k32.NewProc("SetConsoleScreenBufferSize")
-	procSetConsoleTextAttribute    = k32.NewProc("SetConsoleTextAttribute")
+	procReadConsoleInput              = k32.NewProc("ReadConsoleInputW")
+	procGetConsoleCursorInfo          = k32.NewProc("GetConsoleCursorInfo")
+	procSetConsoleCursorInfo          = k32.NewProc("SetConsoleCursorInfo")
+	procSetConsoleCursorPosition      = k32.NewProc("SetConsoleCursorPosition")
+	procSetConsoleMode                = k32.NewProc("SetConsoleMode")
+	procGetConsoleMode                = k32.NewProc("GetConsoleMode")
+	procGetConsoleScreenBufferInfo    = k32.NewProc("GetConsoleScreenBufferInfo")
+	procFillConsoleOutputAttribute    = k32.NewProc("FillConsoleOutputAttribute")
+	procFillConsoleOutputCharacter    = k32.NewProc("FillConsoleOutputCharacterW")
+	procSetConsoleWindowInfo          = k32.NewProc("SetConsoleWindowInfo")
+	procSetConsoleScreenBufferSize    = k32.NewProc("SetConsoleScreenBufferSize")
+	procSetConsoleTextAttribute       = k32.NewProc("SetConsoleTextAttribute")
+	procAttachConsole                 = k32.NewProc("AttachConsole")
+	procAllocConsole                  = k32.NewProc("AllocConsole")
+	procFreeConsole                   = k32.NewProc("FreeConsole")
+	procSetConsoleCtrlHandler         = k32.NewProc("SetConsoleCtrlHandler")
+	procReadConsoleOutput             = k32.NewProc("ReadConsoleOutputW")
+	procWriteConsoleOutput            = k32.NewProc("WriteConsoleOutputW")
+	procGetNumberOfConsoleInputEvents = k32.NewProc("GetNumberOfConsoleInputEvents")
+	procMapVirtualKey                 = u32.NewProc("MapVirtualKeyW")
+)
+
+// attachParentProcess is the ATTACH_PARENT_PROCESS argument of
+// AttachConsole, which is (DWORD)-1.
+const attachParentProcess = uintptr(^uint32(0))
+
+// Console control events, for the handler set by SetConsoleCtrlHandler.
+// CTRL_C_EVENT does not happen, as processed input is off.
+const (
+	ctrlBreakEvent    = 1
+	ctrlCloseEvent    = 2
+	ctrlLogoffEvent   = 5
+	ctrlShutdownEvent = 6
 )
 
+// closeGrace is how long the console control handler waits for the
+// program to Fini the screen, after sending EventClose.  Windows ends
+// the process when the handler returns, or after five seconds.
+const closeGrace = 4500 * time.Millisecond
+
+// The console control handler is called on a thread of its own, for the
+// screen that is initialized.  (There is only one console.)  Callbacks
+// cannot be freed, so there is just the one.
+var (
+	ctrlHandler     uintptr
+	ctrlHandlerOnce sync.Once
+	ctrlScreen      *cScreen
+	ctrlLock        sync.Mutex
+)
+
+// consoleCtrl handles console control events.  Ctrl+Break, which is
+// always a signal, is sent as the key that it is (KeyCancel with Ctrl),
+// rather than ending the process.  Closing the console is sent as an
+// EventClose, and the process kept alive until the screen is finalized,
+// or for as long as Windows allows.
+func consoleCtrl(ev uintptr) uintptr {
+	ctrlLock.Lock()
+	s := ctrlScreen
+	ctrlLock.Unlock()
+	if s == nil {
+		return 0
+	}
+	var reason CloseReason
+	switch ev {
+	case ctrlBreakEvent:
+		s.postInput(NewEventKey(KeyCancel, 0, ModCtrl))
+		return 1
+	case ctrlCloseEvent:
+		reason = CloseWindow
+	case ctrlLogoffEvent:
+		reason = CloseLogoff
+	case ctrlShutdownEvent:
+		reason = CloseShutdown
+	default:
+		return 0
+	}
+	deadline := time.After(closeGrace)
+	select {
+	case s.evch <- NewEventClose(reason):
+	case <-s.quit:
+		return 1
+	case <-deadline:
+		return 1
+	}
+	select {
+	case <-s.quit:
+	case <-deadline:
+	}
+	return 1
+}
+
+// setCtrlHandler installs or removes the console control handler for
+// the screen.
+func (s *cScreen) setCtrlHandler(on bool) {
+	ctrlHandlerOnce.Do(func() {
+		ctrlHandler = syscall.NewCallback(consoleCtrl)
+	})
+	ctrlLock.Lock()
+	defer ctrlLock.Unlock()
+	switch {
+	case on && ctrlScreen == nil:
+		ctrlScreen = s
+		procSetConsoleCtrlHandler.Call(ctrlHandler, 1)
+	case on:
+		ctrlScreen = s
+	case ctrlScreen == s:
+		ctrlScreen = nil
+		procSetConsoleCtrlHandler.Call(ctrlHandler, 0)
+	}
+}
+
 // We have to bring in the kernel32.dll directly, so we can get access to some
 // system calls that the core Go API lacks.
 
 func NewConsoleScreen() (Screen, error) {
-	return &cScreen{}, nil
+	return NewConsoleScreenWithOptions(ScreenOptions{})
 }
 
+// NewConsoleScreenWithOptions is NewConsoleScreen, configured by the
+// options.  Only NoAltScreen, Mouse, MouseFlags, EventQueue and NoColor
+// apply to the console.
+func NewConsoleScreenWithOptions(opts ScreenOptions) (Screen, error) {
+	if e := opts.check(); e != nil {
+		return nil, e
+	}
+	s := &cScreen{fini: true, opts: opts}
+	s.nocolor = opts.NoColor || noColorEnv()
+	s.mouseon = opts.Mouse && mouseSupport
+	return s, nil
+}
+
+// Init may be called again after Fini; while the screen is initialized,
+// it does nothing.
 func (s *cScreen) Init() error {
+	s.Lock()
+	running := !s.fini
+	s.Unlock()
+	if running {
+		return nil
+	}
 
-	s.evch = make(chan Event, 2)
+	s.evch = make(chan Event, s.opts.queueLen())
 	s.quit = make(chan struct{})
 
-	if in, e := syscall.Open("CONIN$", syscall.O_RDWR, 0); e != nil {
-		return e
-	} else {
-		s.in = in
+	// In mintty (without ConPTY) we would draw on a hidden console.
+	if inCygwinPty() {
+		return ErrCygwinPty
 	}
-	if out, e := syscall.Open("CONOUT$", syscall.O_RDWR, 0); e != nil {
-		syscall.Close(s.in)
+	if e := s.openConsole(); e != nil {
 		return e
-	} else {
-		s.out = out
 	}
 
 	s.curx = -1
 	s.cury = -1
 	s.getCursorInfo(&s.ocursor)
 	s.getConsoleInfo(&s.oscreen)
+	s.obuffer = nil
+	if !s.opts.NoAltScreen {
+		s.obuffer = s.saveBuffer()
+	}
 	s.getOutMode(&s.oomode)
 	s.getInMode(&s.oimode)
 	s.resize()
 
-	s.setInMode(modeResizeEn)
-	s.setOutMode(0)
+	if s.mouseon {
+		s.setInMode(modeResizeEn | modeMouseEn)
+	} else {
+		s.setInMode(modeResizeEn)
+	}
+	if s.processed {
+		s.setOutMode(modeCooked)
+	} else {
+		s.setOutMode(0)
+	}
 	s.clearScreen(s.style)
 	s.hideCursor()
-	go s.scanInput()
+
+	// The console has no blink attribute, so we emulate it.
+	s.Lock()
+	s.fini = false
+	s.enableBlink(DefaultBlinkRate)
+	s.Unlock()
+
+	s.setCtrlHandler(true)
+	go s.scanInput(s.quit)
 
 	return nil
 }
 
+// openConsole opens the console input and output.  If the process has
+// no console, it borrows that of its parent process, or, if allowed,
+// creates one.
+func (s *cScreen) openConsole() error {
+	in, e := syscall.Open("CONIN$", syscall.O_RDWR, 0)
+	if e != nil {
+		if rv, _, _ := procAttachConsole.Call(attachParentProcess); rv == 0 {
+			if !s.allocon {
+				return e
+			}
+			if rv, _, e := procAllocConsole.Call(); rv == 0 {
+				return e
+			}
+		}
+		s.owncon = true
+		if in, e = syscall.Open("CONIN$", syscall.O_RDWR, 0); e != nil {
+			s.freeConsole()
+			return e
+		}
+	}
+	out, e := syscall.Open("CONOUT$", syscall.O_RDWR, 0)
+	if e != nil {
+		syscall.Close(in)
+		s.freeConsole()
+		return e
+	}
+	s.in = in
+	s.out = out
+	return nil
+}
+
+// freeConsole releases the console, if we attached or allocated it.
+func (s *cScreen) freeConsole() {
+	if s.owncon {
+		procFreeConsole.Call()
+		s.owncon = false
+	}
+}
+
+func (s *cScreen) SetAllocConsole(on bool) {
+	s.Lock()
+	s.allocon = on
+	s.Unlock()
+}
+
+func (s *cScreen) ConsoleHandles() (syscall.Handle, syscall.Handle) {
+	s.Lock()
+	in, out := s.in, s.out
+	s.Unlock()
+	return in, out
+}
+
+func (s *cScreen) SavedConsoleModes() (uint32, uint32) {
+	s.Lock()
+	in, out := s.oimode, s.oomode
+	s.Unlock()
+	return in, out
+}
+
+func (s *cScreen) SetOutputTranslation(on bool) error {
+	s.Lock()
+	defer s.Unlock()
+	s.processed = on
+	if s.out == 0 {
+		return nil
+	}
+	if on {
+		return s.setOutMode(modeCooked)
+	}
+	return s.setOutMode(0)
+}
+
+func (s *cScreen) EnableRenderStats(on bool) {
+	s.Lock()
+	s.stats.enable(on)
+	s.Unlock()
+}
+
+func (s *cScreen) RenderStats() RenderStats {
+	s.Lock()
+	defer s.Unlock()
+	return s.stats.stats
+}
+
+func (s *cScreen) SetAllocBudget(allocs uint64) {
+	s.Lock()
+	s.stats.budget = allocs
+	s.Unlock()
+}
+
 func (s *cScreen) CharacterSet() string {
 	// We are always UTF-16LE on Windows
 	return "UTF-16LE"
 }
 
-func (s *cScreen) EnableMouse() {
-	s.setInMode(modeResizeEn | modeMouseEn)
+func (s *cScreen) EnableMouse(...MouseFlags) {
+	if mouseSupport {
+		s.Lock()
+		s.mouseon = true
+		s.Unlock()
+		s.setInMode(modeResizeEn | modeMouseEn)
+	}
 }
 
 func (s *cScreen) DisableMouse() {
+	s.Lock()
+	s.mouseon = false
+	s.Unlock()
 	s.setInMode(modeResizeEn)
 }
 
+func (s *cScreen) Features() []Feature {
+	s.Lock()
+	defer s.Unlock()
+	fs := newFeatureSet()
+	if s.nocolor {
+		fs.set(FeatureColor, false, "turned off")
+	} else {
+		fs.set(FeatureColor, true, "16 colors")
+		fs.set(FeatureTrueColor, false, "16 color palette")
+	}
+	fs.set(FeatureBold, true, "bright foreground")
+	fs.set(FeatureDim, true, "normal foreground")
+	fs.set(FeatureUnderline, true, "")
+	fs.set(FeatureUnderlineStyles, false, "single underline")
+	fs.set(FeatureReverse, true, "")
+	if s.blinkq != nil {
+		fs.set(FeatureBlink, true, "software")
+	}
+	fs.set(FeatureUnicode, true, "UTF-16LE")
+	fs.set(FeatureLineDrawing, true, "unicode")
+	switch {
+	case !mouseSupport:
+		fs.set(FeatureMouse, false, "not built in")
+	case s.mouseon:
+		fs.set(FeatureMouse, true, "console input")
+	default:
+		fs.set(FeatureMouse, false, "off")
+	}
+	fs.set(FeatureComposition, true, "dead keys")
+	fs.set(FeatureResize, true, "")
+	return fs.list()
+}
+
+func (s *cScreen) SetNoColor(on bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.nocolor = on
+	s.clear = true
+	s.cells.invalidate()
+}
+
+func (s *cScreen) EnableBlink(rate time.Duration) {
+	s.Lock()
+	s.enableBlink(rate)
+	s.Unlock()
+}
+
+func (s *cScreen) enableBlink(rate time.Duration) {
+	if rate <= 0 {
+		rate = DefaultBlinkRate
+	}
+	if s.blinkq != nil {
+		close(s.blinkq)
+	}
+	s.blinkq = make(chan struct{})
+	s.blinkdur = rate
+	s.blinkrun = false
+	s.blinkoff = false
+	s.cells.invalidateBlink(s.style)
+}
+
+// startBlink starts the software blink loop, when a blinking cell is
+// drawn, unless it is already running.
+func (s *cScreen) startBlink() {
+	if s.blinkrun {
+		return
+	}
+	s.blinkrun = true
+	q := s.blinkq
+	go blinkLoop(s.blinkdur, q, func() bool { return s.blink(q) })
+}
+
+func (s *cScreen) DisableBlink() {
+	s.Lock()
+	if s.blinkq != nil {
+		close(s.blinkq)
+		s.blinkq = nil
+		s.blinkrun = false
+		s.blinkoff = false
+		s.cells.invalidateBlink(s.style)
+	}
+	s.Unlock()
+}
+
+// blink toggles the software blink phase, redrawing only those blinking
+// cells that are not already waiting for the next Show.  It returns false,
+// stopping the loop, once no cell blinks; drawing one starts it again.
+func (s *cScreen) blink(q chan struct{}) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.blinkq != q {
+		return false
+	}
+	if !s.cells.anyBlink(s.style) {
+		s.blinkrun = false
+		s.blinkoff = false
+		return false
+	}
+	s.blinkoff = !s.blinkoff
+	s.hideCursor()
+	for row := 0; row < s.h; row++ {
+		for col := 0; col < s.w; col++ {
+			i := (row * s.w) + col
+			style := s.cells.style[i]
+			if style == StyleDefault {
+				style = s.style
+			}
+			if s.cells.dirty[i] || !isBlink(style) {
+				continue
+			}
+			cell := &s.drawc
+			s.cells.load(i, cell)
+			cell.Style = style
+			width := int(cell.Width)
+			if width < 1 {
+				width = 1
+			}
+			var wcs []uint16
+			if s.blinkoff {
+				for i := 0; i < width; i++ {
+					wcs = append(wcs, uint16(' '))
+				}
+			} else if len(cell.Ch) < 1 {
+				wcs = append(wcs, uint16(' '))
+			} else {
+				wcs = utf16.Encode(cell.Ch)
+			}
+			s.writeString(col, row, cell.Style, wcs)
+			col += width - 1
+		}
+	}
+	s.doCursor()
+	return true
+}
+
+// Fini may be called more than once, and before Init; only the first
+// call after Init does anything.
 func (s *cScreen) Fini() {
+	s.DisableBlink()
+	s.Lock()
+	defer s.Unlock()
+	if s.fini {
+		return
+	}
+	s.fini = true
+	s.frames.stop()
 	s.style = StyleDefault
 	s.curx = -1
 	s.cury = -1
@@ -130,33 +568,66 @@ func (s *cScreen) Fini() {
 	s.setInMode(s.oimode)
 	s.setOutMode(s.oomode)
 	s.setBufferSize(int(s.oscreen.size.x), int(s.oscreen.size.y))
-	s.clearScreen(StyleDefault)
-	s.setCursorPos(0, 0)
+	switch {
+	case s.obuffer != nil:
+		s.restoreBuffer(s.obuffer)
+		s.obuffer = nil
+		procSetConsoleWindowInfo.Call(
+			uintptr(s.out),
+			uintptr(1),
+			uintptr(unsafe.Pointer(&s.oscreen.win)))
+		s.setCursorPos(int(s.oscreen.pos.x), int(s.oscreen.pos.y))
+	case s.opts.NoAltScreen:
+		// leave the drawing, with the cursor below it
+		s.setCursorPos(0, s.h)
+	default:
+		s.clearScreen(StyleDefault)
+		s.setCursorPos(0, 0)
+	}
 	procSetConsoleTextAttribute.Call(
 		uintptr(s.out),
-		uintptr(mapStyle(StyleDefault)))
+		uintptr(s.oscreen.attrs))
 
 	close(s.quit)
+	s.setCtrlHandler(false)
 	syscall.Close(s.in)
 	syscall.Close(s.out)
+	s.freeConsole()
 }
 
+// PostEvent never blocks, so that it can be called from any goroutine,
+// including the one polling for events, and with the lock held.  If the
+// queue is full, the event is dropped.
 func (s *cScreen) PostEvent(ev Event) {
 	select {
-	case <-s.quit:
 	case s.evch <- ev:
+	default:
+		// drop the event on the floor
 	}
 }
 
-func (s *cScreen) PollEvent() Event {
+// postInput posts an event read from the console.  Unlike PostEvent, it
+// waits for room in the queue, since input must not be lost.  It is only
+// called by the input loop, without the lock held.
+func (s *cScreen) postInput(ev Event) {
 	select {
 	case <-s.quit:
-		return nil
-	case ev := <-s.evch:
-		return ev
+	case s.evch <- ev:
 	}
 }
 
+func (s *cScreen) PollEvent() Event {
+	return s.filter.poll(s.quit, s.evch)
+}
+
+func (s *cScreen) SetEventFilter(f EventFilter) {
+	s.filter.set(f)
+}
+
+func (s *cScreen) SetIdleTimeout(d time.Duration) {
+	s.filter.setIdle(d)
+}
+
 type cursorInfo struct {
 	size    uint32
 	visible uint32
@@ -168,8 +639,10 @@ type coord struct {
 }
 
 func (c coord) uintptr() uintptr {
-	// little endian, put x first
-	return uintptr(c.x) | (uintptr(c.y) << 16)
+	// little endian, put x first; the conversions through uint16 keep
+	// negative values from spilling into the upper bits on 64-bit
+	// systems, such as arm64
+	return uintptr(uint16(c.x)) | (uintptr(uint16(c.y)) << 16)
 }
 
 type rect struct {
@@ -306,6 +779,7 @@ const (
 	vkF22    = 0x85
 	vkF23    = 0x86
 	vkF24    = 0x87
+	vkPacket = 0xe7 // a character that did not come from a key
 )
 
 // NB: All Windows platforms are little endian.  We assume this
@@ -342,6 +816,87 @@ func mod2mask(cks uint32) ModMask {
 	return mm
 }
 
+// combineSurrogate joins the halves of a surrogate pair, which arrive in
+// separate key records (as emoji and other characters outside of the
+// Basic Multilingual Plane do, when entered through an IME).  It returns
+// 0 while waiting for the low half.  A half without its mate is dropped.
+func (s *cScreen) combineSurrogate(ch rune) rune {
+	high := s.surrogate
+	s.surrogate = 0
+	switch {
+	case !utf16.IsSurrogate(ch):
+		return ch
+	case ch < 0xdc00:
+		s.surrogate = ch
+		return 0
+	case high == 0:
+		return 0
+	}
+	return utf16.DecodeRune(high, ch)
+}
+
+// postKey posts the key as many times as the record says that it was
+// repeated.  A key that goes down again without having come up is held,
+// and repeating too; each event says how many times it has repeated so
+// far.  (Characters that do not come from a key, as when pasting, are
+// never held.)
+func (s *cScreen) postKey(krec *keyRecord, key Key, ch rune) {
+	rep := 0
+	if krec.kcode == s.heldkey && krec.kcode != 0 && krec.kcode != vkPacket {
+		rep = s.heldreps
+	}
+	s.heldkey = krec.kcode
+	// Pasted text arrives as a flood of records, a press and a release
+	// for each character; keys are marked until the flood has drained.
+	backlog := s.inputBacklog()
+	s.pasting = backlog >= 2*pasteBurstLen || (s.pasting && backlog > 0)
+	for n := 0; n < int(krec.repeat); n++ {
+		ev := NewEventKey(key, ch, mod2mask(krec.mod))
+		ev.repeat = rep + n
+		ev.paste = s.pasting
+		s.postInput(ev)
+	}
+	s.heldreps = rep + int(krec.repeat)
+}
+
+func (s *cScreen) GetCursorPosition() (int, int, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.fini {
+		return -1, -1, ErrNoCursorPosition
+	}
+	info := consoleInfo{}
+	s.getConsoleInfo(&info)
+	return int(info.pos.x), int(info.pos.y), nil
+}
+
+// mapvkVKToChar is the MAPVK_VK_TO_CHAR argument of MapVirtualKey.
+const mapvkVKToChar = 2
+
+// deadKey returns the accent of the key, if it is a dead key, or zero.
+// The console says nothing of dead keys, except that they have no
+// character, so we ask the keyboard layout.  It gives the accent without
+// shift or AltGr, which may not be quite the one being composed.
+func deadKey(vk uint16) rune {
+	rv, _, _ := procMapVirtualKey.Call(uintptr(vk), mapvkVKToChar)
+	if uint32(rv)&0x80000000 == 0 {
+		return 0
+	}
+	return rune(rv & 0xffff)
+}
+
+// inputBacklog returns how many input records are waiting to be read.
+func (s *cScreen) inputBacklog() int {
+	var n uint32
+	rv, _, _ := procGetNumberOfConsoleInputEvents.Call(
+		uintptr(s.in),
+		uintptr(unsafe.Pointer(&n)))
+	if rv == 0 {
+		return 0
+	}
+	return int(n)
+}
+
 func (s *cScreen) getConsoleInput() error {
 	rec := &inputRecord{}
 	var nrec int32
@@ -366,16 +921,42 @@ func (s *cScreen) getConsoleInput() error {
 		krec.ch = getu16(rec.data[10:])
 		krec.mod = getu32(rec.data[12:])
 
-		if krec.isdown == 0 || krec.repeat < 1 {
-			// its a key release event, ignore it
+		if krec.isdown == 0 {
+			// its a key release event, ignore it (but the key is
+			// no longer held down)
+			if krec.kcode == s.heldkey {
+				s.heldkey = 0
+			}
 			return nil
 		}
+		if krec.repeat < 1 {
+			return nil
+		}
+		if krec.ch == 0 {
+			if accent := deadKey(krec.kcode); accent != 0 {
+				s.composing = true
+				s.postInput(NewEventComposition(string(accent), ""))
+				return nil
+			}
+		}
 		if krec.ch != 0 {
 			// synthesized key code
-			for krec.repeat > 0 {
-				s.PostEvent(NewEventKey(KeyRune, rune(krec.ch), mod2mask(krec.mod)))
-				krec.repeat--
+			ch := s.combineSurrogate(rune(krec.ch))
+			if ch == 0 {
+				return nil
+			}
+			if s.composing {
+				// The dead key is done with, whether or not the
+				// character has its accent.  Escape and the
+				// like cancel it.
+				s.composing = false
+				commit := ""
+				if ch >= ' ' {
+					commit = string(ch)
+				}
+				s.postInput(NewEventComposition("", commit))
 			}
+			s.postKey(krec, KeyRune, ch)
 			return nil
 		}
 		key := KeyNUL // impossible on Windows
@@ -467,11 +1048,7 @@ func (s *cScreen) getConsoleInput() error {
 		default:
 			return nil
 		}
-		for krec.repeat > 0 {
-			s.PostEvent(NewEventKey(key, rune(krec.ch),
-				mod2mask(krec.mod)))
-			krec.repeat--
-		}
+		s.postKey(krec, key, rune(krec.ch))
 
 	case mouseEvent:
 		var mrec mouseRecord
@@ -479,7 +1056,7 @@ func (s *cScreen) getConsoleInput() error {
 		mrec.y = geti16(rec.data[2:])
 		mrec.btns = getu32(rec.data[4:])
 		mrec.mod = getu32(rec.data[8:])
-		mrec.flags = getu32(rec.data[12:]) // not using yet
+		mrec.flags = getu32(rec.data[12:])
 		btns := ButtonNone
 
 		s.mbtns = mrec.btns
@@ -513,23 +1090,37 @@ func (s *cScreen) getConsoleInput() error {
 				btns |= WheelLeft
 			}
 		}
-		// we ignore double click, events are delivered normally
-		s.PostEvent(NewEventMouse(int(mrec.x), int(mrec.y), btns,
-			mod2mask(mrec.mod)))
+		ev := NewEventMouse(int(mrec.x), int(mrec.y), btns,
+			mod2mask(mrec.mod))
+		s.click.track(ev)
+		// The console applies the user's own double click settings,
+		// which we honor if they are more generous than ours.
+		if mrec.flags&mouseDoubleClick != 0 && ev.clicks == 1 {
+			ev.clicks = 2
+		}
+		s.postInput(ev)
 
 	case resizeEvent:
 		var rrec resizeRecord
 		rrec.x = geti16(rec.data[0:])
 		rrec.y = geti16(rec.data[2:])
-		s.PostEvent(NewEventResize(int(rrec.x), int(rrec.y)))
+		s.postInput(NewEventResize(int(rrec.x), int(rrec.y)))
 
 	default:
 	}
 	return nil
 }
 
-func (s *cScreen) scanInput() {
+// scanInput reads the console until Fini closes quit (or the console is
+// closed).  A read that is already waiting when Fini is called is only
+// noticed once it completes.
+func (s *cScreen) scanInput(quit chan struct{}) {
 	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
 		if e := s.getConsoleInput(); e != nil {
 			return
 		}
@@ -537,10 +1128,42 @@ func (s *cScreen) scanInput() {
 }
 
 // Windows console can display 8 characters, in either low or high intensity
+func (s *cScreen) ExternalWriter(x, y, width, height int) *RegionWriter {
+	return NewRegionWriter(s, x, y, width, height, StyleDefault)
+}
+
 func (s *cScreen) Colors() int {
+	if s.nocolor {
+		return 0
+	}
 	return 16
 }
 
+func (s *cScreen) HasMouse() bool {
+	return true
+}
+
+// HasKey is true for the keys that getConsoleInput translates.
+func (s *cScreen) HasKey(k Key) bool {
+	switch k {
+	case KeyCancel, KeyClear, KeyPause, KeyPrint, KeyPgUp, KeyPgDn,
+		KeyEnd, KeyHome, KeyLeft, KeyUp, KeyRight, KeyDown,
+		KeyInsert, KeyDelete, KeyHelp:
+		return true
+	}
+	return k <= KeyRune || (k >= KeyF1 && k <= KeyF24)
+}
+
+func (s *cScreen) ColorModel() ColorModel {
+	return paletteModel(s.Colors())
+}
+
+// DefaultColors is false, since the console has no default colors of its
+// own; ColorDefault is shown in the colors it had when we started.
+func (s *cScreen) DefaultColors() bool {
+	return false
+}
+
 // Windows uses RGB signals
 func mapColor2RGB(c Color) uint16 {
 	switch c {
@@ -582,9 +1205,47 @@ func mapColor2RGB(c Color) uint16 {
 	return 0
 }
 
+// consoleRGB are the colors of the console, as it shows them by default.
+var consoleRGB = [16][3]int{
+	{0, 0, 0}, {128, 0, 0}, {0, 128, 0}, {128, 128, 0},
+	{0, 0, 128}, {128, 0, 128}, {0, 128, 128}, {192, 192, 192},
+	{128, 128, 128}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{0, 0, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// SetColorDistance sets the distance used to pick the console colors for
+// those of the 256 color palette that it lacks.  Cells are redrawn in the
+// new colors by the next Show.
+func (s *cScreen) SetColorDistance(d ColorDistance) {
+	s.Lock()
+	s.colordist = d
+	s.colormap = nil
+	s.cells.invalidate()
+	s.Unlock()
+}
+
+// matchColor returns the console color that is nearest to c.
+func (s *cScreen) matchColor(c Color) Color {
+	c = c.Palette()
+	if c <= ColorBrightWhite {
+		return c
+	}
+	if s.colormap == nil {
+		s.colormap = matchColors(consoleRGB[:], s.colordist)
+	}
+	if int(c) < len(s.colormap) {
+		return s.colormap[c]
+	}
+	return ColorDefault
+}
+
 // Map a tcell style to Windows attributes
-func mapStyle(style Style) uint16 {
+func (s *cScreen) mapStyle(style Style) uint16 {
 	f, b, a := style.Decompose()
+	f, b = s.matchColor(f), s.matchColor(b)
+	if s.nocolor {
+		f, b = ColorDefault, ColorDefault
+	}
 	if f == ColorDefault {
 		f = ColorWhite
 	}
@@ -624,8 +1285,7 @@ func (s *cScreen) SetCell(x, y int, style Style, ch ...rune) {
 		return
 	}
 
-	cell := &s.cells[(y*int(s.w))+x]
-	cell.SetCell(ch, style)
+	s.cells.put((y*int(s.w))+x, ch, style)
 	s.Unlock()
 }
 
@@ -635,9 +1295,13 @@ func (s *cScreen) PutCell(x, y int, cell *Cell) {
 		s.Unlock()
 		return
 	}
-	cptr := &s.cells[(y*int(s.w))+x]
-	cptr.PutChars(cell.Ch)
-	cptr.PutStyle(cell.Style)
+	s.cells.put((y*int(s.w))+x, cell.Ch, cell.Style)
+	s.Unlock()
+}
+
+func (s *cScreen) PutCells(x, y, w, h int, cells []Cell) {
+	s.Lock()
+	s.cells.putCells(x, y, w, h, cells, nil)
 	s.Unlock()
 }
 
@@ -647,9 +1311,92 @@ func (s *cScreen) GetCell(x, y int) *Cell {
 		s.Unlock()
 		return nil
 	}
-	cell := s.cells[(y*int(s.w))+x]
+	var cell *Cell
+	if s.opts.ReadBack {
+		cell = s.readCell(x, y)
+	}
+	if cell == nil {
+		cell = s.cells.cell((y * int(s.w)) + x)
+	}
 	s.Unlock()
-	return &cell
+	return cell
+}
+
+// These are the console attributes that are not colors.
+const (
+	consoleLeadingByte  = 0x0100
+	consoleTrailingByte = 0x0200
+	consoleReverse      = 0x4000
+	consoleUnderline    = 0x8000
+)
+
+// readCell returns the cell at the given location as the console buffer
+// holds it, or nil if it cannot be read.  The second half of a wide
+// character is reported as an empty cell, and the default colors of the
+// console (white on black, as mapStyle draws them) as ColorDefault.
+func (s *cScreen) readCell(x, y int) *Cell {
+	var ci [2]charInfo
+	n := 1
+	if x+1 < s.w {
+		// in case of a surrogate pair
+		n = 2
+	}
+	r := rect{int16(x), int16(y), int16(x + n - 1), int16(y)}
+	rv, _, _ := procReadConsoleOutput.Call(
+		uintptr(s.out),
+		uintptr(unsafe.Pointer(&ci[0])),
+		coord{int16(n), 1}.uintptr(),
+		coord{0, 0}.uintptr(),
+		uintptr(unsafe.Pointer(&r)))
+	if rv == 0 {
+		return nil
+	}
+	cell := &Cell{Style: consoleStyle(ci[0].attr), Width: 1}
+	if ci[0].attr&consoleTrailingByte != 0 {
+		return cell
+	}
+	ch := rune(ci[0].ch)
+	if utf16.IsSurrogate(ch) && n == 2 {
+		ch = utf16.DecodeRune(ch, rune(ci[1].ch))
+	}
+	cell.Ch = []rune{ch}
+	if ci[0].attr&consoleLeadingByte != 0 || runeWidth(ch) == 2 {
+		cell.Width = 2
+	}
+	return cell
+}
+
+// consoleStyle returns the style of the console attributes.
+func consoleStyle(attr uint16) Style {
+	fg, bg := consoleColor(attr&0xf), consoleColor((attr>>4)&0xf)
+	if fg == ColorWhite && bg == ColorBlack {
+		fg, bg = ColorDefault, ColorDefault
+	}
+	style := StyleDefault.Foreground(fg).Background(bg)
+	if attr&consoleReverse != 0 {
+		style = style.Reverse(true)
+	}
+	if attr&consoleUnderline != 0 {
+		style = style.Underline(true)
+	}
+	return style
+}
+
+// consoleColor returns the color of a console color attribute, the
+// reverse of mapColor2RGB.
+func consoleColor(n uint16) Color {
+	for c := ColorBlack; c <= ColorBrightWhite; c++ {
+		if mapColor2RGB(c) == n {
+			return c
+		}
+	}
+	return ColorDefault
+}
+
+func (s *cScreen) Contents() *Contents {
+	s.Lock()
+	defer s.Unlock()
+	return s.cells.contents(s.curx, s.cury)
 }
 
 func (s *cScreen) writeString(x, y int, style Style, ch []uint16) {
@@ -660,12 +1407,22 @@ func (s *cScreen) writeString(x, y int, style Style, ch []uint16) {
 	nw := uint32(len(ch))
 	procSetConsoleTextAttribute.Call(
 		uintptr(s.out),
-		uintptr(mapStyle(style)))
+		uintptr(s.mapStyle(style)))
 	s.setCursorPos(x, y)
 	syscall.WriteConsole(s.out, &ch[0], nw, &nw, nil)
 }
 
+// hidden returns true if cells in the style are drawn blank, because
+// they are invisible, or blinking and in the hidden phase.
+func (s *cScreen) hidden(style Style) bool {
+	return isInvisible(style) ||
+		(s.blinkq != nil && s.blinkoff && isBlink(style))
+}
+
 func (s *cScreen) draw() {
+	s.stats.begin(&s.cells, s.clear)
+	defer s.stats.end()
+
 	// allocate a scratch line bit enough for no combining chars.
 	// if you have combining characters, you may pay for extra allocs.
 	if s.clear {
@@ -674,7 +1431,7 @@ func (s *cScreen) draw() {
 	}
 	buf := make([]uint16, 0, s.w)
 	wcs := buf[:]
-	style := Style(-1) // invalid attribute
+	style := styleInvalid // invalid attribute
 
 	x, y := -1, -1
 
@@ -682,54 +1439,164 @@ func (s *cScreen) draw() {
 		width := 1
 		for col := 0; col < int(s.w); col += width {
 
-			cell := &s.cells[(row*s.w)+col]
+			i := (row * s.w) + col
+			cell := &s.drawc
+			s.cells.load(i, cell)
+			if cell.Style == StyleDefault {
+				cell.Style = s.style
+			}
 			width = int(cell.Width)
 			if width < 1 {
 				width = 1
 			}
+			if cell.Dirty && s.blinkq != nil && isBlink(cell.Style) {
+				s.startBlink()
+			}
 
 			if !cell.Dirty || style != cell.Style {
 				s.writeString(x, y, style, wcs)
 				wcs = buf[0:0]
-				style = Style(-1)
+				style = styleInvalid
 				if !cell.Dirty {
 					continue
 				}
 			}
+			if (width > 1 || needsSurrogates(cell.Ch)) &&
+				!s.hidden(cell.Style) {
+				// Wide characters are written on their own, at an
+				// explicit position, so that whatever the console
+				// does with them, the cells that follow stay aligned.
+				s.writeString(x, y, style, wcs)
+				wcs = buf[0:0]
+				style = styleInvalid
+				s.drawWide(col, row, width, cell)
+				s.drawnCell(i, col, width)
+				continue
+			}
 			if len(wcs) == 0 {
 				style = cell.Style
 				x = col
 				y = row
 			}
-			if len(cell.Ch) < 1 {
+			if s.hidden(cell.Style) {
+				for i := 0; i < width; i++ {
+					wcs = append(wcs, uint16(' '))
+				}
+			} else if len(cell.Ch) < 1 {
 				wcs = append(wcs, uint16(' '))
 			} else {
 				wcs = append(wcs, utf16.Encode(cell.Ch)...)
 			}
-			cell.Dirty = false
+			s.drawnCell(i, col, width)
 		}
 		s.writeString(x, y, style, wcs)
 		wcs = buf[0:0]
-		style = Style(-1)
+		style = styleInvalid
+	}
+}
+
+// drawnCell records that the cell at index i, in column col, has been
+// drawn width columns wide.
+func (s *cScreen) drawnCell(i, col, width int) {
+	if width > 1 && col+width <= s.w {
+		s.cells.drawnWide(i)
+	} else {
+		s.cells.drawn(i)
 	}
 }
 
+// drawWide draws a cell that has a double width character, or one that
+// needs a UTF-16 surrogate pair.  The legacy console is inconsistent
+// about these.  Depending on the font and code page, a wide character may
+// take one column or two, and a surrogate pair is often shown as two
+// replacement glyphs.  So we first pad the covered cell with a space,
+// and then write the character itself; if the console uses both columns
+// the character overwrites the padding, and otherwise the padding keeps
+// stale content from showing through.
+func (s *cScreen) drawWide(col, row, width int, cell *Cell) {
+	if col+width > s.w {
+		// No room for it in the last column, so just leave a space,
+		// as we do on the terminfo backend.
+		s.writeString(col, row, cell.Style, []uint16{' '})
+		return
+	}
+	for i := 1; i < width; i++ {
+		s.writeString(col+i, row, cell.Style, []uint16{' '})
+	}
+	s.writeString(col, row, cell.Style, utf16.Encode(cell.Ch))
+}
+
+// needsSurrogates returns true if the runes need a surrogate pair in UTF-16.
+func needsSurrogates(ch []rune) bool {
+	return len(ch) > 0 && ch[0] > 0xffff
+}
+
 func (s *cScreen) Show() {
 	s.Lock()
-	s.hideCursor()
+	defer s.Unlock()
+	if s.fini {
+		return
+	}
+	s.resize()
+	if s.clear || s.cells.anyDirty(-1) {
+		if !s.frames.ready(s.showFrame) {
+			// cursor motion is not held back
+			s.doCursor()
+			return
+		}
+		s.hideCursor()
+		s.draw()
+		s.frames.drawn()
+	}
+	s.doCursor()
+}
+
+// showFrame draws the frame that Show scheduled.  (See FrameRateScreen.)
+func (s *cScreen) showFrame() {
+	s.Lock()
+	defer s.Unlock()
+	if s.fini || !s.frames.pending() {
+		return
+	}
 	s.resize()
+	s.hideCursor()
 	s.draw()
+	s.frames.drawn()
 	s.doCursor()
+}
+
+func (s *cScreen) SetFrameRate(fps int) {
+	s.Lock()
+	s.frames.setRate(fps)
 	s.Unlock()
 }
 
+// Reinitialize just redraws the screen, since the console has no
+// notion of a terminal type.
+func (s *cScreen) Reinitialize(string) error {
+	s.Sync()
+	return nil
+}
+
 func (s *cScreen) Sync() {
 	s.Lock()
-	InvalidateCells(s.cells)
+	defer s.Unlock()
+	if s.fini {
+		return
+	}
+	s.cells.invalidate()
 	s.hideCursor()
 	s.resize()
 	s.draw()
+	s.frames.drawn()
 	s.doCursor()
+	s.redraw.all(s.PostEvent, s.w, s.h)
+}
+
+func (s *cScreen) EnableRedrawEvents(on bool) {
+	s.Lock()
+	s.redraw.on = on
+	s.redraw.all(s.PostEvent, s.w, s.h)
 	s.Unlock()
 }
 
@@ -771,6 +1638,64 @@ func (s *cScreen) setBufferSize(x, y int) {
 		coord{int16(x), int16(y)}.uintptr())
 }
 
+// The console limits how much can be read or written at once (to some
+// 64K bytes, less what else it needs), so the whole buffer is done some
+// rows at a time.
+func consoleChunkRows(w int) int {
+	if n := 8000 / w; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// saveBuffer returns the contents of the whole console buffer, which we
+// are about to draw over, and which Init shrinks to the window.  Fini
+// puts them back, as a terminal does on leaving its alternate screen.
+// It returns nil if the buffer cannot be read.
+func (s *cScreen) saveBuffer() []charInfo {
+	w, h := int(s.oscreen.size.x), int(s.oscreen.size.y)
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	buf := make([]charInfo, w*h)
+	n := consoleChunkRows(w)
+	for y := 0; y < h; y += n {
+		if y+n > h {
+			n = h - y
+		}
+		r := rect{0, int16(y), int16(w - 1), int16(y + n - 1)}
+		rv, _, _ := procReadConsoleOutput.Call(
+			uintptr(s.out),
+			uintptr(unsafe.Pointer(&buf[y*w])),
+			coord{int16(w), int16(n)}.uintptr(),
+			coord{0, 0}.uintptr(),
+			uintptr(unsafe.Pointer(&r)))
+		if rv == 0 {
+			return nil
+		}
+	}
+	return buf
+}
+
+// restoreBuffer writes the contents saved by saveBuffer back to the
+// console buffer, which must have been restored to its old size.
+func (s *cScreen) restoreBuffer(buf []charInfo) {
+	w, h := int(s.oscreen.size.x), int(s.oscreen.size.y)
+	n := consoleChunkRows(w)
+	for y := 0; y < h; y += n {
+		if y+n > h {
+			n = h - y
+		}
+		r := rect{0, int16(y), int16(w - 1), int16(y + n - 1)}
+		procWriteConsoleOutput.Call(
+			uintptr(s.out),
+			uintptr(unsafe.Pointer(&buf[y*w])),
+			coord{int16(w), int16(n)}.uintptr(),
+			coord{0, 0}.uintptr(),
+			uintptr(unsafe.Pointer(&r)))
+	}
+}
+
 func (s *cScreen) Size() (int, int) {
 
 	s.Lock()
@@ -792,7 +1717,8 @@ func (s *cScreen) resize() {
 		return
 	}
 
-	s.cells = ResizeCells(s.cells, s.w, s.h, w, h)
+	ow, oh := s.w, s.h
+	s.cells.resize(w, h)
 	s.w = w
 	s.h = h
 
@@ -805,18 +1731,45 @@ func (s *cScreen) resize() {
 	s.setBufferSize(w, h)
 
 	s.PostEvent(NewEventResize(w, h))
+	s.redraw.exposed(s.PostEvent, ow, oh, w, h)
+}
+
+// SetSize resizes the console window, and its buffer to match.  The
+// window must always fit within the buffer, so the buffer is first made
+// large enough for both the old and the new window.
+func (s *cScreen) SetSize(w, h int) {
+	s.Lock()
+	defer s.Unlock()
+	if s.fini || w <= 0 || h <= 0 {
+		return
+	}
+	bw, bh := w, h
+	if s.w > bw {
+		bw = s.w
+	}
+	if s.h > bh {
+		bh = s.h
+	}
+	s.setBufferSize(bw, bh)
+	r := rect{0, 0, int16(w - 1), int16(h - 1)}
+	procSetConsoleWindowInfo.Call(
+		uintptr(s.out),
+		uintptr(1),
+		uintptr(unsafe.Pointer(&r)))
+	s.setBufferSize(w, h)
+	s.resize()
 }
 
 func (s *cScreen) Clear() {
 	s.Lock()
-	ClearCells(s.cells, s.style)
+	s.cells.clear(s.style)
 	s.clear = true
 	s.Unlock()
 }
 
 func (s *cScreen) clearScreen(style Style) {
 	pos := coord{0, 0}
-	attr := mapStyle(style)
+	attr := s.mapStyle(style)
 	x, y := s.w, s.h
 	scratch := uint32(0)
 	count := uint32(x * y)
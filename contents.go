@@ -0,0 +1,128 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+)
+
+// Contents is a snapshot of what the application has drawn on a Screen,
+// as returned by ContentsScreen.  It is a copy, so it can be inspected
+// at leisure while the application goes on drawing.
+type Contents struct {
+	// Width and Height are the size of the screen.
+	Width  int
+	Height int
+
+	// Cells holds the cells, row by row; the cell at x, y is at index
+	// (y * Width) + x.  A wide character also covers the cell to its
+	// right.
+	Cells []Cell
+
+	// CursorX and CursorY are the location of the cursor, which are
+	// both -1 if it is hidden (or off of the screen).
+	CursorX int
+	CursorY int
+}
+
+// ContentsScreen is implemented by Screens that can take a snapshot of
+// their contents, in one call that takes the lock once, rather than a
+// GetCell call for every cell.  This is intended for tests, and for
+// tools such as screen readers.  All of the Screens in this package
+// implement it.  The contents are those that the application has drawn,
+// including changes not yet shown with Show.
+type ContentsScreen interface {
+	// Contents returns a snapshot of the screen.
+	Contents() *Contents
+
+	Screen
+}
+
+// newContents returns a snapshot of the cells and cursor.  The caller
+// holds the lock of the screen.
+func newContents(cells []Cell, w, h int, cx, cy int) *Contents {
+	c := &Contents{Width: w, Height: h, CursorX: cx, CursorY: cy}
+	c.Cells = make([]Cell, w*h)
+	// Ch slices are replaced, never written to, so they can be shared.
+	copy(c.Cells, cells)
+	for i := range c.Cells {
+		c.Cells[i].Dirty = false
+	}
+	if cx < 0 || cy < 0 || cx >= w || cy >= h {
+		c.CursorX, c.CursorY = -1, -1
+	}
+	return c
+}
+
+// screenContents returns a snapshot of any Screen, cell by cell if it
+// cannot take one itself.  The cursor location of such a Screen is not
+// known, so it is reported as hidden.
+func screenContents(s Screen) *Contents {
+	if cs, ok := s.(ContentsScreen); ok {
+		return cs.Contents()
+	}
+	w, h := s.Size()
+	c := newContents(nil, w, h, -1, -1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if cell := s.GetCell(x, y); cell != nil {
+				c.Cells[(y*w)+x] = *cell
+				c.Cells[(y*w)+x].Dirty = false
+			}
+		}
+	}
+	return c
+}
+
+// Cell returns the cell at the given location, or nil if it is off of
+// the screen.
+func (c *Contents) Cell(x, y int) *Cell {
+	if x < 0 || y < 0 || x >= c.Width || y >= c.Height {
+		return nil
+	}
+	return &c.Cells[(y*c.Width)+x]
+}
+
+// Row returns the text of a row, with a space for each empty cell.
+// Wide characters are included once, along with any combining marks.
+func (c *Contents) Row(y int) string {
+	if y < 0 || y >= c.Height {
+		return ""
+	}
+	var b bytes.Buffer
+	row := c.Cells[y*c.Width : (y+1)*c.Width]
+	for x := 0; x < len(row); x++ {
+		if len(row[x].Ch) == 0 {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteString(string(row[x].Ch))
+		if row[x].Width == 2 {
+			x++
+		}
+	}
+	return b.String()
+}
+
+// String returns the text of all of the rows, each ending with a
+// newline, which is handy for comparing against what is expected.
+func (c *Contents) String() string {
+	var b bytes.Buffer
+	for y := 0; y < c.Height; y++ {
+		b.WriteString(c.Row(y))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
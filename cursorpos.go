@@ -0,0 +1,102 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoCursorPosition is returned when the terminal does not report the
+// position of its cursor.
+var ErrNoCursorPosition = errors.New("terminal did not report the cursor position")
+
+// CursorPositionScreen is implemented by Screens that can find out where
+// the cursor of the display actually is.  The terminfo based screen and
+// the Windows console do so.  This is for programs that draw inline, below
+// what is already there, and for those that run code which writes to the
+// terminal behind the Screen's back.
+type CursorPositionScreen interface {
+	// GetCursorPosition returns the position of the display's cursor,
+	// which is not necessarily where ShowCursor put it: a hidden cursor
+	// is left where drawing left it, and other output may have moved it.
+	// Terminals are asked with a device status report (CSI 6 n), and
+	// the call waits up to a second for the answer; if there is none,
+	// or the screen is not running, it returns ErrNoCursorPosition.
+	GetCursorPosition() (x, y int, err error)
+
+	Screen
+}
+
+// cursorQuery asks the terminal to report the cursor position, which it
+// does with CSI row ; col R.
+const cursorQuery = "\x1b[6n"
+
+// cursorReportTimeout is how long to wait for the report.
+const cursorReportTimeout = time.Second
+
+// parseCursorReport parses a cursor position report, CSI row ; col R, and
+// sends the position to the first of the waiting requests.  Reports are
+// only looked for while a request is waiting, as CSI 1 ; 2 R is also
+// Shift-F3 on some terminals.
+func (ip *InputParser) parseCursorReport(buf *bytes.Buffer) (bool, bool) {
+	if len(ip.cursorq) == 0 {
+		return false, false
+	}
+	b := buf.Bytes()
+	if len(b) < 2 {
+		return b[0] == '\x1b', false
+	}
+	if b[0] != '\x1b' || b[1] != '[' {
+		return false, false
+	}
+	for i := 2; i < len(b); i++ {
+		switch c := b[i]; {
+		case c >= '0' && c <= '9', c == ';':
+			continue
+		case c == 'R':
+			f := strings.Split(string(b[2:i]), ";")
+			if len(f) != 2 {
+				return false, false
+			}
+			row, e1 := strconv.Atoi(f[0])
+			col, e2 := strconv.Atoi(f[1])
+			if e1 != nil || e2 != nil {
+				return false, false
+			}
+			buf.Next(i + 1)
+			x, y := ip.xform.invert(col-1, row-1, ip.w, ip.h)
+			ip.cursorq[0] <- [2]int{x, y}
+			ip.cursorq = ip.cursorq[1:]
+			return true, true
+		default:
+			return false, false
+		}
+	}
+	return true, false
+}
+
+// cancelCursorReport stops waiting for the report on ch.
+func (ip *InputParser) cancelCursorReport(ch chan [2]int) {
+	for i, c := range ip.cursorq {
+		if c == ch {
+			ip.cursorq = append(ip.cursorq[:i:i], ip.cursorq[i+1:]...)
+			return
+		}
+	}
+}
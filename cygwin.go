@@ -0,0 +1,44 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrCygwinPty is returned by the Windows console screen when it is run
+// in a Cygwin or MSYS2 pty, as in mintty.  Those are pipes, with the
+// line discipline kept by the Cygwin runtime, which native programs
+// cannot change; and any console that the program has is hidden.
+// Running the program through winpty, or setting MSYS=enable_pcon (or
+// CYGWIN=enable_pcon) so that mintty gives it a real (pseudo) console,
+// makes it work.
+var ErrCygwinPty = errors.New("cygwin pty is not a console " +
+	"(use winpty, or set MSYS=enable_pcon)")
+
+// isCygwinPtyName reports whether the name of a pipe is that of a Cygwin
+// or MSYS2 pty, such as \msys-1888ae32e00d56aa-pty0-from-master.
+func isCygwinPtyName(name string) bool {
+	if !strings.HasPrefix(name, `\cygwin-`) &&
+		!strings.HasPrefix(name, `\msys-`) {
+		return false
+	}
+	if !strings.HasSuffix(name, "-from-master") &&
+		!strings.HasSuffix(name, "-to-master") {
+		return false
+	}
+	return strings.Contains(name, "-pty")
+}
@@ -0,0 +1,59 @@
+// +build windows,!tcell_noconsole
+
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetFileInformationByHandleEx = k32.NewProc("GetFileInformationByHandleEx")
+
+// fileNameInfo is the FileNameInfo class of GetFileInformationByHandleEx.
+const fileNameInfo = 2
+
+// inCygwinPty reports whether the standard input or output is a Cygwin
+// (or MSYS2) pty.  With ConPTY enabled, they are consoles instead.
+func inCygwinPty() bool {
+	for _, std := range []int{syscall.STD_INPUT_HANDLE, syscall.STD_OUTPUT_HANDLE} {
+		h, e := syscall.GetStdHandle(std)
+		if e != nil || h == syscall.InvalidHandle {
+			continue
+		}
+		if t, _ := syscall.GetFileType(h); t != syscall.FILE_TYPE_PIPE {
+			continue
+		}
+		// A DWORD length, followed by the name in UTF-16.
+		var buf [4 + syscall.MAX_PATH*2]uint16
+		rv, _, _ := procGetFileInformationByHandleEx.Call(
+			uintptr(h),
+			uintptr(fileNameInfo),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(len(buf)*2))
+		if rv == 0 {
+			continue
+		}
+		n := *(*uint32)(unsafe.Pointer(&buf[0])) / 2
+		if n > uint32(len(buf)-2) {
+			n = uint32(len(buf) - 2)
+		}
+		if isCygwinPtyName(syscall.UTF16ToString(buf[2 : 2+n])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// rowDamage records which rows of a screen may hold dirty cells.  Only
+// those rows need to be looked at when drawing, so that on a very large
+// terminal, a typical update (a status line, or the line being edited)
+// does not cost a walk over every cell.  A row may be marked even though
+// none of its cells is dirty any more, but never the reverse: whatever
+// marks cells dirty must also mark their rows.
+type rowDamage []bool
+
+// touch marks the row.
+func (d rowDamage) touch(row int) {
+	if row >= 0 && row < len(d) {
+		d[row] = true
+	}
+}
+
+// all marks every row.
+func (d rowDamage) all() {
+	for i := range d {
+		d[i] = true
+	}
+}
+
+// fit resizes the record to the given number of rows, if need be, in
+// which case every row is marked.
+func (d *rowDamage) fit(rows int) {
+	if len(*d) != rows {
+		*d = make(rowDamage, rows)
+		d.all()
+	}
+}
+
+// dirty returns true if any of the cells is dirty.  Rows that are found to
+// be clean are unmarked along the way.
+func (d rowDamage) dirty(g *cellGrid) bool {
+	for row := range d {
+		if !d[row] {
+			continue
+		}
+		if g.anyDirty(row) {
+			return true
+		}
+		d[row] = false
+	}
+	return false
+}
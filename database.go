@@ -87,6 +87,230 @@ func init() {
 		KeyClear:     "\x1b[144q",
 		KeyBacktab:   "\x1b[Z",
 	})
+	AddTerminfo(&Terminfo{
+		Name:         "alacritty",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h\x1b[22;0;0t",
+		ExitCA:       "\x1b[?1049l\x1b[23;0;0t",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "alacritty-direct",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h\x1b[22;0;0t",
+		ExitCA:       "\x1b[?1049l\x1b[23;0;0t",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		SetFgRGB:     "\x1b[38;2;%p1%d;%p2%d;%p3%dm",
+		SetBgRGB:     "\x1b[48;2;%p1%d;%p2%d;%p3%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
 	AddTerminfo(&Terminfo{
 		Name:         "ansi",
 		Columns:      80,
@@ -278,43 +502,259 @@ func init() {
 		KeyPgDn:      "\x1b[G",
 	})
 	AddTerminfo(&Terminfo{
-		Name:         "cygwin",
-		Columns:      -1,
-		Lines:        -1,
-		Colors:       8,
+		Name:         "contour",
+		Aliases:      []string{ "contour-latest" },
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
 		Bell:         "\a",
-		Clear:        "\x1b[H\x1b[J",
-		EnterCA:      "\x1b7\x1b[?47h",
-		ExitCA:       "\x1b[2J\x1b[?47l\x1b8",
-		AttrOff:      "\x1b[0;10m",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
 		Underline:    "\x1b[4m",
 		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
 		Reverse:      "\x1b[7m",
-		SetFg:        "\x1b[3%p1%dm",
-		SetBg:        "\x1b[4%p1%dm",
-		PadChar:      "\x00",
-		AltChars:     "+\x10,\x11-\x18.\x190\xdb`\x04a\xb1f\xf8g\xf1h\xb0j\xd9k\xbfl\xdam\xc0n\xc5o~p\xc4q\xc4r\xc4s_t\xc3u\xb4v\xc1w\xc2x\xb3y\xf3z\xf2{\xe3|\xd8}\x9c~\xfe",
-		EnterAcs:     "\x1b[11m",
-		ExitAcs:      "\x1b[10m",
+		EnterKeypad:  "\x1b[?1h",
+		ExitKeypad:   "\x1b[?1l",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\x1b\\",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
-		KeyUp:        "\x1b[A",
-		KeyDown:      "\x1b[B",
-		KeyRight:     "\x1b[C",
-		KeyLeft:      "\x1b[D",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
 		KeyInsert:    "\x1b[2~",
 		KeyDelete:    "\x1b[3~",
-		KeyBackspace: "\b",
-		KeyHome:      "\x1b[1~",
-		KeyEnd:       "\x1b[4~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
 		KeyPgUp:      "\x1b[5~",
 		KeyPgDn:      "\x1b[6~",
-		KeyF1:        "\x1b[[A",
-		KeyF2:        "\x1b[[B",
-		KeyF3:        "\x1b[[C",
-		KeyF4:        "\x1b[[D",
-		KeyF5:        "\x1b[[E",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "contour-direct",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h",
+		ExitKeypad:   "\x1b[?1l",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		SetFgRGB:     "\x1b[38;2;%p1%d;%p2%d;%p3%dm",
+		SetBgRGB:     "\x1b[48;2;%p1%d;%p2%d;%p3%dm",
+		ResetColors:  "\x1b]104\x1b\\",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "cygwin",
+		Columns:      -1,
+		Lines:        -1,
+		Colors:       8,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[J",
+		EnterCA:      "\x1b7\x1b[?47h",
+		ExitCA:       "\x1b[2J\x1b[?47l\x1b8",
+		AttrOff:      "\x1b[0;10m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Reverse:      "\x1b[7m",
+		SetFg:        "\x1b[3%p1%dm",
+		SetBg:        "\x1b[4%p1%dm",
+		PadChar:      "\x00",
+		AltChars:     "+\x10,\x11-\x18.\x190\xdb`\x04a\xb1f\xf8g\xf1h\xb0j\xd9k\xbfl\xdam\xc0n\xc5o~p\xc4q\xc4r\xc4s_t\xc3u\xb4v\xc1w\xc2x\xb3y\xf3z\xf2{\xe3|\xd8}\x9c~\xfe",
+		EnterAcs:     "\x1b[11m",
+		ExitAcs:      "\x1b[10m",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1b[A",
+		KeyDown:      "\x1b[B",
+		KeyRight:     "\x1b[C",
+		KeyLeft:      "\x1b[D",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\b",
+		KeyHome:      "\x1b[1~",
+		KeyEnd:       "\x1b[4~",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1b[[A",
+		KeyF2:        "\x1b[[B",
+		KeyF3:        "\x1b[[C",
+		KeyF4:        "\x1b[[D",
+		KeyF5:        "\x1b[[E",
 		KeyF6:        "\x1b[17~",
 		KeyF7:        "\x1b[18~",
 		KeyF8:        "\x1b[19~",
@@ -555,6 +995,13 @@ func init() {
 		KeyF20:       "\x1b[34~",
 		KeyHelp:      "\x1b[28~",
 	})
+	AddTerminfo(&Terminfo{
+		Name:         "dumb",
+		Columns:      80,
+		Lines:        -1,
+		Bell:         "\a",
+		PadChar:      "\x00",
+	})
 	AddTerminfo(&Terminfo{
 		Name:         "Eterm",
 		Aliases:      []string{ "Eterm-color" },
@@ -664,6 +1111,8 @@ func init() {
 		ExitAcs:      "\x0f",
 		Mouse:        "\x1b[M",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -742,30 +1191,37 @@ func init() {
 		CursorUp1:    "\x1b[A",
 	})
 	AddTerminfo(&Terminfo{
-		Name:         "gnome",
+		Name:         "foot",
 		Columns:      80,
 		Lines:        24,
-		Colors:       8,
+		Colors:       256,
 		Bell:         "\a",
 		Clear:        "\x1b[H\x1b[2J",
-		EnterCA:      "\x1b7\x1b[?47h",
-		ExitCA:       "\x1b[2J\x1b[?47l\x1b8",
-		ShowCursor:   "\x1b[?25h",
+		EnterCA:      "\x1b[?1049h\x1b[22;0;0t",
+		ExitCA:       "\x1b[?1049l\x1b[23;0;0t",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
 		HideCursor:   "\x1b[?25l",
-		AttrOff:      "\x1b[0m\x0f",
+		AttrOff:      "\x1b(B\x1b[m",
 		Underline:    "\x1b[4m",
 		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
 		Reverse:      "\x1b[7m",
 		EnterKeypad:  "\x1b[?1h\x1b=",
 		ExitKeypad:   "\x1b[?1l\x1b>",
-		SetFg:        "\x1b[3%p1%dm",
-		SetBg:        "\x1b[4%p1%dm",
-		PadChar:      "\x00",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38:5:%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48:5:%p1%d%;m",
 		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
-		EnterAcs:     "\x0e",
-		ExitAcs:      "\x0f",
-		Mouse:        "\x1b[M",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\x1b\\",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -775,7 +1231,7 @@ func init() {
 		KeyLeft:      "\x1bOD",
 		KeyInsert:    "\x1b[2~",
 		KeyDelete:    "\x1b[3~",
-		KeyBackspace: "\u007f",
+		KeyBackspace: "\x7f",
 		KeyHome:      "\x1bOH",
 		KeyEnd:       "\x1bOF",
 		KeyPgUp:      "\x1b[5~",
@@ -792,10 +1248,10 @@ func init() {
 		KeyF10:       "\x1b[21~",
 		KeyF11:       "\x1b[23~",
 		KeyF12:       "\x1b[24~",
-		KeyF13:       "\x1bO1;2P",
-		KeyF14:       "\x1bO1;2Q",
-		KeyF15:       "\x1bO1;2R",
-		KeyF16:       "\x1bO1;2S",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
 		KeyF17:       "\x1b[15;2~",
 		KeyF18:       "\x1b[17;2~",
 		KeyF19:       "\x1b[18;2~",
@@ -804,10 +1260,10 @@ func init() {
 		KeyF22:       "\x1b[21;2~",
 		KeyF23:       "\x1b[23;2~",
 		KeyF24:       "\x1b[24;2~",
-		KeyF25:       "\x1bO1;5P",
-		KeyF26:       "\x1bO1;5Q",
-		KeyF27:       "\x1bO1;5R",
-		KeyF28:       "\x1bO1;5S",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
 		KeyF29:       "\x1b[15;5~",
 		KeyF30:       "\x1b[17;5~",
 		KeyF31:       "\x1b[18;5~",
@@ -816,10 +1272,10 @@ func init() {
 		KeyF34:       "\x1b[21;5~",
 		KeyF35:       "\x1b[23;5~",
 		KeyF36:       "\x1b[24;5~",
-		KeyF37:       "\x1bO1;6P",
-		KeyF38:       "\x1bO1;6Q",
-		KeyF39:       "\x1bO1;6R",
-		KeyF40:       "\x1bO1;6S",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
 		KeyF41:       "\x1b[15;6~",
 		KeyF42:       "\x1b[17;6~",
 		KeyF43:       "\x1b[18;6~",
@@ -828,12 +1284,227 @@ func init() {
 		KeyF46:       "\x1b[21;6~",
 		KeyF47:       "\x1b[23;6~",
 		KeyF48:       "\x1b[24;6~",
-		KeyF49:       "\x1bO1;3P",
-		KeyF50:       "\x1bO1;3Q",
-		KeyF51:       "\x1bO1;3R",
-		KeyF52:       "\x1bO1;3S",
-		KeyF53:       "\x1b[15;3~",
-		KeyF54:       "\x1b[17;3~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "foot-direct",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h\x1b[22;0;0t",
+		ExitCA:       "\x1b[?1049l\x1b[23;0;0t",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		SetFgRGB:     "\x1b[38;2;%p1%d;%p2%d;%p3%dm",
+		SetBgRGB:     "\x1b[48;2;%p1%d;%p2%d;%p3%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "gnome",
+		Columns:      80,
+		Lines:        24,
+		Colors:       8,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b7\x1b[?47h",
+		ExitCA:       "\x1b[2J\x1b[?47l\x1b8",
+		ShowCursor:   "\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b[0m\x0f",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[3%p1%dm",
+		SetBg:        "\x1b[4%p1%dm",
+		PadChar:      "\x00",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x0e",
+		ExitAcs:      "\x0f",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\u007f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1bO1;2P",
+		KeyF14:       "\x1bO1;2Q",
+		KeyF15:       "\x1bO1;2R",
+		KeyF16:       "\x1bO1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1bO1;5P",
+		KeyF26:       "\x1bO1;5Q",
+		KeyF27:       "\x1bO1;5R",
+		KeyF28:       "\x1bO1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1bO1;6P",
+		KeyF38:       "\x1bO1;6Q",
+		KeyF39:       "\x1bO1;6R",
+		KeyF40:       "\x1bO1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1bO1;3P",
+		KeyF50:       "\x1bO1;3Q",
+		KeyF51:       "\x1bO1;3R",
+		KeyF52:       "\x1bO1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
 		KeyF55:       "\x1b[18;3~",
 		KeyF56:       "\x1b[19;3~",
 		KeyF57:       "\x1b[20;3~",
@@ -870,6 +1541,8 @@ func init() {
 		ExitAcs:      "\x0f",
 		Mouse:        "\x1b[M",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -1006,29 +1679,38 @@ func init() {
 		KeyHome:      "~\x12",
 	})
 	AddTerminfo(&Terminfo{
-		Name:         "konsole",
+		Name:         "kitty",
+		Aliases:      []string{ "xterm-kitty" },
 		Columns:      80,
 		Lines:        24,
-		Colors:       8,
+		Colors:       256,
+		Bell:         "\a",
 		Clear:        "\x1b[H\x1b[2J",
-		EnterCA:      "\x1b7\x1b[?47h",
-		ExitCA:       "\x1b[2J\x1b[?47l\x1b8",
-		ShowCursor:   "\x1b[?25h",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
 		HideCursor:   "\x1b[?25l",
-		AttrOff:      "\x1b[0m\x0f",
+		AttrOff:      "\x1b(B\x1b[m",
 		Underline:    "\x1b[4m",
 		Bold:         "\x1b[1m",
-		Blink:        "\x1b[5m",
+		Dim:          "\x1b[2m",
 		Reverse:      "\x1b[7m",
-		EnterKeypad:  "\x1b[?1h\x1b=",
-		ExitKeypad:   "\x1b[?1l\x1b>",
-		SetFg:        "\x1b[3%p1%dm",
-		SetBg:        "\x1b[4%p1%dm",
-		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
-		EnterAcs:     "\x0e",
-		ExitAcs:      "\x0f",
-		Mouse:        "\x1b[M",
+		EnterKeypad:  "\x1b[?1h",
+		ExitKeypad:   "\x1b[?1l",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -1038,7 +1720,7 @@ func init() {
 		KeyLeft:      "\x1bOD",
 		KeyInsert:    "\x1b[2~",
 		KeyDelete:    "\x1b[3~",
-		KeyBackspace: "\u007f",
+		KeyBackspace: "\x7f",
 		KeyHome:      "\x1bOH",
 		KeyEnd:       "\x1bOF",
 		KeyPgUp:      "\x1b[5~",
@@ -1055,10 +1737,10 @@ func init() {
 		KeyF10:       "\x1b[21~",
 		KeyF11:       "\x1b[23~",
 		KeyF12:       "\x1b[24~",
-		KeyF13:       "\x1bO2P",
-		KeyF14:       "\x1bO2Q",
-		KeyF15:       "\x1bO2R",
-		KeyF16:       "\x1bO2S",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
 		KeyF17:       "\x1b[15;2~",
 		KeyF18:       "\x1b[17;2~",
 		KeyF19:       "\x1b[18;2~",
@@ -1067,10 +1749,224 @@ func init() {
 		KeyF22:       "\x1b[21;2~",
 		KeyF23:       "\x1b[23;2~",
 		KeyF24:       "\x1b[24;2~",
-		KeyF25:       "\x1bO5P",
-		KeyF26:       "\x1bO5Q",
-		KeyF27:       "\x1bO5R",
-		KeyF28:       "\x1bO5S",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "kitty-direct",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h",
+		ExitKeypad:   "\x1b[?1l",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		SetFgRGB:     "\x1b[38;2;%p1%d;%p2%d;%p3%dm",
+		SetBgRGB:     "\x1b[48;2;%p1%d;%p2%d;%p3%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "konsole",
+		Columns:      80,
+		Lines:        24,
+		Colors:       8,
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b7\x1b[?47h",
+		ExitCA:       "\x1b[2J\x1b[?47l\x1b8",
+		ShowCursor:   "\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b[0m\x0f",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[3%p1%dm",
+		SetBg:        "\x1b[4%p1%dm",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x0e",
+		ExitAcs:      "\x0f",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\u007f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1bO2P",
+		KeyF14:       "\x1bO2Q",
+		KeyF15:       "\x1bO2R",
+		KeyF16:       "\x1bO2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1bO5P",
+		KeyF26:       "\x1bO5Q",
+		KeyF27:       "\x1bO5R",
+		KeyF28:       "\x1bO5S",
 		KeyF29:       "\x1b[15;5~",
 		KeyF30:       "\x1b[17;5~",
 		KeyF31:       "\x1b[18;5~",
@@ -1187,6 +2083,8 @@ func init() {
 		ExitAcs:      "\x1b[10m",
 		Mouse:        "\x1b[M",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		InitColor:    "\x1b]P%p1%x%p2%{255}%*%{1000}%/%02x%p3%{255}%*%{1000}%/%02x%p4%{255}%*%{1000}%/%02x",
+		ResetColors:  "\x1b]R",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -1449,6 +2347,8 @@ func init() {
 		ExitAcs:      "\x0f",
 		Mouse:        "\x1b[M",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -1510,10 +2410,415 @@ func init() {
 		KeyBacktab:   "\x1b[Z",
 	})
 	AddTerminfo(&Terminfo{
-		Name:         "screen",
+		Name:         "screen",
+		Columns:      80,
+		Lines:        24,
+		Colors:       8,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[34h\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b[m\x0f",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[3%p1%dm",
+		SetBg:        "\x1b[4%p1%dm",
+		PadChar:      "\x00",
+		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x0e",
+		ExitAcs:      "\x0f",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1bM",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\b",
+		KeyHome:      "\x1b[1~",
+		KeyEnd:       "\x1b[4~",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "screen-256color",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[34h\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b[m\x0f",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		PadChar:      "\x00",
+		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x0e",
+		ExitAcs:      "\x0f",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1bM",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1b[1~",
+		KeyEnd:       "\x1b[4~",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "sun",
+		Aliases:      []string{ "sun1", "sun2" },
+		Columns:      80,
+		Lines:        34,
+		Bell:         "\a",
+		Clear:        "\f",
+		AttrOff:      "\x1b[m",
+		Reverse:      "\x1b[7m",
+		PadChar:      "\x00",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1b[A",
+		KeyDown:      "\x1b[B",
+		KeyRight:     "\x1b[C",
+		KeyLeft:      "\x1b[D",
+		KeyDelete:    "\u007f",
+		KeyBackspace: "\b",
+		KeyHome:      "\x1b[214z",
+		KeyEnd:       "\x1b[220z",
+		KeyPgUp:      "\x1b[216z",
+		KeyPgDn:      "\x1b[222z",
+		KeyF1:        "\x1b[224z",
+		KeyF2:        "\x1b[225z",
+		KeyF3:        "\x1b[226z",
+		KeyF4:        "\x1b[227z",
+		KeyF5:        "\x1b[228z",
+		KeyF6:        "\x1b[229z",
+		KeyF7:        "\x1b[230z",
+		KeyF8:        "\x1b[231z",
+		KeyF9:        "\x1b[232z",
+		KeyF10:       "\x1b[233z",
+		KeyF11:       "\x1b[234z",
+		KeyF12:       "\x1b[235z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "sun-color",
+		Columns:      80,
+		Lines:        34,
+		Colors:       8,
+		Bell:         "\a",
+		Clear:        "\f",
+		AttrOff:      "\x1b[m",
+		Reverse:      "\x1b[7m",
+		SetFg:        "\x1b[3%p1%dm",
+		SetBg:        "\x1b[4%p1%dm",
+		PadChar:      "\x00",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1b[A",
+		KeyDown:      "\x1b[B",
+		KeyRight:     "\x1b[C",
+		KeyLeft:      "\x1b[D",
+		KeyDelete:    "\u007f",
+		KeyBackspace: "\b",
+		KeyHome:      "\x1b[214z",
+		KeyEnd:       "\x1b[220z",
+		KeyPgUp:      "\x1b[216z",
+		KeyPgDn:      "\x1b[222z",
+		KeyF1:        "\x1b[224z",
+		KeyF2:        "\x1b[225z",
+		KeyF3:        "\x1b[226z",
+		KeyF4:        "\x1b[227z",
+		KeyF5:        "\x1b[228z",
+		KeyF6:        "\x1b[229z",
+		KeyF7:        "\x1b[230z",
+		KeyF8:        "\x1b[231z",
+		KeyF9:        "\x1b[232z",
+		KeyF10:       "\x1b[233z",
+		KeyF11:       "\x1b[234z",
+		KeyF12:       "\x1b[235z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "tmux",
+		Columns:      80,
+		Lines:        24,
+		Colors:       8,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[34h\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b[m\x0f",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[3%p1%dm",
+		SetBg:        "\x1b[4%p1%dm",
+		PadChar:      "\x00",
+		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x0e",
+		ExitAcs:      "\x0f",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1bM",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1b[1~",
+		KeyEnd:       "\x1b[4~",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "tmux-256color",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[J",
+		EnterCA:      "\x1b[?1049h",
+		ExitCA:       "\x1b[?1049l",
+		ShowCursor:   "\x1b[34h\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b[m\x0f",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h\x1b=",
+		ExitKeypad:   "\x1b[?1l\x1b>",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		PadChar:      "\x00",
+		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x0e",
+		ExitAcs:      "\x0f",
+		Mouse:        "\x1b[M",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1bM",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1b[1~",
+		KeyEnd:       "\x1b[4~",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
+	AddTerminfo(&Terminfo{
+		Name:         "tmux-direct",
 		Columns:      80,
 		Lines:        24,
-		Colors:       8,
+		Colors:       256,
 		Bell:         "\a",
 		Clear:        "\x1b[H\x1b[J",
 		EnterCA:      "\x1b[?1049h",
@@ -1523,18 +2828,26 @@ func init() {
 		AttrOff:      "\x1b[m\x0f",
 		Underline:    "\x1b[4m",
 		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
 		Blink:        "\x1b[5m",
 		Reverse:      "\x1b[7m",
 		EnterKeypad:  "\x1b[?1h\x1b=",
 		ExitKeypad:   "\x1b[?1l\x1b>",
-		SetFg:        "\x1b[3%p1%dm",
-		SetBg:        "\x1b[4%p1%dm",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
 		PadChar:      "\x00",
 		AltChars:     "++,,--..00``aaffgghhiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
 		EnterAcs:     "\x0e",
 		ExitAcs:      "\x0f",
 		Mouse:        "\x1b[M",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		UnderlineX:   "\x1b[4:%p1%dm",
+		SetFgRGB:     "\x1b[38;2;%p1%d;%p2%d;%p3%dm",
+		SetBgRGB:     "\x1b[48;2;%p1%d;%p2%d;%p3%dm",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1bM",
@@ -1544,7 +2857,7 @@ func init() {
 		KeyLeft:      "\x1bOD",
 		KeyInsert:    "\x1b[2~",
 		KeyDelete:    "\x1b[3~",
-		KeyBackspace: "\b",
+		KeyBackspace: "\x7f",
 		KeyHome:      "\x1b[1~",
 		KeyEnd:       "\x1b[4~",
 		KeyPgUp:      "\x1b[5~",
@@ -1561,82 +2874,59 @@ func init() {
 		KeyF10:       "\x1b[21~",
 		KeyF11:       "\x1b[23~",
 		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
 		KeyBacktab:   "\x1b[Z",
 	})
-	AddTerminfo(&Terminfo{
-		Name:         "sun",
-		Aliases:      []string{ "sun1", "sun2" },
-		Columns:      80,
-		Lines:        34,
-		Bell:         "\a",
-		Clear:        "\f",
-		AttrOff:      "\x1b[m",
-		Reverse:      "\x1b[7m",
-		PadChar:      "\x00",
-		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
-		CursorBack1:  "\b",
-		CursorUp1:    "\x1b[A",
-		KeyUp:        "\x1b[A",
-		KeyDown:      "\x1b[B",
-		KeyRight:     "\x1b[C",
-		KeyLeft:      "\x1b[D",
-		KeyDelete:    "\u007f",
-		KeyBackspace: "\b",
-		KeyHome:      "\x1b[214z",
-		KeyEnd:       "\x1b[220z",
-		KeyPgUp:      "\x1b[216z",
-		KeyPgDn:      "\x1b[222z",
-		KeyF1:        "\x1b[224z",
-		KeyF2:        "\x1b[225z",
-		KeyF3:        "\x1b[226z",
-		KeyF4:        "\x1b[227z",
-		KeyF5:        "\x1b[228z",
-		KeyF6:        "\x1b[229z",
-		KeyF7:        "\x1b[230z",
-		KeyF8:        "\x1b[231z",
-		KeyF9:        "\x1b[232z",
-		KeyF10:       "\x1b[233z",
-		KeyF11:       "\x1b[234z",
-		KeyF12:       "\x1b[235z",
-	})
-	AddTerminfo(&Terminfo{
-		Name:         "sun-color",
-		Columns:      80,
-		Lines:        34,
-		Colors:       8,
-		Bell:         "\a",
-		Clear:        "\f",
-		AttrOff:      "\x1b[m",
-		Reverse:      "\x1b[7m",
-		SetFg:        "\x1b[3%p1%dm",
-		SetBg:        "\x1b[4%p1%dm",
-		PadChar:      "\x00",
-		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
-		CursorBack1:  "\b",
-		CursorUp1:    "\x1b[A",
-		KeyUp:        "\x1b[A",
-		KeyDown:      "\x1b[B",
-		KeyRight:     "\x1b[C",
-		KeyLeft:      "\x1b[D",
-		KeyDelete:    "\u007f",
-		KeyBackspace: "\b",
-		KeyHome:      "\x1b[214z",
-		KeyEnd:       "\x1b[220z",
-		KeyPgUp:      "\x1b[216z",
-		KeyPgDn:      "\x1b[222z",
-		KeyF1:        "\x1b[224z",
-		KeyF2:        "\x1b[225z",
-		KeyF3:        "\x1b[226z",
-		KeyF4:        "\x1b[227z",
-		KeyF5:        "\x1b[228z",
-		KeyF6:        "\x1b[229z",
-		KeyF7:        "\x1b[230z",
-		KeyF8:        "\x1b[231z",
-		KeyF9:        "\x1b[232z",
-		KeyF10:       "\x1b[233z",
-		KeyF11:       "\x1b[234z",
-		KeyF12:       "\x1b[235z",
-	})
 	AddTerminfo(&Terminfo{
 		Name:         "tvi910",
 		Columns:      80,
@@ -1851,6 +3141,7 @@ func init() {
 		EnterKeypad:  "\x1b[?1h\x1b=",
 		ExitKeypad:   "\x1b[?1l\x1b>",
 		PadChar:      "\x00",
+		XOnXOff:      true,
 		AltChars:     "``aaffggjjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
 		EnterAcs:     "\x0e",
 		ExitAcs:      "\x0f",
@@ -1887,6 +3178,7 @@ func init() {
 		EnterKeypad:  "\x1b[?1h\x1b=",
 		ExitKeypad:   "\x1b[?1l\x1b>",
 		PadChar:      "\x00",
+		XOnXOff:      true,
 		AltChars:     "``aaffggjjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
 		EnterAcs:     "\x0e",
 		ExitAcs:      "\x0f",
@@ -1922,6 +3214,7 @@ func init() {
 		Blink:        "\x1b[5m",
 		Reverse:      "\x1b[7m",
 		PadChar:      "\x00",
+		XOnXOff:      true,
 		AltChars:     "``aaffggjjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
 		EnterAcs:     "\x1b(0$<2>",
 		ExitAcs:      "\x1b(B$<4>",
@@ -2024,6 +3317,7 @@ func init() {
 		EnterKeypad:  "\x1b[?1h\x1b=",
 		ExitKeypad:   "\x1b[?1l\x1b>",
 		PadChar:      "\x00",
+		XOnXOff:      true,
 		AltChars:     "``aaffggjjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
 		EnterAcs:     "\x1b(0",
 		ExitAcs:      "\x1b(B",
@@ -2058,6 +3352,7 @@ func init() {
 		EnterKeypad:  "\x1b=",
 		ExitKeypad:   "\x1b>",
 		PadChar:      "\x00",
+		XOnXOff:      true,
 		AltChars:     "``aaffggjjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
 		EnterAcs:     "\x1b(0$<2>",
 		ExitAcs:      "\x1b(B$<4>",
@@ -2084,6 +3379,117 @@ func init() {
 		KeyF9:        "\x1b[21~",
 		KeyF10:       "\x1b[29~",
 	})
+	AddTerminfo(&Terminfo{
+		Name:         "wezterm",
+		Columns:      80,
+		Lines:        24,
+		Colors:       256,
+		Bell:         "\a",
+		Clear:        "\x1b[H\x1b[2J",
+		EnterCA:      "\x1b[?1049h\x1b[22;0;0t",
+		ExitCA:       "\x1b[?1049l\x1b[23;0;0t",
+		ShowCursor:   "\x1b[?12l\x1b[?25h",
+		HideCursor:   "\x1b[?25l",
+		AttrOff:      "\x1b(B\x1b[m",
+		Underline:    "\x1b[4m",
+		Bold:         "\x1b[1m",
+		Dim:          "\x1b[2m",
+		Blink:        "\x1b[5m",
+		Reverse:      "\x1b[7m",
+		EnterKeypad:  "\x1b[?1h",
+		ExitKeypad:   "\x1b[?1l",
+		SetFg:        "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		SetBg:        "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+		AltChars:     "``aaffggiijjkkllmmnnooppqqrrssttuuvvwwxxyyzz{{||}}~~",
+		EnterAcs:     "\x1b(0",
+		ExitAcs:      "\x1b(B",
+		Mouse:        "\x1b[<",
+		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		EnablePaste:  "\x1b[?2004h",
+		DisablePaste: "\x1b[?2004l",
+		PasteStart:   "\x1b[200~",
+		PasteEnd:     "\x1b[201~",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
+		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
+		CursorBack1:  "\b",
+		CursorUp1:    "\x1b[A",
+		KeyUp:        "\x1bOA",
+		KeyDown:      "\x1bOB",
+		KeyRight:     "\x1bOC",
+		KeyLeft:      "\x1bOD",
+		KeyInsert:    "\x1b[2~",
+		KeyDelete:    "\x1b[3~",
+		KeyBackspace: "\x7f",
+		KeyHome:      "\x1bOH",
+		KeyEnd:       "\x1bOF",
+		KeyPgUp:      "\x1b[5~",
+		KeyPgDn:      "\x1b[6~",
+		KeyF1:        "\x1bOP",
+		KeyF2:        "\x1bOQ",
+		KeyF3:        "\x1bOR",
+		KeyF4:        "\x1bOS",
+		KeyF5:        "\x1b[15~",
+		KeyF6:        "\x1b[17~",
+		KeyF7:        "\x1b[18~",
+		KeyF8:        "\x1b[19~",
+		KeyF9:        "\x1b[20~",
+		KeyF10:       "\x1b[21~",
+		KeyF11:       "\x1b[23~",
+		KeyF12:       "\x1b[24~",
+		KeyF13:       "\x1b[1;2P",
+		KeyF14:       "\x1b[1;2Q",
+		KeyF15:       "\x1b[1;2R",
+		KeyF16:       "\x1b[1;2S",
+		KeyF17:       "\x1b[15;2~",
+		KeyF18:       "\x1b[17;2~",
+		KeyF19:       "\x1b[18;2~",
+		KeyF20:       "\x1b[19;2~",
+		KeyF21:       "\x1b[20;2~",
+		KeyF22:       "\x1b[21;2~",
+		KeyF23:       "\x1b[23;2~",
+		KeyF24:       "\x1b[24;2~",
+		KeyF25:       "\x1b[1;5P",
+		KeyF26:       "\x1b[1;5Q",
+		KeyF27:       "\x1b[1;5R",
+		KeyF28:       "\x1b[1;5S",
+		KeyF29:       "\x1b[15;5~",
+		KeyF30:       "\x1b[17;5~",
+		KeyF31:       "\x1b[18;5~",
+		KeyF32:       "\x1b[19;5~",
+		KeyF33:       "\x1b[20;5~",
+		KeyF34:       "\x1b[21;5~",
+		KeyF35:       "\x1b[23;5~",
+		KeyF36:       "\x1b[24;5~",
+		KeyF37:       "\x1b[1;6P",
+		KeyF38:       "\x1b[1;6Q",
+		KeyF39:       "\x1b[1;6R",
+		KeyF40:       "\x1b[1;6S",
+		KeyF41:       "\x1b[15;6~",
+		KeyF42:       "\x1b[17;6~",
+		KeyF43:       "\x1b[18;6~",
+		KeyF44:       "\x1b[19;6~",
+		KeyF45:       "\x1b[20;6~",
+		KeyF46:       "\x1b[21;6~",
+		KeyF47:       "\x1b[23;6~",
+		KeyF48:       "\x1b[24;6~",
+		KeyF49:       "\x1b[1;3P",
+		KeyF50:       "\x1b[1;3Q",
+		KeyF51:       "\x1b[1;3R",
+		KeyF52:       "\x1b[1;3S",
+		KeyF53:       "\x1b[15;3~",
+		KeyF54:       "\x1b[17;3~",
+		KeyF55:       "\x1b[18;3~",
+		KeyF56:       "\x1b[19;3~",
+		KeyF57:       "\x1b[20;3~",
+		KeyF58:       "\x1b[21;3~",
+		KeyF59:       "\x1b[23;3~",
+		KeyF60:       "\x1b[24;3~",
+		KeyF61:       "\x1b[1;4P",
+		KeyF62:       "\x1b[1;4Q",
+		KeyF63:       "\x1b[1;4R",
+		KeyBacktab:   "\x1b[Z",
+	})
 	AddTerminfo(&Terminfo{
 		Name:         "wy50",
 		Aliases:      []string{ "wyse50" },
@@ -2097,6 +3503,7 @@ func init() {
 		Dim:          "\x1b`7\x1b)",
 		Reverse:      "\x1b`6\x1b)",
 		PadChar:      "\x00",
+		XOnXOff:      true,
 		AltChars:     "0wa_h[jukslrmqnxqzttuyv]wpxv",
 		EnterAcs:     "\x1bH\x02",
 		ExitAcs:      "\x1bH\x03",
@@ -2541,6 +3948,8 @@ func init() {
 		ExitAcs:      "\x1b(B",
 		Mouse:        "\x1b[M",
 		MouseMode:    "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c",
+		InitColor:    "\x1b]4;%p1%d;rgb:%p2%{255}%*%{1000}%/%2.2X/%p3%{255}%*%{1000}%/%2.2X/%p4%{255}%*%{1000}%/%2.2X\x1b\\",
+		ResetColors:  "\x1b]104\a",
 		SetCursor:    "\x1b[%i%p1%d;%p2%dH",
 		CursorBack1:  "\b",
 		CursorUp1:    "\x1b[A",
@@ -0,0 +1,225 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Terminals can describe themselves, which tells us about features that
+// terminfo has no capabilities for, or that the entry for $TERM (often
+// just "xterm-256color") does not know about.  These are the queries
+// that we send:
+//
+//	XTVERSION          CSI > 0 q    DCS > | name(version) ST
+//	Secondary DA       CSI > c      CSI > type ; version ; 0 c
+//	Kitty keyboard     CSI ? u      CSI ? flags u
+//	XTGETTCAP RGB, Tc  DCS + q hex ST   DCS 1 + r hex = value ST
+//	XTWINOPS 14        CSI 14 t     CSI 4 ; height ; width t
+//	Primary DA         CSI c        CSI ? attr ; ... c
+//
+// Practically every terminal answers the primary device attributes (DA1)
+// query, and terminals answer in order, so we send it last; its reply
+// tells us that all of the others that will be answered have been.
+const (
+	deviceQueries = "\x1b[>0q" + "\x1b[>c" + "\x1b[?u" +
+		"\x1bP+q524742\x1b\\" + "\x1bP+q5463\x1b\\" + "\x1b[14t" + "\x1b[c"
+)
+
+// DeviceAttributes describes the terminal, as it reports itself.
+type DeviceAttributes struct {
+	// Name is the name and version reported by XTVERSION, for example
+	// "xterm(388)" or "kitty(0.31.0)", or empty if not reported.
+	Name string
+
+	// Type and Version are from the secondary device attributes.  For
+	// xterm the type is 41, and the version is the patch level.
+	Type    int
+	Version int
+
+	// Attributes are those of the primary device attributes, such as
+	// 4 for sixel graphics and 22 for ANSI color.
+	Attributes []int
+
+	// Sixel is true if the terminal can display sixel graphics.
+	Sixel bool
+
+	// TrueColor is true if the terminal claims direct (24-bit) color
+	// through the RGB or Tc capabilities.
+	TrueColor bool
+
+	// KittyKeyboard is true if the terminal supports the progressive
+	// keyboard enhancements introduced by kitty.
+	KittyKeyboard bool
+
+	// Complete is true once the terminal has replied to all of the
+	// queries that it will reply to.
+	Complete bool
+}
+
+// EventDeviceAttributes is sent once the terminal has replied to the
+// queries sent by ProbeDeviceAttributes.
+type EventDeviceAttributes struct {
+	t     time.Time
+	attrs DeviceAttributes
+}
+
+// NewEventDeviceAttributes creates an EventDeviceAttributes.
+func NewEventDeviceAttributes(attrs DeviceAttributes) *EventDeviceAttributes {
+	return &EventDeviceAttributes{t: time.Now(), attrs: attrs}
+}
+
+func (ev *EventDeviceAttributes) When() time.Time {
+	return ev.t
+}
+
+// Attributes returns the attributes reported by the terminal.
+func (ev *EventDeviceAttributes) Attributes() DeviceAttributes {
+	return ev.attrs
+}
+
+// DeviceAttributesScreen is implemented by Screens that can ask the
+// terminal to describe itself.  The terminfo based screen does so.
+// Probing is also done by Init if $TCELL_PROBE_DEVICE is set to 1.
+type DeviceAttributesScreen interface {
+	// ProbeDeviceAttributes sends the queries.  The replies arrive
+	// with the rest of the input, and once they are in, an
+	// EventDeviceAttributes is posted.  Terminals that do not reply
+	// at all (which is rare) are not reported on.
+	ProbeDeviceAttributes()
+
+	// DeviceAttributes returns what the terminal has reported so far.
+	DeviceAttributes() DeviceAttributes
+
+	Screen
+}
+
+// parseDeviceReply parses the replies to the device queries.  Those
+// that begin with DCS (ESC P) are only looked for while a probe is
+// outstanding, since otherwise ESC P is Alt-P.
+func (ip *InputParser) parseDeviceReply(buf *bytes.Buffer) (bool, bool) {
+	b := buf.Bytes()
+	switch {
+	case bytes.HasPrefix(b, []byte("\x1b[")):
+		return ip.parseDeviceCsi(buf, 2)
+	case b[0] == '\x9b':
+		return ip.parseDeviceCsi(buf, 1)
+	case ip.probing && bytes.HasPrefix(b, []byte("\x1bP")):
+		return ip.parseDeviceDcs(buf, 2)
+	case ip.probing && b[0] == '\x90':
+		return ip.parseDeviceDcs(buf, 1)
+	}
+	return false, false
+}
+
+// parseDeviceCsi parses CSI ? ... c, CSI > ... c, CSI ? ... u, and
+// CSI 4 ; ... t.
+func (ip *InputParser) parseDeviceCsi(buf *bytes.Buffer, skip int) (bool, bool) {
+	b := buf.Bytes()[skip:]
+	if len(b) == 0 {
+		return true, false
+	}
+	lead := b[0]
+	start := 1
+	switch {
+	case lead == '4':
+		// no leader; the 4 is the first parameter
+		start = 0
+	case lead != '?' && lead != '>':
+		return false, false
+	}
+	for i := 1; i < len(b); i++ {
+		switch c := b[i]; {
+		case c >= '0' && c <= '9', c == ';':
+			continue
+		case (c == 'c' && start == 1) || (c == 'u' && lead == '?') ||
+			(c == 't' && start == 0 && strings.HasPrefix(string(b[:i]), "4;")):
+			var vals []int
+			for _, f := range strings.Split(string(b[start:i]), ";") {
+				v, _ := strconv.Atoi(f)
+				vals = append(vals, v)
+			}
+			buf.Next(skip + i + 1)
+			ip.deviceReply(lead, c, vals)
+			return true, true
+		default:
+			return false, false
+		}
+	}
+	return true, false
+}
+
+// parseDeviceDcs parses DCS > | text ST and DCS n + r hex = hex ST.
+func (ip *InputParser) parseDeviceDcs(buf *bytes.Buffer, skip int) (bool, bool) {
+	b := buf.Bytes()
+	end, st := bytes.Index(b, []byte("\x1b\\")), 2
+	if i := bytes.IndexByte(b, '\x9c'); i >= 0 && (end < 0 || i < end) {
+		end, st = i, 1
+	}
+	if end < 0 {
+		return true, false
+	}
+	s := string(b[skip:end])
+	buf.Next(end + st)
+	switch {
+	case strings.HasPrefix(s, ">|"):
+		ip.devattr.Name = s[2:]
+	case strings.HasPrefix(s, "1+r"):
+		name := s[3:]
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name = name[:i]
+		}
+		if cap, e := hex.DecodeString(name); e == nil {
+			switch string(cap) {
+			case "RGB", "Tc":
+				ip.devattr.TrueColor = true
+			}
+		}
+	}
+	return true, true
+}
+
+// deviceReply records a reply to one of the CSI queries.
+func (ip *InputParser) deviceReply(lead, final byte, vals []int) {
+	da := &ip.devattr
+	switch {
+	case final == 't':
+		if len(vals) == 3 {
+			ip.setPixelSize(vals[2], vals[1], true)
+		}
+	case final == 'u':
+		da.KittyKeyboard = true
+	case lead == '>':
+		if len(vals) >= 2 {
+			da.Type, da.Version = vals[0], vals[1]
+		}
+	default:
+		da.Attributes = vals[1:]
+		for _, v := range da.Attributes {
+			if v == 4 {
+				da.Sixel = true
+			}
+		}
+		if ip.probing {
+			ip.probing = false
+			da.Complete = true
+			ip.post(NewEventDeviceAttributes(*da))
+		}
+	}
+}
@@ -37,4 +37,14 @@
 // A rich set of keycodes is supported, with support for up to 65 function
 // keys, and various other special keys.
 //
+// Parts of Tcell can be left out, for very small binaries (on embedded
+// systems for example), by building with the following tags:
+//
+//	tcell_nomouse     no mouse support; EnableMouse does nothing
+//	tcell_noencoding  encoding.Register registers no character sets
+//	tcell_noconsole   no Windows console support; NewConsoleScreen fails
+//	tcell_noimage     no images; PutImage fails with ErrNoImages
+//
+// By default, everything is included.
+//
 package tcell
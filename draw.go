@@ -0,0 +1,185 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"strings"
+)
+
+// These helpers draw text and boxes on a Screen, which nearly every
+// application needs to do.  They draw with SetCell, so nothing is visible
+// until Show.  Everything is clipped to the rectangle given, as well as
+// to the Screen.  Wide characters take two columns, and are not drawn if
+// only one is left; combining marks are drawn with the character that
+// they follow.
+
+// textCell is a character, with any combining marks, and its width.
+type textCell struct {
+	runes []rune
+	width int
+}
+
+// textCells splits the string into the characters that occupy cells.
+// Control characters are dropped.
+func textCells(s string) []textCell {
+	var cells []textCell
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			continue
+		}
+		w := runeWidth(r)
+		if w == 0 {
+			if len(cells) == 0 {
+				cells = append(cells, textCell{runes: []rune{' '}, width: 1})
+			}
+			c := &cells[len(cells)-1]
+			c.runes = append(c.runes, r)
+			continue
+		}
+		cells = append(cells, textCell{runes: []rune{r}, width: w})
+	}
+	return cells
+}
+
+// drawCells draws the cells in a row, starting at x, y, but not past
+// column x+width, and returns the number of columns used.
+func drawCells(s Screen, x, y, width int, style Style, cells []textCell) int {
+	col := 0
+	for _, c := range cells {
+		if col+c.width > width {
+			break
+		}
+		s.SetCell(x+col, y, style, c.runes...)
+		col += c.width
+	}
+	return col
+}
+
+// DrawText draws the text on one row, starting at x, y, in the style, and
+// clipped to width columns.  It returns the number of columns used.
+func DrawText(s Screen, x, y, width int, style Style, text string) int {
+	return drawCells(s, x, y, width, style, textCells(text))
+}
+
+// DrawWrapped draws the text in the rectangle with the given origin and
+// dimensions, in the style.  Lines are broken at newlines, and between
+// words where they would otherwise be wider than the rectangle; words
+// that are too long for a row on their own are broken where they must
+// be.  Text that does not fit in the rectangle is not drawn.  It returns
+// the number of rows that the text needs, which may be more than height.
+func DrawWrapped(s Screen, x, y, width, height int, style Style, text string) int {
+	lines := wrapText(text, width)
+	for row, line := range lines {
+		if row >= height {
+			break
+		}
+		drawCells(s, x, y+row, width, style, line)
+	}
+	return len(lines)
+}
+
+// wrapText breaks the text into lines of at most width columns.
+func wrapText(text string, width int) [][]textCell {
+	if width < 1 {
+		return nil
+	}
+	var lines [][]textCell
+	for _, para := range strings.Split(text, "\n") {
+		var line, spaces []textCell
+		lw := 0
+		cells := textCells(para)
+		for len(cells) > 0 {
+			if cells[0].runes[0] == ' ' && len(cells[0].runes) == 1 {
+				// spaces are only kept between words on a line
+				// (and to indent the paragraph)
+				spaces = append(spaces, cells[0])
+				cells = cells[1:]
+				continue
+			}
+			n, ww := 0, 0
+			for n < len(cells) && !(cells[n].runes[0] == ' ' && len(cells[n].runes) == 1) {
+				ww += cells[n].width
+				n++
+			}
+			word := cells[:n]
+			cells = cells[n:]
+			sw := cellsWidth(spaces)
+			if lw > 0 && lw+sw+ww > width {
+				lines = append(lines, line)
+				line, lw, spaces, sw = nil, 0, nil, 0
+			}
+			if lw+sw < width {
+				line = append(line, spaces...)
+				lw += sw
+			}
+			spaces = nil
+			for _, c := range word {
+				if lw+c.width > width {
+					// break a word that does not fit
+					lines = append(lines, line)
+					line, lw = nil, 0
+				}
+				line = append(line, c)
+				lw += c.width
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func cellsWidth(cells []textCell) int {
+	w := 0
+	for _, c := range cells {
+		w += c.width
+	}
+	return w
+}
+
+// DrawBox draws a border around the rectangle with the given origin and
+// dimensions, in the style, using the line drawing runes.  (On terminals
+// without them, they are drawn with the alternate character set, or with
+// ASCII characters.)  The inside of the box is left alone; see FillRect.
+// Rectangles that are less than two cells wide or high get a single line.
+func DrawBox(s Screen, x, y, width, height int, style Style) {
+	if width < 1 || height < 1 {
+		return
+	}
+	x2, y2 := x+width-1, y+height-1
+	for col := x; col <= x2; col++ {
+		s.SetCell(col, y, style, RuneHLine)
+		s.SetCell(col, y2, style, RuneHLine)
+	}
+	for row := y; row <= y2; row++ {
+		s.SetCell(x, row, style, RuneVLine)
+		s.SetCell(x2, row, style, RuneVLine)
+	}
+	if width > 1 && height > 1 {
+		s.SetCell(x, y, style, RuneULCorner)
+		s.SetCell(x2, y, style, RuneURCorner)
+		s.SetCell(x, y2, style, RuneLLCorner)
+		s.SetCell(x2, y2, style, RuneLRCorner)
+	}
+}
+
+// FillRect sets every cell of the rectangle with the given origin and
+// dimensions to the rune, in the style.
+func FillRect(s Screen, x, y, width, height int, style Style, r rune) {
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			s.SetCell(col, row, style, r)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDraw(t *testing.T) {
+	Convey("Drawing helpers", t, WithScreen(t, "", func(s SimulationScreen) {
+
+		Convey("Text is clipped", func() {
+			n := DrawText(s, 2, 1, 5, StyleDefault, "hello world")
+			s.Show()
+			So(n, ShouldEqual, 5)
+			So(regionText(s, 2, 1, 6), ShouldEqual, "hello ")
+		})
+
+		Convey("Combining marks join their character", func() {
+			n := DrawText(s, 0, 0, 10, StyleDefault, "éx")
+			s.Show()
+			So(n, ShouldEqual, 2)
+			So(regionText(s, 0, 0, 2), ShouldEqual, "éx")
+		})
+
+		Convey("Wide characters are not split", func() {
+			n := DrawText(s, 0, 0, 3, StyleDefault, "a世世")
+			s.Show()
+			So(n, ShouldEqual, 3)
+			b, pw, _ := s.GetContents()
+			So(b[1].Runes, ShouldResemble, []rune{'世'})
+			So(regionText(s, 3, 0, 1), ShouldEqual, " ")
+			So(pw, ShouldBeGreaterThan, 3)
+		})
+
+		Convey("Text wraps at words", func() {
+			rows := DrawWrapped(s, 0, 0, 10, 5, StyleDefault,
+				"the quick brown fox\n  jumps")
+			s.Show()
+			So(rows, ShouldEqual, 3)
+			So(regionText(s, 0, 0, 10), ShouldEqual, "the quick ")
+			So(regionText(s, 0, 1, 10), ShouldEqual, "brown fox ")
+			So(regionText(s, 0, 2, 10), ShouldEqual, "  jumps   ")
+		})
+
+		Convey("Long words are broken", func() {
+			rows := DrawWrapped(s, 0, 0, 4, 1, StyleDefault, "abcdefghij")
+			s.Show()
+			So(rows, ShouldEqual, 3)
+			So(regionText(s, 0, 0, 5), ShouldEqual, "abcd ")
+			So(regionText(s, 0, 1, 5), ShouldEqual, "     ")
+		})
+
+		Convey("Boxes are drawn", func() {
+			FillRect(s, 1, 1, 3, 3, StyleDefault, '.')
+			DrawBox(s, 0, 0, 5, 5, StyleDefault)
+			s.Show()
+			So(regionText(s, 0, 0, 5), ShouldEqual, "┌───┐")
+			So(regionText(s, 0, 2, 5), ShouldEqual, "│...│")
+			So(regionText(s, 0, 4, 5), ShouldEqual, "└───┘")
+		})
+	}))
+}
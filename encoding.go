@@ -15,6 +15,7 @@
 package tcell
 
 import (
+	"strings"
 	"sync"
 
 	"golang.org/x/text/encoding"
@@ -59,6 +60,16 @@ var encodingLk sync.Mutex
 // any of this.  Use of UTF-8 is recommended when possible, as it saves
 // quite a lot processing overhead.
 //
+// Any encoding.Encoding can be registered, including one of the
+// application's own, for terminals that use a character set that neither
+// x/text nor tcell knows about; and one registered under a name that is
+// already registered replaces it.  Without an encoding for the character
+// set of the terminal, Init fails.  Names are matched without regard to
+// case or punctuation, so "ISO-8859-15", "iso885915" and "ISO8859_15" are
+// all the same.  UTF-8 and US-ASCII (under any of their usual names) are
+// always supported, and cannot be registered.  Registering nil removes
+// the encoding.
+//
 // Note that some encodings are quite large (for example GB18030 which is a
 // superset of Unicode) and so the application size can be expected ot
 // increase quite a bit as each encoding is added.  The East Asian encodings
@@ -69,7 +80,11 @@ func RegisterEncoding(name string, enc encoding.Encoding) {
 	if encodings == nil {
 		encodings = make(map[string]encoding.Encoding)
 	}
-	encodings[name] = enc
+	if enc == nil {
+		delete(encodings, encodingKey(name))
+	} else {
+		encodings[encodingKey(name)] = enc
+	}
 	encodingLk.Unlock()
 }
 
@@ -80,8 +95,36 @@ func RegisterEncoding(name string, enc encoding.Encoding) {
 func GetEncoding(name string) encoding.Encoding {
 	encodingLk.Lock()
 	defer encodingLk.Unlock()
-	if enc, ok := encodings[name]; ok {
+	if enc, ok := encodings[encodingKey(name)]; ok {
 		return enc
 	}
 	return nil
 }
+
+// encodingKey returns the key that the encoding for a character set is
+// registered under: its name in upper case, without punctuation.
+func encodingKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// canonicalCharset returns the name that we use for the character set,
+// which is "UTF-8" or "US-ASCII" for those, however they are spelled (as
+// in the "en_US.utf8" locale), and otherwise the name as given.  The
+// empty name means UTF-8.
+func canonicalCharset(name string) string {
+	switch encodingKey(name) {
+	case "", "UTF8":
+		return "UTF-8"
+	case "USASCII", "ASCII", "ANSIX341968", "646":
+		return "US-ASCII"
+	}
+	return name
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chinese registers the Chinese character sets, GB18030, GB2312,
+// GBK and Big5 (including Big5-HKSCS), with tcell.
+package chinese
+
+import (
+	"github.com/gdamore/tcell"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// Register registers the character sets, and their common aliases.
+func Register() {
+	tcell.RegisterEncoding("GB18030", simplifiedchinese.GB18030)
+	// GB2312 locales use EUC-CN, of which GBK is a superset.  (HZ is
+	// a 7 bit form for mail, which terminals do not use.)
+	tcell.RegisterEncoding("GB2312", simplifiedchinese.GBK)
+	tcell.RegisterEncoding("GBK", simplifiedchinese.GBK)
+	tcell.RegisterEncoding("HZ-GB-2312", simplifiedchinese.HZGB2312)
+
+	// This includes the HKSCS extensions.
+	tcell.RegisterEncoding("Big5", traditionalchinese.Big5)
+
+	tcell.RegisterEncoding("EUC-CN", simplifiedchinese.GBK)
+	tcell.RegisterEncoding("CP936", simplifiedchinese.GBK)
+	tcell.RegisterEncoding("Big5-HKSCS", traditionalchinese.Big5)
+	tcell.RegisterEncoding("CP950", traditionalchinese.Big5)
+}
@@ -0,0 +1,27 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encoding registers the character sets that tcell knows about,
+// for terminals that do not use UTF-8.  Each of them adds to the size of
+// the program, the East Asian ones by 100-200K apiece, so applications
+// that only need some of them should import the sub packages for those
+// instead, and call their Register functions:
+//
+//	charmap   ISO 8859 and KOI8
+//	japanese  EUC-JP, Shift_JIS and ISO-2022-JP
+//	korean    EUC-KR
+//	chinese   GB18030, GB2312, GBK and Big5 (with HKSCS)
+//
+// Applications that only use UTF-8 need none of this.
+package encoding
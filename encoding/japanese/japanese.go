@@ -0,0 +1,36 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package japanese registers the Japanese character sets, EUC-JP,
+// Shift_JIS and ISO-2022-JP, with tcell.
+package japanese
+
+import (
+	"github.com/gdamore/tcell"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Register registers the character sets, and their common aliases.
+func Register() {
+	tcell.RegisterEncoding("EUC-JP", japanese.EUCJP)
+	tcell.RegisterEncoding("Shift_JIS", japanese.ShiftJIS)
+	tcell.RegisterEncoding("ISO2022JP", japanese.ISO2022JP)
+
+	tcell.RegisterEncoding("SJIS", japanese.ShiftJIS)
+	tcell.RegisterEncoding("CP932", japanese.ShiftJIS)
+	tcell.RegisterEncoding("eucJP", japanese.EUCJP)
+	tcell.RegisterEncoding("2022-JP", japanese.ISO2022JP)
+	tcell.RegisterEncoding("ISO-2022-JP", japanese.ISO2022JP)
+}
@@ -0,0 +1,29 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package korean registers the Korean character set, EUC-KR, with tcell.
+package korean
+
+import (
+	"github.com/gdamore/tcell"
+
+	"golang.org/x/text/encoding/korean"
+)
+
+// Register registers the character set, and its common aliases.
+func Register() {
+	tcell.RegisterEncoding("EUC-KR", korean.EUCKR)
+	tcell.RegisterEncoding("eucKR", korean.EUCKR)
+	tcell.RegisterEncoding("CP949", korean.EUCKR)
+}
@@ -1,4 +1,4 @@
-// +build windows nacl plan9
+// +build windows nacl plan9 tcell_noencoding
 
 // Copyright 2015 The TCell Authors
 //
@@ -16,10 +16,14 @@
 
 package encoding
 
+// Register registers all of the character sets.
 func Register() {
 	// So Windows is only UTF-16LE (yay!)
 
 	// Other platforms that don't use termios/terminfo are pretty much unsupported.
 	// Therefore, we shouldn't bring in all this stuff because it creates a lot of
 	// bloat for those platforms.  So, just punt.
+
+	// The same goes for builds with the tcell_noencoding tag, whose
+	// users have asked us to leave the tables out.
 }
@@ -22,6 +22,7 @@ import (
 var (
 	ErrNoDatabase   = errors.New("terminal database not found")
 	ErrTermNotFound = errors.New("terminal entry not found")
+	ErrNoCapability = errors.New("terminal lacks the capability")
 )
 
 type EventError struct {
@@ -75,3 +75,84 @@ func TestMouseEvents(t *testing.T) {
 	}))
 
 }
+
+func TestKeyNames(t *testing.T) {
+	Convey("Key names", t, func() {
+		So(NewEventKey(KeyF5, 0, ModCtrl|ModShift).Name(),
+			ShouldEqual, "Ctrl+Shift+F5")
+		So(NewEventKey(KeyRune, 'é', ModAlt).Name(), ShouldEqual, "Alt+Rune[é]")
+		So(NewEventKey(KeyRune, 1, ModNone).Name(), ShouldEqual, "Ctrl+A")
+		So(NewEventKey(KeyCtrlA, 0, ModNone).Name(), ShouldEqual, "Ctrl-A")
+		So(NewEventKey(KeyTab, 0, ModNone).Name(), ShouldEqual, "Tab")
+		So(NewEventKey(KeyHelp, 0, ModNone).Name(), ShouldEqual, "Help")
+		So(NewEventKey(Key(999), 0, ModNone).Name(), ShouldEqual, "Key[999,0]")
+
+		Convey("Every key has a name", func() {
+			for k := KeyRune; k <= KeyKPDivide; k++ {
+				So(KeyNames[k], ShouldNotBeEmpty)
+			}
+			for k := KeyCtrlSpace; k <= KeyCtrlUnderscore; k++ {
+				So(KeyNames[k], ShouldNotBeEmpty)
+			}
+		})
+
+		Convey("Names parse back", func() {
+			for _, ev := range []*EventKey{
+				NewEventKey(KeyF5, 0, ModCtrl|ModShift),
+				NewEventKey(KeyRune, 'é', ModAlt),
+				NewEventKey(KeyRune, '+', ModCtrl),
+				NewEventKey(KeyRune, 1, ModNone),
+				NewEventKey(KeyRune, 0, ModNone),
+				NewEventKey(KeyRune, ' ', ModNone),
+				NewEventKey(KeyRune, 0x7f, ModNone),
+				NewEventKey(KeyEnter, 0, ModNone),
+				NewEventKey(KeyPgDn, 0, ModMeta),
+				NewEventKey(KeyKP7, '7', ModShift),
+			} {
+				p, e := ParseKeyName(ev.Name())
+				So(e, ShouldBeNil)
+				So(p.Key(), ShouldEqual, ev.Key())
+				So(p.Mod(), ShouldEqual, ev.Mod())
+				if ev.Key() == KeyRune || ev.Key() >= KeyKP0 {
+					So(p.Rune(), ShouldEqual, ev.Rune())
+				}
+			}
+		})
+
+		Convey("Names are parsed leniently", func() {
+			ev, e := ParseKeyName("shift+ctrl+f5")
+			So(e, ShouldBeNil)
+			So(ev.Key(), ShouldEqual, KeyF5)
+			So(ev.Mod(), ShouldEqual, ModCtrl|ModShift)
+
+			ev, e = ParseKeyName("Ctrl+x")
+			So(e, ShouldBeNil)
+			So(ev.Key(), ShouldEqual, KeyCtrlX)
+			So(ev.Mod(), ShouldEqual, ModCtrl)
+
+			ev, e = ParseKeyName("q")
+			So(e, ShouldBeNil)
+			So(ev.Key(), ShouldEqual, KeyRune)
+			So(ev.Rune(), ShouldEqual, 'q')
+
+			_, e = ParseKeyName("Ctrl+Nope")
+			So(e, ShouldEqual, ErrBadKeyName)
+			_, e = ParseKeyName("")
+			So(e, ShouldEqual, ErrBadKeyName)
+		})
+	})
+}
+
+func TestCompositionEvents(t *testing.T) {
+	Convey("Composition events", t, WithScreen(t, "", func(s SimulationScreen) {
+		s.PostEvent(NewEventComposition("´", ""))
+		s.PostEvent(NewEventComposition("", "é"))
+		ev := s.PollEvent().(*EventComposition)
+		So(ev.Preedit(), ShouldEqual, "´")
+		So(ev.Commit(), ShouldBeEmpty)
+		ev = s.PollEvent().(*EventComposition)
+		So(ev.Preedit(), ShouldBeEmpty)
+		So(ev.Commit(), ShouldEqual, "é")
+		So(ev.When().IsZero(), ShouldBeFalse)
+	}))
+}
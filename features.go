@@ -0,0 +1,126 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// Feature reports on one of the features that a Screen may offer, and
+// how it is provided on the display at hand.  It is meant for diagnostic
+// reports, such as an "about my terminal" screen, or logs attached to a
+// bug report; applications should not rely on the exact Mode strings.
+// The field tags allow a list of features to be marshalled as JSON.
+type Feature struct {
+	// Name is one of the Feature constants.
+	Name string `json:"name"`
+
+	// Active is true if the feature is available, and in use where
+	// it has to be enabled (as with the mouse).
+	Active bool `json:"active"`
+
+	// Mode describes how the feature is provided, or the fallback
+	// that is used in its absence, for example "software" for blink
+	// on a terminal that cannot blink by itself.
+	Mode string `json:"mode,omitempty"`
+}
+
+// These are the names of the features reported.  Every Screen reports
+// all of them, in this order.
+const (
+	FeatureColor           = "color"
+	FeatureTrueColor       = "truecolor"
+	FeatureBold            = "bold"
+	FeatureDim             = "dim"
+	FeatureUnderline       = "underline"
+	FeatureUnderlineStyles = "underline-styles"
+	FeatureUnderlineColor  = "underline-color"
+	FeatureReverse         = "reverse"
+	FeatureBlink           = "blink"
+	FeatureUnicode         = "unicode"
+	FeatureLineDrawing     = "line-drawing"
+	FeatureMouse           = "mouse"
+	FeatureMousePixels     = "mouse-pixels"
+	FeaturePaste           = "paste"
+	FeatureComposition     = "composition"
+	FeatureResize          = "resize"
+	FeatureColorScheme     = "color-scheme"
+	FeatureTransform       = "transform"
+	FeatureRecording       = "recording"
+)
+
+// FeatureNames lists all of the features, in the order reported.
+var FeatureNames = []string{
+	FeatureColor,
+	FeatureTrueColor,
+	FeatureBold,
+	FeatureDim,
+	FeatureUnderline,
+	FeatureUnderlineStyles,
+	FeatureUnderlineColor,
+	FeatureReverse,
+	FeatureBlink,
+	FeatureUnicode,
+	FeatureLineDrawing,
+	FeatureMouse,
+	FeatureMousePixels,
+	FeaturePaste,
+	FeatureComposition,
+	FeatureResize,
+	FeatureColorScheme,
+	FeatureTransform,
+	FeatureRecording,
+}
+
+// FeatureScreen is implemented by Screens that can report on their
+// features.  All of the screens in this package do so.
+type FeatureScreen interface {
+	// Features returns the state of every feature, in the order
+	// of FeatureNames.  The report reflects the current state, so
+	// that for example the mouse is only reported as active once it
+	// has been enabled.
+	Features() []Feature
+
+	Screen
+}
+
+// featureSet collects features for a report.  Screens start with
+// newFeatureSet, which marks everything as unsupported, and then fill
+// in what they know about.
+type featureSet map[string]Feature
+
+func newFeatureSet() featureSet {
+	fs := make(featureSet)
+	for _, name := range FeatureNames {
+		fs[name] = Feature{Name: name, Mode: "unsupported"}
+	}
+	return fs
+}
+
+func (fs featureSet) set(name string, active bool, mode string) {
+	fs[name] = Feature{Name: name, Active: active, Mode: mode}
+}
+
+// setFlag sets a feature that is active if the capability string is
+// present.
+func (fs featureSet) setFlag(name string, cap string) {
+	if cap != "" {
+		fs.set(name, true, "")
+	}
+}
+
+func (fs featureSet) list() []Feature {
+	features := make([]Feature, 0, len(FeatureNames))
+	for _, name := range FeatureNames {
+		features = append(features, fs[name])
+	}
+	return features
+}
@@ -0,0 +1,172 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"sync"
+	"time"
+)
+
+// EventFilter is a function that sees each event before PollEvent returns
+// it.  It returns true if it has handled the event, in which case
+// PollEvent goes on to wait for the next one instead.
+type EventFilter func(ev Event) bool
+
+// FilterScreen is implemented by Screens that support an EventFilter.
+// All of the screens in this package do so.
+type FilterScreen interface {
+	// SetEventFilter installs the filter, replacing any that was set
+	// before.  A nil filter removes it.
+	//
+	// The filter is called from PollEvent, in the goroutine that called
+	// PollEvent, and no lock of the Screen is held while it runs.  So it
+	// may use any of the methods of the Screen -- including SetCell,
+	// Show and Sync -- to redraw inline, which is what callback based
+	// applications want to do.  A filter that handles EventResize serves
+	// as a resize hook; by the time it runs, the Screen has already
+	// taken on the new size.  The only thing that a filter must not do
+	// is call PollEvent itself.
+	SetEventFilter(f EventFilter)
+
+	Screen
+}
+
+// eventFilter holds the filter of a Screen, and implements PollEvent
+// around it.  It has its own lock, so that the filter never runs with
+// the lock of the Screen held.  It also keeps track of idle input.
+type eventFilter struct {
+	f       EventFilter
+	idle    time.Duration // idle timeout, or zero if off
+	last    time.Time     // time of the last input
+	isIdle  bool          // an EventIdle was delivered
+	pending Event         // the input that ended the idle spell
+	changed chan struct{} // closed when the idle timeout changes
+	sync.Mutex
+}
+
+func (ef *eventFilter) set(f EventFilter) {
+	ef.Lock()
+	ef.f = f
+	ef.Unlock()
+}
+
+func (ef *eventFilter) setIdle(d time.Duration) {
+	ef.Lock()
+	ef.idle = d
+	ef.last = time.Now()
+	ef.isIdle = false
+	if ef.changed != nil {
+		close(ef.changed)
+		ef.changed = nil
+	}
+	ef.Unlock()
+}
+
+// idleWait returns how long to wait for input before going idle (which
+// is less than zero if we should not), and a channel that is closed if
+// that changes.
+func (ef *eventFilter) idleWait() (time.Duration, <-chan struct{}) {
+	if ef.changed == nil {
+		ef.changed = make(chan struct{})
+	}
+	if ef.idle <= 0 || ef.isIdle {
+		return -1, ef.changed
+	}
+	if d := ef.idle - time.Since(ef.last); d > 0 {
+		return d, ef.changed
+	}
+	return 0, ef.changed
+}
+
+// goIdle returns an EventIdle, if the input has now been idle for long
+// enough, or nil.
+func (ef *eventFilter) goIdle() Event {
+	ef.Lock()
+	defer ef.Unlock()
+	if ef.idle <= 0 || ef.isIdle || time.Since(ef.last) < ef.idle {
+		return nil
+	}
+	ef.isIdle = true
+	return NewEventIdle(ef.last)
+}
+
+// active notes input.  If that ends an idle spell, it returns an
+// EventActive, and holds the input back to be delivered after it.
+func (ef *eventFilter) active(ev Event) Event {
+	switch ev.(type) {
+	case *EventKey, *EventMouse:
+	default:
+		return ev
+	}
+	ef.Lock()
+	defer ef.Unlock()
+	ef.last = time.Now()
+	if !ef.isIdle {
+		return ev
+	}
+	ef.isIdle = false
+	ef.pending = ev
+	return NewEventActive()
+}
+
+// poll returns the next event from evch that the filter does not
+// handle, or nil once quit is closed.
+func (ef *eventFilter) poll(quit <-chan struct{}, evch <-chan Event) Event {
+	for {
+		// Once finalized, don't hand out events that were still
+		// queued.
+		select {
+		case <-quit:
+			return nil
+		default:
+		}
+		ef.Lock()
+		ev := ef.pending
+		ef.pending = nil
+		wait, changed := ef.idleWait()
+		ef.Unlock()
+		if ev == nil {
+			got := false
+			var timer *time.Timer
+			var expired <-chan time.Time
+			if wait >= 0 {
+				timer = time.NewTimer(wait)
+				expired = timer.C
+			}
+			select {
+			case <-quit:
+				return nil
+			case ev = <-evch:
+				ev, got = ef.active(ev), true
+			case <-expired:
+				ev = ef.goIdle()
+				got = ev != nil
+			case <-changed:
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			if !got {
+				continue
+			}
+		}
+		ef.Lock()
+		f := ef.f
+		ef.Unlock()
+		if f == nil || !f(ev) {
+			return ev
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EventFormatter spells out events as short, human readable strings,
+// such as "Ctrl+Shift+PgUp", "Mouse Button1 Press @ (10,4)" or
+// "Resize 80x24", for trace logs, debugging displays and help screens.
+// The English forms are stable, so that they can also be used as keys
+// in configuration files or compared in tests.
+//
+// The zero value is ready to use, and produces English.
+type EventFormatter struct {
+	// Translate, if not nil, is called with each of the fixed words
+	// that make up a string -- modifier, key, button and event names,
+	// such as "Ctrl", "PgUp", "Button1", "Press" or "Resize" -- and
+	// returns the word to use in its place.  This is the hook for
+	// localization.  Runes, numbers and error messages are used as is.
+	Translate func(word string) string
+}
+
+// FormatEvent spells out the event in English.  (See EventFormatter.)
+func FormatEvent(ev Event) string {
+	var f EventFormatter
+	return f.Format(ev)
+}
+
+// Format returns the string for the event.  Events of types that are
+// not defined by this package are formatted with their String method,
+// if they have one, or else by their type name.
+func (f *EventFormatter) Format(ev Event) string {
+	switch ev := ev.(type) {
+	case *EventKey:
+		return f.formatKey(ev)
+	case *EventMouse:
+		return f.formatMouse(ev)
+	case *EventResize:
+		w, h := ev.Size()
+		return fmt.Sprintf("%s %dx%d", f.word("Resize"), w, h)
+	case *EventRedraw:
+		x, y, w, h := ev.Region()
+		return fmt.Sprintf("%s %dx%d @ (%d,%d)", f.word("Redraw"), w, h, x, y)
+	case *EventColorsChanged:
+		return f.word("Colors") + " " + f.word(ev.Scheme().String())
+	case *EventIdle:
+		return f.word("Idle")
+	case *EventActive:
+		return f.word("Active")
+	case *EventInterrupt:
+		return f.word("Interrupt")
+	case *EventClose:
+		return f.word("Close")
+	case *EventError:
+		return f.word("Error") + ": " + ev.Error()
+	case fmt.Stringer:
+		return ev.String()
+	case nil:
+		return ""
+	}
+	name := fmt.Sprintf("%T", ev)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func (f *EventFormatter) word(w string) string {
+	if f.Translate != nil {
+		return f.Translate(w)
+	}
+	return w
+}
+
+// mods returns the words for the modifiers, in the conventional order.
+func (f *EventFormatter) mods(mod ModMask) []string {
+	var words []string
+	for _, mn := range modNames {
+		if mod&mn.mod != 0 {
+			words = append(words, f.word(mn.name))
+		}
+	}
+	return words
+}
+
+// keyWords are the names of the keys that have one.  Keys that double
+// as control characters (Tab is also Ctrl-I) are listed under the name
+// of the key.
+var keyWords = map[Key]string{
+	KeySpace:          "Space",
+	KeyEnter:          "Enter",
+	KeyBackspace:      "Backspace",
+	KeyTab:            "Tab",
+	KeyBacktab:        "Backtab",
+	KeyEsc:            "Esc",
+	KeyBackspace2:     "Backspace2",
+	KeyDelete:         "Delete",
+	KeyInsert:         "Insert",
+	KeyUp:             "Up",
+	KeyDown:           "Down",
+	KeyLeft:           "Left",
+	KeyRight:          "Right",
+	KeyHome:           "Home",
+	KeyEnd:            "End",
+	KeyUpLeft:         "UpLeft",
+	KeyUpRight:        "UpRight",
+	KeyDownLeft:       "DownLeft",
+	KeyDownRight:      "DownRight",
+	KeyCenter:         "Center",
+	KeyPgUp:           "PgUp",
+	KeyPgDn:           "PgDn",
+	KeyHelp:           "Help",
+	KeyClear:          "Clear",
+	KeyExit:           "Exit",
+	KeyCancel:         "Cancel",
+	KeyPause:          "Pause",
+	KeyPrint:          "Print",
+	KeyCtrlSpace:      "Space",
+	KeyCtrlBackslash:  "\\",
+	KeyCtrlRightSq:    "]",
+	KeyCtrlCarat:      "^",
+	KeyCtrlUnderscore: "_",
+}
+
+func (f *EventFormatter) formatKey(ev *EventKey) string {
+	mod := ev.Mod()
+	key := ev.Key()
+	var name string
+	switch {
+	case key == KeyRune:
+		name = string(ev.Rune())
+	case keyWords[key] != "":
+		name = f.word(keyWords[key])
+		if key <= KeyCtrlUnderscore && key != KeyBackspace &&
+			key != KeyTab && key != KeyEnter && key != KeyEsc {
+			mod |= ModCtrl
+		}
+	case key >= KeyF1 && key <= KeyF64:
+		name = fmt.Sprintf("F%d", int(key-KeyF1)+1)
+	case key >= KeyKP0 && key <= KeyKPDivide:
+		name = f.word(KeyNames[key])
+	case key >= KeyCtrlA && key <= KeyCtrlZ:
+		name = string(rune(key-KeyCtrlA) + 'A')
+		mod |= ModCtrl
+	default:
+		name = fmt.Sprintf("%s[%d]", f.word("Key"), int(key))
+	}
+	return strings.Join(append(f.mods(mod), name), "+")
+}
+
+var buttonWords = []struct {
+	btn  ButtonMask
+	word string
+}{
+	{Button1, "Button1"},
+	{Button2, "Button2"},
+	{Button3, "Button3"},
+	{Button4, "Button4"},
+	{Button5, "Button5"},
+	{Button6, "Button6"},
+	{Button7, "Button7"},
+	{Button8, "Button8"},
+	{WheelUp, "WheelUp"},
+	{WheelDown, "WheelDown"},
+	{WheelLeft, "WheelLeft"},
+	{WheelRight, "WheelRight"},
+}
+
+func (f *EventFormatter) formatMouse(ev *EventMouse) string {
+	words := f.mods(ev.Modifiers())
+	words = append(words, f.word("Mouse"))
+
+	btn := ev.Buttons()
+	var btns []string
+	for _, bw := range buttonWords {
+		if btn&bw.btn != 0 {
+			btns = append(btns, f.word(bw.word))
+		}
+	}
+	s := strings.Join(words, "+")
+	switch {
+	case len(btns) == 0:
+		s += " " + f.word("Move")
+	case btn&(WheelUp|WheelDown|WheelLeft|WheelRight) == btn:
+		s += " " + strings.Join(btns, "+")
+	default:
+		s += " " + strings.Join(btns, "+") + " " + f.word("Press")
+		if n := ev.Clicks(); n > 1 {
+			s += fmt.Sprintf(" x%d", n)
+		}
+	}
+	x, y := ev.Position()
+	return fmt.Sprintf("%s @ (%d,%d)", s, x, y)
+}
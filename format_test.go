@@ -0,0 +1,75 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFormatEvent(t *testing.T) {
+	Convey("Events are spelled out", t, func() {
+		So(FormatEvent(NewEventKey(KeyPgUp, 0, ModCtrl|ModShift)),
+			ShouldEqual, "Ctrl+Shift+PgUp")
+		So(FormatEvent(NewEventKey(KeyRune, 'x', ModAlt)),
+			ShouldEqual, "Alt+x")
+		So(FormatEvent(NewEventKey(KeyRune, ' ', ModNone)),
+			ShouldEqual, "Space")
+		So(FormatEvent(NewEventKey(KeyRune, 3, ModNone)),
+			ShouldEqual, "Ctrl+C")
+		So(FormatEvent(NewEventKey(KeyTab, 0, ModNone)),
+			ShouldEqual, "Tab")
+		So(FormatEvent(NewEventKey(KeyF5, 0, ModNone)),
+			ShouldEqual, "F5")
+		So(FormatEvent(NewEventKey(KeyKPEnter, '\r', ModShift)),
+			ShouldEqual, "Shift+KPEnter")
+
+		ev := NewEventMouse(10, 4, Button1, ModNone)
+		So(FormatEvent(ev), ShouldEqual, "Mouse Button1 Press @ (10,4)")
+		ev = NewEventMouse(10, 4, WheelUp, ModCtrl)
+		So(FormatEvent(ev), ShouldEqual, "Ctrl+Mouse WheelUp @ (10,4)")
+		ev = NewEventMouse(1, 2, ButtonNone, ModNone)
+		So(FormatEvent(ev), ShouldEqual, "Mouse Move @ (1,2)")
+
+		So(FormatEvent(NewEventResize(80, 24)), ShouldEqual, "Resize 80x24")
+		So(FormatEvent(NewEventRedraw(70, 0, 10, 24)),
+			ShouldEqual, "Redraw 10x24 @ (70,0)")
+		So(FormatEvent(NewEventColorsChanged(ColorSchemeDark)),
+			ShouldEqual, "Colors dark")
+		So(FormatEvent(NewEventError(errors.New("oops"))),
+			ShouldEqual, "Error: oops")
+		So(FormatEvent(NewEventInterrupt(nil)), ShouldEqual, "Interrupt")
+		So(FormatEvent(NewEventIdle(time.Now())), ShouldEqual, "Idle")
+		So(FormatEvent(NewEventActive()), ShouldEqual, "Active")
+		So(FormatEvent(NewEventClose(CloseWindow)), ShouldEqual, "Close")
+	})
+
+	Convey("Words can be translated", t, func() {
+		f := &EventFormatter{Translate: func(w string) string {
+			switch w {
+			case "Ctrl":
+				return "Strg"
+			case "PgUp":
+				return "BildAuf"
+			}
+			return w
+		}}
+		So(f.Format(NewEventKey(KeyPgUp, 0, ModCtrl)),
+			ShouldEqual, "Strg+BildAuf")
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// FrameRateScreen is implemented by Screens that can limit how often
+// they draw.  The terminfo and Windows console screens do so.  This is
+// for applications that stream rapid updates, such as logs or progress
+// bars, and would otherwise spend their time (and the terminal's)
+// drawing frames that nobody has a chance to see.
+//
+// With a limit, Show only draws if the last frame was long enough ago;
+// otherwise it schedules a frame for when it will have been, and any
+// other calls to Show until then are folded into that one frame.  The
+// changes are never lost, just delayed.  Cursor motion alone is not
+// delayed, so typing stays responsive.  Sync always draws right away.
+type FrameRateScreen interface {
+	// SetFrameRate limits drawing to fps frames per second.  Zero,
+	// the default, means there is no limit.
+	SetFrameRate(fps int)
+
+	Screen
+}
+
+// frameLimiter schedules frames for a screen with a frame rate limit.
+// Callers hold the screen lock.
+type frameLimiter struct {
+	interval time.Duration
+	last     time.Time
+	timer    *time.Timer
+
+	// now and after stand in for time.Now and time.AfterFunc, if set,
+	// so that tests need not wait for frames
+	now   func() time.Time
+	after func(time.Duration, func()) *time.Timer
+}
+
+func (fl *frameLimiter) setRate(fps int) {
+	if fps <= 0 {
+		fl.interval = 0
+		return
+	}
+	fl.interval = time.Second / time.Duration(fps)
+}
+
+// ready reports whether a frame may be drawn now.  If not, it arranges
+// for fn (which draws the frame) to be called once one may, unless that
+// is already arranged.
+func (fl *frameLimiter) ready(fn func()) bool {
+	if fl.interval == 0 {
+		return true
+	}
+	wait := fl.last.Add(fl.interval).Sub(fl.clock())
+	if wait <= 0 {
+		return true
+	}
+	if fl.timer == nil {
+		after := time.AfterFunc
+		if fl.after != nil {
+			after = fl.after
+		}
+		fl.timer = after(wait, fn)
+	}
+	return false
+}
+
+// pending reports whether a frame has been scheduled.
+func (fl *frameLimiter) pending() bool {
+	return fl.timer != nil
+}
+
+// drawn records that a frame was drawn, which also takes the place of
+// any that was scheduled.
+func (fl *frameLimiter) drawn() {
+	fl.last = fl.clock()
+	fl.stop()
+}
+
+func (fl *frameLimiter) clock() time.Time {
+	if fl.now != nil {
+		return fl.now()
+	}
+	return time.Now()
+}
+
+// stop cancels the scheduled frame, if any.
+func (fl *frameLimiter) stop() {
+	if fl.timer != nil {
+		fl.timer.Stop()
+		fl.timer = nil
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// EventIdle is sent when there has been no input (neither keys nor mouse
+// activity) for the idle timeout.  (See IdleScreen.)
+type EventIdle struct {
+	t    time.Time
+	last time.Time
+}
+
+// NewEventIdle creates an EventIdle, for input last seen at the given
+// time.
+func NewEventIdle(last time.Time) *EventIdle {
+	return &EventIdle{t: time.Now(), last: last}
+}
+
+func (ev *EventIdle) When() time.Time {
+	return ev.t
+}
+
+// LastInput returns the time of the last input, or of when the idle
+// timeout was set, if there has been none since.
+func (ev *EventIdle) LastInput() time.Time {
+	return ev.last
+}
+
+// EventActive is sent when input arrives after an EventIdle.  It comes
+// just before the event for that input.
+type EventActive struct {
+	t time.Time
+}
+
+// NewEventActive creates an EventActive.
+func NewEventActive() *EventActive {
+	return &EventActive{t: time.Now()}
+}
+
+func (ev *EventActive) When() time.Time {
+	return ev.t
+}
+
+// IdleScreen is implemented by Screens that can tell the application when
+// the user has gone idle, so that dashboards and kiosks can dim the
+// display, lock it, or start a screensaver, and stop again when the user
+// returns.  All of the screens in this package do so.
+//
+// Only input counts as activity; events posted with PostEvent, resizes
+// and the like do not.  Both events pass through the EventFilter, which
+// makes the filter a convenient place for a screensaver hook.
+type IdleScreen interface {
+	// SetIdleTimeout sets how long the input must be idle before an
+	// EventIdle is delivered.  The time is counted from the last input,
+	// or from the call, whichever is later.  Only one EventIdle is sent
+	// for each idle spell, followed by an EventActive once input
+	// resumes.  A timeout of zero, the default, turns idle detection
+	// off.
+	SetIdleTimeout(d time.Duration)
+
+	Screen
+}
@@ -0,0 +1,250 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoImages is returned by PutImage when the terminal cannot show
+// images.
+var ErrNoImages = errors.New("terminal cannot show images")
+
+// ImageScreen is implemented by screens that can show images on
+// terminals that support the kitty graphics protocol, which include
+// kitty and WezTerm, or the inline images of iTerm2.  Elsewhere,
+// PutImage returns ErrNoImages.
+//
+// An image covers a rectangle of cells, and is shown by the next Show.
+// Setting any of the cells it covers, as to draw text over it, removes
+// the image once that Show draws the change; so does a change in the
+// size of the screen.  Applications should put images again after
+// redrawing in response to EventResize.
+type ImageScreen interface {
+	// PutImage shows the image in the rectangle of cells with the
+	// given origin and dimensions, scaled to fill it.  It returns an
+	// identifier for DeleteImage.
+	PutImage(x, y, width, height int, img image.Image) (int, error)
+
+	// DeleteImage removes the image, if it is still shown.  The cells
+	// that it covered are drawn again by the next Show.
+	DeleteImage(id int)
+
+	Screen
+}
+
+// imageProtocol is a way of showing images on a terminal.
+type imageProtocol int
+
+const (
+	imageNone imageProtocol = iota
+
+	// The kitty graphics protocol sends commands as APC G keys ;
+	// payload ST.  Images are sent as PNG (f=100), base64 encoded in
+	// chunks of at most 4096 bytes (m=1 on all but the last), and
+	// placed with the cursor at the upper left cell, without moving
+	// it (C=1).  q=2 suppresses the replies, which would otherwise
+	// arrive with the input.  Deleting an image (d=I) frees its data.
+	imageKitty
+
+	// iTerm2 shows an image sent with OSC 1337 ; File= at the cursor.
+	// It becomes part of the cells it covers, so drawing over them
+	// erases it; the image must be sent again to place it again.
+	imageITerm
+)
+
+const kittyChunk = 4096
+
+// screenImage is an image that has been put on the screen.
+type screenImage struct {
+	id     int
+	x      int
+	y      int
+	w      int
+	h      int
+	data   []byte // PNG, kept for protocols that send it to place it
+	placed bool
+}
+
+// screenImages tracks the images that a screen shows.
+type screenImages struct {
+	proto  imageProtocol
+	nextid int
+	list   []*screenImage
+}
+
+// detectImages determines, from the environment and the terminal type,
+// the protocol that the terminal supports for showing images.
+// $TCELL_IMAGES, set to kitty, iterm2, or none, overrides this.  There
+// is none when tcell is built with the tcell_noimage tag.
+func detectImages(term string) imageProtocol {
+	if !imageSupport {
+		return imageNone
+	}
+	switch os.Getenv("TCELL_IMAGES") {
+	case "kitty":
+		return imageKitty
+	case "iterm2":
+		return imageITerm
+	case "none":
+		return imageNone
+	}
+	switch {
+	case strings.Contains(term, "kitty"),
+		os.Getenv("KITTY_WINDOW_ID") != "",
+		os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return imageKitty
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app":
+		return imageITerm
+	}
+	return imageNone
+}
+
+// add records a new image, and returns the commands (if any) that send
+// it to the terminal ahead of placing it.
+func (si *screenImages) add(x, y, w, h int, img image.Image) (*screenImage, string, error) {
+	data, e := encodeImage(img)
+	if e != nil {
+		return nil, "", e
+	}
+	si.nextid++
+	im := &screenImage{id: si.nextid, x: x, y: y, w: w, h: h}
+	si.list = append(si.list, im)
+	if si.proto == imageKitty {
+		return im, kittyTransmit(im.id, data), nil
+	}
+	im.data = data
+	return im, "", nil
+}
+
+// remove forgets the image, and returns it, or nil if there is no such
+// image.
+func (si *screenImages) remove(id int) *screenImage {
+	for i, im := range si.list {
+		if im.id == id {
+			si.list = append(si.list[:i], si.list[i+1:]...)
+			return im
+		}
+	}
+	return nil
+}
+
+// removeAll forgets all of the images, and returns them.
+func (si *screenImages) removeAll() []*screenImage {
+	list := si.list
+	si.list = nil
+	return list
+}
+
+// overwritten forgets the placed images that cover any of the dirty
+// cells of the screen, and returns them.
+func (si *screenImages) overwritten(g *cellGrid) []*screenImage {
+	var gone []*screenImage
+	keep := si.list[:0]
+	for _, im := range si.list {
+		if im.placed && im.covers(g) {
+			gone = append(gone, im)
+			continue
+		}
+		keep = append(keep, im)
+	}
+	si.list = keep
+	return gone
+}
+
+// covers reports whether any of the cells under the image are dirty.
+func (im *screenImage) covers(g *cellGrid) bool {
+	for row := im.y; row < im.y+im.h && row < g.h; row++ {
+		for col := im.x; col < im.x+im.w && col < g.w; col++ {
+			if g.dirty[row*g.w+col] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unplace marks all of the images as needing to be placed again, as
+// after the screen has been cleared.
+func (si *screenImages) unplace() {
+	for _, im := range si.list {
+		im.placed = false
+	}
+}
+
+// pending reports whether any image is waiting to be placed.
+func (si *screenImages) pending() bool {
+	for _, im := range si.list {
+		if !im.placed {
+			return true
+		}
+	}
+	return false
+}
+
+// place returns the command that shows the image at the cursor.
+func (si *screenImages) place(im *screenImage) string {
+	switch si.proto {
+	case imageKitty:
+		return "\x1b_Ga=p,q=2,C=1,i=" + strconv.Itoa(im.id) +
+			",c=" + strconv.Itoa(im.w) + ",r=" + strconv.Itoa(im.h) + "\x1b\\"
+	case imageITerm:
+		return "\x1b]1337;File=inline=1;size=" + strconv.Itoa(len(im.data)) +
+			";width=" + strconv.Itoa(im.w) + ";height=" + strconv.Itoa(im.h) +
+			";preserveAspectRatio=0;doNotMoveCursor=1:" +
+			base64.StdEncoding.EncodeToString(im.data) + "\a"
+	}
+	return ""
+}
+
+// erase returns the command that deletes the image, if the protocol has
+// one.  Either way, the cells that it covered must be drawn again.
+func (si *screenImages) erase(im *screenImage) string {
+	if si.proto == imageKitty {
+		return "\x1b_Ga=d,d=I,q=2,i=" + strconv.Itoa(im.id) + "\x1b\\"
+	}
+	return ""
+}
+
+// kittyTransmit returns the commands that send the PNG data of an image.
+func kittyTransmit(id int, data []byte) string {
+	enc := base64.StdEncoding.EncodeToString(data)
+	var b bytes.Buffer
+	first := true
+	for first || len(enc) > 0 {
+		n := len(enc)
+		if n > kittyChunk {
+			n = kittyChunk
+		}
+		more := "0"
+		if n < len(enc) {
+			more = "1"
+		}
+		b.WriteString("\x1b_G")
+		if first {
+			b.WriteString("a=t,f=100,q=2,i=" + strconv.Itoa(id) + ",")
+		}
+		b.WriteString("m=" + more + ";" + enc[:n] + "\x1b\\")
+		enc = enc[n:]
+		first = false
+	}
+	return b.String()
+}
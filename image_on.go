@@ -0,0 +1,37 @@
+// +build !tcell_noimage
+
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+)
+
+// imageSupport is false when tcell is built with the tcell_noimage tag,
+// which leaves out the PNG encoder that images are sent to the terminal
+// with, for applications that never show images.
+const imageSupport = true
+
+// encodeImage encodes the image as PNG, as the terminals take it.
+func encodeImage(img image.Image) ([]byte, error) {
+	var data bytes.Buffer
+	if e := png.Encode(&data, img); e != nil {
+		return nil, e
+	}
+	return data.Bytes(), nil
+}
@@ -0,0 +1,1453 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// InputParser decodes the input sent by a terminal -- keystrokes, the
+// escape sequences for function keys (with modifiers), and mouse reports
+// -- into Events, using the terminal's Terminfo description.  It is what
+// the terminfo based Screen uses to read its input, but it needs no tty,
+// and so it can also be used by terminal emulators, by tools that record
+// or replay input, and in tests.
+//
+// Bytes are supplied with Feed, and the resulting events collected with
+// Events.  Because some sequences are prefixes of others (a lone ESC
+// versus the start of a function key), the parser holds on to partial
+// input until more arrives; call Expire when no more input is expected
+// soon, to have whatever remains delivered as is.  Alternatively,
+// ParseInput does all of this for input read from an io.Reader.  An
+// InputParser is not safe for concurrent use.
+type InputParser struct {
+	ti       *Terminfo
+	keycodes map[string]*tKeyCode
+	charset  string
+	decoder  transform.Transformer
+	buf      bytes.Buffer
+	evs      []Event
+	postfn   func(Event)
+	wasbtn   bool
+	held     ButtonMask
+	click    clickTracker
+	w        int
+	h        int
+	xform    Transform
+	mousepix bool
+	cellpw   int
+	cellph   int
+	winpw    int
+	winph    int
+	scheme   ColorScheme
+	altgr    AltGrMode
+	probing  bool
+	devattr  DeviceAttributes
+	escdelay time.Duration
+	repeats  keyRepeats
+	feeds    int
+	pasting  bool
+	burst    bool
+	cursorq  []chan [2]int
+}
+
+// NewInputParser returns an InputParser for the terminal described by
+// the Terminfo, which sends characters in the given character set.  (An
+// empty charset means UTF-8.)  An error is returned if the character set
+// is not supported.  (See RegisterEncoding.)
+func NewInputParser(ti *Terminfo, charset string) (*InputParser, error) {
+	ip := newInputParser(ti)
+	if e := ip.setCharset(charset); e != nil {
+		return nil, e
+	}
+	return ip, nil
+}
+
+func newInputParser(ti *Terminfo) *InputParser {
+	ip := &InputParser{charset: "UTF-8", escdelay: escapeDelay()}
+	ip.setTerminfo(ti)
+	return ip
+}
+
+// setTerminfo switches to a different terminal description.  Any input
+// that is buffered is kept.
+func (ip *InputParser) setTerminfo(ti *Terminfo) {
+	ip.ti = ti
+	ip.keycodes = make(map[string]*tKeyCode)
+	ip.prepareKeys()
+}
+
+func (ip *InputParser) setCharset(charset string) error {
+	charset = canonicalCharset(charset)
+	switch charset {
+	case "UTF-8", "US-ASCII":
+		ip.decoder = nil
+	default:
+		enc := GetEncoding(charset)
+		if enc == nil {
+			return errors.New("no support for charset " + charset)
+		}
+		ip.decoder = enc.NewDecoder()
+	}
+	ip.charset = charset
+	return nil
+}
+
+// SetSize tells the parser the size of the screen.  Mouse positions
+// outside of it are moved to its nearest edge, since some terminals
+// report such positions during click-drag operations.  If the size is
+// never set, mouse positions are reported as received.
+func (ip *InputParser) SetSize(width, height int) {
+	ip.w, ip.h = width, height
+}
+
+// setPixelSize records the size of the window in pixels, as the terminal
+// sees it (that is, before any Transform), and if it has changed and
+// post is true, posts an EventResize with it.
+func (ip *InputParser) setPixelSize(pw, ph int, post bool) {
+	if pw <= 0 || ph <= 0 {
+		return
+	}
+	if w, h := ip.physSize(); ip.cellpw != 0 && w > 0 && h > 0 {
+		// mouse positions are in pixels; keep up with the cell size
+		ip.SetCellPixels(pw/w, ph/h)
+	}
+	if ip.xform.swaps() {
+		pw, ph = ph, pw
+	}
+	if pw == ip.winpw && ph == ip.winph {
+		return
+	}
+	ip.winpw, ip.winph = pw, ph
+	if post {
+		ev := NewEventResize(ip.w, ip.h)
+		ev.SetPixelSize(pw, ph)
+		ip.post(ev)
+	}
+}
+
+// SetCellPixels tells the parser that mouse positions are reported in
+// pixels (as terminals do once SGR-Pixels mode, 1016, is enabled), and
+// the size in pixels of each cell, which is used to convert them back
+// to cell positions.  If either dimension is zero, positions are taken
+// to be in cells, which is the default.
+func (ip *InputParser) SetCellPixels(width, height int) {
+	ip.cellpw, ip.cellph = width, height
+	ip.mousepix = width > 0 && height > 0
+}
+
+// SetAltGrMode selects how characters typed with AltGr are told apart
+// from keys pressed with Alt.  The default is AltGrAuto.
+func (ip *InputParser) SetAltGrMode(m AltGrMode) {
+	ip.altgr = m
+}
+
+// Feed supplies bytes of input to the parser.  Events are produced for
+// all of the complete input that it contains.
+func (ip *InputParser) Feed(b []byte) {
+	ip.feeds++
+	ip.burst = pasteBurst(b)
+	ip.buf.Write(b)
+	ip.scanInput(&ip.buf, false)
+}
+
+// Expire tells the parser that no more input is expected for now, so
+// that any partial sequence that it has buffered is delivered as is.
+// For example, a lone ESC is only reported as KeyEscape when the input
+// expires.  Terminal screens do this when no input arrives for a short
+// while.
+func (ip *InputParser) Expire() {
+	ip.scanInput(&ip.buf, true)
+}
+
+// SetEscapeDelay sets how long ParseInput (and the terminal screens)
+// wait for the rest of a partial sequence before delivering it as is.
+// It is how long it takes for a lone ESC to be reported as KeyEscape.
+// Applications using vi-style keys may want it shorter, but too short a
+// delay can split up sequences that arrive slowly, as over a network.
+// The default is 100 milliseconds, or else $TCELL_ESCDELAY milliseconds,
+// as with the ESCDELAY of curses.
+func (ip *InputParser) SetEscapeDelay(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	ip.escdelay = d
+}
+
+// escapeDelay returns the default escape delay.
+func escapeDelay() time.Duration {
+	if ms, e := strconv.Atoi(os.Getenv("TCELL_ESCDELAY")); e == nil && ms >= 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return 100 * time.Millisecond
+}
+
+// ParseInput reads input from r until the end of it, or an error, and
+// calls post with each of the events that it contains, in order.  This
+// lets the parser be used on any stream of terminal input, such as a
+// pipe, a socket, or a recording.  As with the terminal screens, if no
+// more input arrives for a short while (see SetEscapeDelay), any
+// partial sequence is delivered as is; the rest is delivered at the end.
+// It returns nil at the end of the input, and otherwise the error from
+// r.  While it runs, events are passed to post rather than being queued
+// for Events.
+func (ip *InputParser) ParseInput(r io.Reader, post func(Event)) error {
+	type chunk struct {
+		b []byte
+		e error
+	}
+	ch := make(chan chunk)
+	go func() {
+		for {
+			b := make([]byte, 128)
+			n, e := r.Read(b)
+			ch <- chunk{b[:n], e}
+			if e != nil {
+				return
+			}
+		}
+	}()
+
+	postfn := ip.postfn
+	ip.postfn = post
+	defer func() {
+		ip.postfn = postfn
+	}()
+
+	var expire <-chan time.Time
+	for {
+		select {
+		case c := <-ch:
+			ip.Feed(c.b)
+			if c.e != nil {
+				ip.Expire()
+				if c.e == io.EOF {
+					return nil
+				}
+				return c.e
+			}
+			expire = nil
+			if ip.buf.Len() > 0 {
+				expire = time.After(ip.escdelay)
+			}
+		case <-expire:
+			ip.Expire()
+			expire = nil
+		}
+	}
+}
+
+// Events returns the events that have been parsed since the last call,
+// in the order that they were received.
+func (ip *InputParser) Events() []Event {
+	evs := ip.evs
+	ip.evs = nil
+	return evs
+}
+
+// post delivers an event, either to the screen that owns the parser,
+// or to the queue returned by Events.
+func (ip *InputParser) post(ev Event) {
+	if kev, ok := ev.(*EventKey); ok {
+		ip.repeats.track(kev, ip.feeds)
+		kev.paste = ip.pasting || ip.burst
+	}
+	if ip.postfn != nil {
+		ip.postfn(ev)
+	} else {
+		ip.evs = append(ip.evs, ev)
+	}
+}
+
+// pasteBurstLen is how many characters must arrive in one read to be
+// taken as pasted.  Keystrokes usually arrive one at a time, and even when
+// the application falls behind, rarely this many pile up.
+const pasteBurstLen = 8
+
+// pasteBurst reports whether the input holds enough text to have been
+// pasted.  Escape sequences (as for function keys and the mouse) do not
+// count, nor do the continuation bytes of UTF-8.
+func pasteBurst(b []byte) bool {
+	n := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if c == '\x1b' {
+			switch l := sequenceLength(b[i:]); {
+			case bytes.HasPrefix(b[i:], []byte("\x1b[M")):
+				i += 5 // legacy mouse report, with three bytes of data
+			case l > 0:
+				i += l - 1
+			case l == 0:
+				i = len(b)
+			}
+			continue
+		}
+		if c >= ' ' && c != 0x7f && utf8.RuneStart(c) ||
+			c == '\r' || c == '\n' || c == '\t' {
+			n++
+		}
+	}
+	return n >= pasteBurstLen
+}
+
+// keyRepeatGap is how soon the same key must follow, in input of its
+// own, to be taken as repeating.  Keyboards usually repeat 10 to 30 times
+// a second; people rarely type a key twice as fast.
+const keyRepeatGap = 100 * time.Millisecond
+
+// keyRepeats recognizes keys that are repeating, when the terminal does
+// not say.  (See EventKey.Repeat.)
+type keyRepeats struct {
+	key   Key
+	ch    rune
+	mod   ModMask
+	when  time.Time
+	feed  int
+	count int
+}
+
+// track sets the repeat count of the key, which is already set to one
+// if the terminal reported it as a repeat.  The key must have come from
+// the given call to Feed; the same key, in the same input, is more likely
+// to be pasted or typed ahead than repeated.
+func (kr *keyRepeats) track(ev *EventKey, feed int) {
+	same := ev.key == kr.key && ev.ch == kr.ch && ev.mod == kr.mod
+	switch {
+	case same && ev.repeat > 0:
+		kr.count++
+	case same && feed != kr.feed && ev.t.Sub(kr.when) <= keyRepeatGap:
+		kr.count++
+	default:
+		kr.count = 0
+	}
+	kr.key, kr.ch, kr.mod = ev.key, ev.ch, ev.mod
+	kr.when, kr.feed = ev.t, feed
+	ev.repeat = kr.count
+}
+
+// physSize returns the size of the physical display, if known, which
+// differs from that of the screen if the display is rotated sideways.
+func (ip *InputParser) physSize() (int, int) {
+	if ip.xform.swaps() {
+		return ip.h, ip.w
+	}
+	return ip.w, ip.h
+}
+
+// tKeyCode represents a combination of a key code and modifiers, and
+// for keypad keys, the character that the key types.
+type tKeyCode struct {
+	key Key
+	mod ModMask
+	ch  rune
+}
+
+func (ip *InputParser) prepareKeyMod(key Key, mod ModMask, val string) {
+	ip.prepareKeyRune(key, 0, mod, val)
+}
+
+func (ip *InputParser) prepareKeyRune(key Key, ch rune, mod ModMask, val string) {
+	if val != "" {
+		// Do not overrride codes that already exist; the first
+		// definition (usually the one from terminfo) wins.
+		if _, exist := ip.keycodes[val]; !exist {
+			ip.keycodes[val] = &tKeyCode{key: key, mod: mod, ch: ch}
+		}
+	}
+}
+
+func (ip *InputParser) prepareKey(key Key, val string) {
+	ip.prepareKeyMod(key, ModNone, val)
+}
+
+// xtermMods converts the modifier parameter used by XTerm in modified
+// key sequences (e.g. the 5 in CSI 1;5C) to a ModMask.
+func xtermMods(n int) ModMask {
+	mod := ModNone
+	n--
+	if n&1 != 0 {
+		mod |= ModShift
+	}
+	if n&2 != 0 {
+		mod |= ModAlt
+	}
+	if n&4 != 0 {
+		mod |= ModCtrl
+	}
+	if n&8 != 0 {
+		mod |= ModMeta
+	}
+	return mod
+}
+
+// prepareModifiedKeys derives the sequences used by modern terminals to
+// report cursor and function keys pressed together with modifiers.  The
+// terminfo database does not describe these, but almost every emulator
+// in use today sends the XTerm forms, e.g. CSI 1;5C for Ctrl-Right, or
+// CSI 15;2~ for Shift-F5.  The rxvt family uses its own scheme, which
+// we also support.  Sequences that terminfo already defines (such as
+// kf13 being Shift-F1 on XTerm) are left alone.
+func (ip *InputParser) prepareModifiedKeys() {
+	base := make(map[string]*tKeyCode)
+	for esc, kc := range ip.keycodes {
+		if kc.mod == ModNone {
+			base[esc] = kc
+		}
+	}
+	rxvt := strings.HasPrefix(ip.ti.Name, "rxvt")
+
+	for esc, kc := range base {
+		key := kc.key
+		if len(esc) < 3 || esc[0] != '\x1b' {
+			continue
+		}
+		switch {
+		case len(esc) == 3 && esc[1] == 'O' && esc[2] >= 'a' && esc[2] <= 'z':
+			// SS3 j style keypad keys; modifiers as SS3 5j
+			for n := 2; n <= 16; n++ {
+				ip.prepareKeyRune(key, kc.ch, xtermMods(n),
+					fmt.Sprintf("\x1bO%d%c", n, esc[2]))
+			}
+
+		case len(esc) == 3 && (esc[1] == '[' || esc[1] == 'O') &&
+			esc[2] >= 'A' && esc[2] <= 'Z':
+			// SS3 A or CSI A style; modifiers as CSI 1;5A, or in
+			// older terminals, as SS3 5A
+			for n := 2; n <= 16; n++ {
+				ip.prepareKeyRune(key, kc.ch, xtermMods(n),
+					fmt.Sprintf("\x1b[1;%d%c", n, esc[2]))
+				ip.prepareKeyRune(key, kc.ch, xtermMods(n),
+					fmt.Sprintf("\x1bO%d%c", n, esc[2]))
+			}
+			if rxvt && esc[2] >= 'A' && esc[2] <= 'D' {
+				lc := esc[2] - 'A' + 'a'
+				ip.prepareKeyMod(key, ModShift,
+					"\x1b["+string(lc))
+				ip.prepareKeyMod(key, ModCtrl,
+					"\x1bO"+string(lc))
+			}
+
+		case esc[1] == '[' && esc[len(esc)-1] == '~' &&
+			strings.Trim(esc[2:len(esc)-1], "0123456789") == "":
+			// CSI 15~ style; modifiers as CSI 15;5~
+			num := esc[2 : len(esc)-1]
+			for n := 2; n <= 16; n++ {
+				ip.prepareKeyMod(key, xtermMods(n),
+					fmt.Sprintf("\x1b[%s;%d~", num, n))
+			}
+			if rxvt {
+				ip.prepareKeyMod(key, ModShift, "\x1b["+num+"$")
+				ip.prepareKeyMod(key, ModCtrl, "\x1b["+num+"^")
+				ip.prepareKeyMod(key, ModCtrl|ModShift,
+					"\x1b["+num+"@")
+			}
+		}
+	}
+}
+
+func (ip *InputParser) prepareKeys() {
+	ti := ip.ti
+	ip.prepareKey(KeyBackspace, ti.KeyBackspace)
+	ip.prepareKey(KeyF1, ti.KeyF1)
+	ip.prepareKey(KeyF2, ti.KeyF2)
+	ip.prepareKey(KeyF3, ti.KeyF3)
+	ip.prepareKey(KeyF4, ti.KeyF4)
+	ip.prepareKey(KeyF5, ti.KeyF5)
+	ip.prepareKey(KeyF6, ti.KeyF6)
+	ip.prepareKey(KeyF7, ti.KeyF7)
+	ip.prepareKey(KeyF8, ti.KeyF8)
+	ip.prepareKey(KeyF9, ti.KeyF9)
+	ip.prepareKey(KeyF10, ti.KeyF10)
+	ip.prepareKey(KeyF11, ti.KeyF11)
+	ip.prepareKey(KeyF12, ti.KeyF12)
+	ip.prepareKey(KeyF13, ti.KeyF13)
+	ip.prepareKey(KeyF14, ti.KeyF14)
+	ip.prepareKey(KeyF15, ti.KeyF15)
+	ip.prepareKey(KeyF16, ti.KeyF16)
+	ip.prepareKey(KeyF17, ti.KeyF17)
+	ip.prepareKey(KeyF18, ti.KeyF18)
+	ip.prepareKey(KeyF19, ti.KeyF19)
+	ip.prepareKey(KeyF20, ti.KeyF20)
+	ip.prepareKey(KeyF21, ti.KeyF21)
+	ip.prepareKey(KeyF22, ti.KeyF22)
+	ip.prepareKey(KeyF23, ti.KeyF23)
+	ip.prepareKey(KeyF24, ti.KeyF24)
+	ip.prepareKey(KeyF25, ti.KeyF25)
+	ip.prepareKey(KeyF26, ti.KeyF26)
+	ip.prepareKey(KeyF27, ti.KeyF27)
+	ip.prepareKey(KeyF28, ti.KeyF28)
+	ip.prepareKey(KeyF29, ti.KeyF29)
+	ip.prepareKey(KeyF30, ti.KeyF30)
+	ip.prepareKey(KeyF31, ti.KeyF31)
+	ip.prepareKey(KeyF32, ti.KeyF32)
+	ip.prepareKey(KeyF33, ti.KeyF33)
+	ip.prepareKey(KeyF34, ti.KeyF34)
+	ip.prepareKey(KeyF35, ti.KeyF35)
+	ip.prepareKey(KeyF36, ti.KeyF36)
+	ip.prepareKey(KeyF37, ti.KeyF37)
+	ip.prepareKey(KeyF38, ti.KeyF38)
+	ip.prepareKey(KeyF39, ti.KeyF39)
+	ip.prepareKey(KeyF40, ti.KeyF40)
+	ip.prepareKey(KeyF41, ti.KeyF41)
+	ip.prepareKey(KeyF42, ti.KeyF42)
+	ip.prepareKey(KeyF43, ti.KeyF43)
+	ip.prepareKey(KeyF44, ti.KeyF44)
+	ip.prepareKey(KeyF45, ti.KeyF45)
+	ip.prepareKey(KeyF46, ti.KeyF46)
+	ip.prepareKey(KeyF47, ti.KeyF47)
+	ip.prepareKey(KeyF48, ti.KeyF48)
+	ip.prepareKey(KeyF49, ti.KeyF49)
+	ip.prepareKey(KeyF50, ti.KeyF50)
+	ip.prepareKey(KeyF51, ti.KeyF51)
+	ip.prepareKey(KeyF52, ti.KeyF52)
+	ip.prepareKey(KeyF53, ti.KeyF53)
+	ip.prepareKey(KeyF54, ti.KeyF54)
+	ip.prepareKey(KeyF55, ti.KeyF55)
+	ip.prepareKey(KeyF56, ti.KeyF56)
+	ip.prepareKey(KeyF57, ti.KeyF57)
+	ip.prepareKey(KeyF58, ti.KeyF58)
+	ip.prepareKey(KeyF59, ti.KeyF59)
+	ip.prepareKey(KeyF60, ti.KeyF60)
+	ip.prepareKey(KeyF61, ti.KeyF61)
+	ip.prepareKey(KeyF62, ti.KeyF62)
+	ip.prepareKey(KeyF63, ti.KeyF63)
+	ip.prepareKey(KeyF64, ti.KeyF64)
+	ip.prepareKey(KeyInsert, ti.KeyInsert)
+	ip.prepareKey(KeyDelete, ti.KeyDelete)
+	ip.prepareKey(KeyHome, ti.KeyHome)
+	ip.prepareKey(KeyEnd, ti.KeyEnd)
+	ip.prepareKey(KeyUp, ti.KeyUp)
+	ip.prepareKey(KeyDown, ti.KeyDown)
+	ip.prepareKey(KeyLeft, ti.KeyLeft)
+	ip.prepareKey(KeyRight, ti.KeyRight)
+	ip.prepareKey(KeyPgUp, ti.KeyPgUp)
+	ip.prepareKey(KeyPgDn, ti.KeyPgDn)
+	ip.prepareKey(KeyHelp, ti.KeyHelp)
+	ip.prepareKey(KeyPrint, ti.KeyPrint)
+	ip.prepareKey(KeyCancel, ti.KeyCancel)
+	ip.prepareKey(KeyExit, ti.KeyExit)
+	ip.prepareKey(KeyBacktab, ti.KeyBacktab)
+
+	ip.prepareCursorKeys()
+	ip.prepareKeypadKeys()
+	ip.prepareModifiedKeys()
+}
+
+// prepareCursorKeys adds the other forms of the cursor keys, and of Home,
+// End, and the keypad center, which terminfo does not describe.  Terminals
+// send CSI A for Up in normal cursor mode, but SS3 A in application mode,
+// which is what terminfo gives (as we use that mode); multiplexers and
+// terminals with entries that don't match them may send either.
+func (ip *InputParser) prepareCursorKeys() {
+	for _, k := range []struct {
+		key Key
+		c   byte
+	}{
+		{KeyUp, 'A'},
+		{KeyDown, 'B'},
+		{KeyRight, 'C'},
+		{KeyLeft, 'D'},
+		{KeyCenter, 'E'},
+		{KeyEnd, 'F'},
+		{KeyHome, 'H'},
+	} {
+		ip.prepareKey(k.key, "\x1b["+string(k.c))
+		ip.prepareKey(k.key, "\x1bO"+string(k.c))
+	}
+}
+
+// keypadKeys are the keys of the numeric keypad, with the final byte
+// of the SS3 sequence that each sends in application keypad mode (which
+// is set by smkx, on terminals that have it), and the character that
+// each types.
+var keypadKeys = []struct {
+	key Key
+	c   byte
+	ch  rune
+}{
+	{KeyKP0, 'p', '0'},
+	{KeyKP1, 'q', '1'},
+	{KeyKP2, 'r', '2'},
+	{KeyKP3, 's', '3'},
+	{KeyKP4, 't', '4'},
+	{KeyKP5, 'u', '5'},
+	{KeyKP6, 'v', '6'},
+	{KeyKP7, 'w', '7'},
+	{KeyKP8, 'x', '8'},
+	{KeyKP9, 'y', '9'},
+	{KeyKPEnter, 'M', '\r'},
+	{KeyKPEqual, 'X', '='},
+	{KeyKPMultiply, 'j', '*'},
+	{KeyKPPlus, 'k', '+'},
+	{KeyKPComma, 'l', ','},
+	{KeyKPMinus, 'm', '-'},
+	{KeyKPDecimal, 'n', '.'},
+	{KeyKPDivide, 'o', '/'},
+}
+
+// prepareKeypadKeys adds the keypad keys.  Which of them a terminal sends
+// depends on it, and on the state of NumLock: many send the digits (as
+// plain characters) when NumLock is on, and the cursor keys when it is
+// off, so only the operators and Enter are told apart from the main keys.
+// Keypad cursor keys can never be told apart from the main ones.
+func (ip *InputParser) prepareKeypadKeys() {
+	for _, k := range keypadKeys {
+		ip.prepareKeyRune(k.key, k.ch, ModNone, "\x1bO"+string(k.c))
+	}
+}
+
+func (ip *InputParser) postMouseEvent(x, y, btn int) {
+	ev := ip.buildMouseEvent(x, y, btn)
+	ip.click.track(ev)
+	ip.post(ev)
+}
+
+// mouseRelease marks, in the button code given to buildMouseEvent, the
+// release of the button in the low bits, as SGR records report it.  (The
+// legacy encodings only report that all of the buttons were released.)
+const mouseRelease = 0x100
+
+func (ip *InputParser) buildMouseEvent(x, y, btn int) *EventMouse {
+
+	// XTerm mouse events only report at most one button at a time,
+	// which may include a wheel button.  Wheel motion events are
+	// reported as single impulses, while other button events are reported
+	// as separate press & release events.  We keep track of the buttons
+	// that are held, so that each event reports all of them, as on
+	// Windows.
+
+	button := ButtonNone
+	mod := ModNone
+
+	// Mouse wheel has bit 6 set, no release events.  It should be noted
+	// that wheel events are sometimes misdelivered as mouse button events
+	// during a click-drag, so we debounce these, considering them to be
+	// button press events unless we see an intervening release event.
+	// Buttons 6 and 7 (0x42 and 0x43) are the horizontal wheel.
+	switch btn & 0x43 {
+	case 0:
+		button = Button1
+		ip.wasbtn = true
+	case 1:
+		button = Button2
+		ip.wasbtn = true
+	case 2:
+		button = Button3
+		ip.wasbtn = true
+	case 3:
+		button = ButtonNone
+		ip.wasbtn = false
+	case 0x40:
+		if !ip.wasbtn {
+			button = WheelUp
+		} else {
+			button = Button1
+		}
+	case 0x41:
+		if !ip.wasbtn {
+			button = WheelDown
+		} else {
+			button = Button2
+		}
+	case 0x42:
+		if !ip.wasbtn {
+			button = WheelLeft
+		} else {
+			button = Button3
+		}
+	case 0x43:
+		if !ip.wasbtn {
+			button = WheelRight
+		} else {
+			button = ButtonNone
+		}
+	}
+
+	switch {
+	case btn&mouseRelease != 0:
+		if button == ButtonNone {
+			// not a button we know; assume they are all up
+			ip.held = ButtonNone
+		}
+		ip.held &^= button
+		if ip.held == ButtonNone {
+			ip.wasbtn = false
+		}
+		button = ip.held
+	case button == ButtonNone:
+		ip.held = ButtonNone
+	case button&(Button1|Button2|Button3) != 0:
+		ip.held |= button
+		button = ip.held
+	}
+
+	if btn&0x4 != 0 {
+		mod |= ModShift
+	}
+	if btn&0x8 != 0 {
+		mod |= ModMeta
+	}
+	if btn&0x10 != 0 {
+		mod |= ModCtrl
+	}
+
+	// Some terminals will report mouse coordinates outside the
+	// screen, especially with click-drag events.  Clip the coordinates
+	// to the screen in that case.
+	pw, ph := ip.physSize()
+	if x < 0 {
+		x = 0
+	}
+	if x > pw-1 {
+		x = pw - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	if y > ph-1 {
+		y = ph - 1
+	}
+	x, y = ip.xform.invert(x, y, ip.w, ip.h)
+	return NewEventMouse(x, y, button, mod)
+}
+
+// parseSgrMouse attempts to locate an SGR mouse record at the start of the
+// buffer.  It returns true, true if it found one, and the associated bytes
+// be removed from the buffer.  It returns true, false if the buffer might
+// contain such an event, but more bytes are necessary (partial match), and
+// false, false if the content is definitely *not* an SGR mouse record.
+func (ip *InputParser) parseSgrMouse(buf *bytes.Buffer) (bool, bool) {
+
+	b := buf.Bytes()
+
+	var x, y, btn, state int
+	dig := false
+	neg := false
+	i := 0
+	val := 0
+
+	for i = range b {
+		switch b[i] {
+		case '\x1b':
+			if state != 0 {
+				return false, false
+			}
+			state = 1
+
+		case '\x9b':
+			if state != 0 {
+				return false, false
+			}
+			state = 2
+
+		case '[':
+			if state != 1 {
+				return false, false
+			}
+			state = 2
+
+		case '<':
+			if state != 2 {
+				return false, false
+			}
+			val = 0
+			dig = false
+			neg = false
+			state = 3
+
+		case '-':
+			if state != 3 && state != 4 && state != 5 {
+				return false, false
+			}
+			if dig || neg {
+				return false, false
+			}
+			neg = true // stay in state
+
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			if state != 3 && state != 4 && state != 5 {
+				return false, false
+			}
+			val *= 10
+			val += int(b[i] - '0')
+			dig = true // stay in state
+
+		case ';':
+			if neg {
+				val = -val
+			}
+			switch state {
+			case 3:
+				btn, val = val, 0
+				neg, dig, state = false, false, 4
+			case 4:
+				x, val = val, 0
+				neg, dig, state = false, false, 5
+			default:
+				return false, false
+			}
+
+		case 'm', 'M':
+			if state != 5 {
+				return false, false
+			}
+			if neg {
+				val = -val
+			}
+			y = val
+
+			// We don't care about the motion bit
+			btn &^= 32
+			if b[i] == 'm' {
+				// mouse release, of the given button
+				btn |= mouseRelease
+			}
+			// consume the event bytes
+			for i >= 0 {
+				buf.ReadByte()
+				i--
+			}
+			if ip.mousepix {
+				// SGR-Pixels reports pixels, not cells
+				px, py := x-1, y-1
+				if px < 0 {
+					px = 0
+				}
+				if py < 0 {
+					py = 0
+				}
+				ev := ip.buildMouseEvent(px/ip.cellpw,
+					py/ip.cellph, btn)
+				ev.SetPixelPosition(px, py)
+				ip.post(ev)
+			} else {
+				// SGR coordinates are one based
+				ip.postMouseEvent(x-1, y-1, btn)
+			}
+			return true, true
+
+		default:
+			return false, false
+		}
+	}
+
+	// incomplete & inconclusve at this point
+	return true, false
+}
+
+// parseUrxvtMouse is like parseSgrMouse, but it parses the urxvt (1015)
+// mouse record, which looks like CSI btn ; x ; y M, with decimal values.
+// The button value is encoded just like the legacy X11 record, and the
+// coordinates are one based.
+func (ip *InputParser) parseUrxvtMouse(buf *bytes.Buffer) (bool, bool) {
+
+	b := buf.Bytes()
+
+	var vals [3]int
+	nval := 0
+	dig := false
+	state := 0
+
+	for i := range b {
+		switch state {
+		case 0:
+			switch b[i] {
+			case '\x1b':
+				state = 1
+			case '\x9b':
+				state = 2
+			default:
+				return false, false
+			}
+		case 1:
+			if b[i] != '[' {
+				return false, false
+			}
+			state = 2
+		case 2:
+			switch {
+			case b[i] >= '0' && b[i] <= '9':
+				vals[nval] *= 10
+				vals[nval] += int(b[i] - '0')
+				dig = true
+			case b[i] == ';' && dig && nval < 2:
+				nval++
+				dig = false
+			case b[i] == 'M' && dig && nval == 2:
+				for i >= 0 {
+					buf.ReadByte()
+					i--
+				}
+				btn := vals[0] - 32
+				if btn&3 == 3 {
+					// release, but don't let a stale wheel
+					// bit confuse us
+					btn &^= 0x40
+				}
+				btn &^= 32 // motion bit
+				ip.postMouseEvent(vals[1]-1, vals[2]-1, btn)
+				return true, true
+			default:
+				return false, false
+			}
+		}
+	}
+	return true, false
+}
+
+// parseColorScheme parses the color scheme report sent by terminals
+// that support theme change notifications, CSI ? 997 ; n n, where n is
+// 1 for a dark theme, and 2 for a light one.
+func (ip *InputParser) parseColorScheme(buf *bytes.Buffer) (bool, bool) {
+
+	b := buf.Bytes()
+
+	var vals [2]int
+	nval := 0
+	dig := false
+	state := 0
+
+	for i := range b {
+		switch state {
+		case 0:
+			switch b[i] {
+			case '\x1b':
+				state = 1
+			case '\x9b':
+				state = 2
+			default:
+				return false, false
+			}
+		case 1:
+			if b[i] != '[' {
+				return false, false
+			}
+			state = 2
+		case 2:
+			if b[i] != '?' {
+				return false, false
+			}
+			state = 3
+		case 3:
+			switch {
+			case b[i] >= '0' && b[i] <= '9':
+				vals[nval] *= 10
+				vals[nval] += int(b[i] - '0')
+				dig = true
+			case b[i] == ';' && dig && nval < 1 && vals[0] == 997:
+				nval++
+				dig = false
+			case b[i] == 'n' && dig && nval == 1:
+				for i >= 0 {
+					buf.ReadByte()
+					i--
+				}
+				scheme := ColorSchemeUnknown
+				switch vals[1] {
+				case 1:
+					scheme = ColorSchemeDark
+				case 2:
+					scheme = ColorSchemeLight
+				}
+				ip.scheme = scheme
+				ip.post(NewEventColorsChanged(scheme))
+				return true, true
+			default:
+				return false, false
+			}
+		}
+	}
+	return true, false
+}
+
+// parsePaste parses the sequences that terminals send before and after
+// pasted text, once bracketed paste is enabled.  They are not reported
+// themselves; the keys in between are marked as pasted instead.
+func (ip *InputParser) parsePaste(buf *bytes.Buffer) (bool, bool) {
+	b := buf.Bytes()
+	partial := false
+	for _, seq := range []string{ip.ti.PasteStart, ip.ti.PasteEnd} {
+		if seq == "" {
+			continue
+		}
+		if bytes.HasPrefix(b, []byte(seq)) {
+			buf.Next(len(seq))
+			ip.pasting = seq == ip.ti.PasteStart
+			return true, true
+		}
+		if strings.HasPrefix(seq, string(b)) {
+			partial = true
+		}
+	}
+	return partial, false
+}
+
+// parseXtermMouse is like parseSgrMouse, but it parses a legacy
+// X11 mouse record.
+func (ip *InputParser) parseXtermMouse(buf *bytes.Buffer) (bool, bool) {
+
+	b := buf.Bytes()
+
+	state := 0
+	btn := 0
+	x := 0
+	y := 0
+
+	for i := range b {
+		switch state {
+		case 0:
+			switch b[i] {
+			case '\x1b':
+				state = 1
+			case '\x9b':
+				state = 2
+			default:
+				return false, false
+			}
+		case 1:
+			if b[i] != '[' {
+				return false, false
+			}
+			state = 2
+		case 2:
+			if b[i] != 'M' {
+				return false, false
+			}
+			state++
+		case 3:
+			btn = int(b[i])
+			state++
+		case 4:
+			x = int(b[i]) - 32 - 1
+			state++
+		case 5:
+			y = int(b[i]) - 32 - 1
+			for i >= 0 {
+				buf.ReadByte()
+				i--
+			}
+			ip.postMouseEvent(x, y, btn)
+			return true, true
+		}
+	}
+	return true, false
+}
+
+func (ip *InputParser) parseFunctionKey(buf *bytes.Buffer) (bool, bool) {
+	b := buf.Bytes()
+	partial := false
+	for e, k := range ip.keycodes {
+		esc := []byte(e)
+		if bytes.HasPrefix(b, esc) {
+			// matched
+			r := k.ch
+			if len(esc) == 1 {
+				r = rune(b[0])
+			}
+			ev := NewEventKey(k.key, r, k.mod)
+			ip.post(ev)
+			for i := 0; i < len(esc); i++ {
+				buf.ReadByte()
+			}
+			return true, true
+		}
+		if bytes.HasPrefix(esc, b) {
+			partial = true
+		}
+	}
+
+	// Many terminals report Alt (or Meta) by sending an ESC before
+	// the key's normal sequence.  Check for that too.
+	if len(b) > 1 && b[0] == '\x1b' && b[1] == '\x1b' {
+		b = b[1:]
+		for e, k := range ip.keycodes {
+			esc := []byte(e)
+			if bytes.HasPrefix(b, esc) {
+				ev := NewEventKey(k.key, k.ch, k.mod|ModAlt)
+				ip.post(ev)
+				for i := 0; i <= len(esc); i++ {
+					buf.ReadByte()
+				}
+				return true, true
+			}
+			if bytes.HasPrefix(esc, b) {
+				partial = true
+			}
+		}
+	}
+	return partial, false
+}
+
+// parseAltRune parses a character that is preceded by an ESC, which is
+// how most terminals report a key pressed together with Alt (or Meta).
+// ESC [ and ESC O introduce other sequences, and are left alone.
+func (ip *InputParser) parseAltRune(buf *bytes.Buffer) (bool, bool) {
+	b := buf.Bytes()
+	if len(b) < 2 || b[0] != '\x1b' {
+		return false, false
+	}
+	var r rune
+	n := 2
+	if state, _, _ := sequenceIntroducer(b); state != seqEscape {
+		return false, false
+	}
+	switch {
+	case b[1] >= ' ' && b[1] <= 0x7F:
+		r = rune(b[1])
+	case b[1] >= 0x80 && ip.charset == "UTF-8":
+		if !utf8.FullRune(b[1:]) {
+			return true, false
+		}
+		var sz int
+		if r, sz = utf8.DecodeRune(b[1:]); r == utf8.RuneError {
+			return false, false
+		}
+		n = 1 + sz
+	default:
+		return false, false
+	}
+	ip.post(NewEventKey(KeyRune, r, ip.altgr.composed(r, ModAlt)))
+	buf.Next(n)
+	return true, true
+}
+
+// parseCsiU parses the sequences that some terminals use to report
+// keys which have no escape sequence of their own, such as Ctrl-Alt-a
+// or Shift-Enter.  These are CSI code ; mods u (the "fixterms" form,
+// also used by kitty), and CSI 27 ; mods ; code ~ (XTerm with
+// modifyOtherKeys), where code is the Unicode code point of the key.
+// Sub-parameters are ignored, except that key releases are discarded,
+// and repeats are noted.
+func (ip *InputParser) parseCsiU(buf *bytes.Buffer) (bool, bool) {
+
+	b := buf.Bytes()
+
+	var vals [3]int
+	nval := 0
+	sub := false
+	release := false
+	repeat := false
+	dig := false
+	state := 0
+
+	for i := range b {
+		switch state {
+		case 0:
+			switch b[i] {
+			case '\x1b':
+				state = 1
+			case '\x9b':
+				state = 2
+			default:
+				return false, false
+			}
+		case 1:
+			if b[i] != '[' {
+				return false, false
+			}
+			state = 2
+		case 2:
+			switch {
+			case b[i] >= '0' && b[i] <= '9':
+				if sub {
+					// only the event type (of the modifiers) matters
+					if nval == 1 && b[i] == '3' {
+						release = true
+					}
+					if nval == 1 && b[i] == '2' {
+						repeat = true
+					}
+					continue
+				}
+				vals[nval] *= 10
+				vals[nval] += int(b[i] - '0')
+				if vals[nval] > utf8.MaxRune {
+					return false, false
+				}
+				dig = true
+			case b[i] == ':' && dig:
+				sub = true
+			case b[i] == ';' && dig && nval < 2:
+				nval++
+				sub = false
+				dig = false
+			case b[i] == 'u' && (dig || nval == 2):
+				buf.Next(i + 1)
+				if !release {
+					ip.postCsiKey(rune(vals[0]), vals[1], repeat)
+				}
+				return true, true
+			case b[i] == '~' && dig && nval == 2 && vals[0] == 27:
+				buf.Next(i + 1)
+				ip.postCsiKey(rune(vals[2]), vals[1], false)
+				return true, true
+			default:
+				return false, false
+			}
+		}
+	}
+	return true, false
+}
+
+// postCsiKey posts the key for the code point and XTerm style modifier
+// parameter (which is 0 if absent) of a CSI u sequence, which may say
+// that the key is repeating.
+func (ip *InputParser) postCsiKey(r rune, mods int, repeat bool) {
+	mod := ModNone
+	if mods > 1 {
+		mod = xtermMods(mods)
+	}
+	if r >= 'a' && r <= 'z' && mod&ModCtrl != 0 {
+		// report these as the control keys that they are
+		r = r - 'a' + rune(KeyCtrlA)
+	} else {
+		mod = ip.altgr.composed(r, mod)
+	}
+	ev := NewEventKey(KeyRune, r, mod)
+	if repeat {
+		ev.repeat = 1
+	}
+	ip.post(ev)
+}
+
+func (ip *InputParser) parseRune(buf *bytes.Buffer) (bool, bool) {
+	b := buf.Bytes()
+	if b[0] >= ' ' && b[0] <= 0x7F {
+		// printable ASCII easy to deal with -- no encodings
+		ev := NewEventKey(KeyRune, rune(b[0]), ModNone)
+		ip.post(ev)
+		buf.ReadByte()
+		return true, true
+	}
+
+	if b[0] < 0x80 {
+		// No encodings start with low numbered values
+		return false, false
+	}
+
+	switch ip.charset {
+	case "UTF-8":
+		if utf8.FullRune(b) {
+			r, _, e := buf.ReadRune()
+			if e == nil {
+				ev := NewEventKey(KeyRune, r, ModNone)
+				ip.post(ev)
+				return true, true
+			}
+		}
+	case "US-ASCII":
+		// ASCII cannot generate this, so most likely it was
+		// entered as an Alt sequence
+		r := rune(b[0] - 128)
+		ev := NewEventKey(KeyRune, r, ip.altgr.composed(r, ModAlt))
+		ip.post(ev)
+		buf.ReadByte()
+		return true, true
+
+	default:
+		// Multibyte characters may be split across reads, so we
+		// decode a byte at a time until the decoder has a whole
+		// character.  (It says so with ErrShortSrc until then.  At
+		// EOF it would give up instead, and return RuneError for
+		// every prefix.)  Invalid sequences are discarded.
+		utfb := make([]byte, 12)
+		for l := 1; l <= len(b); l++ {
+			ip.decoder.Reset()
+			nout, nin, e := ip.decoder.Transform(utfb, b[:l], false)
+			if e == transform.ErrShortSrc && nout == 0 {
+				continue
+			}
+			if nout == 0 && nin == 0 {
+				break
+			}
+			if r, _ := utf8.DecodeRune(utfb[:nout]); r != utf8.RuneError {
+				ev := NewEventKey(KeyRune, r, ModNone)
+				ip.post(ev)
+			}
+			if nin == 0 {
+				nin = l
+			}
+			buf.Next(nin)
+			return true, true
+		}
+	}
+	// Looks like potential escape
+	return true, false
+}
+
+// partialRune reports whether the input starts with part of a multibyte
+// character, which the next read may complete.
+func (ip *InputParser) partialRune(b []byte) bool {
+	if len(b) == 0 || b[0] < 0x80 {
+		return false
+	}
+	switch ip.charset {
+	case "UTF-8":
+		// invalid encodings count as full runes
+		return !utf8.FullRune(b)
+	case "US-ASCII":
+		return false
+	}
+	if ip.decoder == nil {
+		return false
+	}
+	var utfb [12]byte
+	ip.decoder.Reset()
+	nout, _, e := ip.decoder.Transform(utfb[:], b, false)
+	return e == transform.ErrShortSrc && nout == 0
+}
+
+func (ip *InputParser) scanInput(buf *bytes.Buffer, expire bool) {
+
+	for {
+		ip.convertC1(buf)
+		b := buf.Bytes()
+		if len(b) == 0 {
+			buf.Reset()
+			return
+		}
+
+		partials := 0
+
+		if part, comp := ip.parseRune(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		if part, comp := ip.parsePaste(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		if part, comp := ip.parseCursorReport(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		if part, comp := ip.parseFunctionKey(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		if part, comp := ip.parseColorScheme(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		if part, comp := ip.parseDeviceReply(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		if part, comp := ip.parseCsiU(buf); comp {
+			continue
+		} else if part {
+			partials++
+		}
+
+		// Only parse mouse records if this term claims to have
+		// mouse support
+
+		if mouseSupport && ip.ti.Mouse != "" {
+			if part, comp := ip.parseXtermMouse(buf); comp {
+				continue
+			} else if part {
+				partials++
+			}
+
+			if part, comp := ip.parseSgrMouse(buf); comp {
+				continue
+			} else if part {
+				partials++
+			}
+
+			if part, comp := ip.parseUrxvtMouse(buf); comp {
+				continue
+			} else if part {
+				partials++
+			}
+		}
+
+		// An ESC prefix for Alt is only considered once nothing
+		// else could match, since ESC starts all of the above.
+		if partials == 0 {
+			if part, comp := ip.parseAltRune(buf); comp {
+				continue
+			} else if part {
+				partials++
+			}
+		}
+
+		// Any other control sequence is one that we do not know, so
+		// it is discarded, once it is complete.  If it never will be,
+		// what there is of it is discarded when the input expires,
+		// unless that is just the introducer.  (A lone ESC is the
+		// Escape key, and ESC [ may well be Alt-[.)
+		if partials == 0 {
+			switch n := sequenceLength(b); {
+			case n > 0:
+				buf.Next(n)
+				continue
+			case n == 0:
+				partials++
+			}
+		}
+		if expire && sequenceLength(b) == 0 {
+			if _, start, _ := sequenceIntroducer(b); len(b) > start {
+				buf.Reset()
+				return
+			}
+		}
+
+		if expire && ip.partialRune(b) {
+			// The rest of the character may just be late, in
+			// the next read, so it is kept for that rather than
+			// delivered as bytes.  (If what comes next cannot
+			// complete it, it is decoded as an error.)
+			break
+		}
+
+		if partials == 0 || expire {
+			// Nothing was going to match, or we timed out
+			// waiting for more data -- just deliver the characters
+			// to the app & let them sort it out.  Possibly we should only
+			// do this for control characters such like ESC.
+			by, _ := buf.ReadByte()
+			ev := NewEventKey(KeyRune, rune(by), ModNone)
+			ip.post(ev)
+			continue
+		}
+
+		// well we have some partial data, wait until we get
+		// some more
+		break
+	}
+}
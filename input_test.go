@@ -0,0 +1,808 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newTestParser returns an InputParser for the given terminal type,
+// with the size of a typical terminal window.
+func newTestParser(term string) *InputParser {
+	ti, e := LookupTerminfo(term)
+	So(e, ShouldBeNil)
+	ip, e := NewInputParser(ti, "UTF-8")
+	So(e, ShouldBeNil)
+	ip.SetSize(80, 24)
+	return ip
+}
+
+// scanEvents feeds the string to the input parser, and returns the
+// events that result.
+func scanEvents(ip *InputParser, s string) []Event {
+	ip.Feed([]byte(s))
+	ip.Expire()
+	return ip.Events()
+}
+
+// scanKeys is like scanEvents, but only returns key events.
+func scanKeys(ip *InputParser, s string) []*EventKey {
+	var evs []*EventKey
+	for _, ev := range scanEvents(ip, s) {
+		if ek, ok := ev.(*EventKey); ok {
+			evs = append(evs, ek)
+		}
+	}
+	return evs
+}
+
+// scanMouse is like scanEvents, but only returns mouse events.
+func scanMouse(ip *InputParser, s string) []*EventMouse {
+	var evs []*EventMouse
+	for _, ev := range scanEvents(ip, s) {
+		if em, ok := ev.(*EventMouse); ok {
+			evs = append(evs, em)
+		}
+	}
+	return evs
+}
+
+func TestModifiedKeys(t *testing.T) {
+	Convey("XTerm modified keys", t, func() {
+		ip := newTestParser("xterm")
+
+		evs := scanKeys(ip, "\x1b[1;5C")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyRight)
+		So(evs[0].Mod(), ShouldEqual, ModCtrl)
+
+		evs = scanKeys(ip, "\x1b[1;2A")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyUp)
+		So(evs[0].Mod(), ShouldEqual, ModShift)
+
+		evs = scanKeys(ip, "\x1b[15;7~")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyF5)
+		So(evs[0].Mod(), ShouldEqual, ModCtrl|ModAlt)
+
+		evs = scanKeys(ip, "\x1bOA")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyUp)
+		So(evs[0].Mod(), ShouldEqual, ModNone)
+	})
+
+	Convey("Rxvt modified keys", t, func() {
+		ip := newTestParser("rxvt")
+
+		evs := scanKeys(ip, "\x1bOd")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyLeft)
+		So(evs[0].Mod(), ShouldEqual, ModCtrl)
+
+		evs = scanKeys(ip, "\x1b[a")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyUp)
+		So(evs[0].Mod(), ShouldEqual, ModShift)
+
+		evs = scanKeys(ip, "\x1b\x1b[A")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyUp)
+		So(evs[0].Mod(), ShouldEqual, ModAlt)
+	})
+
+	Convey("Cursor keys in either mode", t, func() {
+		ip := newTestParser("screen")
+
+		evs := scanKeys(ip, "\x1b[1;5H\x1b[1;5F\x1b[D\x1bO5A\x1bOE")
+		So(len(evs), ShouldEqual, 5)
+		So(evs[0].Key(), ShouldEqual, KeyHome)
+		So(evs[0].Mod(), ShouldEqual, ModCtrl)
+		So(evs[1].Key(), ShouldEqual, KeyEnd)
+		So(evs[1].Mod(), ShouldEqual, ModCtrl)
+		So(evs[2].Key(), ShouldEqual, KeyLeft)
+		So(evs[2].Mod(), ShouldEqual, ModNone)
+		So(evs[3].Key(), ShouldEqual, KeyUp)
+		So(evs[3].Mod(), ShouldEqual, ModCtrl)
+		So(evs[4].Key(), ShouldEqual, KeyCenter)
+	})
+
+	Convey("Keypad keys", t, func() {
+		ip := newTestParser("xterm")
+
+		evs := scanKeys(ip, "\x1bOM\x1bOk\x1bOu\x1bO5j")
+		So(len(evs), ShouldEqual, 4)
+		So(evs[0].Key(), ShouldEqual, KeyKPEnter)
+		So(evs[1].Key(), ShouldEqual, KeyKPPlus)
+		So(evs[1].Rune(), ShouldEqual, '+')
+		So(evs[2].Key(), ShouldEqual, KeyKP5)
+		So(evs[2].Rune(), ShouldEqual, '5')
+		So(evs[2].Name(), ShouldEqual, "KP5")
+		So(evs[3].Key(), ShouldEqual, KeyKPMultiply)
+		So(evs[3].Mod(), ShouldEqual, ModCtrl)
+
+		evs = scanKeys(ip, "\x1b\x1bOm")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyKPMinus)
+		So(evs[0].Mod(), ShouldEqual, ModAlt)
+		So(evs[0].Rune(), ShouldEqual, '-')
+	})
+}
+
+func TestAltGr(t *testing.T) {
+	Convey("Alt and AltGr", t, func() {
+		ip := newTestParser("xterm")
+
+		Convey("ESC prefix reports Alt", func() {
+			evs := scanKeys(ip, "\x1bx")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Rune(), ShouldEqual, 'x')
+			So(evs[0].Mod(), ShouldEqual, ModAlt)
+
+			evs = scanKeys(ip, "\x1b\x7f")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Key(), ShouldEqual, KeyBackspace2)
+			So(evs[0].Mod(), ShouldEqual, ModAlt)
+		})
+
+		Convey("Composed characters are not Alt", func() {
+			ip.Feed([]byte("\x1b\xe2"))
+			So(len(ip.Events()), ShouldEqual, 0)
+			evs := scanKeys(ip, "\x82\xac")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Rune(), ShouldEqual, '€')
+			So(evs[0].Mod(), ShouldEqual, ModNone)
+
+			evs = scanKeys(ip, "\x1b[64;7u")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Rune(), ShouldEqual, '@')
+			So(evs[0].Mod(), ShouldEqual, ModNone)
+
+			evs = scanKeys(ip, "\x1b@")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Mod(), ShouldEqual, ModAlt)
+		})
+
+		Convey("CSI u keys", func() {
+			evs := scanKeys(ip, "\x1b[97;7u")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Key(), ShouldEqual, KeyCtrlA)
+			So(evs[0].Mod(), ShouldEqual, ModCtrl|ModAlt)
+
+			evs = scanKeys(ip, "\x1b[13;2u")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Key(), ShouldEqual, KeyEnter)
+			So(evs[0].Mod(), ShouldEqual, ModShift)
+
+			evs = scanKeys(ip, "\x1b[27;3;120~")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Rune(), ShouldEqual, 'x')
+			So(evs[0].Mod(), ShouldEqual, ModAlt)
+
+			evs = scanKeys(ip, "\x1b[120;3:3u")
+			So(len(evs), ShouldEqual, 0)
+		})
+
+		Convey("Symbols mode", func() {
+			ip.SetAltGrMode(AltGrSymbols)
+			evs := scanKeys(ip, "\x1b@\x1bx")
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].Rune(), ShouldEqual, '@')
+			So(evs[0].Mod(), ShouldEqual, ModNone)
+			So(evs[1].Rune(), ShouldEqual, 'x')
+			So(evs[1].Mod(), ShouldEqual, ModAlt)
+		})
+
+		Convey("Off mode", func() {
+			ip.SetAltGrMode(AltGrOff)
+			evs := scanKeys(ip, "\x1b\xe2\x82\xac")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Rune(), ShouldEqual, '€')
+			So(evs[0].Mod(), ShouldEqual, ModAlt)
+		})
+
+		So(parseAltGrMode("Symbols"), ShouldEqual, AltGrSymbols)
+		So(parseAltGrMode("bogus").String(), ShouldEqual, "auto")
+	})
+}
+
+func TestMouseEncodings(t *testing.T) {
+	Convey("Mouse reporting encodings", t, func() {
+		ip := newTestParser("xterm")
+
+		Convey("X11 encoding", func() {
+			evs := scanMouse(ip, "\x1b[M !!")
+			So(len(evs), ShouldEqual, 1)
+			x, y := evs[0].Position()
+			So(x, ShouldEqual, 0)
+			So(y, ShouldEqual, 0)
+			So(evs[0].Buttons(), ShouldEqual, Button1)
+		})
+
+		Convey("SGR encoding", func() {
+			evs := scanMouse(ip, "\x1b[<0;10;5M")
+			So(len(evs), ShouldEqual, 1)
+			x, y := evs[0].Position()
+			So(x, ShouldEqual, 9)
+			So(y, ShouldEqual, 4)
+			So(evs[0].Buttons(), ShouldEqual, Button1)
+			_, _, ok := evs[0].PixelPosition()
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Urxvt encoding", func() {
+			evs := scanMouse(ip, "\x1b[34;70;20M")
+			So(len(evs), ShouldEqual, 1)
+			x, y := evs[0].Position()
+			So(x, ShouldEqual, 69)
+			So(y, ShouldEqual, 19)
+			So(evs[0].Buttons(), ShouldEqual, Button3)
+		})
+
+		Convey("Horizontal wheel", func() {
+			evs := scanMouse(ip, "\x1b[<66;1;1M\x1b[<67;1;1M")
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].Buttons(), ShouldEqual, WheelLeft)
+			So(evs[1].Buttons(), ShouldEqual, WheelRight)
+			So(evs[0].Clicks(), ShouldEqual, 0)
+
+			// the same, as legacy X11 records
+			evs = scanMouse(ip, "\x1b[Mb!!\x1b[Mc!!")
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].Buttons(), ShouldEqual, WheelLeft)
+			So(evs[1].Buttons(), ShouldEqual, WheelRight)
+		})
+
+		Convey("Chorded buttons", func() {
+			evs := scanMouse(ip, "\x1b[<0;5;5M\x1b[<2;5;5M"+
+				"\x1b[<32;6;5M\x1b[<0;6;5m\x1b[<2;6;5m")
+			So(len(evs), ShouldEqual, 5)
+			So(evs[0].Buttons(), ShouldEqual, Button1)
+			So(evs[1].Buttons(), ShouldEqual, Button1|Button3)
+			So(evs[2].Buttons(), ShouldEqual, Button1|Button3)
+			So(evs[3].Buttons(), ShouldEqual, Button3)
+			So(evs[4].Buttons(), ShouldEqual, ButtonNone)
+
+			// legacy records release them all at once
+			evs = scanMouse(ip, "\x1b[M !!\x1b[M\"!!\x1b[M#!!")
+			So(len(evs), ShouldEqual, 3)
+			So(evs[1].Buttons(), ShouldEqual, Button1|Button3)
+			So(evs[2].Buttons(), ShouldEqual, ButtonNone)
+		})
+
+		Convey("SGR-Pixels encoding", func() {
+			ip.SetCellPixels(10, 20)
+			evs := scanMouse(ip, "\x1b[<0;96;41M")
+			So(len(evs), ShouldEqual, 1)
+			x, y := evs[0].Position()
+			So(x, ShouldEqual, 9)
+			So(y, ShouldEqual, 2)
+			px, py, ok := evs[0].PixelPosition()
+			So(ok, ShouldBeTrue)
+			So(px, ShouldEqual, 95)
+			So(py, ShouldEqual, 40)
+		})
+	})
+}
+
+func TestInputParser(t *testing.T) {
+	Convey("Input parser", t, func() {
+		ip := newTestParser("xterm")
+
+		Convey("Partial sequences are held until complete", func() {
+			ip.Feed([]byte("\x1b[1;"))
+			So(ip.Events(), ShouldBeEmpty)
+			ip.Feed([]byte("5C"))
+			evs := ip.Events()
+			So(len(evs), ShouldEqual, 1)
+			ek := evs[0].(*EventKey)
+			So(ek.Key(), ShouldEqual, KeyRight)
+			So(ek.Mod(), ShouldEqual, ModCtrl)
+			So(ip.Events(), ShouldBeEmpty)
+		})
+
+		Convey("A lone escape is delivered on expiry", func() {
+			ip.Feed([]byte("\x1b"))
+			So(ip.Events(), ShouldBeEmpty)
+			ip.Expire()
+			evs := ip.Events()
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].(*EventKey).Key(), ShouldEqual, KeyEscape)
+		})
+
+		Convey("Split UTF-8 characters are reassembled", func() {
+			ip.Feed([]byte{0xe2, 0x82})
+			So(ip.Events(), ShouldBeEmpty)
+			ip.Feed([]byte{0xac, 'x'})
+			evs := ip.Events()
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].(*EventKey).Rune(), ShouldEqual, '€')
+			So(evs[1].(*EventKey).Rune(), ShouldEqual, 'x')
+
+			Convey("Even if the input expires in between", func() {
+				ip.Feed([]byte{0xf0, 0x9f})
+				ip.Expire()
+				So(ip.Events(), ShouldBeEmpty)
+				ip.Feed([]byte{0x98})
+				ip.Expire()
+				So(ip.Events(), ShouldBeEmpty)
+				evs := scanKeys(ip, "\x80")
+				So(len(evs), ShouldEqual, 1)
+				So(evs[0].Rune(), ShouldEqual, '😀')
+			})
+
+			Convey("But not if the rest never comes", func() {
+				evs := scanKeys(ip, "\xe2\xa0x")
+				So(len(evs), ShouldEqual, 3)
+				So(evs[0].Rune(), ShouldEqual, utf8.RuneError)
+				So(evs[1].Rune(), ShouldEqual, utf8.RuneError)
+				So(evs[2].Rune(), ShouldEqual, 'x')
+			})
+		})
+
+		Convey("Color scheme reports", func() {
+			evs := scanEvents(ip, "\x1b[?997;1n\x1b[?997;2n")
+			So(len(evs), ShouldEqual, 2)
+			ev := evs[0].(*EventColorsChanged)
+			So(ev.Scheme(), ShouldEqual, ColorSchemeDark)
+			So(ev.Dark(), ShouldBeTrue)
+			ev = evs[1].(*EventColorsChanged)
+			So(ev.Scheme(), ShouldEqual, ColorSchemeLight)
+			So(ev.Dark(), ShouldBeFalse)
+		})
+
+		Convey("Unknown character sets are refused", func() {
+			_, e := NewInputParser(ip.ti, "no-such-charset")
+			So(e, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseInput(t *testing.T) {
+	Convey("Parsing a stream of input", t, func() {
+		ip := newTestParser("xterm")
+		var keys []Key
+		post := func(ev Event) {
+			if ek, ok := ev.(*EventKey); ok {
+				keys = append(keys, ek.Key())
+			}
+		}
+
+		e := ip.ParseInput(strings.NewReader("\x1bOAa\x1b"), post)
+		So(e, ShouldBeNil)
+		So(keys, ShouldResemble, []Key{KeyUp, KeyRune, KeyEscape})
+		So(ip.Events(), ShouldBeEmpty)
+
+		Convey("Read errors are returned", func() {
+			oops := errors.New("oops")
+			So(ip.ParseInput(iotest.TimeoutReader(strings.NewReader("x")),
+				post), ShouldEqual, iotest.ErrTimeout)
+			So(ip.ParseInput(&errReader{oops}, post), ShouldEqual, oops)
+		})
+
+		Convey("Partial input expires on a live stream", func() {
+			r, w := io.Pipe()
+			evch := make(chan Event, 10)
+			done := make(chan error)
+			go func() {
+				done <- ip.ParseInput(r, func(ev Event) { evch <- ev })
+			}()
+			w.Write([]byte("\x1b"))
+			select {
+			case ev := <-evch:
+				So(ev.(*EventKey).Key(), ShouldEqual, KeyEscape)
+			case <-time.After(time.Second):
+				So("ESC was not delivered", ShouldBeNil)
+			}
+			w.Close()
+			So(<-done, ShouldBeNil)
+		})
+
+		Convey("The escape delay can be changed", func() {
+			So(ip.escdelay, ShouldEqual, 100*time.Millisecond)
+			ip.SetEscapeDelay(time.Hour)
+			r, w := io.Pipe()
+			evch := make(chan Event, 10)
+			done := make(chan error)
+			go func() {
+				done <- ip.ParseInput(r, func(ev Event) { evch <- ev })
+			}()
+			w.Write([]byte("\x1b"))
+			select {
+			case <-evch:
+				So("ESC was delivered early", ShouldBeNil)
+			case <-time.After(200 * time.Millisecond):
+			}
+			w.Close()
+			So(<-done, ShouldBeNil)
+			So((<-evch).(*EventKey).Key(), ShouldEqual, KeyEscape)
+
+			os.Setenv("TCELL_ESCDELAY", "25")
+			Reset(func() {
+				os.Setenv("TCELL_ESCDELAY", "")
+			})
+			So(escapeDelay(), ShouldEqual, 25*time.Millisecond)
+			os.Setenv("TCELL_ESCDELAY", "soon")
+			So(escapeDelay(), ShouldEqual, 100*time.Millisecond)
+		})
+	})
+}
+
+type errReader struct {
+	e error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.e
+}
+
+func TestDeviceAttributes(t *testing.T) {
+	Convey("Replies to the device queries", t, func() {
+		ip := newTestParser("xterm")
+		ip.probing = true
+		evs := scanEvents(ip, "\x1bP>|xterm(388)\x1b\\"+
+			"\x1b[>41;388;0c"+
+			"\x1bP1+r524742=38\x1b\\\x1bP0+r5463\x1b\\")
+		So(evs, ShouldBeEmpty)
+		So(ip.devattr.Name, ShouldEqual, "xterm(388)")
+		So(ip.devattr.Type, ShouldEqual, 41)
+		So(ip.devattr.Version, ShouldEqual, 388)
+		So(ip.devattr.TrueColor, ShouldBeTrue)
+		So(ip.devattr.Complete, ShouldBeFalse)
+
+		evs = scanEvents(ip, "\x1b[?64;1;4;22c")
+		So(len(evs), ShouldEqual, 1)
+		da := evs[0].(*EventDeviceAttributes).Attributes()
+		So(da.Complete, ShouldBeTrue)
+		So(da.Attributes, ShouldResemble, []int{1, 4, 22})
+		So(da.Sixel, ShouldBeTrue)
+		So(da.KittyKeyboard, ShouldBeFalse)
+		So(ip.probing, ShouldBeFalse)
+
+		Convey("Kitty keyboard flags", func() {
+			So(scanEvents(ip, "\x1b[?0u"), ShouldBeEmpty)
+			So(ip.devattr.KittyKeyboard, ShouldBeTrue)
+		})
+
+		Convey("Window size in pixels", func() {
+			ip.SetSize(80, 24)
+			evs := scanEvents(ip, "\x1b[4;480;960t")
+			So(len(evs), ShouldEqual, 1)
+			ev := evs[0].(*EventResize)
+			w, h := ev.Size()
+			So(w, ShouldEqual, 80)
+			So(h, ShouldEqual, 24)
+			pw, ph, ok := ev.PixelSize()
+			So(ok, ShouldBeTrue)
+			So(pw, ShouldEqual, 960)
+			So(ph, ShouldEqual, 480)
+
+			// the same size again is not reported
+			So(scanEvents(ip, "\x1b[4;480;960t"), ShouldBeEmpty)
+
+			// other window reports are not taken for it
+			So(ip.winpw, ShouldEqual, 960)
+			scanEvents(ip, "\x1b[8;24;80t")
+			So(ip.winpw, ShouldEqual, 960)
+		})
+
+		Convey("ESC P is Alt-P unless probing", func() {
+			keys := scanKeys(ip, "\x1bP")
+			So(len(keys), ShouldEqual, 1)
+			So(keys[0].Rune(), ShouldEqual, 'P')
+			So(keys[0].Mod(), ShouldEqual, ModAlt)
+		})
+	})
+}
+
+func TestParseLocale(t *testing.T) {
+	Convey("Locales name character sets", t, func() {
+		for locale, charset := range map[string]string{
+			"":                       "UTF-8",
+			"C":                      "US-ASCII",
+			"POSIX":                  "US-ASCII",
+			"C.UTF-8":                "UTF-8",
+			"en_US.UTF-8":            "UTF-8",
+			"en_US.utf8":             "UTF-8",
+			"en_US":                  "UTF-8",
+			"de_DE.ISO-8859-15@euro": "ISO-8859-15",
+			"de_DE@euro":             "ISO8859-15",
+			"sr_RS.UTF-8@latin":      "UTF-8",
+			"ru_RU.KOI8-R":           "KOI8-R",
+			"en_US.":                 "UTF-8",
+		} {
+			So(parseLocale(locale), ShouldEqual, charset)
+		}
+	})
+}
+
+func TestMultibyteInput(t *testing.T) {
+	Convey("Multibyte character sets", t, func() {
+		ti, e := LookupTerminfo("xterm")
+		So(e, ShouldBeNil)
+		text := map[string]string{
+			"GB18030":   "中文输入€😀",
+			"Big5":      "中文輸入",
+			"EUC-KR":    "한국어 입력",
+			"Shift_JIS": "日本語ｶﾅ入力",
+		}
+		encs := map[string]encoding.Encoding{
+			"GB18030":   simplifiedchinese.GB18030,
+			"Big5":      traditionalchinese.Big5,
+			"EUC-KR":    korean.EUCKR,
+			"Shift_JIS": japanese.ShiftJIS,
+		}
+		for name, enc := range encs {
+			RegisterEncoding("X-TCELL-TEST-"+name, enc)
+		}
+		Reset(func() {
+			for name := range encs {
+				RegisterEncoding("X-TCELL-TEST-"+name, nil)
+			}
+		})
+		runes := func(evs []Event) string {
+			var rs []rune
+			for _, ev := range evs {
+				rs = append(rs, ev.(*EventKey).Rune())
+			}
+			return string(rs)
+		}
+
+		for name, enc := range encs {
+			b, e := enc.NewEncoder().Bytes([]byte(text[name]))
+			So(e, ShouldBeNil)
+			ip, e := NewInputParser(ti, "X-TCELL-TEST-"+name)
+			So(e, ShouldBeNil)
+
+			// whole
+			ip.Feed(b)
+			So(runes(ip.Events()), ShouldEqual, text[name])
+
+			// a byte at a time, as though each were a separate read
+			for i := range b {
+				ip.Feed(b[i : i+1])
+			}
+			So(runes(ip.Events()), ShouldEqual, text[name])
+
+			// and with the input expiring after each
+			for i := range b {
+				ip.Feed(b[i : i+1])
+				ip.Expire()
+			}
+			So(runes(ip.Events()), ShouldEqual, text[name])
+		}
+
+		Convey("Invalid sequences are discarded", func() {
+			ip, e := NewInputParser(ti, "X-TCELL-TEST-EUC-KR")
+			So(e, ShouldBeNil)
+			ip.Feed([]byte{0xb0, 0x20, 'a'})
+			ip.Expire()
+			So(runes(ip.Events()), ShouldEqual, "a")
+		})
+	})
+}
+
+func TestKeyRepeat(t *testing.T) {
+	Convey("Repeating keys", t, func() {
+		ip := newTestParser("xterm")
+
+		Convey("Keys arriving quickly on their own repeat", func() {
+			So(scanKeys(ip, "a")[0].Repeat(), ShouldEqual, 0)
+			So(scanKeys(ip, "a")[0].Repeat(), ShouldEqual, 1)
+			So(scanKeys(ip, "a")[0].Repeat(), ShouldEqual, 2)
+			So(scanKeys(ip, "b")[0].Repeat(), ShouldEqual, 0)
+		})
+
+		Convey("Keys arriving together do not", func() {
+			evs := scanKeys(ip, "aa")
+			So(evs[0].Repeat(), ShouldEqual, 0)
+			So(evs[1].Repeat(), ShouldEqual, 0)
+		})
+
+		Convey("Nor do keys arriving slowly", func() {
+			scanKeys(ip, "a")
+			time.Sleep(keyRepeatGap + 10*time.Millisecond)
+			So(scanKeys(ip, "a")[0].Repeat(), ShouldEqual, 0)
+		})
+
+		Convey("The kitty protocol says so", func() {
+			evs := scanKeys(ip, "\x1b[97u\x1b[97;1:2u\x1b[97;1:2u")
+			So(len(evs), ShouldEqual, 3)
+			So(evs[0].Repeat(), ShouldEqual, 0)
+			So(evs[1].Repeat(), ShouldEqual, 1)
+			So(evs[2].Repeat(), ShouldEqual, 2)
+		})
+	})
+}
+
+func TestPastedKeys(t *testing.T) {
+	Convey("Pasted keys", t, func() {
+		Convey("Typed keys are not pasted", func() {
+			ip := newTestParser("xterm")
+			for _, ev := range scanKeys(ip, "ls\r") {
+				So(ev.Pasted(), ShouldBeFalse)
+			}
+			for _, ev := range scanKeys(ip, "\x1b[A\x1b[B\x1b[<35;10;20M\x1b[<35;11;20M") {
+				So(ev.Pasted(), ShouldBeFalse)
+			}
+		})
+
+		Convey("A burst of text is pasted", func() {
+			ip := newTestParser("xterm")
+			evs := scanKeys(ip, "echo hello\r")
+			So(len(evs), ShouldEqual, 11)
+			for _, ev := range evs {
+				So(ev.Pasted(), ShouldBeTrue)
+			}
+			So(scanKeys(ip, "x")[0].Pasted(), ShouldBeFalse)
+		})
+
+		Convey("Bracketed paste says so", func() {
+			ip := newTestParser("kitty")
+			evs := scanKeys(ip, "a\x1b[200~bc\x1b[201~d")
+			So(len(evs), ShouldEqual, 4)
+			So(evs[0].Pasted(), ShouldBeFalse)
+			So(evs[1].Pasted(), ShouldBeTrue)
+			So(evs[2].Pasted(), ShouldBeTrue)
+			So(evs[3].Pasted(), ShouldBeFalse)
+
+			ip.Feed([]byte("\x1b[20"))
+			ip.Feed([]byte("0~x"))
+			evs = scanKeys(ip, "\x1b[201~")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Rune(), ShouldEqual, 'x')
+			So(evs[0].Pasted(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestUnknownSequences(t *testing.T) {
+	Convey("Unknown sequences", t, func() {
+		ip := newTestParser("xterm")
+		runes := func(evs []*EventKey) string {
+			var s []rune
+			for _, ev := range evs {
+				if ev.Key() == KeyRune {
+					s = append(s, ev.Rune())
+				} else {
+					s = append(s, '<')
+				}
+			}
+			return string(s)
+		}
+
+		Convey("Are discarded whole", func() {
+			So(runes(scanKeys(ip, "a\x1b[?12;2$yb")), ShouldEqual, "ab")
+			So(runes(scanKeys(ip, "\x1b]11;rgb:0000/0000/0000\x1b\\c")), ShouldEqual, "c")
+			So(runes(scanKeys(ip, "\x1b]2;title\ad")), ShouldEqual, "d")
+			So(runes(scanKeys(ip, "\x1bOze")), ShouldEqual, "e")
+		})
+
+		Convey("Even when split up", func() {
+			ip.Feed([]byte("\x1b[?12"))
+			ip.Feed([]byte(";2$"))
+			ip.Feed([]byte("yq"))
+			evs := ip.Events()
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].(*EventKey).Rune(), ShouldEqual, 'q')
+		})
+
+		Convey("Unless they are interrupted", func() {
+			evs := scanKeys(ip, "\x1b[12\x1b[A")
+			So(len(evs), ShouldEqual, 1)
+			So(evs[0].Key(), ShouldEqual, KeyUp)
+			evs = scanKeys(ip, "\x1b[12\x18f")
+			So(runes(evs), ShouldEqual, "f")
+		})
+
+		Convey("Or never finish", func() {
+			So(len(scanKeys(ip, "\x1b[12;")), ShouldEqual, 0)
+			So(scanKeys(ip, "\x1b")[0].Key(), ShouldEqual, KeyEsc)
+			So(runes(scanKeys(ip, "\x1b[")), ShouldEqual, "<[")
+			evs := scanKeys(ip, "\x1bP")
+			So(evs[0].Rune(), ShouldEqual, 'P')
+			So(evs[0].Mod(), ShouldEqual, ModAlt)
+		})
+
+		Convey("Sequence lengths", func() {
+			for seq, n := range map[string]int{
+				"a":             -1,
+				"\x1b":          0,
+				"\x1bx":         2,
+				"\x1b(B":        3,
+				"\x1b\x1b":      -1,
+				"\x1b[":         0,
+				"\x1b[1;5Cx":    6,
+				"\x1b[1\x05":    3,
+				"\x1bOP":        3,
+				"\x1b]0;t\a":    6,
+				"\x1b]0;t\x1b":  0,
+				"\x1bP+q\x1b\\": 6,
+			} {
+				So(sequenceLength([]byte(seq)), ShouldEqual, n)
+			}
+		})
+	})
+}
+
+func TestC1Controls(t *testing.T) {
+	Convey("8-bit controls", t, func() {
+		Convey("As bytes in UTF-8", func() {
+			ip := newTestParser("xterm")
+			evs := scanKeys(ip, "\x9bA\x8fB\x9b1;5C")
+			So(len(evs), ShouldEqual, 3)
+			So(evs[0].Key(), ShouldEqual, KeyUp)
+			So(evs[1].Key(), ShouldEqual, KeyDown)
+			So(evs[2].Key(), ShouldEqual, KeyRight)
+			So(evs[2].Mod(), ShouldEqual, ModCtrl)
+		})
+
+		Convey("Encoded as UTF-8", func() {
+			ip := newTestParser("xterm")
+			ip.Feed([]byte("\xc2"))
+			ip.Feed([]byte("\x9bD\xc2\x9d2;title\xc2\x9cé"))
+			evs := ip.Events()
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].(*EventKey).Key(), ShouldEqual, KeyLeft)
+			So(evs[1].(*EventKey).Rune(), ShouldEqual, 'é')
+		})
+
+		Convey("Strings end with 8-bit ST", func() {
+			ip := newTestParser("xterm")
+			evs := scanKeys(ip, "\x9d11;rgb:ffff/ffff/ffff\x9cx\x85y")
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].Rune(), ShouldEqual, 'x')
+			So(evs[1].Rune(), ShouldEqual, 'y')
+		})
+
+		Convey("Mouse reports", func() {
+			ip := newTestParser("xterm")
+			evs := scanMouse(ip, "\x9b<0;3;4M")
+			So(len(evs), ShouldEqual, 1)
+			x, y := evs[0].Position()
+			So([]int{x, y}, ShouldResemble, []int{2, 3})
+		})
+
+		Convey("In ISO 8859-1", func() {
+			RegisterEncoding("X-TCELL-TEST-LATIN1", charmap.ISO8859_1)
+			Reset(func() {
+				RegisterEncoding("X-TCELL-TEST-LATIN1", nil)
+			})
+			ti, _ := LookupTerminfo("xterm")
+			ip, e := NewInputParser(ti, "X-TCELL-TEST-LATIN1")
+			So(e, ShouldBeNil)
+			evs := scanKeys(ip, "\x9bA\xe9")
+			So(len(evs), ShouldEqual, 2)
+			So(evs[0].Key(), ShouldEqual, KeyUp)
+			So(evs[1].Rune(), ShouldEqual, 'é')
+		})
+	})
+}
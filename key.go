@@ -43,10 +43,12 @@ import (
 // overly much on availability of modifiers, or the availability of any
 // specific keys.
 type EventKey struct {
-	t   time.Time
-	mod ModMask
-	key Key
-	ch  rune
+	t      time.Time
+	mod    ModMask
+	key    Key
+	ch     rune
+	repeat int
+	paste  bool
 }
 
 // When returns the time when this Event was created, which should closely
@@ -78,100 +80,45 @@ func (ev *EventKey) Mod() ModMask {
 	return ev.mod
 }
 
+// Repeat returns how many times the key has repeated so far, when it is
+// held down, and zero when it was pressed.  The Windows console and
+// terminals using the kitty keyboard protocol (with event types) say so;
+// elsewhere, a key is taken to be repeating when the same key arrives
+// again, on its own, within a tenth of a second.  That is only a guess,
+// and it misses the first repeat, which comes after a longer delay.
+func (ev *EventKey) Repeat() int {
+	return ev.repeat
+}
+
+// Pasted is true if the key is part of text that was pasted, rather than
+// typed.  Editors can use this to hold off on things that they do after
+// each keystroke, such as completion or automatic indentation.  Terminals
+// that support bracketed paste say what was pasted; elsewhere, text that
+// arrives all at once, faster than anyone types, is taken to be pasted.
+// Keys typed ahead while the application is busy may look like that too.
+func (ev *EventKey) Pasted() bool {
+	return ev.paste
+}
+
 // Name returns a printable value or the key stroke.  This can be used
-// when printing the event, for example.
+// when printing the event, for example.  The modifiers come first, as
+// in "Ctrl+Shift+F5" or "Alt+Rune[é]", and the key is named as in
+// KeyNames.  ParseKeyName parses it back.
 func (ev *EventKey) Name() string {
 	s := ""
 	m := []string{}
-	if ev.mod&ModShift != 0 {
-		m = append(m, "Shift")
-	}
-	if ev.mod&ModAlt != 0 {
-		m = append(m, "Alt")
-	}
-	if ev.mod&ModMeta != 0 {
-		m = append(m, "Meta")
-	}
-	if ev.mod&ModCtrl != 0 {
-		m = append(m, "Ctrl")
+	for _, mn := range modNames {
+		if ev.mod&mn.mod != 0 {
+			m = append(m, mn.name)
+		}
 	}
 
-	switch ev.key {
-	case KeyRune:
+	if ev.key == KeyRune {
 		s = "Rune[" + string(ev.ch) + "]"
-	case KeySpace:
-		s = "Space"
-	case KeyEnter:
-		s = "Enter"
-	case KeyBackspace:
-		s = "Backspace"
-	case KeyTab:
-		s = "Tab"
-	case KeyBacktab:
-		s = "Backtab"
-	case KeyEsc:
-		s = "Esc"
-	case KeyBackspace2:
-		s = "Backspace2"
-	case KeyDelete:
-		s = "Delete"
-	case KeyInsert:
-		s = "Insert"
-	case KeyUp:
-		s = "Up"
-	case KeyDown:
-		s = "Down"
-	case KeyLeft:
-		s = "Left"
-	case KeyRight:
-		s = "Right"
-	case KeyHome:
-		s = "Home"
-	case KeyEnd:
-		s = "End"
-	case KeyUpLeft:
-		s = "UpLeft"
-	case KeyUpRight:
-		s = "UpRight"
-	case KeyDownLeft:
-		s = "DownLeft"
-	case KeyDownRight:
-		s = "DownRight"
-	case KeyCenter:
-		s = "Center"
-	case KeyPgDn:
-		s = "PgDn"
-	case KeyPgUp:
-		s = "PgUp"
-	case KeyClear:
-		s = "Clear"
-	case KeyExit:
-		s = "Exit"
-	case KeyCancel:
-		s = "Cancel"
-	case KeyPause:
-		s = "Pause"
-	case KeyPrint:
-		s = "Print"
-	case KeyCtrlSpace:
-		s = "Ctrl-Space"
-	case KeyCtrlUnderscore:
-		s = "Ctrl-_"
-	case KeyCtrlRightSq:
-		s = "Ctrl-]"
-	case KeyCtrlBackslash:
-		s = "Ctrl-\\"
-	case KeyCtrlCarat:
-		s = "Ctrl-^"
-	default:
-		if ev.key >= KeyF1 && ev.key <= KeyF64 {
-			s = fmt.Sprintf("F%d", int(ev.key-KeyF1)+1)
-		} else if ev.key >= KeyCtrlA && ev.key <= KeyCtrlZ {
-			s = fmt.Sprintf("Ctrl-%c",
-				rune(ev.key-KeyCtrlA)+'A')
-		} else {
-			s = fmt.Sprintf("Key[%d,%d]", ev.key, int(ev.ch))
-		}
+	} else if name, ok := KeyNames[ev.key]; ok {
+		s = name
+	} else {
+		s = fmt.Sprintf("Key[%d,%d]", ev.key, int(ev.ch))
 	}
 
 	if len(m) != 0 {
@@ -311,6 +258,29 @@ const (
 	KeyF62
 	KeyF63
 	KeyF64
+
+	// The keys of the numeric keypad, which are only told apart from
+	// the main keys when the terminal is in application keypad mode.
+	// (See prepareKeypadKeys.)  Rune returns the character that each
+	// of these types.
+	KeyKP0
+	KeyKP1
+	KeyKP2
+	KeyKP3
+	KeyKP4
+	KeyKP5
+	KeyKP6
+	KeyKP7
+	KeyKP8
+	KeyKP9
+	KeyKPEnter
+	KeyKPEqual
+	KeyKPMultiply
+	KeyKPPlus
+	KeyKPComma
+	KeyKPMinus
+	KeyKPDecimal
+	KeyKPDivide
 )
 
 const (
@@ -0,0 +1,258 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyNames holds the canonical name of each Key, as used by EventKey's
+// Name method and understood by ParseKeyName.  Control keys that have
+// a key of their own (such as Tab, which is also Ctrl-I) go by the name
+// of the key.
+var KeyNames = map[Key]string{
+	KeyRune:      "Rune",
+	KeyUp:        "Up",
+	KeyDown:      "Down",
+	KeyRight:     "Right",
+	KeyLeft:      "Left",
+	KeyUpLeft:    "UpLeft",
+	KeyUpRight:   "UpRight",
+	KeyDownLeft:  "DownLeft",
+	KeyDownRight: "DownRight",
+	KeyCenter:    "Center",
+	KeyPgUp:      "PgUp",
+	KeyPgDn:      "PgDn",
+	KeyHome:      "Home",
+	KeyEnd:       "End",
+	KeyInsert:    "Insert",
+	KeyDelete:    "Delete",
+	KeyHelp:      "Help",
+	KeyExit:      "Exit",
+	KeyClear:     "Clear",
+	KeyCancel:    "Cancel",
+	KeyPrint:     "Print",
+	KeyPause:     "Pause",
+	KeyBacktab:   "Backtab",
+
+	KeyF1:  "F1",
+	KeyF2:  "F2",
+	KeyF3:  "F3",
+	KeyF4:  "F4",
+	KeyF5:  "F5",
+	KeyF6:  "F6",
+	KeyF7:  "F7",
+	KeyF8:  "F8",
+	KeyF9:  "F9",
+	KeyF10: "F10",
+	KeyF11: "F11",
+	KeyF12: "F12",
+	KeyF13: "F13",
+	KeyF14: "F14",
+	KeyF15: "F15",
+	KeyF16: "F16",
+	KeyF17: "F17",
+	KeyF18: "F18",
+	KeyF19: "F19",
+	KeyF20: "F20",
+	KeyF21: "F21",
+	KeyF22: "F22",
+	KeyF23: "F23",
+	KeyF24: "F24",
+	KeyF25: "F25",
+	KeyF26: "F26",
+	KeyF27: "F27",
+	KeyF28: "F28",
+	KeyF29: "F29",
+	KeyF30: "F30",
+	KeyF31: "F31",
+	KeyF32: "F32",
+	KeyF33: "F33",
+	KeyF34: "F34",
+	KeyF35: "F35",
+	KeyF36: "F36",
+	KeyF37: "F37",
+	KeyF38: "F38",
+	KeyF39: "F39",
+	KeyF40: "F40",
+	KeyF41: "F41",
+	KeyF42: "F42",
+	KeyF43: "F43",
+	KeyF44: "F44",
+	KeyF45: "F45",
+	KeyF46: "F46",
+	KeyF47: "F47",
+	KeyF48: "F48",
+	KeyF49: "F49",
+	KeyF50: "F50",
+	KeyF51: "F51",
+	KeyF52: "F52",
+	KeyF53: "F53",
+	KeyF54: "F54",
+	KeyF55: "F55",
+	KeyF56: "F56",
+	KeyF57: "F57",
+	KeyF58: "F58",
+	KeyF59: "F59",
+	KeyF60: "F60",
+	KeyF61: "F61",
+	KeyF62: "F62",
+	KeyF63: "F63",
+	KeyF64: "F64",
+
+	KeyKP0:        "KP0",
+	KeyKP1:        "KP1",
+	KeyKP2:        "KP2",
+	KeyKP3:        "KP3",
+	KeyKP4:        "KP4",
+	KeyKP5:        "KP5",
+	KeyKP6:        "KP6",
+	KeyKP7:        "KP7",
+	KeyKP8:        "KP8",
+	KeyKP9:        "KP9",
+	KeyKPEnter:    "KPEnter",
+	KeyKPEqual:    "KPEqual",
+	KeyKPMultiply: "KPMultiply",
+	KeyKPPlus:     "KPPlus",
+	KeyKPComma:    "KPComma",
+	KeyKPMinus:    "KPMinus",
+	KeyKPDecimal:  "KPDecimal",
+	KeyKPDivide:   "KPDivide",
+
+	KeyCtrlSpace:      "Ctrl-Space",
+	KeyCtrlA:          "Ctrl-A",
+	KeyCtrlB:          "Ctrl-B",
+	KeyCtrlC:          "Ctrl-C",
+	KeyCtrlD:          "Ctrl-D",
+	KeyCtrlE:          "Ctrl-E",
+	KeyCtrlF:          "Ctrl-F",
+	KeyCtrlG:          "Ctrl-G",
+	KeyBackspace:      "Backspace",
+	KeyTab:            "Tab",
+	KeyCtrlJ:          "Ctrl-J",
+	KeyCtrlK:          "Ctrl-K",
+	KeyCtrlL:          "Ctrl-L",
+	KeyEnter:          "Enter",
+	KeyCtrlN:          "Ctrl-N",
+	KeyCtrlO:          "Ctrl-O",
+	KeyCtrlP:          "Ctrl-P",
+	KeyCtrlQ:          "Ctrl-Q",
+	KeyCtrlR:          "Ctrl-R",
+	KeyCtrlS:          "Ctrl-S",
+	KeyCtrlT:          "Ctrl-T",
+	KeyCtrlU:          "Ctrl-U",
+	KeyCtrlV:          "Ctrl-V",
+	KeyCtrlW:          "Ctrl-W",
+	KeyCtrlX:          "Ctrl-X",
+	KeyCtrlY:          "Ctrl-Y",
+	KeyCtrlZ:          "Ctrl-Z",
+	KeyEsc:            "Esc",
+	KeyCtrlBackslash:  "Ctrl-\\",
+	KeyCtrlRightSq:    "Ctrl-]",
+	KeyCtrlCarat:      "Ctrl-^",
+	KeyCtrlUnderscore: "Ctrl-_",
+	KeySpace:          "Space",
+	KeyBackspace2:     "Backspace2",
+}
+
+// ErrBadKeyName is returned by ParseKeyName for names it does not know.
+var ErrBadKeyName = errors.New("unknown key name")
+
+// keysByName maps the lower case key names back to the keys.
+var keysByName = func() map[string]Key {
+	m := make(map[string]Key, len(KeyNames))
+	for k, n := range KeyNames {
+		m[strings.ToLower(n)] = k
+	}
+	return m
+}()
+
+// modNames are the modifier prefixes, in the order that Name gives them.
+var modNames = []struct {
+	mod  ModMask
+	name string
+}{
+	{ModCtrl, "Ctrl"},
+	{ModAlt, "Alt"},
+	{ModMeta, "Meta"},
+	{ModShift, "Shift"},
+}
+
+// ParseKeyName parses the name of a key chord, as returned by EventKey's
+// Name method, into the EventKey that the chord is reported as.  This
+// lets applications keep key bindings in configuration files.  The key
+// names are those of KeyNames; a printable character stands for itself,
+// and may also be given as Rune[c].  Any of the modifiers Ctrl, Alt,
+// Meta and Shift may come before it, in any order, separated by plus
+// signs, as in "Ctrl+Shift+F5" or "Alt+Rune[é]".  Case is ignored,
+// except in characters.
+//
+// Ctrl with a letter, or with one of @ [ \ ] ^ _ or Space, gives the
+// control key that terminals send for it, so "Ctrl+A" is KeyCtrlA (with
+// ModCtrl), just as when it is typed.  Compare the Key, Rune (for
+// KeyRune) and Mod of events to those of the parsed chord.
+func ParseKeyName(name string) (*EventKey, error) {
+	mod := ModNone
+	rest := name
+outer:
+	for {
+		for _, mn := range modNames {
+			p := mn.name + "+"
+			if len(rest) > len(p) && strings.EqualFold(rest[:len(p)], p) {
+				mod |= mn.mod
+				rest = rest[len(p):]
+				continue outer
+			}
+		}
+		break
+	}
+
+	ch := rune(-1)
+	lower := strings.ToLower(rest)
+	if k, ok := keysByName[lower]; ok && k != KeyRune {
+		if k >= KeyRune {
+			var r rune
+			for _, kp := range keypadKeys {
+				if kp.key == k {
+					r = kp.ch
+				}
+			}
+			return NewEventKey(k, r, mod), nil
+		}
+		ch = rune(k)
+	} else if strings.HasPrefix(lower, "rune[") && strings.HasSuffix(rest, "]") {
+		rest = rest[5 : len(rest)-1]
+	}
+	if ch < 0 {
+		r, n := utf8.DecodeRuneInString(rest)
+		if n == 0 || n != len(rest) || r == utf8.RuneError {
+			return nil, ErrBadKeyName
+		}
+		ch = r
+	}
+	if mod&ModCtrl != 0 {
+		switch {
+		case ch == ' ':
+			ch = 0
+		case ch >= 'a' && ch <= 'z':
+			ch -= 'a' - 1
+		case ch >= '@' && ch <= '_':
+			ch -= '@'
+		}
+	}
+	return NewEventKey(KeyRune, ch, mod), nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"sort"
+)
+
+// Compositor stacks a set of Layers, and composites them onto a Screen.
+// Layers with a higher z-order obscure those beneath them; layers with the
+// same z-order are stacked in the order they were created.  Cells of the
+// screen that no visible layer covers are shown as spaces in the default
+// style.
+//
+// The Compositor remembers what it last drew, so that Draw only updates
+// cells whose composited result actually changed.  This makes it cheap to
+// show, hide, or move popup menus and dialogs over the rest of the
+// display.  When a Compositor is in use, applications should draw only
+// into layers, not directly to the Screen.  A Compositor (and its layers)
+// is not safe for concurrent use.
+type Compositor struct {
+	s      Screen
+	layers []*Layer
+	front  []Cell
+	w      int
+	h      int
+	seq    int
+}
+
+// Layer is an off-screen CellBuffer that is part of a Compositor.  It has
+// a position on the screen, a z-order, and may be shown or hidden.  Its
+// contents are changed using the CellBuffer methods.
+type Layer struct {
+	*CellBuffer
+	c       *Compositor
+	x       int
+	y       int
+	z       int
+	seq     int
+	visible bool
+}
+
+// NewCompositor returns a Compositor that draws onto the given Screen.
+func NewCompositor(s Screen) *Compositor {
+	return &Compositor{s: s}
+}
+
+// NewLayer creates a new visible layer, at the given position and z-order,
+// with the given size.  It is filled with spaces in the default style.
+func (c *Compositor) NewLayer(x, y, width, height, z int) *Layer {
+	c.seq++
+	l := &Layer{CellBuffer: NewCellBuffer(width, height), c: c,
+		x: x, y: y, z: z, seq: c.seq, visible: true}
+	c.layers = append(c.layers, l)
+	c.sort()
+	return l
+}
+
+// Invalidate forgets what was previously drawn, so that the next Draw
+// updates every cell.  Use this if something else has drawn on the
+// Screen.
+func (c *Compositor) Invalidate() {
+	c.front = nil
+}
+
+// Draw composites the visible layers onto the Screen.  Only cells whose
+// composited result changed since the last Draw are updated.  As with any
+// other drawing, the result is not visible until the Screen's Show (or
+// Sync) is called.
+func (c *Compositor) Draw() {
+	w, h := c.s.Size()
+	if c.front == nil || w != c.w || h != c.h {
+		c.front = make([]Cell, w*h)
+		c.w, c.h = w, h
+		InvalidateCells(c.front)
+	}
+	blank := &Cell{Ch: []rune{' '}, Width: 1}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			cell := blank
+			for i := len(c.layers) - 1; i >= 0; i-- {
+				if lc := c.layers[i].at(x, y); lc != nil {
+					cell = lc
+					break
+				}
+			}
+			fc := &c.front[(y*w)+x]
+			if fc.Dirty || !sameCell(fc, cell) {
+				fc.Ch = cell.Ch
+				fc.Style = cell.Style
+				fc.Width = cell.Width
+				fc.Dirty = false
+				c.s.PutCell(x, y, cell)
+			}
+		}
+	}
+	for _, l := range c.layers {
+		l.CellBuffer.clean()
+	}
+}
+
+func (c *Compositor) sort() {
+	sort.Sort(layersByZ(c.layers))
+}
+
+// Position returns the location of the layer's upper left corner.
+func (l *Layer) Position() (int, int) {
+	return l.x, l.y
+}
+
+// SetPosition moves the layer, so that its upper left corner is at the
+// given location.
+func (l *Layer) SetPosition(x, y int) {
+	l.x, l.y = x, y
+}
+
+// Z returns the z-order of the layer.
+func (l *Layer) Z() int {
+	return l.z
+}
+
+// SetZ changes the z-order of the layer.
+func (l *Layer) SetZ(z int) {
+	l.z = z
+	if l.c != nil {
+		l.c.sort()
+	}
+}
+
+// Visible returns true if the layer is being shown.
+func (l *Layer) Visible() bool {
+	return l.visible
+}
+
+// SetVisible shows or hides the layer.
+func (l *Layer) SetVisible(visible bool) {
+	l.visible = visible
+}
+
+// Remove removes the layer from its Compositor.  The layer should not
+// be used afterwards.
+func (l *Layer) Remove() {
+	if l.c == nil {
+		return
+	}
+	for i, o := range l.c.layers {
+		if o == l {
+			l.c.layers = append(l.c.layers[:i], l.c.layers[i+1:]...)
+			break
+		}
+	}
+	l.c = nil
+}
+
+// at returns the layer's cell at the given screen location, or nil if
+// the layer is hidden, or does not cover that location.
+func (l *Layer) at(x, y int) *Cell {
+	if !l.visible {
+		return nil
+	}
+	return l.cell(x-l.x, y-l.y)
+}
+
+type layersByZ []*Layer
+
+func (b layersByZ) Len() int      { return len(b) }
+func (b layersByZ) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b layersByZ) Less(i, j int) bool {
+	if b[i].z != b[j].z {
+		return b[i].z < b[j].z
+	}
+	return b[i].seq < b[j].seq
+}
+
+// sameCell returns true if the cells have the same content and style.
+func sameCell(a, b *Cell) bool {
+	if a.Style != b.Style || len(a.Ch) != len(b.Ch) {
+		return false
+	}
+	for i := range a.Ch {
+		if a.Ch[i] != b.Ch[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCompositor(t *testing.T) {
+	Convey("Compositing layers", t, WithScreen(t, "", func(s SimulationScreen) {
+		c := NewCompositor(s)
+		base := c.NewLayer(0, 0, 80, 25, 0)
+		base.Fill('.', StyleDefault)
+		popup := c.NewLayer(10, 5, 4, 2, 1)
+		popup.Fill('#', StyleDefault.Reverse(true))
+		c.Draw()
+		s.Show()
+
+		b, w, _ := s.GetContents()
+		So(b[0].Runes[0], ShouldEqual, '.')
+		So(b[5*w+10].Runes[0], ShouldEqual, '#')
+		So(b[6*w+13].Runes[0], ShouldEqual, '#')
+		So(b[6*w+14].Runes[0], ShouldEqual, '.')
+
+		Convey("Hiding a layer reveals what is beneath", func() {
+			popup.SetVisible(false)
+			c.Draw()
+			s.Show()
+			So(b[5*w+10].Runes[0], ShouldEqual, '.')
+		})
+
+		Convey("Z-order decides what is on top", func() {
+			popup.SetZ(-1)
+			c.Draw()
+			s.Show()
+			So(b[5*w+10].Runes[0], ShouldEqual, '.')
+
+			popup.SetZ(0)
+			c.Draw()
+			s.Show()
+			So(b[5*w+10].Runes[0], ShouldEqual, '#')
+		})
+
+		Convey("Moving a layer", func() {
+			popup.SetPosition(0, 0)
+			c.Draw()
+			s.Show()
+			So(b[0].Runes[0], ShouldEqual, '#')
+			So(b[5*w+10].Runes[0], ShouldEqual, '.')
+		})
+
+		Convey("Unchanged cells are not redrawn", func() {
+			s.SetCell(0, 0, StyleDefault, 'X')
+			popup.SetContent(0, 0, StyleDefault, '@')
+			c.Draw()
+			s.Show()
+			So(b[0].Runes[0], ShouldEqual, 'X')
+			So(b[5*w+10].Runes[0], ShouldEqual, '@')
+		})
+
+		Convey("Removed layers are not drawn", func() {
+			popup.Remove()
+			c.Draw()
+			s.Show()
+			So(b[5*w+10].Runes[0], ShouldEqual, '.')
+		})
+	}))
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"os"
+	"strings"
+)
+
+// localeCharset returns the character set of the locale.  Per POSIX, the
+// locale is named by the first of $LC_ALL, $LC_CTYPE and $LANG that is
+// set (and not empty).
+func localeCharset() string {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	return parseLocale(locale)
+}
+
+// parseLocale returns the character set of a locale, which is named as
+// language[_territory][.codeset][@modifier]; for example "en_US.UTF-8",
+// "en_US.utf8", "C.UTF-8" or "de_DE.ISO-8859-15@euro".  The "C" and
+// "POSIX" locales use US-ASCII (the POSIX portable character set).  Most
+// systems default to UTF-8 for locales that do not name a codeset, and
+// so do we, except for the old "@euro" locales, which are ISO 8859-15.
+func parseLocale(locale string) string {
+	modifier := ""
+	if i := strings.IndexByte(locale, '@'); i >= 0 {
+		locale, modifier = locale[:i], locale[i+1:]
+	}
+	switch locale {
+	case "C", "POSIX":
+		return "US-ASCII"
+	}
+	if i := strings.IndexByte(locale, '.'); i >= 0 && i+1 < len(locale) {
+		return canonicalCharset(locale[i+1:])
+	}
+	if modifier == "euro" {
+		return "ISO8859-15"
+	}
+	return "UTF-8"
+}
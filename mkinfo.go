@@ -23,13 +23,15 @@
 // mkinfo [-go file.go] [-json file.json] [-quiet] [-nofatal] [<term>...]
 //
 // -go       specifiles Go output into the named file.  Use - for stdout.
-// -json     specifies JSON output in the named file.  Use - for stdout
+// -json     specifies JSON output in the named file.  Use - for stdout.
+//           The file is compressed with gzip if its name ends with .gz.
 // -nofatal  indicates that errors loading definitions should not be fatal
 //
 
 package main
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -69,7 +71,8 @@ func tigetnum(s string) int {
 
 func tigetflag(s string) bool {
 	n := C.tigetflag(C.CString(s))
-	return n != 0
+	// -1 means that it is not a boolean capability at all
+	return n > 0
 }
 
 func tigetstr(s string) string {
@@ -222,7 +225,9 @@ func getinfo(name string) (*tcell.Terminfo, error) {
 	// x11 or SGR mouse events -- if your terminal doesn't support one
 	// of these two forms, you maybe out of luck.
 	t.MouseMode = tigetstr("XM")
-	if t.Mouse != "" && t.MouseMode == "" {
+	// Some newer entries have an XM that only enables click tracking
+	// (1000), which loses drags and motion.  Use our own for those too.
+	if t.Mouse != "" && !strings.Contains(t.MouseMode, "1003") {
 		// we anticipate that all xterm mouse tracking compatible
 		// terminals understand mouse tracking (1000), but we hope
 		// that those that don't understand any-event tracking (1003)
@@ -231,13 +236,42 @@ func getinfo(name string) (*tcell.Terminfo, error) {
 		t.MouseMode = "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;" +
 			"\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c"
 	}
+	// Smulx and Setulc are extensions, which advertise styled (e.g. curly)
+	// underlines and underline colors respectively.
+	t.UnderlineX = tigetstr("Smulx")
+	t.SetUlColor = tigetstr("Setulc")
+	// The RGB forms are extensions too.  Entries for direct color
+	// (the RGB flag) redefine setaf and setab to take a 24-bit value,
+	// which our palette colors cannot use.  For those we record the
+	// 256 color forms instead, which all such terminals understand,
+	// and the direct forms as setrgbf and setrgbb.
+	t.SetFgRGB = tigetstr("setrgbf")
+	t.SetBgRGB = tigetstr("setrgbb")
+	if tigetflag("RGB") || t.Colors > 256 {
+		t.Colors = 256
+		t.SetFg = "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m"
+		t.SetBg = "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m"
+		if t.SetFgRGB == "" {
+			t.SetFgRGB = "\x1b[38;2;%p1%d;%p2%d;%p3%dm"
+			t.SetBgRGB = "\x1b[48;2;%p1%d;%p2%d;%p3%dm"
+		}
+	}
+	// Bracketed paste is advertised with the BE, BD, PS and PE
+	// extensions.
+	t.EnablePaste = tigetstr("BE")
+	t.DisablePaste = tigetstr("BD")
+	t.PasteStart = tigetstr("PS")
+	t.PasteEnd = tigetstr("PE")
+	if tigetflag("ccc") {
+		t.InitColor = tigetstr("initc")
+		t.ResetColors = tigetstr("oc")
+	}
 	// We only support colors in ANSI 8 or 256 color mode.
 	if t.Colors < 8 || t.SetFg == "" {
 		t.Colors = 0
 	}
-	if t.SetCursor == "" {
-		return nil, errors.New("terminal not cursor addressable")
-	}
+	// Terminals that are not cursor addressable (no cup) are drawn
+	// a line at a time.
 
 	// For padding, we lookup the pad char.  If that isn't present,
 	// and npc is *not* set, then we assume a null byte.
@@ -247,6 +281,7 @@ func getinfo(name string) (*tcell.Terminfo, error) {
 			t.PadChar = "\u0000"
 		}
 	}
+	t.XOnXOff = tigetflag("xon")
 
 	return t, nil
 }
@@ -258,6 +293,13 @@ func dotGoAddInt(w io.Writer, n string, i int) {
 	}
 	fmt.Fprintf(w, "		%-13s %d,\n", n+":", i)
 }
+func dotGoAddFlag(w io.Writer, n string, b bool) {
+	if !b {
+		return
+	}
+	fmt.Fprintf(w, "		%-13s true,\n", n+":")
+}
+
 func dotGoAddStr(w io.Writer, n string, s string) {
 	if s == "" {
 		return
@@ -321,11 +363,22 @@ func dotGoInfo(w io.Writer, t *tcell.Terminfo) {
 	dotGoAddStr(w, "SetFg", t.SetFg)
 	dotGoAddStr(w, "SetBg", t.SetBg)
 	dotGoAddStr(w, "PadChar", t.PadChar)
+	dotGoAddFlag(w, "XOnXOff", t.XOnXOff)
 	dotGoAddStr(w, "AltChars", t.AltChars)
 	dotGoAddStr(w, "EnterAcs", t.EnterAcs)
 	dotGoAddStr(w, "ExitAcs", t.ExitAcs)
 	dotGoAddStr(w, "Mouse", t.Mouse)
 	dotGoAddStr(w, "MouseMode", t.MouseMode)
+	dotGoAddStr(w, "UnderlineX", t.UnderlineX)
+	dotGoAddStr(w, "SetUlColor", t.SetUlColor)
+	dotGoAddStr(w, "SetFgRGB", t.SetFgRGB)
+	dotGoAddStr(w, "SetBgRGB", t.SetBgRGB)
+	dotGoAddStr(w, "EnablePaste", t.EnablePaste)
+	dotGoAddStr(w, "DisablePaste", t.DisablePaste)
+	dotGoAddStr(w, "PasteStart", t.PasteStart)
+	dotGoAddStr(w, "PasteEnd", t.PasteEnd)
+	dotGoAddStr(w, "InitColor", t.InitColor)
+	dotGoAddStr(w, "ResetColors", t.ResetColors)
 	dotGoAddStr(w, "SetCursor", t.SetCursor)
 	dotGoAddStr(w, "CursorBack1", t.CursorBack1)
 	dotGoAddStr(w, "CursorUp1", t.CursorUp1)
@@ -425,7 +478,6 @@ func main() {
 	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
 	flag.Parse()
 	var e error
-	js := []byte{}
 
 	args := flag.Args()
 	if len(args) == 0 {
@@ -489,17 +541,29 @@ func main() {
 		if jsonfile != "-" {
 			if w, e = os.Create(jsonfile); e != nil {
 				fmt.Fprintf(os.Stderr, "Failed: %v", e)
+				os.Exit(1)
 			}
 		}
+		// The objects are written one after another, rather than as
+		// an array, as that is how we load them.
+		var out io.Writer = w
+		var gz *gzip.Writer
+		if strings.HasSuffix(jsonfile, ".gz") {
+			gz = gzip.NewWriter(w)
+			out = gz
+		}
+		var tis []*tcell.Terminfo
 		for _, term := range args {
 			if t := tdata[term]; t != nil {
-				js, e = json.Marshal(t)
-				fmt.Fprintln(w, string(js))
+				tis = append(tis, t)
 			}
-			// arguably if there is more than one term, this
-			// should be a javascript array, but that's not how
-			// we load it.  We marshal objects one at a time from
-			// the file.
+		}
+		e = tcell.WriteTerminfo(out, tis...)
+		if gz != nil && e == nil {
+			e = gz.Close()
+		}
+		if w != os.Stdout {
+			w.Close()
 		}
 		if e != nil {
 			fmt.Fprintf(os.Stderr, "Failed: %v", e)
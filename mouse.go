@@ -31,15 +31,22 @@ import (
 // Most terminals cannot report the state of more than one button at a time --
 // and many cannot report motion events.  (Windows consoles, modern XTerm, and
 // modern emulators like iTerm2, are known to support this well, though.)
+// Terminals report one button per event, so tcell keeps track of the ones
+// that are held, and Buttons reports all of them, as on Windows.  Only the
+// SGR encoding says which button was released, though; with the others, a
+// release is taken to be that of every button.
 //
-// Applications can inspect the time between events to figure out double clicks
-// and such.
+// Double and triple clicks are identified for the application; see Clicks.
 type EventMouse struct {
-	t   time.Time
-	btn ButtonMask
-	mod ModMask
-	x   int
-	y   int
+	t      time.Time
+	btn    ButtonMask
+	mod    ModMask
+	x      int
+	y      int
+	px     int
+	py     int
+	pix    bool
+	clicks int
 }
 
 func (ev *EventMouse) When() time.Time {
@@ -63,12 +70,76 @@ func (ev *EventMouse) Position() (int, int) {
 	return ev.x, ev.y
 }
 
+// Clicks returns the number of successive clicks that this event
+// completes, if it reports the press of a mouse button.  It is 1 for a
+// single click, 2 for a double click, 3 for a triple click, and so forth.
+// Presses count as successive if they are of the same button, at the
+// same position, and no more than DoubleClickInterval apart.  For
+// other events (releases, motion, and wheel movement) it is 0.
+func (ev *EventMouse) Clicks() int {
+	return ev.clicks
+}
+
+// PixelPosition returns the mouse position in pixels, relative to the
+// upper left corner of the screen, if the terminal reported it.  (See
+// MousePixels.)  The last value is false if no pixel position is known.
+func (ev *EventMouse) PixelPosition() (int, int, bool) {
+	return ev.px, ev.py, ev.pix
+}
+
+// SetPixelPosition records the pixel position of the mouse event.  Like
+// NewEventMouse, this is intended for use by screen implementors.
+func (ev *EventMouse) SetPixelPosition(px, py int) {
+	ev.px = px
+	ev.py = py
+	ev.pix = true
+}
+
 // NewEventMouse is used to create a new mouse event.  Applications
 // shouldn't need to use this; its mostly for screen implementors.
 func NewEventMouse(x, y int, btn ButtonMask, mod ModMask) *EventMouse {
 	return &EventMouse{t: time.Now(), x: x, y: y, btn: btn, mod: mod}
 }
 
+// DoubleClickInterval is the longest time that may elapse between
+// successive presses of a mouse button for them to be counted as a
+// multiple click.  (See EventMouse.Clicks.)  Applications may change it,
+// but should do so before initializing the screen.
+var DoubleClickInterval = 500 * time.Millisecond
+
+// clickTracker counts successive clicks, so that every screen
+// implementation reports multiple clicks the same way.  Each mouse
+// event should be passed to track before it is posted.
+type clickTracker struct {
+	down   ButtonMask // buttons held down as of the last event
+	btn    ButtonMask // button of the last press
+	x      int
+	y      int
+	t      time.Time
+	clicks int
+}
+
+func (ct *clickTracker) track(ev *EventMouse) {
+	const buttons = Button1 | Button2 | Button3 | Button4 |
+		Button5 | Button6 | Button7 | Button8
+
+	pressed := ev.btn & buttons &^ ct.down
+	ct.down = ev.btn & buttons
+	if pressed == ButtonNone {
+		return
+	}
+	if pressed == ct.btn && ev.x == ct.x && ev.y == ct.y &&
+		ev.t.Sub(ct.t) <= DoubleClickInterval {
+		ct.clicks++
+	} else {
+		ct.clicks = 1
+	}
+	ct.btn = pressed
+	ct.x, ct.y = ev.x, ev.y
+	ct.t = ev.t
+	ev.clicks = ct.clicks
+}
+
 // BtnMask is a mask of mouse buttons.
 type ButtonMask int16
 
@@ -88,7 +159,23 @@ const (
 	WheelUp
 	// WheelDown indicates the wheel being moved down, towards the user.
 	WheelDown
+	// WheelLeft indicates the wheel being tilted (or a horizontal
+	// wheel moved) to the left.
 	WheelLeft
+	// WheelRight indicates the wheel being tilted (or a horizontal
+	// wheel moved) to the right.
 	WheelRight
 )
 const ButtonNone ButtonMask = 0
+
+// MouseFlags are options that can be passed to EnableMouse.
+type MouseFlags int
+
+const (
+	// MousePixels asks for mouse positions to be reported with pixel
+	// granularity, in addition to character cells.  This is only
+	// available on terminals that support the SGR-Pixels (1016)
+	// reporting mode and report their size in pixels.  Elsewhere it
+	// is ignored, and events will lack a pixel position.
+	MousePixels MouseFlags = 1 << iota
+)
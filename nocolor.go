@@ -0,0 +1,38 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"os"
+)
+
+// NoColorScreen is implemented by Screens that can draw without colors,
+// for users who cannot, or would rather not, see them.  Screens start
+// that way if $NO_COLOR is set to anything (see https://no-color.org),
+// so that applications need not check for it themselves.
+type NoColorScreen interface {
+	// SetNoColor turns colors off, or back on.  Without colors, cells
+	// are drawn in the default colors, but still bold, underlined,
+	// reversed and so on, as their styles ask; and Colors reports 0.
+	// The whole screen is redrawn by the next Show.
+	SetNoColor(on bool)
+
+	Screen
+}
+
+// noColorEnv reports whether $NO_COLOR asks that colors not be used.
+func noColorEnv() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// ScreenOptions configures a Screen from the program, for what is
+// otherwise left to the environment, or to the defaults.  (See
+// NewTerminfoScreenWithOptions and NewConsoleScreenWithOptions.)  The
+// zero value changes nothing.  Options that do not apply to a screen
+// are ignored.
+type ScreenOptions struct {
+	// Term is the terminal type, in place of $TERM.  Terminfo screens
+	// only.
+	Term string
+
+	// In and Out are the tty to use, in place of /dev/tty; both must be
+	// given, or neither.  They are not closed by Fini.  Terminfo
+	// screens only, and not on Windows.
+	In  *os.File
+	Out *os.File
+
+	// NoAltScreen keeps the terminal on its normal screen, rather than
+	// switching to its alternate screen, and leaves what was drawn
+	// there after Fini.  (The Windows console has no alternate screen,
+	// so it is emulated by saving the console buffer.)
+	NoAltScreen bool
+
+	// Mouse enables the mouse from Init on, with MouseFlags, as if by
+	// EnableMouse.
+	Mouse      bool
+	MouseFlags MouseFlags
+
+	// EscDelay is the escape delay (see InputParser.SetEscapeDelay),
+	// in place of the default or $TCELL_ESCDELAY.  Zero leaves it
+	// alone, and a negative value means no delay at all.  Not for the
+	// Windows console, which has no need of one.
+	EscDelay time.Duration
+
+	// EventQueue is the number of events that can wait for PollEvent
+	// before more are dropped.  Zero means 10.
+	EventQueue int
+
+	// NoColor draws without colors, as if $NO_COLOR were set (see
+	// NoColorScreen).
+	NoColor bool
+
+	// ReadBack makes GetCell return what is actually in the console
+	// buffer, rather than what the application last drew there.  This
+	// is for applications that share the console with other code that
+	// writes to it.  Windows console only.
+	ReadBack bool
+}
+
+// ErrScreenOptions is returned by the constructors that take
+// ScreenOptions if the options are not valid.
+var ErrScreenOptions = errors.New("invalid screen options")
+
+// check returns ErrScreenOptions if the options are not valid.
+func (o *ScreenOptions) check() error {
+	if (o.In == nil) != (o.Out == nil) || o.EventQueue < 0 {
+		return ErrScreenOptions
+	}
+	return nil
+}
+
+// queueLen returns the size of the event queue.
+func (o *ScreenOptions) queueLen() int {
+	if o.EventQueue > 0 {
+		return o.EventQueue
+	}
+	return 10
+}
+
+// setEscapeDelay applies the escape delay, if any, to the parser.
+func (o *ScreenOptions) setEscapeDelay(ip *InputParser) {
+	if o.EscDelay != 0 {
+		ip.SetEscapeDelay(o.EscDelay)
+	}
+}
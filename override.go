@@ -0,0 +1,90 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"os"
+	"strings"
+)
+
+// Terminal descriptions are sometimes wrong, or out of date, so users can
+// override some of the capabilities in the environment, with on or off
+// (or 1 or 0):
+//
+//	TCELL_TRUECOLOR  the terminal can set colors as RGB values
+//	TCELL_ALTSCREEN  the terminal has an alternate screen
+//	TCELL_MOUSE      the terminal reports the mouse, as xterm does
+//
+// Turning a capability on that the description lacks uses the usual
+// xterm sequences for it.
+
+// The sequences used for capabilities that are turned on.
+const (
+	xtermSetFgRGB  = "\x1b[38;2;%p1%d;%p2%d;%p3%dm"
+	xtermSetBgRGB  = "\x1b[48;2;%p1%d;%p2%d;%p3%dm"
+	xtermEnterCA   = "\x1b[?1049h"
+	xtermExitCA    = "\x1b[?1049l"
+	xtermMouse     = "\x1b[M"
+	xtermMouseMode = "%?%p1%{1}%=%t%'h'%Pa%e%'l'%Pa%;" +
+		"\x1b[?1000%ga%c\x1b[?1003%ga%c\x1b[?1006%ga%c"
+)
+
+// envSwitch returns the setting of an on or off environment variable,
+// and whether it is set at all.
+func envSwitch(name string) (on bool, set bool) {
+	switch strings.ToLower(os.Getenv(name)) {
+	case "on", "1", "yes", "true":
+		return true, true
+	case "off", "0", "no", "false":
+		return false, true
+	}
+	return false, false
+}
+
+// overrideTerminfo returns the terminal with the overrides given in the
+// environment, if any, applied to a copy of it.
+func overrideTerminfo(ti *Terminfo) *Terminfo {
+	up := *ti
+	changed := false
+	if on, set := envSwitch("TCELL_TRUECOLOR"); set {
+		changed = true
+		up.SetFgRGB, up.SetBgRGB = "", ""
+		if on {
+			up.SetFgRGB, up.SetBgRGB = xtermSetFgRGB, xtermSetBgRGB
+		}
+	}
+	if on, set := envSwitch("TCELL_ALTSCREEN"); set {
+		changed = true
+		switch {
+		case !on:
+			up.EnterCA, up.ExitCA = "", ""
+		case up.EnterCA == "":
+			up.EnterCA, up.ExitCA = xtermEnterCA, xtermExitCA
+		}
+	}
+	if on, set := envSwitch("TCELL_MOUSE"); set {
+		changed = true
+		switch {
+		case !on:
+			up.Mouse = ""
+		case up.Mouse == "":
+			up.Mouse, up.MouseMode = xtermMouse, xtermMouseMode
+		}
+	}
+	if !changed {
+		return ti
+	}
+	return &up
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoPalette is returned when the colors of the terminal cannot be
+// redefined.
+var ErrNoPalette = errors.New("terminal palette cannot be changed")
+
+// PaletteScreen is implemented by Screens that can redefine the colors of
+// the terminal's palette.  The terminfo based screen does so.  This lets
+// applications with themes show exact colors, even on terminals limited
+// to 256 (or fewer) colors.  The palette is restored by Fini.
+//
+// Terminals that describe how to change their colors (with the initc
+// capability) are changed that way.  Others are sent the xterm control
+// sequences (OSC 4 and OSC 104), which most modern terminals understand,
+// and which the rest ignore; so there is no telling whether a change
+// took effect.
+type PaletteScreen interface {
+	// SetPaletteColor redefines the palette color c (ColorBlack, or any
+	// of those after it) to have the given red, green and blue values.
+	// Cells already shown in that color are likely to change as well.
+	// It returns ErrNoPalette if the terminal has no colors, or if the
+	// color is not in its palette.
+	SetPaletteColor(c Color, r, g, b uint8) error
+
+	// ResetPalette restores the terminal's original palette.
+	ResetPalette()
+
+	Screen
+}
+
+// The xterm sequences to set a palette color, and to reset them all.
+const (
+	paletteSet   = "\x1b]4;%d;rgb:%02x/%02x/%02x\x1b\\"
+	paletteReset = "\x1b]104\x1b\\"
+)
+
+// paletteString returns the string to set the palette entry (which is
+// the color number less one, as for setaf) of the terminal.
+func paletteString(ti *Terminfo, index int, r, g, b uint8) string {
+	if ti.InitColor == "" {
+		return fmt.Sprintf(paletteSet, index, r, g, b)
+	}
+	// initc wants values from 0 to 1000, and scales them back to 255
+	// itself, rounding down; so round up, to get the same values back.
+	scale := func(v uint8) int {
+		return (int(v)*1000 + 254) / 255
+	}
+	return ti.TParm(ti.InitColor, index, scale(r), scale(g), scale(b))
+}
+
+// paletteResetString returns the string to restore the palette.
+func paletteResetString(ti *Terminfo) string {
+	if ti.ResetColors != "" {
+		return ti.ResetColors
+	}
+	return paletteReset
+}
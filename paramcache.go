@@ -0,0 +1,92 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// Cursor motion and color changes make up most of what we send to the
+// terminal, and each one runs a parameterized string through TParm.  The
+// parameters are nearly always small (a position on the screen, or a
+// color in the palette), so we remember the results in tables indexed
+// by them.
+
+// paletteCacheSize is the number of colors whose strings are cached.
+const paletteCacheSize = 256
+
+// paramCache remembers the results of evaluating one parameterized
+// string.  It starts over if the string, or the size of the table, is
+// different from what it was filled with.
+type paramCache struct {
+	s    string
+	vals []string
+}
+
+// tparm returns the result of ti.TParm(s, p...), which is cached at index
+// i of a table of the given size.  Indexes outside the table are not
+// cached.
+func (c *paramCache) tparm(ti *Terminfo, s string, i, size int, p ...int) string {
+	if i < 0 || i >= size {
+		return ti.TParm(s, p...)
+	}
+	if c.s != s || len(c.vals) != size {
+		c.s = s
+		c.vals = make([]string, size)
+	}
+	if v := c.vals[i]; v != "" {
+		return v
+	}
+	v := ti.TParm(s, p...)
+	c.vals[i] = v
+	return v
+}
+
+// tgoto returns the string that moves the cursor to the given physical
+// position.
+func (t *tScreen) tgoto(x, y int) string {
+	pw, ph := t.physSize()
+	i := -1
+	if x >= 0 && x < pw {
+		i = y*pw + x
+	}
+	return t.gotos.tparm(t.ti, t.ti.SetCursor, i, pw*ph, y, x)
+}
+
+// setFg returns the string that sets the foreground to the color.
+func (t *tScreen) setFg(c Color) string {
+	if c.IsRGB() {
+		return t.setRGB(t.ti.SetFgRGB, xtermSetFgRGB, c)
+	}
+	n := int(c) - 1
+	return t.fgs.tparm(t.ti, t.ti.SetFg, n, paletteCacheSize, n)
+}
+
+// setBg returns the string that sets the background to the color.
+func (t *tScreen) setBg(c Color) string {
+	if c.IsRGB() {
+		return t.setRGB(t.ti.SetBgRGB, xtermSetBgRGB, c)
+	}
+	n := int(c) - 1
+	return t.bgs.tparm(t.ti, t.ti.SetBg, n, paletteCacheSize, n)
+}
+
+// setRGB returns the string that sets an RGB color, with s, or with def
+// if the terminal description has no such string, as when only the answer
+// to our query told us that the terminal can show RGB colors.  These are
+// too many to cache.
+func (t *tScreen) setRGB(s, def string, c Color) string {
+	if s == "" {
+		s = def
+	}
+	r, g, b := c.RGB()
+	return t.ti.TParm(s, r, g, b)
+}
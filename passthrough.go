@@ -0,0 +1,84 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// Terminal multiplexers interpret the output of the programs running in
+// them, and drop the sequences that they do not understand themselves,
+// such as OSC 52 (clipboard), DECSCUSR (cursor shape), and sixel images.
+// Both tmux and GNU screen have a DCS "passthrough" envelope, whose
+// contents are handed to the outer terminal as they are.  (tmux 3.3 and
+// later only honor it with "set -g allow-passthrough on".)
+
+type multiplexer int
+
+const (
+	muxNone multiplexer = iota
+	muxTmux
+	muxScreen
+)
+
+// GNU screen limits the length of a DCS string, so longer passthrough
+// output is sent as several envelopes.
+const screenPassthroughMax = 768
+
+// detectMultiplexer determines, from the environment and the terminal
+// type, the multiplexer (if any) that the program is running in.
+// Setting $TCELL_PASSTHROUGH to 0 disables passthrough.
+func detectMultiplexer(term string) multiplexer {
+	switch {
+	case os.Getenv("TCELL_PASSTHROUGH") == "0":
+		return muxNone
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "tmux"):
+		return muxTmux
+	case os.Getenv("STY") != "" || strings.HasPrefix(term, "screen"):
+		return muxScreen
+	}
+	return muxNone
+}
+
+// wrapPassthrough returns b wrapped in the passthrough envelope of the
+// multiplexer.
+func wrapPassthrough(mux multiplexer, b []byte) []byte {
+	var out bytes.Buffer
+	switch mux {
+	case muxTmux:
+		// ESC within the envelope is doubled
+		out.WriteString("\x1bPtmux;")
+		out.Write(bytes.Replace(b, []byte{'\x1b'}, []byte{'\x1b', '\x1b'}, -1))
+		out.WriteString("\x1b\\")
+	case muxScreen:
+		// the envelope cannot contain ST, so sequences within it
+		// should end with BEL instead
+		for len(b) > 0 {
+			n := len(b)
+			if n > screenPassthroughMax {
+				n = screenPassthroughMax
+			}
+			out.WriteString("\x1bP")
+			out.Write(b[:n])
+			out.WriteString("\x1b\\")
+			b = b[n:]
+		}
+	default:
+		out.Write(b)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,226 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Playback is a sequence of input events, each with the time at which it
+// was received, relative to the start of the sequence.  It can be played
+// back into a Screen with NewPlaybackScreen, for end-to-end regression
+// tests of applications.
+type Playback struct {
+	evs []playbackEvent
+}
+
+type playbackEvent struct {
+	at time.Duration
+	ev Event
+}
+
+// NewPlayback reads a recording in asciicast v2 format, such as made by a
+// Recorder, and returns the input events that it contains.  The recorded
+// input is decoded using the terminal type given by the TERM variable in
+// the "env" of the header, or xterm if there is none.  Recorded output is
+// ignored.
+func NewPlayback(r io.Reader) (*Playback, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		if e := scanner.Err(); e != nil {
+			return nil, e
+		}
+		return nil, errors.New("empty recording")
+	}
+	var hdr struct {
+		Version int
+		Width   int
+		Height  int
+		Env     map[string]string
+	}
+	if e := json.Unmarshal(scanner.Bytes(), &hdr); e != nil {
+		return nil, e
+	}
+	if hdr.Version != 2 {
+		return nil, fmt.Errorf("unsupported asciicast version %d", hdr.Version)
+	}
+	term := hdr.Env["TERM"]
+	if term == "" {
+		term = "xterm"
+	}
+	ti, e := LookupTerminfo(term)
+	if e != nil {
+		return nil, e
+	}
+	p := &Playback{}
+	ip := newInputParser(ti)
+	ip.SetSize(hdr.Width, hdr.Height)
+
+	for line := 2; scanner.Scan(); line++ {
+		var rec [3]interface{}
+		if e := json.Unmarshal(scanner.Bytes(), &rec); e != nil {
+			return nil, fmt.Errorf("line %d: %v", line, e)
+		}
+		secs, ok1 := rec[0].(float64)
+		kind, ok2 := rec[1].(string)
+		data, ok3 := rec[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return nil, fmt.Errorf("line %d: malformed event", line)
+		}
+		at := time.Duration(secs * float64(time.Second))
+		switch kind {
+		case "i":
+			ip.Feed([]byte(data))
+			ip.Expire()
+			for _, ev := range ip.Events() {
+				p.Add(at, ev)
+			}
+		case "r":
+			var w, h int
+			if _, e := fmt.Sscanf(data, "%dx%d", &w, &h); e != nil {
+				return nil, fmt.Errorf("line %d: bad size %q", line, data)
+			}
+			ip.SetSize(w, h)
+			p.Add(at, NewEventResize(w, h))
+		}
+	}
+	if e := scanner.Err(); e != nil {
+		return nil, e
+	}
+	return p, nil
+}
+
+// Add appends an event to the playback, to be delivered at the given
+// time after the start.  This can be used to build a Playback by hand.
+// Events must be added in order.
+func (p *Playback) Add(at time.Duration, ev Event) {
+	p.evs = append(p.evs, playbackEvent{at: at, ev: ev})
+}
+
+// Events returns the events of the playback, in order.
+func (p *Playback) Events() []Event {
+	evs := make([]Event, 0, len(p.evs))
+	for _, pe := range p.evs {
+		evs = append(evs, pe.ev)
+	}
+	return evs
+}
+
+// PlaybackScreen is a Screen that delivers the events of a Playback
+// from PollEvent, in addition to the events of the Screen that it wraps.
+type PlaybackScreen interface {
+	// Done returns a channel that is closed once all of the events
+	// have been delivered.
+	Done() <-chan struct{}
+
+	Screen
+}
+
+// NewPlaybackScreen wraps the Screen, so that once initialized, it plays
+// back the events of the Playback.  With a speed of 1, events are
+// delivered with the same timing as they were recorded; a speed of 2
+// plays back twice as fast, and so on.  A speed of 0 delivers each event
+// as soon as the application polls for it, which keeps tests fast and
+// deterministic.  Either way, no events are lost, even if the
+// application falls behind.
+//
+// If the Screen is a SimulationScreen, resize events also change the size
+// of its physical screen, so that the application sees the new size.
+func NewPlaybackScreen(s Screen, p *Playback, speed float64) PlaybackScreen {
+	return &playscreen{Screen: s, p: p, speed: speed}
+}
+
+type playscreen struct {
+	Screen
+	p      *Playback
+	speed  float64
+	evq    chan Event
+	filter eventFilter
+	quit   chan struct{}
+	done   chan struct{}
+}
+
+func (ps *playscreen) Init() error {
+	if e := ps.Screen.Init(); e != nil {
+		return e
+	}
+	ps.evq = make(chan Event)
+	ps.quit = make(chan struct{})
+	ps.done = make(chan struct{})
+	go ps.pump()
+	go ps.play()
+	return nil
+}
+
+// pump passes on the events of the wrapped Screen.
+func (ps *playscreen) pump() {
+	for {
+		ev := ps.Screen.PollEvent()
+		if ev == nil {
+			close(ps.quit)
+			return
+		}
+		ps.evq <- ev
+	}
+}
+
+func (ps *playscreen) play() {
+	defer close(ps.done)
+	start := time.Now()
+	for _, pe := range ps.p.evs {
+		if ps.speed > 0 {
+			at := time.Duration(float64(pe.at) / ps.speed)
+			time.Sleep(at - time.Since(start))
+		}
+		if ev, ok := pe.ev.(*EventResize); ok {
+			if sim, ok := ps.Screen.(SimulationScreen); ok {
+				sim.Resize(ev.Size())
+			}
+		}
+		select {
+		case ps.evq <- pe.ev:
+		case <-ps.quit:
+			return
+		}
+	}
+}
+
+func (ps *playscreen) PollEvent() Event {
+	return ps.filter.poll(ps.quit, ps.evq)
+}
+
+// SetEventFilter filters the events of the playback as well as those
+// of the wrapped Screen.
+func (ps *playscreen) SetEventFilter(f EventFilter) {
+	ps.filter.set(f)
+}
+
+func (ps *playscreen) SetIdleTimeout(d time.Duration) {
+	ps.filter.setIdle(d)
+}
+
+func (ps *playscreen) Contents() *Contents {
+	return screenContents(ps.Screen)
+}
+
+func (ps *playscreen) Done() <-chan struct{} {
+	return ps.done
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testCast = `{"version": 2, "width": 80, "height": 24, "env": {"TERM": "xterm"}}
+[0.1, "o", "\u001b[2J"]
+[0.2, "i", "a\u001bOA"]
+[0.3, "r", "100x30"]
+[0.4, "i", "\u001b[<0;10;5M"]
+`
+
+func TestPlayback(t *testing.T) {
+	Convey("Input playback", t, func() {
+		p, e := NewPlayback(strings.NewReader(testCast))
+		So(e, ShouldBeNil)
+		evs := p.Events()
+		So(len(evs), ShouldEqual, 4)
+		So(evs[0].(*EventKey).Rune(), ShouldEqual, 'a')
+		So(evs[1].(*EventKey).Key(), ShouldEqual, KeyUp)
+		w, h := evs[2].(*EventResize).Size()
+		So(w, ShouldEqual, 100)
+		So(h, ShouldEqual, 30)
+		x, y := evs[3].(*EventMouse).Position()
+		So(x, ShouldEqual, 9)
+		So(y, ShouldEqual, 4)
+
+		Convey("Bad recordings are refused", func() {
+			_, e := NewPlayback(strings.NewReader(""))
+			So(e, ShouldNotBeNil)
+			_, e = NewPlayback(strings.NewReader(`{"version": 1}`))
+			So(e, ShouldNotBeNil)
+			_, e = NewPlayback(strings.NewReader(
+				"{\"version\": 2}\n[0.1, \"i\"]\n"))
+			So(e, ShouldNotBeNil)
+		})
+
+		Convey("Played into a screen", func() {
+			sim := NewSimulationScreen("")
+			s := NewPlaybackScreen(sim, p, 0)
+			So(s.Init(), ShouldBeNil)
+			for i := range evs {
+				ev := s.PollEvent()
+				So(ev, ShouldEqual, evs[i])
+			}
+			<-s.Done()
+			_, pw, ph := sim.GetContents()
+			So(pw, ShouldEqual, 100)
+			So(ph, ShouldEqual, 30)
+
+			// events posted to the screen still arrive
+			ev := NewEventInterrupt(nil)
+			s.PostEvent(ev)
+			So(s.PollEvent(), ShouldEqual, ev)
+
+			s.Fini()
+			So(s.PollEvent(), ShouldBeNil)
+		})
+
+		Convey("Played with timing", func() {
+			p := &Playback{}
+			p.Add(40*time.Millisecond, NewEventKey(KeyEnter, 0, ModNone))
+			s := NewPlaybackScreen(NewSimulationScreen(""), p, 2)
+			So(s.Init(), ShouldBeNil)
+			start := time.Now()
+			So(s.PollEvent().(*EventKey).Key(), ShouldEqual, KeyEnter)
+			So(time.Since(start), ShouldBeGreaterThan, 15*time.Millisecond)
+			s.Fini()
+		})
+	})
+
+	Convey("Recorded input plays back", t, func() {
+		out := &bytes.Buffer{}
+		r := NewRecorder(out, RecordAsciicast)
+		r.WriteString("hello")
+		r.Input([]byte("q"))
+		So(r.Flush(), ShouldBeNil)
+		p, e := NewPlayback(out)
+		So(e, ShouldBeNil)
+		evs := p.Events()
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].(*EventKey).Rune(), ShouldEqual, 'q')
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// These tests exercise the concurrency contract documented on Screen.
+// They are most useful when run with the race detector (go test -race),
+// which reports any unsynchronized access that they provoke.
+
+// hammer runs fn concurrently in n goroutines, each calling it count
+// times, and waits for them all to finish.
+func hammer(n, count int, fn func(g, i int)) {
+	var wg sync.WaitGroup
+	for g := 0; g < n; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				fn(g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestSimScreenConcurrency(t *testing.T) {
+	Convey("Simulation screen used concurrently", t, func() {
+		s := NewSimulationScreen("")
+		So(s.Init(), ShouldBeNil)
+		s.(BlinkScreen).EnableBlink(time.Millisecond)
+
+		polled := make(chan int)
+		go func() {
+			n := 0
+			for s.PollEvent() != nil {
+				n++
+			}
+			polled <- n
+		}()
+
+		hammer(4, 200, func(g, i int) {
+			s.SetCell(i%80, g, StyleDefault.Blink(i%2 == 0), 'x')
+			s.PutCell(i%80, g+4, &Cell{Ch: []rune{'y'}})
+			s.GetCell(i%80, g)
+			s.ShowCursor(i%80, g)
+			s.Show()
+			s.Size()
+			s.SetStyle(StyleDefault)
+			s.EnableMouse()
+			s.DisableMouse()
+			s.InjectKey(KeyRune, 'a', ModNone)
+			s.InjectMouse(1, 1, Button1, ModNone)
+			s.GetContents()
+			s.GetCursor()
+			if i%50 == 0 {
+				s.Resize(80+g, 25)
+				s.Sync()
+				s.Clear()
+			}
+		})
+
+		Convey("Fini releases PollEvent", func() {
+			s.Fini()
+			select {
+			case <-polled:
+			case <-time.After(time.Second):
+				So("PollEvent still blocked", ShouldBeNil)
+			}
+			So(s.PollEvent(), ShouldBeNil)
+
+			Convey("And can be called again", func() {
+				s.Fini()
+				s.SetCell(0, 0, StyleDefault, 'x')
+				s.Show()
+				So(s.GetCell(0, 0), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Fini concurrent with drawing", t, func() {
+		s := NewSimulationScreen("")
+		So(s.Init(), ShouldBeNil)
+		hammer(4, 100, func(g, i int) {
+			if g == 0 && i == 50 {
+				s.Fini()
+			}
+			s.SetCell(i%80, g, StyleDefault, 'x')
+			s.Show()
+			s.Sync()
+		})
+		So(s.PollEvent(), ShouldBeNil)
+	})
+}
+
+func TestTScreenConcurrency(t *testing.T) {
+	Convey("Terminal screen used concurrently", t, func() {
+		ts := newTestTScreen("xterm")
+		f, e := ioutil.TempFile("", "tcell")
+		So(e, ShouldBeNil)
+		Reset(func() {
+			f.Close()
+			os.Remove(f.Name())
+		})
+		ts.out = f
+		ts.evch = make(chan Event, 10)
+		ts.input.postfn = ts.PostEvent
+		ts.cells.resize(ts.w, ts.h)
+		ts.mouse = []byte(ts.ti.Mouse)
+		ts.Lock()
+		ts.enableBlink(time.Millisecond)
+		ts.Unlock()
+
+		// events are dropped rather than blocking, so nobody
+		// needs to poll
+		hammer(4, 100, func(g, i int) {
+			switch g {
+			case 0:
+				// the input loop
+				ts.scanInput([]byte("\x1b[<0;10;5M\x1b[1;5C"), false)
+				ts.scanInput(nil, true)
+			case 1:
+				if i%20 == 0 {
+					ts.SetTransform(Transform(i / 20 % 2))
+					ts.Sync()
+				}
+			}
+			ts.SetCell(i%20, g, StyleDefault.Blink(true), 'x')
+			ts.GetCell(i%20, g)
+			ts.ShowCursor(i%20, g)
+			ts.Show()
+			ts.Size()
+			ts.Colors()
+			ts.EnableMouse(MousePixels)
+			ts.DisableMouse()
+		})
+		ts.DisableBlink()
+	})
+}
+
+func TestTScreenSlowTerminal(t *testing.T) {
+	Convey("A terminal that stops reading", t, func() {
+		ts := newTestTScreen("xterm")
+		r, w, e := os.Pipe()
+		So(e, ShouldBeNil)
+		Reset(func() {
+			r.Close()
+			w.Close()
+		})
+		ts.out = w
+		ts.evch = make(chan Event, 10)
+		ts.cells.resize(ts.w, ts.h)
+
+		// Redraw until the pipe fills up, and the writer is stuck.
+		drawn := make(chan struct{})
+		go func() {
+			for i := 0; i < 100; i++ {
+				ts.SetCell(0, 0, StyleDefault, 'x')
+				ts.Sync()
+			}
+			close(drawn)
+		}()
+		time.Sleep(100 * time.Millisecond)
+
+		Convey("Does not block the other goroutines", func() {
+			done := make(chan struct{})
+			go func() {
+				ts.SetCell(1, 1, StyleDefault, 'y')
+				ts.GetCell(1, 1)
+				ts.Show()
+				ts.PostEvent(NewEventInterrupt(nil))
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				So("blocked by the terminal", ShouldBeNil)
+			}
+
+			// Now let the writer finish, including our output.
+			go io.Copy(ioutil.Discard, r)
+			<-drawn
+			ts.Lock()
+			So(ts.writing, ShouldBeFalse)
+			So(ts.obuf.Len(), ShouldEqual, 0)
+			ts.Unlock()
+		})
+	})
+}
@@ -0,0 +1,334 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordFormat selects the file format written by a Recorder.
+type RecordFormat int
+
+const (
+	// RecordAsciicast is the asciicast v2 format used by asciinema.
+	// Screen size changes are recorded as well.
+	RecordAsciicast RecordFormat = iota
+
+	// RecordTtyrec is the format used by ttyrec and ttyplay.  It has no
+	// way to record the screen size.
+	RecordTtyrec
+)
+
+// Recorder records terminal output, with timestamps, so that a session
+// can be played back later with standard tools (asciinema play, ttyplay),
+// for demos or bug reports.  Terminal input can be recorded as well, for
+// use with NewPlayback.  Output is collected by Write, and becomes a
+// single timestamped frame when Flush is called.  Screens that support
+// recording flush after each update of the display.  (See TtyScreen.)
+//
+// Sensitive output, such as what is typed at a password prompt, can be
+// masked in the recording with SetRedact.
+//
+// Errors from the underlying writer stop the recording; the first one is
+// returned by Err.
+type Recorder struct {
+	w      io.Writer
+	format RecordFormat
+	start  time.Time
+	header bool
+	width  int
+	height int
+	buf    bytes.Buffer
+	err    error
+
+	// redaction, which follows the cursor and the style through the
+	// output to know which cell each character lands in
+	redact func(x, y int, style Style) bool
+	vt     vtParser
+	x      int
+	y      int
+	style  Style
+	savex  int
+	savey  int
+	savest Style
+	masked bool
+
+	sync.Mutex
+}
+
+// NewRecorder returns a Recorder that writes a recording in the given
+// format to w.  The recording starts now.
+func NewRecorder(w io.Writer, format RecordFormat) *Recorder {
+	return &Recorder{w: w, format: format, start: time.Now(),
+		width: 80, height: 24}
+}
+
+// Write adds output to the current frame.  It never fails; see Err.
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.Lock()
+	r.capture(b)
+	r.Unlock()
+	return len(b), nil
+}
+
+// WriteString is like Write, but takes a string.
+func (r *Recorder) WriteString(s string) (int, error) {
+	r.Lock()
+	r.capture([]byte(s))
+	r.Unlock()
+	return len(s), nil
+}
+
+// SetRedact sets the function that decides which characters are masked
+// in the recording, or stops masking them if fn is nil.  As output is
+// written, fn is called with the position (column and row) and the style
+// of each character that the output puts on the terminal, and if it
+// returns true, the character is recorded as asterisks, one for each
+// column that it takes.  Positions and styles are those that the terminal
+// is given: an application that wants to mask its password prompt masks
+// the cells that the prompt's input is drawn in.  The function must not
+// use the Recorder.
+//
+// Screens that record redraw the whole screen when recording starts, so
+// this is best called before SetRecorder.
+func (r *Recorder) SetRedact(fn func(x, y int, style Style) bool) {
+	r.Lock()
+	defer r.Unlock()
+	if fn == nil && len(r.vt.pend) > 0 {
+		r.buf.Write(r.vt.pend)
+		r.vt.pend = nil
+	}
+	r.redact = fn
+	r.vt.raw = func(b []byte) { r.buf.Write(b) }
+}
+
+// Flush writes out the output collected since the last Flush, if any,
+// as a single frame stamped with the current time.
+func (r *Recorder) Flush() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.buf.Len() > 0 {
+		r.event("o", r.buf.Bytes())
+		r.buf.Reset()
+	}
+	return r.err
+}
+
+// Resize records a change of the screen size.  Output that is pending
+// is flushed first.  The size given before anything has been recorded
+// becomes the initial size of the recording.
+func (r *Recorder) Resize(width, height int) {
+	r.Lock()
+	defer r.Unlock()
+	if !r.header {
+		r.width, r.height = width, height
+		return
+	}
+	if r.buf.Len() > 0 {
+		r.event("o", r.buf.Bytes())
+		r.buf.Reset()
+	}
+	if width != r.width || height != r.height {
+		r.width, r.height = width, height
+		r.event("r", []byte(fmt.Sprintf("%dx%d", width, height)))
+	}
+}
+
+// Input records input that was received from the terminal, so that it
+// can be played back later.  (See NewPlayback.)  Output that is pending
+// is flushed first.  Only the asciicast format can record input.
+func (r *Recorder) Input(b []byte) {
+	r.Lock()
+	defer r.Unlock()
+	if r.buf.Len() > 0 {
+		r.event("o", r.buf.Bytes())
+		r.buf.Reset()
+	}
+	r.event("i", b)
+}
+
+// Err returns the first error encountered when writing the recording.
+func (r *Recorder) Err() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.err
+}
+
+// capture adds output to the current frame, masking the characters that
+// are to be redacted.
+func (r *Recorder) capture(b []byte) {
+	if r.redact == nil {
+		r.buf.Write(b)
+		return
+	}
+	r.vt.parse(b, r)
+}
+
+func (r *Recorder) putRune(c rune) {
+	switch c {
+	case '\r':
+		r.x = 0
+	case '\n':
+		r.linefeed()
+	case '\b':
+		if r.x > 0 {
+			r.x--
+		}
+	case '\t':
+		r.x = clampInt((r.x+8)&^7, 0, r.width-1)
+	}
+	if c < ' ' || c == 0x7f {
+		r.buf.WriteRune(c)
+		return
+	}
+	w := runeWidth(c)
+	if w == 0 {
+		// combining marks go with the character before them
+		if !r.masked {
+			r.buf.WriteRune(c)
+		}
+		return
+	}
+	if r.x+w > r.width {
+		r.x = 0
+		r.linefeed()
+	}
+	r.masked = r.redact(r.x, r.y, r.style)
+	if r.masked {
+		r.buf.WriteString(strings.Repeat("*", w))
+	} else {
+		r.buf.WriteRune(c)
+	}
+	r.x += w
+}
+
+// linefeed moves the cursor down a row, unless it is on the last row,
+// where the terminal scrolls instead.
+func (r *Recorder) linefeed() {
+	if r.y < r.height-1 {
+		r.y++
+	}
+}
+
+func (r *Recorder) escape(inter string, final byte) {
+	if inter != "" {
+		return
+	}
+	switch final {
+	case '7':
+		r.savex, r.savey, r.savest = r.x, r.y, r.style
+	case '8':
+		r.x, r.y, r.style = r.savex, r.savey, r.savest
+	case 'D':
+		r.linefeed()
+	case 'E':
+		r.x = 0
+		r.linefeed()
+	case 'M':
+		if r.y > 0 {
+			r.y--
+		}
+	case 'c':
+		r.x, r.y, r.style = 0, 0, StyleDefault
+	}
+}
+
+func (r *Recorder) csi(final byte, s string) {
+	if len(s) > 0 && (s[0] < '0' || s[0] > ';') {
+		// private sequences do not move the cursor
+		return
+	}
+	params := csiParams(s)
+	// arg returns the i'th parameter, or def if it is missing or zero
+	arg := func(i, def int) int {
+		if i < len(params) && params[i] != 0 {
+			return params[i]
+		}
+		return def
+	}
+	switch final {
+	case 'A':
+		r.y = clampInt(r.y-arg(0, 1), 0, r.height-1)
+	case 'B', 'e':
+		r.y = clampInt(r.y+arg(0, 1), 0, r.height-1)
+	case 'C', 'a':
+		r.x = clampInt(r.x+arg(0, 1), 0, r.width-1)
+	case 'D':
+		r.x = clampInt(r.x-arg(0, 1), 0, r.width-1)
+	case 'E':
+		r.x = 0
+		r.y = clampInt(r.y+arg(0, 1), 0, r.height-1)
+	case 'F':
+		r.x = 0
+		r.y = clampInt(r.y-arg(0, 1), 0, r.height-1)
+	case 'G', '`':
+		r.x = clampInt(arg(0, 1)-1, 0, r.width-1)
+	case 'd':
+		r.y = clampInt(arg(0, 1)-1, 0, r.height-1)
+	case 'H', 'f':
+		r.y = clampInt(arg(0, 1)-1, 0, r.height-1)
+		r.x = clampInt(arg(1, 1)-1, 0, r.width-1)
+	case 'm':
+		r.style = sgrStyle(r.style, StyleDefault, params)
+	case 's':
+		r.savex, r.savey = r.x, r.y
+	case 'u':
+		r.x, r.y = r.savex, r.savey
+	}
+}
+
+func (r *Recorder) osc(string) {}
+
+func (r *Recorder) event(kind string, data []byte) {
+	if r.err != nil {
+		return
+	}
+	now := time.Now()
+	switch r.format {
+	case RecordAsciicast:
+		if !r.header {
+			r.header = true
+			_, r.err = fmt.Fprintf(r.w,
+				"{\"version\": 2, \"width\": %d, \"height\": %d, \"timestamp\": %d}\n",
+				r.width, r.height, r.start.Unix())
+			if r.err != nil {
+				return
+			}
+		}
+		str, _ := json.Marshal(string(data))
+		_, r.err = fmt.Fprintf(r.w, "[%.6f, %q, %s]\n",
+			now.Sub(r.start).Seconds(), kind, str)
+
+	case RecordTtyrec:
+		r.header = true
+		if kind != "o" {
+			return
+		}
+		var hdr [12]byte
+		binary.LittleEndian.PutUint32(hdr[0:], uint32(now.Unix()))
+		binary.LittleEndian.PutUint32(hdr[4:], uint32(now.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(hdr[8:], uint32(len(data)))
+		if _, r.err = r.w.Write(hdr[:]); r.err == nil {
+			_, r.err = r.w.Write(data)
+		}
+	}
+}
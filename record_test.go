@@ -0,0 +1,120 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("Session recording", t, func() {
+		out := &bytes.Buffer{}
+
+		Convey("Asciicast", func() {
+			r := NewRecorder(out, RecordAsciicast)
+			r.Resize(40, 10)
+			r.Write([]byte("hello"))
+			r.WriteString("\x1b[m")
+			So(r.Flush(), ShouldBeNil)
+			r.Resize(50, 12)
+			So(r.Flush(), ShouldBeNil)
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			So(len(lines), ShouldEqual, 3)
+			var hdr map[string]int
+			So(json.Unmarshal([]byte(lines[0]), &hdr), ShouldBeNil)
+			So(hdr["version"], ShouldEqual, 2)
+			So(hdr["width"], ShouldEqual, 40)
+			So(hdr["height"], ShouldEqual, 10)
+
+			var ev []interface{}
+			So(json.Unmarshal([]byte(lines[1]), &ev), ShouldBeNil)
+			So(len(ev), ShouldEqual, 3)
+			So(ev[1], ShouldEqual, "o")
+			So(ev[2], ShouldEqual, "hello\x1b[m")
+			So(json.Unmarshal([]byte(lines[2]), &ev), ShouldBeNil)
+			So(ev[1], ShouldEqual, "r")
+			So(ev[2], ShouldEqual, "50x12")
+		})
+
+		Convey("Ttyrec", func() {
+			r := NewRecorder(out, RecordTtyrec)
+			r.WriteString("one")
+			r.Flush()
+			r.Flush()
+			r.WriteString("three")
+			r.Flush()
+
+			b := out.Bytes()
+			So(len(b), ShouldEqual, 12+3+12+5)
+			So(binary.LittleEndian.Uint32(b[8:]), ShouldEqual, 3)
+			So(string(b[12:15]), ShouldEqual, "one")
+			So(binary.LittleEndian.Uint32(b[23:]), ShouldEqual, 5)
+			So(string(b[27:]), ShouldEqual, "three")
+		})
+
+		Convey("Redaction", func() {
+			r := NewRecorder(out, RecordTtyrec)
+			r.SetRedact(func(x, y int, style Style) bool {
+				_, _, attrs := style.Decompose()
+				return y == 2 && x >= 10 || attrs&AttrReverse != 0
+			})
+			r.WriteString("\x1b[3;5Hpass: secret\x1b[")
+			r.WriteString("1;1H\x1b[7mhi\x1b[m世")
+			r.WriteString("\x1b[3;11H世e\u0301!")
+			r.Flush()
+			So(string(out.Bytes()[12:]), ShouldEqual,
+				"\x1b[3;5Hpass: ******\x1b[1;1H\x1b[7m**\x1b[m世"+
+					"\x1b[3;11H****")
+		})
+
+		Convey("Terminal output is recorded", func() {
+			ts := newTestTScreen("xterm")
+			ts.cells.resize(ts.w, ts.h)
+			ts.curstyle = styleInvalid
+			r := NewRecorder(out, RecordAsciicast)
+			ts.SetRecorder(r)
+			ts.SetCell(2, 1, StyleDefault, 'Z')
+			ts.draw()
+			So(r.Err(), ShouldBeNil)
+			So(out.String(), ShouldStartWith, "{\"version\": 2, \"width\": 80, \"height\": 24")
+			// the whole screen is redrawn, from a cleared screen
+			So(out.String(), ShouldContainSubstring, "\\u001b[2J")
+			So(out.String(), ShouldContainSubstring, "\\u001b[2;1H  Z")
+		})
+
+		Convey("Terminal output is redacted", func() {
+			ts := newTestTScreen("xterm")
+			ts.cells.resize(ts.w, ts.h)
+			ts.curstyle = styleInvalid
+			r := NewRecorder(out, RecordAsciicast)
+			r.SetRedact(func(x, y int, style Style) bool {
+				return style == StyleDefault.Foreground(ColorRed)
+			})
+			ts.SetRecorder(r)
+			ts.SetCell(2, 1, StyleDefault, 'Z')
+			ts.SetCell(3, 1, StyleDefault.Foreground(ColorRed), 'x')
+			ts.draw()
+			So(r.Err(), ShouldBeNil)
+			So(out.String(), ShouldContainSubstring, "\\u001b[2;1H  Z\\u001b[31m*\\u001b[39m ")
+		})
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"time"
+)
+
+// EventRedraw asks the application to paint a region of the screen.
+// It is only sent to applications that ask for it.  (See RedrawScreen.)
+type EventRedraw struct {
+	t time.Time
+	x int
+	y int
+	w int
+	h int
+}
+
+// NewEventRedraw creates an EventRedraw for the given region.
+func NewEventRedraw(x, y, width, height int) *EventRedraw {
+	return &EventRedraw{t: time.Now(), x: x, y: y, w: width, h: height}
+}
+
+func (ev *EventRedraw) When() time.Time {
+	return ev.t
+}
+
+// Region returns the origin and size of the region to paint.
+func (ev *EventRedraw) Region() (x, y, width, height int) {
+	return ev.x, ev.y, ev.w, ev.h
+}
+
+// RedrawScreen is implemented by Screens that can tell the application
+// which parts of the screen need painting, for applications (such as
+// retained mode toolkits) that would rather not repaint everything on
+// every resize.  All of the screens in this package do so.
+//
+// When redraw events are on, an EventRedraw is posted for each region
+// whose contents are not known to the screen: the newly exposed strips
+// after the screen grows (each EventResize is followed by these), and
+// the whole screen after a Sync or Reinitialize, since those are used
+// when the display may have been disturbed.  The contents of the rest
+// of the screen are kept, and so do not need painting.  Call Show once
+// the regions have been painted.
+type RedrawScreen interface {
+	// EnableRedrawEvents turns redraw events on or off.  Turning them
+	// on posts an EventRedraw for the whole screen, so that the first
+	// frame can be painted the same way as the others.
+	EnableRedrawEvents(on bool)
+
+	Screen
+}
+
+// redrawer posts EventRedraw events for a screen, if they are on.
+type redrawer struct {
+	on bool
+}
+
+// exposed posts the regions exposed when the screen is resized from
+// ow by oh cells to w by h cells.  Cells outside of the old screen
+// hold nothing; those inside it are kept by ResizeCells.
+func (r *redrawer) exposed(post func(Event), ow, oh, w, h int) {
+	if !r.on {
+		return
+	}
+	if w > ow {
+		post(NewEventRedraw(ow, 0, w-ow, h))
+	}
+	if h > oh {
+		if ow > w {
+			ow = w
+		}
+		if ow > 0 {
+			post(NewEventRedraw(0, oh, ow, h-oh))
+		}
+	}
+}
+
+// all posts the whole screen.
+func (r *redrawer) all(post func(Event), w, h int) {
+	if r.on && w > 0 && h > 0 {
+		post(NewEventRedraw(0, 0, w, h))
+	}
+}
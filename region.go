@@ -0,0 +1,70 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// RegionScreen is implemented by screens that can store a rectangle of
+// cells while taking their lock only once.
+type RegionScreen interface {
+	// PutCells stores the cells of the rectangle with the given origin
+	// and dimensions, which are in row order, as PutCell would store
+	// each of them.  Cells that fall outside the screen are ignored.
+	PutCells(x, y, width, height int, cells []Cell)
+
+	Screen
+}
+
+// Region is a CellBuffer for drawing into a rectangular area of a Screen.
+// Cells that are set in it are kept in the Region, without taking the
+// Screen's lock, until Flush stores them on the Screen all at once.  So
+// goroutines that each draw into their own Region, such as the panels of
+// a dashboard, do not contend for the lock cell by cell, but only once
+// per Flush (on screens that implement RegionScreen).
+//
+// Like a CellBuffer, a Region is not safe for concurrent use.  Regions
+// may overlap, in which case the last Flush wins.
+type Region struct {
+	CellBuffer
+	s Screen
+	x int
+	y int
+}
+
+// NewRegion returns a Region for the area of the Screen with the given
+// origin and dimensions.  The Region starts out filled with spaces in the
+// default style; nothing is stored on the Screen until Flush.
+func NewRegion(s Screen, x, y, width, height int) *Region {
+	r := &Region{s: s, x: x, y: y}
+	r.Resize(width, height)
+	return r
+}
+
+// Origin returns the location of the upper left corner of the Region on
+// the Screen.
+func (r *Region) Origin() (int, int) {
+	return r.x, r.y
+}
+
+// Move changes the location of the Region on the Screen.  The cells are
+// all marked dirty.
+func (r *Region) Move(x, y int) {
+	r.x, r.y = x, y
+	r.Invalidate()
+}
+
+// Flush stores the contents of the Region on the Screen.  As with the
+// Screen's own methods, they are not visible until Show is called.
+func (r *Region) Flush() {
+	r.Blit(r.s, r.x, r.y)
+}
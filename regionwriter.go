@@ -0,0 +1,190 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import "sync"
+
+// RegionWriter is an io.Writer that renders whatever is written to it into
+// a rectangular region of a Screen.  It is intended for capturing the
+// output of third party code (progress bars and the like) that insists on
+// writing to stdout, which would otherwise corrupt the display.
+//
+// The writer understands the control characters that such code commonly
+// uses -- carriage return, newline, backspace and tab -- as well as the
+// erase-in-line sequence and SGR colors (256 color and RGB ones too) and
+// attributes.  Other escape sequences are consumed and discarded.  When a newline is written on the
+// last row of the region, the region's contents scroll up.
+//
+// Characters take as many columns as they are wide, and one that does not
+// fit on the rest of the row starts the next one.  Combining marks join
+// the character written before them.
+//
+// Each Write is made visible by calling Show on the Screen.  Screens give
+// out RegionWriters with ExternalWriter (see ExternalWriterScreen), and
+// NewRegionWriter makes one for any Screen.
+type RegionWriter struct {
+	s     Screen
+	x     int
+	y     int
+	w     int
+	h     int
+	col   int
+	row   int
+	last  int // column of the last character written on the row, or -1
+	base  Style
+	style Style
+	vt    vtParser
+	sync.Mutex
+}
+
+// ExternalWriterScreen is implemented by screens that hand out writers for
+// capturing external output.  All of the screens in this package do so.
+type ExternalWriterScreen interface {
+	// ExternalWriter returns a RegionWriter for the region of the
+	// screen with the given origin and dimensions.  Text is drawn in
+	// the screen's default style, until SGR sequences change it.
+	ExternalWriter(x, y, width, height int) *RegionWriter
+
+	Screen
+}
+
+// NewRegionWriter returns a RegionWriter that draws into the region of
+// the Screen with the given origin and dimensions, using the given style
+// as the base style for text.
+func NewRegionWriter(s Screen, x, y, width, height int, style Style) *RegionWriter {
+	return &RegionWriter{s: s, x: x, y: y, w: width, h: height,
+		last: -1, base: style, style: style}
+}
+
+// Clear erases the region, and moves the writing position back to the
+// upper left corner of it.
+func (rw *RegionWriter) Clear() {
+	rw.Lock()
+	for row := 0; row < rw.h; row++ {
+		rw.eraseLine(row, 0)
+	}
+	rw.col, rw.row, rw.last = 0, 0, -1
+	rw.Unlock()
+	rw.s.Show()
+}
+
+// Write implements io.Writer.  It always consumes all of its input.
+func (rw *RegionWriter) Write(b []byte) (int, error) {
+	rw.Lock()
+	rw.vt.parse(b, rw)
+	rw.Unlock()
+	rw.s.Show()
+	return len(b), nil
+}
+
+func (rw *RegionWriter) putRune(r rune) {
+	switch r {
+	case '\r':
+		rw.col = 0
+	case '\n':
+		rw.col = 0
+		rw.newline()
+	case '\b':
+		if rw.col > 0 {
+			rw.col--
+		}
+	case '\t':
+		rw.col = (rw.col + 8) &^ 7
+		if rw.col > rw.w {
+			rw.col = rw.w
+		}
+	default:
+		if r < ' ' || r == 0x7f {
+			return
+		}
+		rw.printRune(r)
+		return
+	}
+	rw.last = -1
+}
+
+// printRune draws the character at the writing position, wrapping first
+// if it does not fit on the row.  Combining marks (of zero width) join
+// the character written before them.
+func (rw *RegionWriter) printRune(r rune) {
+	width := runeWidth(r)
+	if width == 0 {
+		if rw.last >= 0 {
+			x, y := rw.x+rw.last, rw.y+rw.row
+			if c := rw.s.GetCell(x, y); c != nil {
+				rw.s.SetCell(x, y, c.Style, append(append([]rune{}, c.Ch...), r)...)
+			}
+		}
+		return
+	}
+	if width > rw.w {
+		return
+	}
+	if rw.col+width > rw.w {
+		rw.col = 0
+		rw.newline()
+	}
+	rw.s.SetCell(rw.x+rw.col, rw.y+rw.row, rw.style, r)
+	rw.last = rw.col
+	rw.col += width
+}
+
+func (rw *RegionWriter) newline() {
+	rw.last = -1
+	if rw.row < rw.h-1 {
+		rw.row++
+		return
+	}
+	// scroll the region up by one line
+	for row := 1; row < rw.h; row++ {
+		for col := 0; col < rw.w; col++ {
+			c := rw.s.GetCell(rw.x+col, rw.y+row)
+			if c != nil {
+				rw.s.PutCell(rw.x+col, rw.y+row-1, c)
+			}
+		}
+	}
+	rw.eraseLine(rw.h-1, 0)
+}
+
+func (rw *RegionWriter) eraseLine(row, from int) {
+	if row == rw.row && rw.last >= from {
+		rw.last = -1
+	}
+	for col := from; col < rw.w; col++ {
+		rw.s.SetCell(rw.x+col, rw.y+row, rw.base, ' ')
+	}
+}
+
+// escape ignores ESC sequences, which move the cursor about in ways
+// that make no sense in a region.
+func (rw *RegionWriter) escape(string, byte) {}
+
+// osc ignores operating system commands.
+func (rw *RegionWriter) osc(string) {}
+
+func (rw *RegionWriter) csi(final byte, params string) {
+	switch final {
+	case 'K':
+		switch params {
+		case "", "0":
+			rw.eraseLine(rw.row, rw.col)
+		case "2":
+			rw.eraseLine(rw.row, 0)
+		}
+	case 'm':
+		rw.style = sgrStyle(rw.style, rw.base, csiParams(params))
+	}
+}
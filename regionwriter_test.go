@@ -0,0 +1,152 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func regionText(s SimulationScreen, x, y, w int) string {
+	b, pw, _ := s.GetContents()
+	str := ""
+	for col := x; col < x+w; col++ {
+		c := b[y*pw+col]
+		if len(c.Runes) == 0 {
+			str += " "
+		} else {
+			str += string(c.Runes)
+		}
+	}
+	return str
+}
+
+func TestRegionWriter(t *testing.T) {
+	Convey("Region writer", t, WithScreen(t, "", func(s SimulationScreen) {
+		rw := NewRegionWriter(s, 10, 5, 8, 2, StyleDefault)
+		rw.Clear()
+
+		Convey("Carriage return overwrites", func() {
+			fmt.Fprint(rw, "hello\rHE")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "HEllo   ")
+		})
+
+		Convey("Erase in line works", func() {
+			fmt.Fprint(rw, "50%\r\x1b[K1")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "1       ")
+		})
+
+		Convey("Colors are parsed", func() {
+			fmt.Fprint(rw, "\x1b[31mX\x1b[0mY")
+			b, pw, _ := s.GetContents()
+			fg, _, _ := b[5*pw+10].Style.Decompose()
+			So(fg, ShouldEqual, ColorRed)
+			So(b[5*pw+11].Style, ShouldEqual, StyleDefault)
+		})
+
+		Convey("Extended colors are parsed", func() {
+			fmt.Fprint(rw, "\x1b[38;5;196mX\x1b[48;2;255;0;0mY")
+			b, pw, _ := s.GetContents()
+			So(b[5*pw+10].Style, ShouldEqual,
+				StyleDefault.Foreground(ColorBlack+196))
+			So(b[5*pw+11].Style, ShouldEqual,
+				StyleDefault.Foreground(ColorBlack+196).
+					Background(NewRGBColor(255, 0, 0)))
+		})
+
+		Convey("Attributes are turned off", func() {
+			on := "\x1b[1;2;4;5;7;8m"
+			st := StyleDefault.Bold(true).Dim(true).Underline(true).
+				Blink(true).Reverse(true).Invisible(true)
+			offs := []struct {
+				code  string
+				style Style
+			}{
+				{"22", st.Bold(false).Dim(false)},
+				{"24", st.Underline(false)},
+				{"25", st.Blink(false)},
+				{"27", st.Reverse(false)},
+				{"28", st.Invisible(false)},
+			}
+			for i, off := range offs {
+				fmt.Fprintf(rw, "%s\x1b[%smX", on, off.code)
+				b, pw, _ := s.GetContents()
+				So(b[5*pw+10+i].Style, ShouldEqual, off.style)
+			}
+		})
+
+		Convey("Region scrolls", func() {
+			fmt.Fprint(rw, "one\ntwo\nthree")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "two     ")
+			So(regionText(s, 10, 6, 8), ShouldEqual, "three   ")
+		})
+
+		Convey("Split UTF-8 is reassembled", func() {
+			rw.Write([]byte{0xc3})
+			rw.Write([]byte{0xa9})
+			So(regionText(s, 10, 5, 1), ShouldEqual, "é")
+		})
+
+		Convey("Split sequences are reassembled", func() {
+			fmt.Fprint(rw, "ab\x1b[")
+			fmt.Fprint(rw, "3")
+			fmt.Fprint(rw, "1mc")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "abc     ")
+		})
+
+		Convey("Strings are discarded", func() {
+			fmt.Fprint(rw, "a\x1b]0;title\x07b\x1bPq#0\x1b\\c")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "abc     ")
+		})
+
+		Convey("Wide characters take two columns", func() {
+			fmt.Fprint(rw, "a世b")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "a世 b    ")
+		})
+
+		Convey("Wide characters wrap whole", func() {
+			fmt.Fprint(rw, "abcdefg世")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "abcdefg ")
+			So(regionText(s, 10, 6, 8), ShouldEqual, "世       ")
+		})
+
+		Convey("Combining marks join the character before", func() {
+			fmt.Fprint(rw, "e\u0301x")
+			So(regionText(s, 10, 5, 8), ShouldEqual, "e\u0301x      ")
+		})
+	}))
+
+	Convey("Resets go back to the base style", t, WithScreen(t, "", func(s SimulationScreen) {
+		base := StyleDefault.Foreground(ColorYellow).Background(ColorBlue)
+		rw := NewRegionWriter(s, 0, 0, 8, 1, base)
+		fmt.Fprint(rw, "\x1b[1;31;42mA\x1b[39mB\x1b[49mC\x1b[0mD")
+		b, _, _ := s.GetContents()
+		So(b[0].Style, ShouldEqual,
+			base.Bold(true).Foreground(ColorRed).Background(ColorGreen))
+		So(b[1].Style, ShouldEqual, base.Bold(true).Background(ColorGreen))
+		So(b[2].Style, ShouldEqual, base.Bold(true))
+		So(b[3].Style, ShouldEqual, base)
+	}))
+
+	Convey("Screens give out region writers", t, WithScreen(t, "", func(s SimulationScreen) {
+		ew, ok := s.(ExternalWriterScreen)
+		So(ok, ShouldBeTrue)
+		rw := ew.ExternalWriter(2, 1, 4, 1)
+		fmt.Fprint(rw, "ok")
+		So(regionText(s, 2, 1, 4), ShouldEqual, "ok  ")
+	}))
+}
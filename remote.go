@@ -0,0 +1,166 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"io"
+	"sync"
+)
+
+// RemoteScreen is a Screen for a terminal at the other end of a
+// connection.  (See NewRemoteScreen.)
+type RemoteScreen interface {
+	// WindowChanged tells the screen that the size of the terminal
+	// has changed, as when an SSH session gets a window-change request,
+	// or a telnet connection a NAWS subnegotiation.  The size function
+	// given to NewRemoteScreen is called for the new size, and an
+	// EventResize posted if it differs from the old one.
+	WindowChanged()
+
+	Screen
+}
+
+// remoteConn is the connection of a remote screen.  Reads on it cannot
+// be interrupted, so a single goroutine reads it for the life of the
+// screen, and the input loop of each Init takes the data from there.
+type remoteConn struct {
+	rw    io.ReadWriter
+	size  func() (int, int)
+	data  chan []byte
+	err   error
+	start sync.Once
+	held  []byte // read, but not yet delivered when Fini was called
+}
+
+// NewRemoteScreen returns a Screen for a terminal at the other end of a
+// connection, such as an SSH session or a telnet connection, so that a
+// server can give each of its users a Screen of their own.  The terminal
+// type is the one negotiated by the connection (such as the TERM of an
+// SSH pty request), and size returns the current size of the terminal;
+// call WindowChanged when that changes.
+//
+// The connection must carry just the data of the terminal; telnet
+// commands, for example, must be handled by the caller.  The terminal is
+// assumed to use UTF-8, and to already be in raw mode, as the client end
+// of an SSH session is.  Fini does not close the connection, so the
+// screen may be initialized again; if the connection fails, an EventError
+// is posted with the error, and the caller should Fini the screen.
+//
+// The $TCELL_* settings that apply to NewTerminfoScreen apply here too,
+// to all connections alike, but $LINES, $COLUMNS, $TCELL_CHARSET and the
+// locale do not, and the widths of characters are not probed.
+func NewRemoteScreen(rw io.ReadWriter, term string, size func() (int, int)) (RemoteScreen, error) {
+	ti, e := lookupTermWithFallback(term)
+	if e != nil {
+		return nil, e
+	}
+	ti = overrideTerminfo(ti)
+	w, h := size()
+	t := newTScreen(ti, w, h)
+	t.remote = &remoteConn{rw: rw, size: size, data: make(chan []byte)}
+	return t, nil
+}
+
+// read reads the connection until it fails.
+func (rc *remoteConn) read() {
+	for {
+		chunk := make([]byte, 128)
+		n, e := rc.rw.Read(chunk)
+		if n > 0 {
+			rc.data <- chunk[:n]
+		}
+		if e != nil {
+			rc.err = e
+			close(rc.data)
+			return
+		}
+	}
+}
+
+// remoteInit sets up the screen for its connection, in place of
+// termioInit.
+func (t *tScreen) remoteInit() {
+	rc := t.remote
+	rc.start.Do(func() { go rc.read() })
+	if w, h := rc.size(); w > 0 && h > 0 {
+		if t.xform.swaps() {
+			w, h = h, w
+		}
+		t.w, t.h = w, h
+	}
+}
+
+// readRemote passes on what is read from the connection, until Fini or
+// the connection fails.
+func (t *tScreen) readRemote(chunks chan<- []byte) {
+	rc := t.remote
+	for {
+		b := rc.held
+		rc.held = nil
+		if b == nil {
+			var ok bool
+			select {
+			case <-t.quit:
+				return
+			case b, ok = <-rc.data:
+				if !ok {
+					t.PostEvent(NewEventError(rc.err))
+					return
+				}
+			}
+		}
+		select {
+		case chunks <- b:
+		case <-t.quit:
+			rc.held = b
+			return
+		}
+	}
+}
+
+// WindowChanged is harmless on other screens, where it rereads the size
+// of the tty, as for SIGWINCH.
+func (t *tScreen) WindowChanged() {
+	select {
+	case t.sigwinch <- nil:
+	default:
+	}
+}
+
+// winSize returns the size of the terminal, in cells.
+func (t *tScreen) winSize() (int, int, error) {
+	if t.remote != nil {
+		w, h := t.remote.size()
+		return w, h, nil
+	}
+	return t.getWinSize()
+}
+
+// pixSize returns the size of the terminal, in pixels.  Remote screens
+// only learn that from the terminal itself.  (See deviceQueries.)
+func (t *tScreen) pixSize() (int, int, error) {
+	if t.remote != nil {
+		return 0, 0, ErrNoCapability
+	}
+	return t.getPixelSize()
+}
+
+// output returns where output for the terminal is written.
+func (t *tScreen) output() io.Writer {
+	if t.remote != nil {
+		return t.remote.rw
+	}
+	return t.out
+}
@@ -20,9 +20,12 @@ import (
 
 // EventResize is sent when the window size changes.
 type EventResize struct {
-	t time.Time
-	w int
-	h int
+	t   time.Time
+	w   int
+	h   int
+	pw  int
+	ph  int
+	pix bool
 }
 
 func NewEventResize(width, height int) *EventResize {
@@ -36,3 +39,33 @@ func (ev *EventResize) When() time.Time {
 func (ev *EventResize) Size() (int, int) {
 	return ev.w, ev.h
 }
+
+// PixelSize returns the size of the window in pixels, if the terminal
+// reported it, for applications that draw images and need to know how
+// many pixels a cell holds.  The last value is false if no pixel size
+// is known.  (See PixelSizeScreen.)
+func (ev *EventResize) PixelSize() (int, int, bool) {
+	return ev.pw, ev.ph, ev.pix
+}
+
+// SetPixelSize records the size of the window in pixels.  Like
+// NewEventResize, this is intended for use by screen implementors.
+func (ev *EventResize) SetPixelSize(pw, ph int) {
+	ev.pw = pw
+	ev.ph = ph
+	ev.pix = true
+}
+
+// PixelSizeScreen is implemented by screens that can know the size of
+// the window in pixels.  The terminfo based screen learns it from the
+// tty driver, where the terminal emulator sets it, and otherwise from the
+// reply to the XTWINOPS query sent by ProbeDeviceAttributes.  When the
+// pixel size becomes known, or changes, an EventResize is posted, even if
+// the size in cells is the same.
+type PixelSizeScreen interface {
+	// PixelSize returns the size of the window in pixels.  The last
+	// value is false if it is not known.
+	PixelSize() (width, height int, ok bool)
+
+	Screen
+}
@@ -14,9 +14,30 @@
 
 package tcell
 
+import "runtime"
+
 // Screen represents the physical (or emulated) screen.
 // This can be a terminal window or a physical console.  Platforms implement
 // this differerently.
+//
+// Once Init has returned, all of the methods may be called concurrently
+// from any goroutines.  A typical application has one goroutine blocked
+// in PollEvent, while others update the contents and call Show.  (The
+// same holds for the methods of the optional interfaces, such as
+// TtyScreen.)  Each method is atomic with respect to the others, but a
+// series of calls is not; for example, a Show in one goroutine may pick
+// up some, but not all, of the cells that another goroutine is setting.
+// Applications that care about that must coordinate among themselves.
+//
+// Output to the terminal is written without holding the screen's lock,
+// so a terminal that is slow to accept it (or has been stopped with flow
+// control) holds up only the goroutine that is writing.  Others can keep
+// updating cells and posting events meanwhile; a Show that finds output
+// already being written leaves its own to that writer, and returns.
+//
+// Init must not be called concurrently with any other method.  Fini may
+// be called from any goroutine, at any time, and more than once.  After
+// Fini, PollEvent returns nil, and the other methods do nothing.
 type Screen interface {
 	// Init initializes the screen for use.
 	Init() error
@@ -68,7 +89,9 @@ type Screen interface {
 
 	// ShowCursor is used to display the cursor at a given location.
 	// If the coordinates -1, -1 are given or are otherwise outside the
-	// dimensions of the screen, the cursor will be hidden.
+	// dimensions of the screen, the cursor will be hidden.  The change
+	// takes effect on the next Show; if nothing else has changed, that
+	// Show only moves the cursor, which is very cheap.
 	ShowCursor(x int, y int)
 
 	// HideCursor is used to hide the cursor.  Its an alias for
@@ -84,18 +107,23 @@ type Screen interface {
 	// Furthermore, this will return nil if the Screen is finalized.
 	PollEvent() Event
 
-	// PostEvent posts an event into the event stream.
+	// PostEvent posts an event into the event stream.  It may be called
+	// from any goroutine, including the one calling PollEvent, and from
+	// an EventFilter.  It never blocks; if the event queue is full, the
+	// event is dropped.
 	PostEvent(Event)
 
 	// EnableMouse enables the mouse.  (If your terminal supports it.)
-	EnableMouse()
+	// Flags can be supplied to request additional reporting features;
+	// those which are not supported are silently ignored.
+	EnableMouse(...MouseFlags)
 
 	// DisableMouse disables the mouse.
 	DisableMouse()
 
 	// Colors returns the number of colors.  All colors are assumed to
 	// use the ANSI color map.  If a terminal is monochrome, it will
-	// return 0.
+	// return 0.  Terminals that show RGB colors directly return 1<<24.
 	Colors() int
 
 	// Show takes any output that was deferred due to buffering, and
@@ -120,9 +148,38 @@ type Screen interface {
 	CharacterSet() string
 }
 
+// ReinitializeScreen is implemented by Screens that can switch to a
+// different terminal while they run.  All of those in this package do.
+type ReinitializeScreen interface {
+	// Reinitialize switches to a different terminal type, for use when
+	// an application finds itself reattached to a different terminal
+	// mid-session (for example by tmux attach or reptyr).  The terminal
+	// capabilities are looked up again, terminal modes (including the
+	// mouse, if enabled) are set up afresh for the new terminal, and the
+	// screen is redrawn.  The cell contents are preserved.  If the
+	// terminal type is not known, an error is returned and nothing is
+	// changed.  Screens that are not terminal based ignore the terminal
+	// type, and just redraw.
+	Reinitialize(term string) error
+
+	Screen
+}
+
 // NewScreen returns a default Screen suitable for the user's terminal
-// environment.
+// environment.  On Windows that is the console, even if $TERM is set (as
+// mintty and MSYS2 do), since there is no termios there; elsewhere, it
+// is a terminfo screen.
 func NewScreen() (Screen, error) {
+	if runtime.GOOS == "windows" {
+		if s, e := NewConsoleScreen(); s != nil {
+			return s, nil
+		} else if s, _ := NewTerminfoScreen(); s != nil {
+			return s, nil
+		} else {
+			return nil, e
+		}
+	}
+
 	// First we attempt to obtain a terminfo screen.  This should work
 	// in most places if $TERM is set.
 	if s, e := NewTerminfoScreen(); s != nil {
@@ -0,0 +1,795 @@
+// +build js,wasm
+
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+// This file implements a Screen for WebAssembly programs running in a
+// browser.  There is no tty there, so instead we talk to a terminal
+// emulator written in JavaScript, normally xterm.js.  We only need a
+// small part of the xterm.js Terminal API, so that an xterm.js Terminal
+// can be used directly as the bridge to Go:
+//
+//	cols, rows         the size of the terminal, in cells
+//	write(data)        write the string (with escape sequences)
+//	onData(fn)         call fn(data) with the string typed by the user
+//	onResize(fn)       call fn({cols, rows}) when the size changes
+//	textarea           the element that takes keyboard input (optional)
+//
+// The onData and onResize functions may return an object with a
+// dispose() method, which is used to unregister the callbacks on Fini.
+// The composition events of the textarea, if there is one, are sent on
+// as EventComposition.
+// Other frontends, such as one drawing into a grid of DOM elements,
+// just need to provide the same, and to understand the xterm control
+// sequences that we send, which are those of xterm-256color.
+
+// jsTerm is the terminal type we emulate when talking to the frontend.
+const jsTerm = "xterm-256color"
+
+// NewConsoleScreen returns a Screen that draws into the terminal emulator
+// found in the JavaScript global variable "tcell", which must be set up
+// by the hosting web page before the program is started.  This is what
+// NewScreen uses in the browser.
+func NewConsoleScreen() (Screen, error) {
+	return NewConsoleScreenWithOptions(ScreenOptions{})
+}
+
+// NewConsoleScreenWithOptions is NewConsoleScreen, configured by the
+// options.  Term, In and Out do not apply here.
+func NewConsoleScreenWithOptions(opts ScreenOptions) (Screen, error) {
+	if e := opts.check(); e != nil {
+		return nil, e
+	}
+	term := js.Global().Get("tcell")
+	if term.IsUndefined() || term.IsNull() {
+		return nil, errors.New("no terminal emulator in global variable tcell")
+	}
+	s, e := NewJSScreen(term)
+	if e != nil {
+		return nil, e
+	}
+	jss := s.(*jsScreen)
+	jss.opts = opts
+	jss.opts.setEscapeDelay(jss.input)
+	if opts.NoColor {
+		jss.nocolor = true
+	}
+	return jss, nil
+}
+
+// NewJSScreen returns a Screen that draws into the given JavaScript
+// terminal emulator object, such as an xterm.js Terminal.
+func NewJSScreen(term js.Value) (Screen, error) {
+	for _, m := range []string{"write", "onData", "onResize"} {
+		if term.Get(m).Type() != js.TypeFunction {
+			return nil, errors.New("terminal emulator lacks " + m)
+		}
+	}
+	ti, e := LookupTerminfo(jsTerm)
+	if e != nil {
+		return nil, e
+	}
+	s := &jsScreen{term: term, ti: ti, fini: true, nocolor: noColorEnv()}
+	s.input = newInputParser(ti)
+	s.input.postfn = s.PostEvent
+	return s, nil
+}
+
+type jsScreen struct {
+	term     js.Value
+	ti       *Terminfo
+	fini     bool
+	w        int
+	h        int
+	cells    cellGrid
+	drawc    Cell // scratch space for drawing cells
+	style    Style
+	curstyle Style
+	cx       int
+	cy       int
+	cursorx  int
+	cursory  int
+	clear    bool
+	evch     chan Event
+	filter   eventFilter
+	redraw   redrawer
+	quit     chan struct{}
+	dataq    chan string
+	input    *InputParser
+	mouseon  bool
+	compose  bool // composition is watched
+	blinkoff bool
+	blinkq   chan struct{}
+	blinkdur time.Duration
+	blinkrun bool
+	stats    renderStats
+	buf      bytes.Buffer
+	funcs    []js.Func
+	handles  []js.Value
+	nocolor  bool
+	opts     ScreenOptions
+
+	sync.Mutex
+}
+
+// Init may be called again after Fini; while the screen is initialized,
+// it does nothing.
+func (s *jsScreen) Init() error {
+	s.Lock()
+	running := !s.fini
+	s.Unlock()
+	if running {
+		return nil
+	}
+
+	s.evch = make(chan Event, s.opts.queueLen())
+	s.quit = make(chan struct{})
+	s.dataq = make(chan string, 64)
+	s.w = s.term.Get("cols").Int()
+	s.h = s.term.Get("rows").Int()
+	s.cells.resize(s.w, s.h)
+	s.style = StyleDefault
+	s.curstyle = styleInvalid
+	s.cx = -1
+	s.cy = -1
+	s.cursorx = -1
+	s.cursory = -1
+
+	// The callbacks are run by the JavaScript event loop, which is
+	// stalled until they return.  So they just queue things up, and
+	// leave the real work to the input loop.
+	onData := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 && args[0].String() != "" {
+			s.dataq <- args[0].String()
+		}
+		return nil
+	})
+	onResize := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		s.dataq <- ""
+		return nil
+	})
+	s.funcs = []js.Func{onData, onResize}
+	s.handles = []js.Value{
+		s.term.Call("onData", onData),
+		s.term.Call("onResize", onResize),
+	}
+	if ta := s.term.Get("textarea"); ta.Type() == js.TypeObject {
+		s.watchComposition(ta)
+	}
+
+	s.Lock()
+	s.fini = false
+	if !s.opts.NoAltScreen {
+		s.TPuts(s.ti.EnterCA)
+	}
+	s.TPuts(s.ti.EnterKeypad)
+	s.TPuts(s.ti.HideCursor)
+	s.TPuts(s.ti.Clear)
+	if s.opts.Mouse && mouseSupport {
+		s.mouseon = true
+		s.TPuts(s.ti.TParm(s.ti.MouseMode, 1))
+	}
+	// xterm.js does not blink, so we always do it ourselves.
+	s.enableBlink(DefaultBlinkRate)
+	s.flush()
+	s.Unlock()
+
+	go s.inputLoop()
+	return nil
+}
+
+func (s *jsScreen) Fini() {
+	s.Lock()
+	if s.fini {
+		s.Unlock()
+		return
+	}
+	s.fini = true
+	if s.blinkq != nil {
+		close(s.blinkq)
+		s.blinkq = nil
+		s.blinkrun = false
+	}
+	s.disableMouse()
+	s.TPuts(s.ti.ShowCursor)
+	s.TPuts(s.ti.AttrOff)
+	if !s.opts.NoAltScreen {
+		s.TPuts(s.ti.Clear)
+		s.TPuts(s.ti.ExitCA)
+	}
+	s.TPuts(s.ti.ExitKeypad)
+	s.flush()
+	s.w = 0
+	s.h = 0
+	s.cells = cellGrid{}
+	s.Unlock()
+
+	for _, h := range s.handles {
+		if h.Type() == js.TypeObject && h.Get("dispose").Type() == js.TypeFunction {
+			h.Call("dispose")
+		}
+	}
+	for _, f := range s.funcs {
+		f.Release()
+	}
+	s.handles = nil
+	s.funcs = nil
+	close(s.quit)
+}
+
+// inputLoop processes what the callbacks queued.  Input from the frontend
+// arrives in whole sequences, so anything left over after parsing is not
+// going to be completed, and can be delivered at once.  An empty string
+// means that the terminal was resized.
+func (s *jsScreen) inputLoop() {
+	for {
+		select {
+		case <-s.quit:
+			return
+		case data := <-s.dataq:
+			s.Lock()
+			if !s.fini {
+				if data == "" {
+					s.resize()
+				} else {
+					s.input.SetSize(s.w, s.h)
+					s.input.Feed([]byte(data))
+					s.input.Expire()
+				}
+			}
+			s.Unlock()
+		}
+	}
+}
+
+func (s *jsScreen) resize() {
+	w := s.term.Get("cols").Int()
+	h := s.term.Get("rows").Int()
+	if w == s.w && h == s.h {
+		return
+	}
+	ow, oh := s.w, s.h
+	s.cells.resize(w, h)
+	s.w = w
+	s.h = h
+	s.cx = -1
+	s.cy = -1
+	s.cells.invalidate()
+	s.PostEvent(NewEventResize(w, h))
+	s.redraw.exposed(s.PostEvent, ow, oh, w, h)
+}
+
+func (s *jsScreen) SetStyle(style Style) {
+	s.Lock()
+	if !s.fini {
+		s.style = style
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) Clear() {
+	s.Lock()
+	if !s.fini {
+		s.cells.clear(s.style)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) SetCell(x, y int, style Style, ch ...rune) {
+	s.Lock()
+	if !s.fini && x >= 0 && y >= 0 && x < s.w && y < s.h {
+		s.cells.put((y*s.w)+x, ch, style)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) PutCell(x, y int, cell *Cell) {
+	s.Lock()
+	if !s.fini && x >= 0 && y >= 0 && x < s.w && y < s.h {
+		s.cells.put((y*s.w)+x, cell.Ch, cell.Style)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) PutCells(x, y, w, h int, cells []Cell) {
+	s.Lock()
+	if !s.fini {
+		s.cells.putCells(x, y, w, h, cells, nil)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) GetCell(x, y int) *Cell {
+	s.Lock()
+	defer s.Unlock()
+	if s.fini || x < 0 || y < 0 || x >= s.w || y >= s.h {
+		return nil
+	}
+	return s.cells.cell((y * s.w) + x)
+}
+
+func (s *jsScreen) Contents() *Contents {
+	s.Lock()
+	defer s.Unlock()
+	return s.cells.contents(s.cursorx, s.cursory)
+}
+
+func (s *jsScreen) ShowCursor(x, y int) {
+	s.Lock()
+	if !s.fini {
+		s.cursorx = x
+		s.cursory = y
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) HideCursor() {
+	s.ShowCursor(-1, -1)
+}
+
+// TPuts adds the string to the output, which is sent to the frontend
+// by flush.  There is no padding to worry about.
+func (s *jsScreen) TPuts(str string) {
+	s.buf.WriteString(str)
+}
+
+func (s *jsScreen) flush() {
+	if s.buf.Len() > 0 {
+		s.term.Call("write", s.buf.String())
+		s.buf.Reset()
+	}
+}
+
+func (s *jsScreen) showCursor() {
+	x, y := s.cursorx, s.cursory
+	if x < 0 || y < 0 || x >= s.w || y >= s.h {
+		s.TPuts(s.ti.HideCursor)
+		return
+	}
+	if s.cx != x || s.cy != y {
+		s.TPuts(s.ti.TGoto(x, y))
+	}
+	s.TPuts(s.ti.ShowCursor)
+	s.cx = x
+	s.cy = y
+}
+
+func (s *jsScreen) drawCell(x, y int, cell *Cell) int {
+	ti := s.ti
+	if s.cy != y || s.cx != x {
+		s.TPuts(ti.TGoto(x, y))
+	}
+	style := cell.Style
+	if style == StyleDefault {
+		style = s.style
+	}
+	blank := false
+	if s.blinkq != nil && isBlink(style) {
+		s.startBlink()
+		blank = s.blinkoff
+		style = style.Blink(false)
+	}
+	if isInvisible(style) {
+		blank = true
+		style = style.Invisible(false)
+	}
+	if s.nocolor {
+		style = style.colorless()
+	}
+	style = style.palette()
+	if style != s.curstyle {
+		fg, bg, attrs := style.Decompose()
+
+		s.TPuts(ti.AttrOff)
+		if attrs&AttrBold != 0 {
+			s.TPuts(ti.Bold)
+		}
+		if attrs&AttrUnderline != 0 {
+			s.TPuts(ti.Underline)
+		}
+		if attrs&AttrReverse != 0 {
+			s.TPuts(ti.Reverse)
+		}
+		if attrs&AttrDim != 0 {
+			s.TPuts(ti.Dim)
+		}
+		if fg != ColorDefault {
+			s.TPuts(ti.TParm(ti.SetFg, int(fg)-1))
+		}
+		if bg != ColorDefault {
+			s.TPuts(ti.TParm(ti.SetBg, int(bg)-1))
+		}
+		s.curstyle = style
+	}
+
+	width := int(cell.Width)
+	str := string(cell.Ch)
+	if len(cell.Ch) == 0 {
+		str = " "
+		width = 1
+	}
+	if width == 2 && x >= s.w-1 {
+		// too wide to fit; emit space instead
+		str = " "
+		width = 1
+	}
+	if blank {
+		str = "  "[:width]
+	}
+	s.TPuts(str)
+	s.cy = y
+	s.cx = x + width
+	return width
+}
+
+func (s *jsScreen) Show() {
+	s.Lock()
+	if !s.fini {
+		s.resize()
+		s.draw()
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) Sync() {
+	s.Lock()
+	if !s.fini {
+		s.resize()
+		s.clear = true
+		s.cells.invalidate()
+		s.draw()
+		s.redraw.all(s.PostEvent, s.w, s.h)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) EnableRedrawEvents(on bool) {
+	s.Lock()
+	s.redraw.on = on
+	s.redraw.all(s.PostEvent, s.w, s.h)
+	s.Unlock()
+}
+
+func (s *jsScreen) draw() {
+	s.stats.begin(&s.cells, s.clear)
+	defer s.stats.end()
+
+	if !s.clear && !s.cells.anyDirty(-1) {
+		s.showCursor()
+		s.flush()
+		return
+	}
+
+	s.cx = -1
+	s.cy = -1
+	s.TPuts(s.ti.HideCursor)
+	if s.clear {
+		s.TPuts(s.ti.Clear)
+		s.clear = false
+	}
+
+	for row := 0; row < s.h; row++ {
+		for col := 0; col < s.w; col++ {
+			i := (row * s.w) + col
+			if !s.cells.dirty[i] {
+				if s.cells.width[i] > 1 {
+					// leave the cell it covers alone
+					col++
+				}
+				continue
+			}
+			s.cells.load(i, &s.drawc)
+			if s.drawCell(col, row, &s.drawc) > 1 && col+1 < s.w {
+				s.cells.drawnWide(i)
+				col++
+			} else {
+				s.cells.drawn(i)
+			}
+		}
+	}
+	s.showCursor()
+	s.flush()
+}
+
+func (s *jsScreen) Size() (int, int) {
+	s.Lock()
+	w, h := s.w, s.h
+	s.Unlock()
+	return w, h
+}
+
+func (s *jsScreen) PollEvent() Event {
+	return s.filter.poll(s.quit, s.evch)
+}
+
+func (s *jsScreen) SetEventFilter(f EventFilter) {
+	s.filter.set(f)
+}
+
+func (s *jsScreen) SetIdleTimeout(d time.Duration) {
+	s.filter.setIdle(d)
+}
+
+// SetSize calls the resize method of the terminal, if it has one (as
+// xterm.js does), which reports the new size through onResize.
+func (s *jsScreen) SetSize(w, h int) {
+	if w <= 0 || h <= 0 || s.term.Get("resize").Type() != js.TypeFunction {
+		return
+	}
+	s.term.Call("resize", w, h)
+}
+
+func (s *jsScreen) PostEvent(ev Event) {
+	select {
+	case s.evch <- ev:
+	default:
+		// drop the event on the floor
+	}
+}
+
+func (s *jsScreen) EnableMouse(...MouseFlags) {
+	s.Lock()
+	if !s.fini && mouseSupport {
+		s.mouseon = true
+		s.TPuts(s.ti.TParm(s.ti.MouseMode, 1))
+		s.flush()
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) DisableMouse() {
+	s.Lock()
+	s.disableMouse()
+	s.flush()
+	s.Unlock()
+}
+
+func (s *jsScreen) disableMouse() {
+	if s.mouseon {
+		s.mouseon = false
+		s.TPuts(s.ti.TParm(s.ti.MouseMode, 0))
+	}
+}
+
+func (s *jsScreen) SetNoColor(on bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.nocolor = on
+	s.curstyle = styleInvalid
+	s.clear = true
+	s.cells.invalidate()
+}
+
+func (s *jsScreen) EnableBlink(rate time.Duration) {
+	s.Lock()
+	if !s.fini {
+		s.enableBlink(rate)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) enableBlink(rate time.Duration) {
+	if rate <= 0 {
+		rate = DefaultBlinkRate
+	}
+	if s.blinkq != nil {
+		close(s.blinkq)
+	}
+	s.blinkq = make(chan struct{})
+	s.blinkdur = rate
+	s.blinkrun = false
+	s.blinkoff = false
+	s.cells.invalidateBlink(s.style)
+}
+
+// startBlink starts the software blink loop, when a blinking cell is
+// drawn, unless it is already running.
+func (s *jsScreen) startBlink() {
+	if s.blinkrun {
+		return
+	}
+	s.blinkrun = true
+	q := s.blinkq
+	go blinkLoop(s.blinkdur, q, func() bool { return s.blink(q) })
+}
+
+func (s *jsScreen) DisableBlink() {
+	s.Lock()
+	if s.blinkq != nil {
+		close(s.blinkq)
+		s.blinkq = nil
+		s.blinkrun = false
+		s.blinkoff = false
+		s.cells.invalidateBlink(s.style)
+	}
+	s.Unlock()
+}
+
+func (s *jsScreen) blink(q chan struct{}) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.fini || s.blinkq != q {
+		return false
+	}
+	if !s.cells.anyBlink(s.style) {
+		s.blinkrun = false
+		s.blinkoff = false
+		return false
+	}
+	s.blinkoff = !s.blinkoff
+
+	s.cx = -1
+	s.cy = -1
+	s.TPuts(s.ti.HideCursor)
+	for row := 0; row < s.h; row++ {
+		for col := 0; col < s.w; col++ {
+			i := (row * s.w) + col
+			style := s.cells.style[i]
+			if style == StyleDefault {
+				style = s.style
+			}
+			if s.cells.dirty[i] || !isBlink(style) {
+				continue
+			}
+			s.cells.load(i, &s.drawc)
+			if s.drawCell(col, row, &s.drawc) > 1 {
+				col++
+			}
+		}
+	}
+	s.showCursor()
+	s.flush()
+	return true
+}
+
+func (s *jsScreen) ExternalWriter(x, y, width, height int) *RegionWriter {
+	return NewRegionWriter(s, x, y, width, height, StyleDefault)
+}
+
+func (s *jsScreen) Colors() int {
+	if s.nocolor {
+		return 0
+	}
+	return s.ti.Colors
+}
+
+func (s *jsScreen) HasMouse() bool {
+	return true
+}
+
+func (s *jsScreen) HasKey(k Key) bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.input.hasKey(k)
+}
+
+func (s *jsScreen) ColorModel() ColorModel {
+	return paletteModel(s.Colors())
+}
+
+func (s *jsScreen) DefaultColors() bool {
+	return true
+}
+
+// Reinitialize just redraws, since the frontend is always the same.
+func (s *jsScreen) Reinitialize(string) error {
+	s.Sync()
+	return nil
+}
+
+func (s *jsScreen) EnableRenderStats(on bool) {
+	s.Lock()
+	s.stats.enable(on)
+	s.Unlock()
+}
+
+func (s *jsScreen) RenderStats() RenderStats {
+	s.Lock()
+	defer s.Unlock()
+	return s.stats.stats
+}
+
+func (s *jsScreen) SetAllocBudget(allocs uint64) {
+	s.Lock()
+	s.stats.budget = allocs
+	s.Unlock()
+}
+
+func (s *jsScreen) Features() []Feature {
+	s.Lock()
+	defer s.Unlock()
+	ti := s.ti
+	fs := newFeatureSet()
+	if s.nocolor {
+		fs.set(FeatureColor, false, "turned off")
+	} else {
+		fs.set(FeatureColor, true, fmt.Sprintf("%d colors", ti.Colors))
+		fs.set(FeatureTrueColor, false,
+			fmt.Sprintf("%d color palette", ti.Colors))
+	}
+	fs.set(FeatureBold, true, "")
+	fs.set(FeatureDim, true, "")
+	fs.set(FeatureUnderline, true, "")
+	fs.set(FeatureUnderlineStyles, false, "single underline")
+	fs.set(FeatureReverse, true, "")
+	if s.blinkq != nil {
+		fs.set(FeatureBlink, true, "software")
+	}
+	fs.set(FeatureUnicode, true, "UTF-8")
+	fs.set(FeatureLineDrawing, true, "unicode")
+	switch {
+	case !mouseSupport:
+		fs.set(FeatureMouse, false, "not built in")
+	case s.mouseon:
+		fs.set(FeatureMouse, true, "SGR or X11 reports")
+	default:
+		fs.set(FeatureMouse, false, "off")
+	}
+	if s.compose {
+		fs.set(FeatureComposition, true, "textarea")
+	}
+	fs.set(FeatureResize, true, "")
+	return fs.list()
+}
+
+// watchComposition posts an EventComposition for each of the composition
+// events of the textarea, which is where the browser composes the text
+// that xterm.js sends on to onData once it is committed.  These are
+// unregistered on Fini along with the other callbacks.
+func (s *jsScreen) watchComposition(ta js.Value) {
+	listener := func(done bool) js.Func {
+		return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			data := ""
+			if len(args) > 0 {
+				if d := args[0].Get("data"); d.Type() == js.TypeString {
+					data = d.String()
+				}
+			}
+			if done {
+				s.PostEvent(NewEventComposition("", data))
+			} else {
+				s.PostEvent(NewEventComposition(data, ""))
+			}
+			return nil
+		})
+	}
+	update, end := listener(false), listener(true)
+	ta.Call("addEventListener", "compositionupdate", update)
+	ta.Call("addEventListener", "compositionend", end)
+	dispose := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ta.Call("removeEventListener", "compositionupdate", update)
+		ta.Call("removeEventListener", "compositionend", end)
+		return nil
+	})
+	handle := js.Global().Get("Object").New()
+	handle.Set("dispose", dispose)
+	s.funcs = append(s.funcs, update, end, dispose)
+	s.handles = append(s.handles, handle)
+	s.compose = true
+}
+
+func (s *jsScreen) CharacterSet() string {
+	return "UTF-8"
+}
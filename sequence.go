@@ -0,0 +1,304 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// The input parsers each recognize the sequences that they know about,
+// and anything else would otherwise reach the application a byte at a
+// time, as an ESC and a handful of runes.  Terminals send things we do
+// not know about (replies to queries that some other program made, or
+// reports that we never asked for), so as a last resort scanInput uses
+// sequenceLength, which follows the state machine of the DEC VT500
+// series (as described by Paul Williams), to find where such a sequence
+// ends, and discards it whole.
+//
+// The specific parsers come first, because some terminals use key
+// sequences which do not follow the rules, such as ESC [ [ A for F1 on
+// the Linux console.
+
+// The states of the sequence state machine, once past the introducer.
+const (
+	seqEscape = iota // ESC, then intermediates, then a final byte
+	seqCsi           // CSI, then parameters, intermediates, and a final
+	seqSs3           // SS3, then (for modified keys) parameters and a final
+	seqString        // OSC, DCS, SOS, PM or APC, up to ST
+)
+
+// sequenceIntroducer returns the state that follows the ESC sequence
+// that starts b, and its length, or false if b does not start with one.
+// Strings (OSC, DCS, SOS, PM, APC) must have something after their
+// introducer, since a lone ESC P (for example) is Alt-P.
+func sequenceIntroducer(b []byte) (int, int, bool) {
+	if len(b) == 0 || b[0] != '\x1b' {
+		return 0, 0, false
+	}
+	if len(b) == 1 {
+		return seqEscape, 1, true
+	}
+	switch b[1] {
+	case '[':
+		return seqCsi, 2, true
+	case 'O':
+		return seqSs3, 2, true
+	case ']', 'P', 'X', '^', '_':
+		if len(b) > 2 && b[2] != '\x1b' {
+			return seqString, 2, true
+		}
+	}
+	return seqEscape, 1, true
+}
+
+// sequenceLength returns the length of the control sequence at the start
+// of b.  It returns 0 if the sequence is not yet complete, and -1 if b
+// does not start with a control sequence.  A sequence that is cancelled
+// (by CAN or SUB, which are discarded with it), or interrupted by ESC or
+// by another control character (which is left to be parsed after it),
+// ends there; but an introducer that is interrupted right away is not
+// taken to be a sequence at all.  Strings end with ST (in any of its
+// forms), or, as xterm allows, with BEL.
+func sequenceLength(b []byte) int {
+	state, start, ok := sequenceIntroducer(b)
+	if !ok {
+		return -1
+	}
+	interrupt := func(i int) int {
+		if i == start {
+			return -1
+		}
+		return i
+	}
+	for i := start; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '\x18' || c == '\x1a':
+			return i + 1
+		case state == seqString && c == '\a':
+			return i + 1
+		case state == seqString && c == '\x9c' && b[i-1] < 0x80:
+			// 8-bit ST (which cannot be part of a UTF-8 character
+			// that follows an ASCII byte)
+			return i + 1
+		case state == seqString && c == '\xc2' && i+1 < len(b) && b[i+1] == '\x9c':
+			// ST encoded as UTF-8
+			return i + 2
+		case state == seqString && c == '\x1b':
+			if i+1 == len(b) {
+				return 0
+			}
+			if b[i+1] == '\\' {
+				return i + 2
+			}
+			return i
+		case c == '\x1b' || c < ' ':
+			return interrupt(i)
+		case state == seqString:
+			continue
+		case c <= '/':
+			// intermediate bytes
+			continue
+		case c <= '?' && state != seqEscape:
+			// parameter bytes (misplaced ones are ignored too)
+			continue
+		case c <= '~':
+			return i + 1
+		case state == seqEscape:
+			return interrupt(i)
+		}
+		// DEL, and bytes above ASCII, are otherwise ignored
+	}
+	return 0
+}
+
+// Terminals set up for 8-bit controls send the C1 controls, such as CSI
+// (0x9B), in place of the ESC sequences that stand for them (ESC [).
+// When the character set is UTF-8, some send them as bytes, which are not
+// otherwise valid there, and others encode them, as U+009B and so on.
+// In the ISO 8859 character sets they are just bytes.  The parsers only
+// look for the ESC forms, so scanInput converts the introducers as they
+// come, and discards any other C1 controls, which are no use as input.
+
+// c1Introducers are the C1 controls that start sequences.
+var c1Introducers = map[byte]bool{
+	0x8f: true, // SS3
+	0x90: true, // DCS
+	0x98: true, // SOS
+	0x9b: true, // CSI
+	0x9d: true, // OSC
+	0x9e: true, // PM
+	0x9f: true, // APC
+}
+
+// c1Control returns the C1 control at the start of b, and how many bytes
+// it takes, or zero if b does not start with one.  In character sets
+// other than UTF-8, a byte is a C1 control if it decodes to one.  (Not in
+// US-ASCII, where the high bit stands for Alt.)
+func (ip *InputParser) c1Control(b []byte) (byte, int) {
+	switch {
+	case len(b) == 0 || b[0] < 0x80:
+	case ip.charset == "UTF-8" && b[0] <= 0x9f:
+		return b[0], 1
+	case ip.charset == "UTF-8" && b[0] == 0xc2 && len(b) > 1 &&
+		b[1] >= 0x80 && b[1] <= 0x9f:
+		return b[1], 2
+	case ip.decoder != nil && b[0] <= 0x9f:
+		var out [utf8.UTFMax]byte
+		ip.decoder.Reset()
+		n, _, e := ip.decoder.Transform(out[:], b[:1], true)
+		if r, _ := utf8.DecodeRune(out[:n]); e == nil && r == rune(b[0]) {
+			return b[0], 1
+		}
+	}
+	return 0, 0
+}
+
+// convertC1 replaces the C1 control at the start of the buffer, if any,
+// with its ESC form, or discards it if it does not start a sequence.
+func (ip *InputParser) convertC1(buf *bytes.Buffer) {
+	c, n := ip.c1Control(buf.Bytes())
+	if n == 0 {
+		return
+	}
+	rest := buf.Bytes()[n:]
+	var b []byte
+	if c1Introducers[c] {
+		b = append(b, '\x1b', c-0x40)
+	}
+	b = append(b, rest...)
+	buf.Reset()
+	buf.Write(b)
+}
+
+// vtParser splits output meant for a terminal, as the Terminal and the
+// RegionWriter are given, into characters and control sequences.  It
+// finds where sequences end with sequenceLength, so that output is parsed
+// by the same state machine as input.  What is left over at the end of a
+// write, a partial character or sequence, is kept for the next one.
+type vtParser struct {
+	pend []byte
+
+	// raw, if set, is given the bytes that are not given to putRune:
+	// those of sequences (whether or not the handler is told of them),
+	// of stray ESCs, and of C1 controls.
+	raw func(b []byte)
+}
+
+// vtHandler is told what a vtParser finds.
+type vtHandler interface {
+	// putRune is given characters, and the C0 controls other than ESC.
+	putRune(r rune)
+
+	// escape is given ESC sequences, other than those that introduce
+	// control sequences and strings, as their intermediate bytes and
+	// their final byte.
+	escape(inter string, final byte)
+
+	// csi is given control sequences, as their final byte, and the
+	// parameter and intermediate bytes before it.
+	csi(final byte, params string)
+
+	// osc is given operating system commands, without the introducer
+	// and the terminator.
+	osc(s string)
+}
+
+// parse parses b, telling h what it finds.
+func (p *vtParser) parse(b []byte, h vtHandler) {
+	if len(p.pend) > 0 {
+		b = append(p.pend, b...)
+		p.pend = nil
+	}
+	for len(b) > 0 {
+		if b[0] == '\x1b' {
+			n := vtSequenceLength(b)
+			if n == 0 {
+				break
+			}
+			if n < 0 {
+				// interrupted at once, so just a stray ESC
+				n = 1
+			} else {
+				p.dispatch(b[:n], h)
+			}
+			if p.raw != nil {
+				p.raw(b[:n])
+			}
+			b = b[n:]
+			continue
+		}
+		if b[0] >= 0x80 && !utf8.FullRune(b) {
+			break
+		}
+		r, l := utf8.DecodeRune(b)
+		if r >= 0x80 && r < 0xa0 {
+			// C1 controls, which programs do not send as such
+			if p.raw != nil {
+				p.raw(b[:l])
+			}
+			b = b[l:]
+			continue
+		}
+		b = b[l:]
+		h.putRune(r)
+	}
+	if len(b) > 0 {
+		p.pend = append([]byte(nil), b...)
+	}
+}
+
+// vtSequenceLength is sequenceLength for output.  There, the introducer
+// of a string may come at the end of a write, with the string itself in
+// the next, where in input it would be a key pressed with Alt.
+func vtSequenceLength(b []byte) int {
+	if len(b) == 2 && bytes.IndexByte([]byte("]PX^_"), b[1]) >= 0 {
+		return 0
+	}
+	return sequenceLength(b)
+}
+
+// dispatch tells h about the complete sequence b.  Sequences that were
+// cancelled, or interrupted before their final byte, are discarded, as
+// are strings other than OSC.
+func (p *vtParser) dispatch(b []byte, h vtHandler) {
+	state, start, _ := sequenceIntroducer(b)
+	last := b[len(b)-1]
+	switch state {
+	case seqEscape:
+		if len(b) > 1 && last >= '0' && last <= '~' {
+			h.escape(string(b[1:len(b)-1]), last)
+		}
+	case seqCsi:
+		if last >= '@' && last <= '~' {
+			h.csi(last, string(b[start:len(b)-1]))
+		}
+	case seqString:
+		if b[1] != ']' || last == '\x18' || last == '\x1a' {
+			return
+		}
+		s := b[start:]
+		switch {
+		case bytes.HasSuffix(s, []byte("\x1b\\")),
+			bytes.HasSuffix(s, []byte("\xc2\x9c")):
+			s = s[:len(s)-2]
+		case last == '\a' || last == '\x9c':
+			s = s[:len(s)-1]
+		}
+		// (An OSC that is interrupted by ESC ends there too.)
+		h.osc(string(s))
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+)
+
+// Parity selects the parity bit of a serial line.
+type Parity int
+
+const (
+	ParityNone Parity = iota
+	ParityEven
+	ParityOdd
+)
+
+// FlowControl selects the flow control of a serial line.
+type FlowControl int
+
+const (
+	FlowNone     FlowControl = iota
+	FlowXonXoff              // software flow control, with ^S and ^Q
+	FlowHardware             // RTS/CTS
+)
+
+// SerialConfig describes a physically attached terminal, and the serial
+// line that it is attached to.  (See NewSerialScreen.)
+type SerialConfig struct {
+	// Device is the path of the serial device, such as /dev/ttyUSB0.
+	Device string
+
+	// Term is the terminal type, such as "vt100" or "wy50".  It is
+	// required, since $TERM describes our own terminal, if any.
+	Term string
+
+	// Baud is the speed of the line, such as 9600.  Only the speeds
+	// that the system supports can be used.
+	Baud int
+
+	// DataBits is 7 or 8.  Zero means 8.
+	DataBits int
+
+	// Parity is the parity bit, if any.
+	Parity Parity
+
+	// StopBits is 1 or 2.  Zero means 1.
+	StopBits int
+
+	// Flow is the flow control.
+	Flow FlowControl
+
+	// Charset is the character set of the terminal.  Empty means
+	// US-ASCII, which is what most hardware terminals understand.
+	Charset string
+}
+
+// ErrSerialConfig is returned by NewSerialScreen if the configuration is
+// not valid.
+var ErrSerialConfig = errors.New("invalid serial configuration")
+
+// NewSerialScreen returns a Screen for a terminal attached to a serial
+// line, for embedded and kiosk applications that drive a terminal of
+// their own.  Init opens the device (without it becoming the controlling
+// terminal of the process, and without waiting for carrier), and sets
+// the line up as configured; Fini restores the settings that it had.
+// An error is returned by Init if the device cannot be opened, or if
+// the speed or flow control is not supported.  This requires termios,
+// so it is not available on Windows.
+//
+// Serial lines do not report the size of the terminal, so that comes
+// from the terminal description, and there are no resize events.  The
+// $TCELL_* settings that apply to NewTerminfoScreen apply here too, but
+// $TERM, $LINES, $COLUMNS, $TCELL_CHARSET and the locale do not.
+func NewSerialScreen(cfg SerialConfig) (Screen, error) {
+	switch {
+	case cfg.Device == "" || cfg.Baud <= 0:
+		return nil, ErrSerialConfig
+	case cfg.DataBits != 0 && cfg.DataBits != 7 && cfg.DataBits != 8:
+		return nil, ErrSerialConfig
+	case cfg.StopBits != 0 && cfg.StopBits != 1 && cfg.StopBits != 2:
+		return nil, ErrSerialConfig
+	case cfg.Parity < ParityNone || cfg.Parity > ParityOdd:
+		return nil, ErrSerialConfig
+	case cfg.Flow < FlowNone || cfg.Flow > FlowHardware:
+		return nil, ErrSerialConfig
+	}
+	ti, e := LookupTerminfo(cfg.Term)
+	if e != nil {
+		return nil, e
+	}
+	ti = overrideTerminfo(ti)
+	if cfg.Charset == "" {
+		cfg.Charset = "US-ASCII"
+	}
+	t := newTScreen(ti, ti.Columns, ti.Lines)
+	t.serial = &cfg
+	return t, nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+// ResizeScreen is implemented by Screens that can ask for the size of
+// the terminal window to be changed, for example so that a tool can get
+// a sane minimum size without the user having to resize the window.
+// The terminfo based screen asks with the xterm window manipulation
+// sequence (XTWINOPS, CSI 8 ; rows ; columns t), and the Windows console
+// screen resizes the console window and its buffer.
+//
+// Many terminals ignore the request, whether because they are tiled, are
+// full screen, or do not allow it (xterm itself only does so when its
+// allowWindowOps resource is set).  So applications must not count on
+// it: the size only changes if, and when, an EventResize says so.
+type ResizeScreen interface {
+	// SetSize asks for the screen to be w columns by h rows.
+	SetSize(w, h int)
+
+	Screen
+}
+
+// The xterm sequence to resize the window, given rows and columns.
+const setSizeString = "\x1b[8;%d;%dt"
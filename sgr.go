@@ -0,0 +1,209 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The simple way to change from one style to another is to turn off all
+// attributes, and then turn on those of the new style, which is all that
+// terminfo offers.  But styled interfaces change style very often, and
+// usually only a little, such as the color alone.  On terminals that use
+// ANSI (ECMA-48) SGR sequences, which is nearly all of them, we can turn
+// off individual attributes too, and so only send the difference.  We
+// send whichever of the two is shorter.
+//
+// Setting the TCELL_FULL_SGR environment variable to 1 always uses the
+// simple way, for terminals that misbehave.
+
+// These are the ECMA-48 SGR sequences to turn off single attributes.
+const (
+	sgrNormal      = "\x1b[22m" // neither bold nor dim
+	sgrNoUnderline = "\x1b[24m"
+	sgrNoBlink     = "\x1b[25m"
+	sgrNoReverse   = "\x1b[27m"
+	sgrDefaultFg   = "\x1b[39m"
+	sgrDefaultBg   = "\x1b[49m"
+	sgrDefaultUl   = "\x1b[59m"
+)
+
+// sgr returns the output that changes the attributes of the terminal from
+// one style to another.
+// Both candidates are built in scratch buffers kept by the screen, as
+// this is done for nearly every cell drawn.
+func (t *tScreen) sgr(from, to Style) string {
+	t.sgrFull(to)
+	if t.sgrDiff(from, to) && t.sgrdiff.Len() < t.sgrfull.Len() {
+		return t.sgrdiff.String()
+	}
+	return t.sgrfull.String()
+}
+
+// sgrFull resets all of the attributes, and then sets those of the style,
+// leaving the result in t.sgrfull.
+func (t *tScreen) sgrFull(style Style) {
+	ti := t.ti
+	fg, bg, attrs := style.Decompose()
+
+	sb := &t.sgrfull
+	sb.Reset()
+	sb.WriteString(ti.AttrOff)
+	if attrs&AttrBold != 0 {
+		sb.WriteString(ti.Bold)
+	}
+	if attrs&AttrUnderline != 0 {
+		sb.WriteString(t.underline(style))
+		_, uc := style.DecomposeUnderline()
+		sb.WriteString(t.underlineColor(uc))
+	}
+	if attrs&AttrReverse != 0 {
+		sb.WriteString(ti.Reverse)
+	}
+	if attrs&AttrBlink != 0 {
+		sb.WriteString(ti.Blink)
+	}
+	if attrs&AttrDim != 0 {
+		sb.WriteString(ti.Dim)
+	}
+	if fg != ColorDefault {
+		sb.WriteString(t.setFg(fg))
+	}
+	if bg != ColorDefault {
+		sb.WriteString(t.setBg(bg))
+	}
+}
+
+// sgrDiff leaves the output that changes only what differs between the
+// styles in t.sgrdiff.  It returns false if that cannot be done, because
+// the terminal does not use ANSI sequences, or the current style is not
+// known.
+func (t *tScreen) sgrDiff(from, to Style) bool {
+	ti := t.ti
+	if t.fullsgr || from == styleInvalid || !ansiSGR(ti) {
+		return false
+	}
+	ffg, fbg, fattrs := from.Decompose()
+	tfg, tbg, tattrs := to.Decompose()
+	fus, fuc := from.DecomposeUnderline()
+	tus, tuc := to.DecomposeUnderline()
+	off := fattrs &^ tattrs
+	on := tattrs &^ fattrs
+
+	sb := &t.sgrdiff
+	sb.Reset()
+	if off&(AttrBold|AttrDim) != 0 {
+		// This turns off both, so restore the one that stays.
+		sb.WriteString(sgrNormal)
+		on |= tattrs & (AttrBold | AttrDim)
+	}
+	if off&AttrUnderline != 0 {
+		sb.WriteString(sgrNoUnderline)
+		// Keep the underline color at the default while there is
+		// no underline, as it would be after AttrOff.
+		if fuc != ColorDefault && ti.SetUlColor != "" {
+			sb.WriteString(sgrDefaultUl)
+		}
+	}
+	if off&AttrBlink != 0 {
+		sb.WriteString(sgrNoBlink)
+	}
+	if off&AttrReverse != 0 {
+		sb.WriteString(sgrNoReverse)
+	}
+	if on&AttrBold != 0 {
+		sb.WriteString(ti.Bold)
+	}
+	if on&AttrDim != 0 {
+		sb.WriteString(ti.Dim)
+	}
+	if on&AttrReverse != 0 {
+		sb.WriteString(ti.Reverse)
+	}
+	if on&AttrBlink != 0 {
+		sb.WriteString(ti.Blink)
+	}
+	switch {
+	case on&AttrUnderline != 0:
+		sb.WriteString(t.underline(to))
+		sb.WriteString(t.underlineColor(tuc))
+	case tattrs&AttrUnderline != 0:
+		if fus != tus {
+			sb.WriteString(t.underline(to))
+		}
+		if fuc != tuc && ti.SetUlColor != "" {
+			if tuc == ColorDefault {
+				sb.WriteString(sgrDefaultUl)
+			} else {
+				sb.WriteString(t.underlineColor(tuc))
+			}
+		}
+	}
+	if tfg != ffg {
+		if tfg == ColorDefault {
+			sb.WriteString(sgrDefaultFg)
+		} else {
+			sb.WriteString(t.setFg(tfg))
+		}
+	}
+	if tbg != fbg {
+		if tbg == ColorDefault {
+			sb.WriteString(sgrDefaultBg)
+		} else {
+			sb.WriteString(t.setBg(tbg))
+		}
+	}
+	return true
+}
+
+// ansiSGR reports whether the terminal uses ANSI SGR sequences, and so
+// understands those that turn off single attributes.  We take setting
+// colors with CSI, and resetting attributes with CSI m (or CSI 0 m), as
+// the sign.
+func ansiSGR(ti *Terminfo) bool {
+	if !strings.HasPrefix(ti.SetFg, "\x1b[") {
+		return false
+	}
+	return strings.Contains(ti.AttrOff, "\x1b[m") ||
+		strings.Contains(ti.AttrOff, "\x1b[0m") ||
+		strings.Contains(ti.AttrOff, "\x1b[0;")
+}
+
+// underline returns the output that starts an underline, in the style
+// of underline requested, if the terminal supports it.
+func (t *tScreen) underline(style Style) string {
+	ti := t.ti
+	if us, _ := style.DecomposeUnderline(); us != UnderlineSingle &&
+		ti.UnderlineX != "" {
+		return ti.TParm(ti.UnderlineX, int(us))
+	}
+	return ti.Underline
+}
+
+// underlineColor returns the output that sets the underline color, if
+// the terminal supports it.  Terminals that advertise Setulc all accept
+// both the indexed and the RGB forms of SGR 58, which suit our colors
+// better than the packed RGB value that Setulc itself takes.
+func (t *tScreen) underlineColor(c Color) string {
+	if c == ColorDefault || t.ti.SetUlColor == "" {
+		return ""
+	}
+	if c.IsRGB() {
+		r, g, b := c.RGB()
+		return fmt.Sprintf("\x1b[58:2::%d:%d:%dm", r, g, b)
+	}
+	return fmt.Sprintf("\x1b[58:5:%dm", int(c)-1)
+}
@@ -16,6 +16,9 @@ package tcell
 
 import (
 	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -149,3 +152,367 @@ func TestResize(t *testing.T) {
 		})
 	}))
 }
+
+func TestSoftwareBlink(t *testing.T) {
+	st := StyleDefault.Blink(true)
+	Convey("Software blink", t, WithScreen(t, "", func(s SimulationScreen) {
+		s.SetCell(3, 3, st, '*')
+		s.(BlinkScreen).EnableBlink(time.Hour)
+		s.Show()
+		b, _, _ := s.GetContents()
+		So(b[3*80+3].Runes[0], ShouldEqual, '*')
+
+		sim := s.(*simscreen)
+		sim.blink(sim.blinkq)
+		So(b[3*80+3].Runes[0], ShouldEqual, ' ')
+		sim.blink(sim.blinkq)
+		So(b[3*80+3].Runes[0], ShouldEqual, '*')
+
+		Convey("Disabled blink is steady", func() {
+			sim.blink(sim.blinkq)
+			s.(BlinkScreen).DisableBlink()
+			s.Show()
+			So(b[3*80+3].Runes[0], ShouldEqual, '*')
+		})
+
+		Convey("The timer runs only while cells blink", func() {
+			So(sim.blinkrun, ShouldBeTrue)
+			sim.blink(sim.blinkq)
+			s.SetCell(3, 3, StyleDefault, '*')
+			s.Show()
+			So(sim.blink(sim.blinkq), ShouldBeFalse)
+			So(sim.blinkrun, ShouldBeFalse)
+			So(sim.blinkoff, ShouldBeFalse)
+
+			s.SetCell(4, 3, st, '*')
+			s.Show()
+			So(sim.blinkrun, ShouldBeTrue)
+			So(b[3*80+4].Runes[0], ShouldEqual, '*')
+		})
+	}))
+}
+
+func TestInvisible(t *testing.T) {
+	st := StyleDefault.Background(ColorBlue).Invisible(true)
+	Convey("Invisible cells are blank", t, WithScreen(t, "", func(s SimulationScreen) {
+		s.SetCell(3, 3, st, '*')
+		s.Show()
+		b, _, _ := s.GetContents()
+		So(b[3*80+3].Runes, ShouldResemble, []rune{' '})
+		So(b[3*80+3].Style, ShouldEqual, st)
+		So(s.GetCell(3, 3).Ch, ShouldResemble, []rune{'*'})
+
+		s.SetCell(3, 3, st.Invisible(false), '*')
+		s.Show()
+		So(b[3*80+3].Runes, ShouldResemble, []rune{'*'})
+	}))
+}
+
+func TestMouseClicks(t *testing.T) {
+	Convey("Mouse clicks", t, WithScreen(t, "", func(s SimulationScreen) {
+		click := func(x, y int, btn ButtonMask) int {
+			s.InjectMouse(x, y, btn, ModNone)
+			ev := s.PollEvent().(*EventMouse)
+			s.InjectMouse(x, y, ButtonNone, ModNone)
+			So(s.PollEvent().(*EventMouse).Clicks(), ShouldEqual, 0)
+			return ev.Clicks()
+		}
+
+		Convey("Successive clicks are counted", func() {
+			So(click(5, 5, Button1), ShouldEqual, 1)
+			So(click(5, 5, Button1), ShouldEqual, 2)
+			So(click(5, 5, Button1), ShouldEqual, 3)
+		})
+
+		Convey("Moving or changing buttons starts over", func() {
+			So(click(5, 5, Button1), ShouldEqual, 1)
+			So(click(6, 5, Button1), ShouldEqual, 1)
+			So(click(6, 5, Button3), ShouldEqual, 1)
+			So(click(6, 5, Button3), ShouldEqual, 2)
+		})
+
+		Convey("Slow clicks are single", func() {
+			save := DoubleClickInterval
+			DoubleClickInterval = 0
+			Reset(func() { DoubleClickInterval = save })
+			So(click(5, 5, Button1), ShouldEqual, 1)
+			time.Sleep(time.Millisecond)
+			So(click(5, 5, Button1), ShouldEqual, 1)
+		})
+	}))
+}
+
+func TestRenderStats(t *testing.T) {
+	Convey("Render statistics", t, WithScreen(t, "", func(s SimulationScreen) {
+		rs := s.(RenderStatsScreen)
+		rs.EnableRenderStats(true)
+		s.Show()
+		s.SetCell(1, 1, StyleDefault, 'A')
+		s.SetCell(2, 1, StyleDefault, 'B')
+		s.Show()
+		st := rs.RenderStats()
+		So(st.Frames, ShouldEqual, 2)
+		So(st.LastCells, ShouldEqual, 2)
+
+		Convey("Budget overruns are counted", func() {
+			rs.SetAllocBudget(1)
+			s.Sync()
+			st = rs.RenderStats()
+			So(st.LastCells, ShouldEqual, 80*25)
+			So(st.LastAllocs, ShouldBeGreaterThan, 1)
+			So(st.OverBudget, ShouldEqual, 1)
+		})
+
+		Convey("Unchanged cells are not drawn again", func() {
+			s.Sync()
+			s.Clear()
+			s.SetCell(1, 1, StyleDefault, 'A')
+			s.SetCell(2, 1, StyleDefault, 'C')
+			s.Show()
+			So(rs.RenderStats().LastCells, ShouldEqual, 1)
+			s.Clear()
+			s.SetCell(1, 1, StyleDefault, 'A')
+			s.SetCell(2, 1, StyleDefault, 'C')
+			s.Show()
+			So(rs.RenderStats().LastCells, ShouldEqual, 0)
+		})
+
+		Convey("Disabled statistics stay put", func() {
+			rs.EnableRenderStats(false)
+			s.Show()
+			So(rs.RenderStats().Frames, ShouldEqual, 0)
+		})
+	}))
+}
+
+func TestFeatures(t *testing.T) {
+	Convey("Feature report", t, WithScreen(t, "", func(s SimulationScreen) {
+		features := func() map[string]Feature {
+			m := make(map[string]Feature)
+			list := s.(FeatureScreen).Features()
+			So(len(list), ShouldEqual, len(FeatureNames))
+			for i, f := range list {
+				So(f.Name, ShouldEqual, FeatureNames[i])
+				m[f.Name] = f
+			}
+			return m
+		}
+		fs := features()
+		So(fs[FeatureColor].Active, ShouldBeTrue)
+		So(fs[FeatureTrueColor].Active, ShouldBeFalse)
+		So(fs[FeaturePaste].Mode, ShouldEqual, "unsupported")
+		So(fs[FeatureMouse].Active, ShouldBeFalse)
+
+		s.EnableMouse()
+		So(features()[FeatureMouse].Active, ShouldBeTrue)
+	}))
+}
+
+func TestEventFilter(t *testing.T) {
+	Convey("Event filters can redraw inline", t, WithScreen(t, "", func(s SimulationScreen) {
+		fs, ok := s.(FilterScreen)
+		So(ok, ShouldBeTrue)
+		resized := false
+		fs.SetEventFilter(func(ev Event) bool {
+			switch ev := ev.(type) {
+			case *EventKey:
+				if ev.Rune() != 'a' {
+					return false
+				}
+				s.SetCell(0, 0, StyleDefault, 'A')
+				s.Show()
+				return true
+			case *EventResize:
+				resized = true
+				s.Sync()
+			}
+			return false
+		})
+
+		s.InjectKey(KeyRune, 'a', ModNone)
+		s.PostEvent(NewEventResize(80, 25))
+		s.InjectKey(KeyRune, 'b', ModNone)
+
+		ev := s.PollEvent()
+		_, ok = ev.(*EventResize)
+		So(ok, ShouldBeTrue)
+		So(resized, ShouldBeTrue)
+		b, _, _ := s.GetContents()
+		So(b[0].Runes[0], ShouldEqual, 'A')
+
+		ev = s.PollEvent()
+		So(ev.(*EventKey).Rune(), ShouldEqual, 'b')
+
+		Convey("Fini from a filter stops PollEvent", func() {
+			fs.SetEventFilter(func(Event) bool {
+				s.Fini()
+				return true
+			})
+			s.InjectKey(KeyRune, 'c', ModNone)
+			So(s.PollEvent(), ShouldBeNil)
+		})
+	}))
+}
+
+func TestRedrawEvents(t *testing.T) {
+	Convey("Redraw events", t, WithScreen(t, "", func(s SimulationScreen) {
+		rs, ok := s.(RedrawScreen)
+		So(ok, ShouldBeTrue)
+		region := func() [4]int {
+			ev, ok := s.PollEvent().(*EventRedraw)
+			So(ok, ShouldBeTrue)
+			x, y, w, h := ev.Region()
+			return [4]int{x, y, w, h}
+		}
+
+		rs.EnableRedrawEvents(true)
+		So(region(), ShouldResemble, [4]int{0, 0, 80, 25})
+
+		Convey("Growing exposes new strips", func() {
+			s.Resize(90, 30)
+			s.Show()
+			_, ok := s.PollEvent().(*EventResize)
+			So(ok, ShouldBeTrue)
+			So(region(), ShouldResemble, [4]int{80, 0, 10, 30})
+			So(region(), ShouldResemble, [4]int{0, 25, 80, 5})
+		})
+
+		Convey("Shrinking exposes nothing", func() {
+			s.Resize(70, 20)
+			s.Show()
+			_, ok := s.PollEvent().(*EventResize)
+			So(ok, ShouldBeTrue)
+			s.Sync()
+			So(region(), ShouldResemble, [4]int{0, 0, 70, 20})
+		})
+
+		Convey("Turned off", func() {
+			rs.EnableRedrawEvents(false)
+			s.Sync()
+			s.PostEvent(NewEventInterrupt(nil))
+			_, ok := s.PollEvent().(*EventInterrupt)
+			So(ok, ShouldBeTrue)
+		})
+	}))
+}
+
+func TestIdleEvents(t *testing.T) {
+	Convey("Idle events", t, WithScreen(t, "", func(s SimulationScreen) {
+		is, ok := s.(IdleScreen)
+		So(ok, ShouldBeTrue)
+		is.SetIdleTimeout(20 * time.Millisecond)
+
+		start := time.Now()
+		ev, ok := s.PollEvent().(*EventIdle)
+		So(ok, ShouldBeTrue)
+		So(time.Since(start) >= 20*time.Millisecond, ShouldBeTrue)
+		So(ev.LastInput().Before(start), ShouldBeTrue)
+
+		Convey("Posted events are not input", func() {
+			s.PostEvent(NewEventInterrupt(nil))
+			_, ok := s.PollEvent().(*EventInterrupt)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Input ends the idle spell", func() {
+			s.InjectKey(KeyRune, 'a', ModNone)
+			_, ok := s.PollEvent().(*EventActive)
+			So(ok, ShouldBeTrue)
+			_, ok = s.PollEvent().(*EventKey)
+			So(ok, ShouldBeTrue)
+			_, ok = s.PollEvent().(*EventIdle)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Turned off", func() {
+			is.SetIdleTimeout(0)
+			s.InjectKey(KeyRune, 'a', ModNone)
+			_, ok := s.PollEvent().(*EventKey)
+			So(ok, ShouldBeTrue)
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				s.PostEvent(NewEventInterrupt(nil))
+			}()
+			_, ok = s.PollEvent().(*EventInterrupt)
+			So(ok, ShouldBeTrue)
+		})
+	}))
+}
+
+func TestSimSetSize(t *testing.T) {
+	Convey("Simulated resize requests", t, WithScreen(t, "", func(s SimulationScreen) {
+		rs, ok := s.(ResizeScreen)
+		So(ok, ShouldBeTrue)
+		rs.SetSize(100, 40)
+		s.Show()
+		ev, ok := s.PollEvent().(*EventResize)
+		So(ok, ShouldBeTrue)
+		w, h := ev.Size()
+		So(w, ShouldEqual, 100)
+		So(h, ShouldEqual, 40)
+	}))
+}
+
+func TestContents(t *testing.T) {
+	Convey("Contents snapshot", t, WithScreen(t, "", func(s SimulationScreen) {
+		s.Resize(6, 2)
+		s.Show()
+		s.SetCell(0, 0, StyleDefault.Bold(true), 'h')
+		s.SetCell(1, 0, StyleDefault, 'i')
+		s.SetCell(2, 0, StyleDefault, '世')
+		s.SetCell(4, 0, StyleDefault, 'e', '\u0301')
+		s.ShowCursor(1, 1)
+
+		c := s.(ContentsScreen).Contents()
+		So(c.Width, ShouldEqual, 6)
+		So(c.Height, ShouldEqual, 2)
+		So(c.String(), ShouldEqual, "hi世e\u0301 \n      \n")
+		So(c.Cell(0, 0).Style, ShouldEqual, StyleDefault.Bold(true))
+		So(c.Cell(6, 0), ShouldBeNil)
+		So([]int{c.CursorX, c.CursorY}, ShouldResemble, []int{1, 1})
+
+		Convey("It is a copy", func() {
+			s.SetCell(0, 0, StyleDefault, 'x')
+			So(c.Row(0), ShouldStartWith, "hi")
+			So(s.(ContentsScreen).Contents().Row(0), ShouldStartWith, "xi")
+		})
+
+		Convey("A hidden cursor is reported as such", func() {
+			s.HideCursor()
+			c := s.(ContentsScreen).Contents()
+			So([]int{c.CursorX, c.CursorY}, ShouldResemble, []int{-1, -1})
+		})
+	}))
+}
+
+func TestRegisterEncoding(t *testing.T) {
+	Convey("Character sets", t, func() {
+		Convey("UTF-8 is known by other names", func() {
+			s := NewSimulationScreen("utf8")
+			So(s.Init(), ShouldBeNil)
+			So(s.CharacterSet(), ShouldEqual, "UTF-8")
+			s.Fini()
+		})
+
+		Convey("Unknown character sets fail", func() {
+			s := NewSimulationScreen("x-tcell-test")
+			So(s.Init(), ShouldNotBeNil)
+		})
+
+		Convey("Registered encodings are used", func() {
+			RegisterEncoding("X-TCELL-TEST", charmap.CodePage437)
+			Reset(func() {
+				RegisterEncoding("X-TCELL-TEST", nil)
+			})
+			So(GetEncoding("x_tcell_test"), ShouldEqual, charmap.CodePage437)
+
+			s := NewSimulationScreen("x-tcell-test")
+			So(s.Init(), ShouldBeNil)
+			s.SetCell(0, 0, StyleDefault, '░')
+			s.Show()
+			b, _, _ := s.GetContents()
+			So(b[0].Bytes, ShouldResemble, []byte{0xb0})
+			s.Fini()
+		})
+	})
+}
@@ -17,6 +17,7 @@ package tcell
 import (
 	"errors"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"golang.org/x/text/transform"
@@ -25,10 +26,7 @@ import (
 // NewSimulationScreen returns a SimulationScreen.  Note that
 // SimulationScreen is also a Screen.
 func NewSimulationScreen(charset string) SimulationScreen {
-	if charset == "" {
-		charset = "UTF-8"
-	}
-	s := &simscreen{charset: charset}
+	s := &simscreen{charset: canonicalCharset(charset)}
 	return s
 }
 
@@ -84,16 +82,19 @@ type SimCell struct {
 }
 
 type simscreen struct {
-	logw  int
-	logh  int
-	physw int
-	physh int
-	style Style
-	evch  chan Event
-	quit  chan struct{}
+	logw   int
+	logh   int
+	physw  int
+	physh  int
+	style  Style
+	evch   chan Event
+	filter eventFilter
+	redraw redrawer
+	quit   chan struct{}
 
 	front     []SimCell
-	back      []Cell
+	back      cellGrid
+	drawc     Cell // scratch space for drawing cells
 	clear     bool
 	cursorx   int
 	cursory   int
@@ -104,12 +105,19 @@ type simscreen struct {
 	decoder   transform.Transformer
 	fillchar  rune
 	fillstyle Style
+	blinkoff  bool
+	blinkq    chan struct{}
+	blinkdur  time.Duration
+	blinkrun  bool
+	click     clickTracker
+	stats     renderStats
 
 	sync.Mutex
 }
 
 func (s *simscreen) Init() error {
 	s.evch = make(chan Event, 10)
+	s.quit = make(chan struct{})
 	s.fillchar = 'X'
 	s.fillstyle = StyleDefault
 	s.mouse = false
@@ -135,21 +143,29 @@ func (s *simscreen) Init() error {
 	}
 
 	s.front = make([]SimCell, s.physw*s.physh)
-	s.back = ResizeCells(nil, 0, 0, s.logw, s.logh)
+	s.back.resize(s.logw, s.logh)
 
 	return nil
 }
 
 func (s *simscreen) Fini() {
+	s.DisableBlink()
+	s.Lock()
 	if s.quit != nil {
-		close(s.quit)
+		select {
+		case <-s.quit:
+			// already finalized
+		default:
+			close(s.quit)
+		}
 	}
 	s.logw = 0
 	s.logh = 0
 	s.physw = 0
 	s.physh = 0
 	s.front = nil
-	s.back = nil
+	s.back = cellGrid{}
+	s.Unlock()
 }
 
 func (s *simscreen) SetStyle(style Style) {
@@ -161,7 +177,7 @@ func (s *simscreen) SetStyle(style Style) {
 func (s *simscreen) Clear() {
 
 	s.Lock()
-	ClearCells(s.back, s.style)
+	s.back.clear(s.style)
 	s.Unlock()
 }
 
@@ -172,8 +188,7 @@ func (s *simscreen) SetCell(x, y int, style Style, ch ...rune) {
 		s.Unlock()
 		return
 	}
-	cell := &s.back[(y*s.logw)+x]
-	cell.SetCell(ch, style)
+	s.back.put((y*s.logw)+x, ch, style)
 	s.Unlock()
 }
 
@@ -183,9 +198,13 @@ func (s *simscreen) PutCell(x, y int, cell *Cell) {
 		s.Unlock()
 		return
 	}
-	cp := &s.back[(y*s.logw)+x]
-	cp.PutStyle(cell.Style)
-	cp.PutChars(cell.Ch)
+	s.back.put((y*s.logw)+x, cell.Ch, cell.Style)
+	s.Unlock()
+}
+
+func (s *simscreen) PutCells(x, y, w, h int, cells []Cell) {
+	s.Lock()
+	s.back.putCells(x, y, w, h, cells, nil)
 	s.Unlock()
 }
 
@@ -195,9 +214,15 @@ func (s *simscreen) GetCell(x, y int) *Cell {
 		s.Unlock()
 		return nil
 	}
-	cell := s.back[(y*s.logw)+x]
+	cell := s.back.cell((y * s.logw) + x)
 	s.Unlock()
-	return &cell
+	return cell
+}
+
+func (s *simscreen) Contents() *Contents {
+	s.Lock()
+	defer s.Unlock()
+	return s.back.contents(s.cursorx, s.cursory)
 }
 
 func (s *simscreen) drawCell(x, y int, cell *Cell) {
@@ -213,6 +238,16 @@ func (s *simscreen) drawCell(x, y int, cell *Cell) {
 	simc.Runes = nil
 	simc.Runes = append(simc.Runes, cell.Ch...)
 
+	if s.blinkq != nil && isBlink(simc.Style) {
+		s.startBlink()
+	}
+	if isInvisible(simc.Style) ||
+		(s.blinkq != nil && s.blinkoff && isBlink(simc.Style)) {
+		simc.Runes = []rune{' '}
+		simc.Bytes = []byte{' '}
+		return
+	}
+
 	// now emit runes - taking care to not overrun width with a
 	// wide character, and to ensure that we emit exactly one regular
 	// character followed up by any residual combing characters
@@ -299,6 +334,9 @@ func (s *simscreen) clearScreen() {
 }
 
 func (s *simscreen) draw() {
+	s.stats.begin(&s.back, s.clear)
+	defer s.stats.end()
+
 	// hide the cursor while we move stuff around
 	s.hideCursor()
 
@@ -308,15 +346,22 @@ func (s *simscreen) draw() {
 
 	for row := 0; row < s.logh; row++ {
 		for col := 0; col < s.logw; col++ {
-			cell := &s.back[(row*s.logw)+col]
-			if !cell.Dirty {
+			i := (row * s.logw) + col
+			if !s.back.dirty[i] {
+				if s.back.width[i] > 1 {
+					// leave the cell it covers alone
+					col++
+				}
 				continue
 			}
-			s.drawCell(col, row, cell)
-			if cell.Width > 1 {
+			s.back.load(i, &s.drawc)
+			s.drawCell(col, row, &s.drawc)
+			if s.drawc.Width > 1 && col+1 < s.logw {
+				s.back.drawnWide(i)
 				col++
+			} else {
+				s.back.drawn(i)
 			}
-			cell.Dirty = false
 		}
 	}
 
@@ -324,12 +369,87 @@ func (s *simscreen) draw() {
 	s.showCursor()
 }
 
-func (s *simscreen) EnableMouse() {
+func (s *simscreen) EnableMouse(...MouseFlags) {
+	s.Lock()
 	s.mouse = true
+	s.Unlock()
 }
 
 func (s *simscreen) DisableMouse() {
+	s.Lock()
 	s.mouse = false
+	s.Unlock()
+}
+
+func (s *simscreen) EnableBlink(rate time.Duration) {
+	s.Lock()
+	if rate <= 0 {
+		rate = DefaultBlinkRate
+	}
+	if s.blinkq != nil {
+		close(s.blinkq)
+	}
+	s.blinkq = make(chan struct{})
+	s.blinkdur = rate
+	s.blinkrun = false
+	s.blinkoff = false
+	s.back.invalidateBlink(s.style)
+	s.Unlock()
+}
+
+// startBlink starts the software blink loop, when a blinking cell is
+// drawn, unless it is already running.
+func (s *simscreen) startBlink() {
+	if s.blinkrun {
+		return
+	}
+	s.blinkrun = true
+	q := s.blinkq
+	go blinkLoop(s.blinkdur, q, func() bool { return s.blink(q) })
+}
+
+func (s *simscreen) DisableBlink() {
+	s.Lock()
+	if s.blinkq != nil {
+		close(s.blinkq)
+		s.blinkq = nil
+		s.blinkrun = false
+		s.blinkoff = false
+		s.back.invalidateBlink(s.style)
+	}
+	s.Unlock()
+}
+
+func (s *simscreen) blink(q chan struct{}) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.blinkq != q {
+		return false
+	}
+	if !s.back.anyBlink(s.style) {
+		s.blinkrun = false
+		s.blinkoff = false
+		return false
+	}
+	s.blinkoff = !s.blinkoff
+	for row := 0; row < s.logh; row++ {
+		for col := 0; col < s.logw; col++ {
+			i := (row * s.logw) + col
+			style := s.back.style[i]
+			if style == StyleDefault {
+				style = s.style
+			}
+			if s.back.dirty[i] || !isBlink(style) {
+				continue
+			}
+			s.back.load(i, &s.drawc)
+			s.drawCell(col, row, &s.drawc)
+			if s.drawc.Width > 1 {
+				col++
+			}
+		}
+	}
+	return true
 }
 
 func (s *simscreen) Size() (int, int) {
@@ -340,29 +460,59 @@ func (s *simscreen) Size() (int, int) {
 }
 
 func (s *simscreen) resize() {
-	var ev Event
 	w, h := s.physw, s.physh
 	if w != s.logw || h != s.logh {
-		ev = NewEventResize(w, h)
-		s.back = ResizeCells(s.back, s.logw, s.logh, w, h)
+		ow, oh := s.logw, s.logh
+		s.back.resize(w, h)
 		s.logw = w
 		s.logh = h
+		s.PostEvent(NewEventResize(w, h))
+		s.redraw.exposed(s.PostEvent, ow, oh, w, h)
 	}
-	if ev != nil {
-		s.PostEvent(ev)
-	}
+}
+
+func (s *simscreen) ExternalWriter(x, y, width, height int) *RegionWriter {
+	return NewRegionWriter(s, x, y, width, height, StyleDefault)
 }
 
 func (s *simscreen) Colors() int {
 	return 256
 }
 
+func (s *simscreen) HasMouse() bool {
+	return true
+}
+
+// HasKey is true, since any key can be injected.
+func (s *simscreen) HasKey(Key) bool {
+	return true
+}
+
+func (s *simscreen) ColorModel() ColorModel {
+	return ColorModel256
+}
+
+func (s *simscreen) DefaultColors() bool {
+	return true
+}
+
 func (s *simscreen) PollEvent() Event {
-	select {
-	case <-s.quit:
-		return nil
-	case ev := <-s.evch:
-		return ev
+	return s.filter.poll(s.quit, s.evch)
+}
+
+func (s *simscreen) SetEventFilter(f EventFilter) {
+	s.filter.set(f)
+}
+
+func (s *simscreen) SetIdleTimeout(d time.Duration) {
+	s.filter.setIdle(d)
+}
+
+// SetSize acts as a terminal that honors the request would, resizing
+// the physical screen; the new size is seen by the next Show.
+func (s *simscreen) SetSize(w, h int) {
+	if w > 0 && h > 0 {
+		s.Resize(w, h)
 	}
 }
 
@@ -376,6 +526,9 @@ func (s *simscreen) PostEvent(ev Event) {
 
 func (s *simscreen) InjectMouse(x, y int, buttons ButtonMask, mod ModMask) {
 	ev := NewEventMouse(x, y, buttons, mod)
+	s.Lock()
+	s.click.track(ev)
+	s.Unlock()
 	s.PostEvent(ev)
 }
 
@@ -461,15 +614,75 @@ outer:
 	return failed == false
 }
 
+func (s *simscreen) Reinitialize(string) error {
+	s.Sync()
+	return nil
+}
+
 func (s *simscreen) Sync() {
 	s.Lock()
 	s.clear = true
 	s.resize()
-	InvalidateCells(s.back)
+	s.back.invalidate()
 	s.draw()
+	s.redraw.all(s.PostEvent, s.logw, s.logh)
+	s.Unlock()
+}
+
+func (s *simscreen) EnableRedrawEvents(on bool) {
+	s.Lock()
+	s.redraw.on = on
+	s.redraw.all(s.PostEvent, s.logw, s.logh)
 	s.Unlock()
 }
 
+func (s *simscreen) EnableRenderStats(on bool) {
+	s.Lock()
+	s.stats.enable(on)
+	s.Unlock()
+}
+
+func (s *simscreen) RenderStats() RenderStats {
+	s.Lock()
+	defer s.Unlock()
+	return s.stats.stats
+}
+
+func (s *simscreen) SetAllocBudget(allocs uint64) {
+	s.Lock()
+	s.stats.budget = allocs
+	s.Unlock()
+}
+
+func (s *simscreen) Features() []Feature {
+	s.Lock()
+	defer s.Unlock()
+	fs := newFeatureSet()
+	fs.set(FeatureColor, true, "256 colors")
+	fs.set(FeatureTrueColor, false, "256 color palette")
+	fs.set(FeatureBold, true, "")
+	fs.set(FeatureDim, true, "")
+	fs.set(FeatureUnderline, true, "")
+	fs.set(FeatureUnderlineStyles, true, "")
+	fs.set(FeatureUnderlineColor, true, "")
+	fs.set(FeatureReverse, true, "")
+	if s.blinkq != nil {
+		fs.set(FeatureBlink, true, "software")
+	} else {
+		fs.set(FeatureBlink, true, "")
+	}
+	fs.set(FeatureUnicode, s.encoder == nil && s.charset != "US-ASCII",
+		s.charset)
+	fs.set(FeatureLineDrawing, true, "")
+	if s.mouse {
+		fs.set(FeatureMouse, true, "")
+	} else {
+		fs.set(FeatureMouse, false, "off")
+	}
+	fs.set(FeatureResize, true, "")
+	return fs.list()
+}
+
 func (s *simscreen) CharacterSet() string {
 	return s.charset
 }
@@ -482,6 +695,7 @@ func (s *simscreen) Resize(w, h int) {
 			newc[(row*w)+col] = s.front[(row*s.physw)+col]
 		}
 	}
+	s.front = newc
 	s.physw = w
 	s.physh = h
 	s.Unlock()
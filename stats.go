@@ -0,0 +1,119 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"runtime"
+)
+
+// RenderStats reports on the work done to render frames, that is, by
+// calls to Show and Sync.  Allocation figures are taken from the Go
+// runtime, and so they are for the whole process; allocations made by
+// other goroutines while a frame is drawn are included.
+type RenderStats struct {
+	// Frames is the number of frames rendered.
+	Frames int
+
+	// Cells is the total number of cells drawn, over all frames.
+	Cells int
+
+	// LastCells is the number of cells drawn in the last frame.
+	LastCells int
+
+	// LastAllocs and LastBytes are the number of heap allocations,
+	// and the number of bytes allocated, while the last frame was drawn.
+	LastAllocs uint64
+	LastBytes  uint64
+
+	// MaxAllocs is the largest number of allocations for any frame.
+	MaxAllocs uint64
+
+	// OverBudget is the number of frames that made more allocations
+	// than the budget allowed.  (See SetAllocBudget.)
+	OverBudget int
+}
+
+// RenderStatsScreen is implemented by Screens that can collect
+// RenderStats.  All of the screens in this package do so.  Collection
+// is off by default, because reading the allocation counters briefly
+// stops the Go runtime; it is meant for testing and diagnostics.
+type RenderStatsScreen interface {
+	// EnableRenderStats turns collection of statistics on or off.
+	// Turning it on also resets the statistics.
+	EnableRenderStats(on bool)
+
+	// RenderStats returns the statistics collected so far.
+	RenderStats() RenderStats
+
+	// SetAllocBudget sets the number of allocations that a frame may
+	// make before it is counted in OverBudget.  Zero, the default,
+	// means that there is no budget.
+	SetAllocBudget(allocs uint64)
+
+	Screen
+}
+
+// renderStats is used by the screen implementations to collect
+// RenderStats.  Callers hold the screen lock.
+type renderStats struct {
+	on      bool
+	budget  uint64
+	stats   RenderStats
+	ms      runtime.MemStats
+	mallocs uint64
+	bytes   uint64
+	cells   int
+}
+
+func (rs *renderStats) enable(on bool) {
+	rs.on = on
+	rs.stats = RenderStats{}
+}
+
+// begin is called at the start of a frame, with the cells to be drawn.
+func (rs *renderStats) begin(g *cellGrid, clear bool) {
+	if !rs.on {
+		return
+	}
+	rs.cells = 0
+	for _, dirty := range g.dirty {
+		if clear || dirty {
+			rs.cells++
+		}
+	}
+	runtime.ReadMemStats(&rs.ms)
+	rs.mallocs = rs.ms.Mallocs
+	rs.bytes = rs.ms.TotalAlloc
+}
+
+// end is called when the frame has been drawn.
+func (rs *renderStats) end() {
+	if !rs.on {
+		return
+	}
+	runtime.ReadMemStats(&rs.ms)
+	st := &rs.stats
+	st.Frames++
+	st.Cells += rs.cells
+	st.LastCells = rs.cells
+	st.LastAllocs = rs.ms.Mallocs - rs.mallocs
+	st.LastBytes = rs.ms.TotalAlloc - rs.bytes
+	if st.LastAllocs > st.MaxAllocs {
+		st.MaxAllocs = st.LastAllocs
+	}
+	if rs.budget != 0 && st.LastAllocs > rs.budget {
+		st.OverBudget++
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"math/rand"
+)
+
+// StressPattern generates pseudo-random screen content, which exercises
+// as much of the renderer and the terminal as it can: double wide
+// characters, combining marks, every attribute, and every color in the
+// palette.  The content is entirely determined by the seed and the size
+// of the screen, so that a given frame can be reproduced exactly.  This
+// makes it useful both for checking terminal emulators for correctness
+// (by comparing what they display against a known good one), and for
+// generating load when measuring rendering performance.
+type StressPattern struct {
+	rnd  *rand.Rand
+	seed int64
+}
+
+var (
+	stressNarrow = []rune("abcdefghijklmnopqrstuvwxyz" +
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()-_=+[]{};:,.<>/?" +
+		"éñüßαβωжя")
+	stressWide      = []rune("一二三四五日本語가나다あいうＡＢ")
+	stressCombining = []rune("\u0300\u0301\u0302\u0303\u0308\u0327")
+	stressAttrs     = []AttrMask{AttrBold, AttrBlink, AttrReverse,
+		AttrUnderline, AttrDim}
+)
+
+// NewStressPattern returns a StressPattern that starts with the given seed.
+func NewStressPattern(seed int64) *StressPattern {
+	return &StressPattern{rnd: rand.New(rand.NewSource(seed)), seed: seed}
+}
+
+// Reset returns the pattern to its initial state, so that the same
+// sequence of frames will be drawn again.
+func (sp *StressPattern) Reset() {
+	sp.rnd = rand.New(rand.NewSource(sp.seed))
+}
+
+// Draw fills every cell of the screen with the next frame of content.
+// Like any other drawing, the result is not visible until Show or Sync
+// is called on the screen.
+func (sp *StressPattern) Draw(s Screen) {
+	w, h := s.Size()
+	colors := s.Colors()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			style := sp.style(colors)
+			var ch []rune
+			wide := x < w-1 && sp.rnd.Intn(5) == 0
+			if wide {
+				ch = append(ch, sp.pick(stressWide))
+			} else {
+				ch = append(ch, sp.pick(stressNarrow))
+			}
+			if sp.rnd.Intn(8) == 0 {
+				ch = append(ch, sp.pick(stressCombining))
+			}
+			s.SetCell(x, y, style, ch...)
+			if wide {
+				// The next cell is covered by the wide character.
+				// Give it fixed content, so that what was there
+				// before cannot show through later.
+				x++
+				s.SetCell(x, y, style, ' ')
+			}
+		}
+	}
+}
+
+func (sp *StressPattern) pick(runes []rune) rune {
+	return runes[sp.rnd.Intn(len(runes))]
+}
+
+// color picks one of the colors of a screen that has the given number.
+func (sp *StressPattern) color(colors int) Color {
+	if colors > 256 {
+		return NewHexColor(int32(sp.rnd.Intn(colors)))
+	}
+	// ColorDefault is zero, and the palette starts after it.
+	return Color(sp.rnd.Intn(colors + 1))
+}
+
+func (sp *StressPattern) style(colors int) Style {
+	style := StyleDefault
+	if colors > 0 {
+		style = style.Foreground(sp.color(colors))
+		style = style.Background(sp.color(colors))
+	}
+	var attrs AttrMask
+	for _, a := range stressAttrs {
+		if sp.rnd.Intn(4) == 0 {
+			attrs |= a
+		}
+	}
+	return style.Attributes(attrs)
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStressPattern(t *testing.T) {
+	Convey("Stress pattern", t, WithScreen(t, "", func(s SimulationScreen) {
+		snapshot := func() []Cell {
+			var c []Cell
+			w, h := s.Size()
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					cell := s.GetCell(x, y)
+					cell.Dirty = false
+					c = append(c, *cell)
+				}
+			}
+			return c
+		}
+		sp := NewStressPattern(42)
+		sp.Draw(s)
+		first := snapshot()
+		sp.Draw(s)
+		second := snapshot()
+		So(second, ShouldNotResemble, first)
+
+		Convey("The same seed gives the same content", func() {
+			sp.Reset()
+			sp.Draw(s)
+			So(snapshot(), ShouldResemble, first)
+
+			NewStressPattern(42).Draw(s)
+			So(snapshot(), ShouldResemble, first)
+		})
+	}))
+}
+
+func BenchmarkStressShow(b *testing.B) {
+	s := NewSimulationScreen("")
+	if e := s.Init(); e != nil {
+		b.Fatal(e)
+	}
+	defer s.Fini()
+	sp := NewStressPattern(1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sp.Draw(s)
+		s.Show()
+	}
+}
@@ -14,54 +14,182 @@
 
 package tcell
 
+import "sync"
+
 // Style represents a complete text style, including both foreground
-// and background color.  We encode it in a 64-bit int for efficiency.
-// The coding is (MSB): <16b rsvd><16b attr><16b fgcolor><16b bgcolor>.
-// This gives 16bit color options, if it ever becomes truly necessary.
-// However, applications must not rely on this encoding.
+// and background color, and the underline color.  We encode it in a
+// 64-bit int for efficiency.  The coding is (MSB): <16b ulcolor><16b attr>
+// <16b fgcolor><16b bgcolor>.  The upper bits of the attribute field hold
+// the underline style.  A color field holds a palette color as is, or,
+// with its top bit set, the number under which an RGB color is kept in
+// styleColors.  However, applications must not rely on this encoding.
+//
+// Colors are kept as they are given, so a Style can hold RGB colors as
+// well as palette ones; screens that cannot show RGB colors draw the
+// palette colors that look most like them.
+//
+// Styles are values; the methods that modify a style return a new one,
+// leaving the original unchanged.  They can be chained, so a style is
+// normally built up like this:
+//
+//	st := StyleDefault.Foreground(ColorWhite).Background(ColorBlue).
+//		Bold(true).Underline(true)
+//
+// Use Decompose to take a style apart again.  New attributes are added
+// as new methods, so code written this way keeps working as they arrive.
+// Styles can be compared with ==.
 //
 // Note that not all terminals can display all colors or attributes, and
 // many might have specific incompatibilities between specific attributes
 // and color combinations.
 type Style int64
 
+// NewStyle returns a new style, which is the same as StyleDefault.
 func NewStyle() Style {
 	return Style(0)
 }
 
+// StyleDefault represents a default style, based upon the context.
+// It is the zero value.
 const StyleDefault Style = 0
 
+// styleInvalid is a style that no Style method can make.  Screens use
+// it for the style of the terminal when that is not known, so that the
+// next style is sent in full.
+const styleInvalid Style = -1
+
+const (
+	styleRGB      = 0x8000 // set in color fields that hold RGB colors
+	styleMaxColor = 0x7fff // the number of RGB colors that styles keep
+)
+
+// styleColors keeps the RGB colors that styles hold, so that a color
+// only takes 16 bits of a Style.  Each is kept once, so styles with the
+// same colors are equal.  Colors are never forgotten; once there are
+// styleMaxColor of them, other RGB colors are replaced by the palette
+// colors that look most like them.
+var styleColors struct {
+	index  map[Color]Style
+	colors []Color
+	sync.RWMutex
+}
+
+// styleColor returns the bits of a color field of a style that hold c.
+// Palette colors that are out of range become ColorDefault.
+func styleColor(c Color) Style {
+	if !c.IsRGB() {
+		return Style(c.Palette())
+	}
+	sc := &styleColors
+	sc.RLock()
+	v, ok := sc.index[c]
+	sc.RUnlock()
+	if ok {
+		return v
+	}
+	sc.Lock()
+	defer sc.Unlock()
+	if v, ok = sc.index[c]; ok {
+		return v
+	}
+	if len(sc.colors) >= styleMaxColor {
+		return Style(c.Palette())
+	}
+	if sc.index == nil {
+		sc.index = make(map[Color]Style)
+	}
+	v = styleRGB | Style(len(sc.colors))
+	sc.colors = append(sc.colors, c)
+	sc.index[c] = v
+	return v
+}
+
+// color returns the color held by the 16 bits of s at shift.  (Those of
+// styleInvalid hold no color.)
+func (s Style) color(shift uint) Color {
+	v := (s >> shift) & 0xffff
+	if v&styleRGB == 0 {
+		return Color(v)
+	}
+	c := ColorDefault
+	sc := &styleColors
+	sc.RLock()
+	if i := int(v &^ styleRGB); i < len(sc.colors) {
+		c = sc.colors[i]
+	}
+	sc.RUnlock()
+	return c
+}
+
 // Foreground returns a new style based on s, with the foreground color set
 // as requested.  ColorDefault can be used to select the global default.
 func (s Style) Foreground(c Color) Style {
-	return (s &^ Style(0xffff0000)) | (Style(c) << 16)
+	return (s &^ Style(0xffff0000)) | (styleColor(c) << 16)
 }
 
 // Background returns a new style based on s, with the background color set
 // as requested.  ColorDefault can be used to select the global default.
 func (s Style) Background(c Color) Style {
-	return (s &^ (0xffff)) | Style(c)
+	return (s &^ (0xffff)) | styleColor(c)
 }
 
 // Decompose breaks a style up, returning the foreground, background,
-// and other attributes.
+// and other attributes.  The attributes are returned as a mask, which
+// includes every attribute that is set.  (See also DecomposeUnderline.)
 func (s Style) Decompose() (fg Color, bg Color, attr AttrMask) {
-	return Color((s >> 16) & 0xffff),
-		Color(s & 0xfffff),
-		AttrMask((s >> 32) & 0xffff)
+	return s.color(16), s.color(0), AttrMask((s>>32)&0xffff) & attrMask
+}
+
+// DecomposeUnderline returns the underline style and underline color of
+// the style.  These only matter if the underline attribute is set.
+func (s Style) DecomposeUnderline() (UnderlineStyle, Color) {
+	us := UnderlineStyle((s >> ulStyleShift) & 0x7)
+	if us == 0 {
+		us = UnderlineSingle
+	}
+	return us, s.color(48)
 }
 
 func (s Style) setAttrs(attrs Style, on bool) Style {
 	if on {
 		return s | (attrs << 32)
-	} else {
-		return s &^ (attrs << 32)
 	}
+	return s &^ (attrs << 32)
+}
+
+// colorless returns s with its colors set to ColorDefault.
+func (s Style) colorless() Style {
+	return s & (Style(0xffff) << 32)
+}
+
+// palette returns s with its RGB colors replaced by the palette colors
+// that look most like them.
+func (s Style) palette() Style {
+	fg, bg, _ := s.Decompose()
+	_, ul := s.DecomposeUnderline()
+	if fg.IsRGB() {
+		s = s.Foreground(fg.Palette())
+	}
+	if bg.IsRGB() {
+		s = s.Background(bg.Palette())
+	}
+	if ul.IsRGB() {
+		s = s.UnderlineColor(ul.Palette())
+	}
+	return s
 }
 
 // Normal returns the style with all attributes disabled.
 func (s Style) Normal() Style {
-	return s &^ (Style(0xfffff) << 32)
+	return s &^ (Style(0xffff) << 32)
+}
+
+// Attributes returns a new style based on s, with its attributes set
+// to exactly those in the mask.  This is the counterpart of the mask
+// returned by Decompose.  The underline style is not changed.
+func (s Style) Attributes(attrs AttrMask) Style {
+	s = s.setAttrs(Style(attrMask), false)
+	return s.setAttrs(Style(attrs&attrMask), true)
 }
 
 // Bold returns a new style based on s, with the bold attribute set
@@ -82,6 +210,12 @@ func (s Style) Dim(on bool) Style {
 	return s.setAttrs(Style(AttrDim), on)
 }
 
+// Invisible returns a new style based on s, with the invisible attribute
+// set as requested.
+func (s Style) Invisible(on bool) Style {
+	return s.setAttrs(Style(AttrInvisible), on)
+}
+
 // Reverse returns a new style based on s, with the reverse attribute set
 // as requested.  (Reverse usually changes the foreground and background
 // colors.)
@@ -89,8 +223,28 @@ func (s Style) Reverse(on bool) Style {
 	return s.setAttrs(Style(AttrReverse), on)
 }
 
-// Reverse returns a new style based on s, with the underline attribute set
+// Underline returns a new style based on s, with the underline attribute set
 // as requested.
 func (s Style) Underline(on bool) Style {
 	return s.setAttrs(Style(AttrUnderline), on)
 }
+
+// UnderlineStyle returns a new style based on s, with the given style
+// of underline.  The underline attribute is also turned on.  Terminals
+// that cannot display styled underlines use a plain one instead.
+// UnderlineSingle is the plain underline, so that the style equals
+// s.Underline(true).
+func (s Style) UnderlineStyle(us UnderlineStyle) Style {
+	s &^= Style(0x7) << ulStyleShift
+	if us != UnderlineSingle {
+		s |= Style(us&0x7) << ulStyleShift
+	}
+	return s.Underline(true)
+}
+
+// UnderlineColor returns a new style based on s, with the underline
+// drawn in the given color.  ColorDefault means that the underline has
+// the same color as the text, which is all that most terminals support.
+func (s Style) UnderlineColor(c Color) Style {
+	return (s & 0xffffffffffff) | (styleColor(c) << 48)
+}
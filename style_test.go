@@ -39,5 +39,149 @@ func TestStyle(t *testing.T) {
 		So(fg, ShouldEqual, ColorBlue)
 		So(bg, ShouldEqual, ColorRed)
 		So(attr, ShouldEqual, AttrBlink)
+
+		Convey("Every attribute decomposes", func() {
+			s3 := s2.Bold(true).Underline(true).Reverse(true).Dim(true).
+				Invisible(true)
+			fg, bg, attr = s3.Decompose()
+			So(fg, ShouldEqual, ColorBlue)
+			So(bg, ShouldEqual, ColorRed)
+			So(attr, ShouldEqual, AttrBold|AttrBlink|AttrUnderline|
+				AttrReverse|AttrDim|AttrInvisible)
+
+			s4 := s3.Bold(false).Blink(false)
+			_, _, attr = s4.Decompose()
+			So(attr, ShouldEqual, AttrUnderline|AttrReverse|AttrDim|
+				AttrInvisible)
+
+			So(s3.Normal(), ShouldEqual, s2.Blink(false))
+			So(s2.Normal().Attributes(attr), ShouldEqual, s4)
+		})
+
+		Convey("Underline styles and colors", func() {
+			s3 := s2.UnderlineStyle(UnderlineCurly).
+				UnderlineColor(ColorRed)
+			fg, bg, attr = s3.Decompose()
+			So(fg, ShouldEqual, ColorBlue)
+			So(bg, ShouldEqual, ColorRed)
+			So(attr, ShouldEqual, AttrBlink|AttrUnderline)
+			us, uc := s3.DecomposeUnderline()
+			So(us, ShouldEqual, UnderlineCurly)
+			So(uc, ShouldEqual, ColorRed)
+
+			us, uc = s2.Underline(true).DecomposeUnderline()
+			So(us, ShouldEqual, UnderlineSingle)
+			So(uc, ShouldEqual, ColorDefault)
+
+			So(s3.UnderlineStyle(UnderlineSingle), ShouldEqual,
+				s3.UnderlineStyle(0).UnderlineColor(ColorRed))
+			So(s2.UnderlineStyle(UnderlineSingle), ShouldEqual,
+				s2.Underline(true))
+		})
+
+		Convey("RGB colors are kept", func() {
+			c := NewRGBColor(0x12, 0x34, 0x56)
+			s3 := s2.Foreground(c).UnderlineColor(NewHexColor(0xabcdef))
+			fg, bg, _ = s3.Decompose()
+			So(fg, ShouldEqual, c)
+			So(bg, ShouldEqual, ColorRed)
+			_, uc := s3.DecomposeUnderline()
+			So(uc, ShouldEqual, NewHexColor(0xabcdef))
+			So(s3, ShouldEqual,
+				s2.UnderlineColor(NewHexColor(0xabcdef)).Foreground(c))
+			So(s3.palette(), ShouldEqual, s2.Foreground(c.Palette()).
+				UnderlineColor(NewHexColor(0xabcdef).Palette()))
+		})
+
+		Convey("Builders do not modify the original", func() {
+			s3 := s2.Foreground(ColorGreen)
+			fg, _, _ = s2.Decompose()
+			So(fg, ShouldEqual, ColorBlue)
+			fg, bg, _ = s3.Decompose()
+			So(fg, ShouldEqual, ColorGreen)
+			So(bg, ShouldEqual, ColorRed)
+		})
 	}))
 }
+
+func TestParseStyle(t *testing.T) {
+	Convey("Style specifications", t, func() {
+		Convey("Attributes and colors are parsed", func() {
+			s, e := ParseStyle("bold, Underline,fg=yellow,bg=#202030")
+			So(e, ShouldBeNil)
+			fg, bg, attrs := s.Decompose()
+			So(fg, ShouldEqual, ColorYellow)
+			So(bg, ShouldEqual, NewHexColor(0x202030))
+			So(attrs, ShouldEqual, AttrBold|AttrUnderline)
+		})
+
+		Convey("Underline styles and colors are parsed", func() {
+			s, e := ParseStyle("underline=curly,ul=red")
+			So(e, ShouldBeNil)
+			us, ul := s.DecomposeUnderline()
+			So(us, ShouldEqual, UnderlineCurly)
+			So(ul, ShouldEqual, ColorRed)
+			So(s.String(), ShouldEqual, "underline=curly,ul=1")
+		})
+
+		Convey("Styles format as they parse", func() {
+			for _, s := range []Style{
+				StyleDefault,
+				StyleDefault.Bold(true).Dim(true).Foreground(ColorGreen),
+				StyleDefault.Reverse(true).Background(PaletteColor(200)),
+				StyleDefault.UnderlineStyle(UnderlineDashed).Blink(true),
+				StyleDefault.Invisible(true).UnderlineColor(ColorBlue),
+				StyleDefault.Foreground(NewHexColor(0x336699)),
+			} {
+				p, e := ParseStyle(s.String())
+				So(e, ShouldBeNil)
+				So(p, ShouldEqual, s)
+			}
+			for _, an := range attrNames {
+				s := StyleDefault.Foreground(ColorRed).setAttrs(Style(an.attr), true)
+				p, e := ParseStyle(s.String())
+				So(e, ShouldBeNil)
+				So(p, ShouldEqual, s)
+			}
+			for us, name := range underlineNames {
+				if name == "" {
+					continue
+				}
+				s := StyleDefault.UnderlineStyle(UnderlineStyle(us))
+				p, e := ParseStyle(s.String())
+				So(e, ShouldBeNil)
+				So(p, ShouldEqual, s)
+				p, e = ParseStyle("underline=" + name)
+				So(e, ShouldBeNil)
+				So(p, ShouldEqual, s)
+			}
+			s, e := ParseStyle("underline=single")
+			So(e, ShouldBeNil)
+			So(s, ShouldEqual, StyleDefault.Underline(true))
+			So(s.String(), ShouldEqual, "underline")
+
+			So(StyleDefault.String(), ShouldEqual, "default")
+			So(StyleDefault.Bold(true).Foreground(ColorYellow).String(),
+				ShouldEqual, "bold,fg=3")
+			s, e = ParseStyle("")
+			So(e, ShouldBeNil)
+			So(s, ShouldEqual, StyleDefault)
+		})
+
+		Convey("Errors name the bad item", func() {
+			_, e := ParseStyle("bold,italic")
+			So(e, ShouldNotBeNil)
+			So(e.Error(), ShouldContainSubstring, `"italic"`)
+			_, e = ParseStyle("fg=pink")
+			So(e, ShouldNotBeNil)
+			So(e.Error(), ShouldContainSubstring, `"pink"`)
+			_, e = ParseStyle("bg")
+			So(e, ShouldNotBeNil)
+			_, e = ParseStyle("underline=wavy")
+			So(e, ShouldNotBeNil)
+			So(e.Error(), ShouldContainSubstring, `"wavy"`)
+			_, e = ParseStyle("bold=1")
+			So(e, ShouldNotBeNil)
+		})
+	})
+}
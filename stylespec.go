@@ -0,0 +1,156 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attrNames are the names of the attributes in style specifications, in
+// the order that Style's String method gives them.
+var attrNames = []struct {
+	attr AttrMask
+	name string
+}{
+	{AttrBold, "bold"},
+	{AttrBlink, "blink"},
+	{AttrReverse, "reverse"},
+	{AttrUnderline, "underline"},
+	{AttrDim, "dim"},
+	{AttrInvisible, "invisible"},
+}
+
+// underlineNames are the names of the underline styles, indexed by style.
+var underlineNames = []string{
+	UnderlineSingle: "single",
+	UnderlineDouble: "double",
+	UnderlineCurly:  "curly",
+	UnderlineDotted: "dotted",
+	UnderlineDashed: "dashed",
+}
+
+// String returns the specification of the style, as ParseStyle accepts
+// it: the attributes that are set, then the colors that are not the
+// default, separated by commas, as in "bold,underline=curly,fg=yellow".
+// StyleDefault is "default".
+func (s Style) String() string {
+	fg, bg, attrs := s.Decompose()
+	us, ul := s.DecomposeUnderline()
+	var items []string
+	for _, an := range attrNames {
+		if attrs&an.attr == 0 {
+			continue
+		}
+		if an.attr == AttrUnderline && us != UnderlineSingle {
+			items = append(items, an.name+"="+underlineNames[us])
+			continue
+		}
+		items = append(items, an.name)
+	}
+	for _, c := range []struct {
+		name  string
+		color Color
+	}{{"fg", fg}, {"bg", bg}, {"ul", ul}} {
+		if c.color != ColorDefault {
+			items = append(items, c.name+"="+c.color.String())
+		}
+	}
+	if len(items) == 0 {
+		return "default"
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseStyle parses a style specification, such as
+// "bold,underline,fg=yellow,bg=#202030", so that applications can let
+// users choose their styles in configuration files.  The items are
+// separated by commas, and each is one of:
+//
+//	bold, blink, reverse, underline, dim, invisible
+//	underline=STYLE  underline in one of single, double, curly, dotted
+//	                 or dashed
+//	fg=COLOR         the foreground color
+//	bg=COLOR         the background color
+//	ul=COLOR         the underline color
+//	default          nothing; the specification of StyleDefault
+//
+// Colors are given as ParseColor accepts them.  Case and space around
+// the items are ignored.  The error names the first item that is not
+// understood.
+func ParseStyle(spec string) (Style, error) {
+	style := StyleDefault
+	if strings.TrimSpace(spec) == "" {
+		return style, nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		name, value, hasValue := item, "", false
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			name = strings.TrimSpace(item[:i])
+			value = strings.TrimSpace(item[i+1:])
+			hasValue = true
+		}
+		switch name {
+		case "fg", "bg", "ul":
+			c, e := ParseColor(value)
+			if !hasValue || e != nil {
+				return StyleDefault,
+					fmt.Errorf("style: bad color %q for %s", value, name)
+			}
+			switch name {
+			case "fg":
+				style = style.Foreground(c)
+			case "bg":
+				style = style.Background(c)
+			default:
+				style = style.UnderlineColor(c)
+			}
+			continue
+		case "underline":
+			if !hasValue {
+				break
+			}
+			us := UnderlineStyle(0)
+			for i, n := range underlineNames {
+				if n != "" && n == value {
+					us = UnderlineStyle(i)
+				}
+			}
+			if us == 0 {
+				return StyleDefault,
+					fmt.Errorf("style: unknown underline style %q", value)
+			}
+			style = style.UnderlineStyle(us)
+			continue
+		case "default":
+			if !hasValue {
+				continue
+			}
+		}
+		found := false
+		for _, an := range attrNames {
+			if an.name == name && !hasValue {
+				style = style.setAttrs(Style(an.attr), true)
+				found = true
+			}
+		}
+		if !found {
+			return StyleDefault,
+				fmt.Errorf("style: unknown attribute %q", item)
+		}
+	}
+	return style, nil
+}
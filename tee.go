@@ -0,0 +1,257 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"sync"
+	"time"
+)
+
+// TeeScreen is a Screen that mirrors everything drawn on it to several
+// Screens, for example the local terminal and one attached to a remote
+// viewer.  (See NewTeeScreen.)
+type TeeScreen interface {
+	// SetSharedInput controls whether the key and mouse events of the
+	// mirrors are delivered along with those of the primary Screen.
+	// When off, which is the default, the mirrors are read-only: their
+	// input is discarded.  Turn it on to let everyone type, as when
+	// pair programming.
+	SetSharedInput(on bool)
+
+	Screen
+}
+
+// NewTeeScreen returns a TeeScreen that draws on the primary Screen and
+// on each of the mirrors.  Init and Fini apply to all of them.  Queries,
+// such as Size, Colors and GetCell, are answered by the primary, and only
+// its events (including resizes) are delivered; the application should
+// lay out its display for the primary.  Mirrors that are smaller than
+// the primary show only the part of the display that fits, and those
+// that are larger show blank space around it.
+func NewTeeScreen(primary Screen, mirrors ...Screen) TeeScreen {
+	return &teescreen{Screen: primary, mirrors: mirrors}
+}
+
+type teescreen struct {
+	Screen
+	mirrors []Screen
+	shared  bool
+	evq     chan Event
+	filter  eventFilter
+	quit    chan struct{}
+
+	sync.Mutex
+}
+
+func (ts *teescreen) Init() error {
+	if e := ts.Screen.Init(); e != nil {
+		return e
+	}
+	for i, s := range ts.mirrors {
+		if e := s.Init(); e != nil {
+			for _, s := range ts.mirrors[:i] {
+				s.Fini()
+			}
+			ts.Screen.Fini()
+			return e
+		}
+	}
+	ts.evq = make(chan Event)
+	ts.quit = make(chan struct{})
+	go ts.pump()
+	for _, s := range ts.mirrors {
+		go ts.pumpMirror(s)
+	}
+	return nil
+}
+
+// pump passes on the events of the primary Screen.
+func (ts *teescreen) pump() {
+	for {
+		ev := ts.Screen.PollEvent()
+		if ev == nil {
+			close(ts.quit)
+			return
+		}
+		ts.evq <- ev
+	}
+}
+
+// pumpMirror drains the events of a mirror, passing on its input if
+// that is shared.
+func (ts *teescreen) pumpMirror(s Screen) {
+	for {
+		ev := s.PollEvent()
+		if ev == nil {
+			return
+		}
+		switch ev.(type) {
+		case *EventKey, *EventMouse:
+		default:
+			continue
+		}
+		ts.Lock()
+		shared := ts.shared
+		ts.Unlock()
+		if !shared {
+			continue
+		}
+		select {
+		case ts.evq <- ev:
+		case <-ts.quit:
+			return
+		}
+	}
+}
+
+func (ts *teescreen) SetSharedInput(on bool) {
+	ts.Lock()
+	ts.shared = on
+	ts.Unlock()
+}
+
+func (ts *teescreen) PollEvent() Event {
+	return ts.filter.poll(ts.quit, ts.evq)
+}
+
+func (ts *teescreen) SetEventFilter(f EventFilter) {
+	ts.filter.set(f)
+}
+
+func (ts *teescreen) SetIdleTimeout(d time.Duration) {
+	ts.filter.setIdle(d)
+}
+
+// Contents returns the contents of the primary Screen.
+func (ts *teescreen) Contents() *Contents {
+	return screenContents(ts.Screen)
+}
+
+// SetSize asks each of the screens that can be resized to change size.
+func (ts *teescreen) SetSize(w, h int) {
+	if rs, ok := ts.Screen.(ResizeScreen); ok {
+		rs.SetSize(w, h)
+	}
+	for _, s := range ts.mirrors {
+		if rs, ok := s.(ResizeScreen); ok {
+			rs.SetSize(w, h)
+		}
+	}
+}
+
+func (ts *teescreen) Fini() {
+	for _, s := range ts.mirrors {
+		s.Fini()
+	}
+	ts.Screen.Fini()
+}
+
+func (ts *teescreen) Clear() {
+	ts.Screen.Clear()
+	for _, s := range ts.mirrors {
+		s.Clear()
+	}
+}
+
+func (ts *teescreen) SetCell(x, y int, style Style, ch ...rune) {
+	ts.Screen.SetCell(x, y, style, ch...)
+	for _, s := range ts.mirrors {
+		s.SetCell(x, y, style, ch...)
+	}
+}
+
+func (ts *teescreen) PutCell(x, y int, cell *Cell) {
+	ts.Screen.PutCell(x, y, cell)
+	for _, s := range ts.mirrors {
+		s.PutCell(x, y, cell)
+	}
+}
+
+func (ts *teescreen) SetStyle(style Style) {
+	ts.Screen.SetStyle(style)
+	for _, s := range ts.mirrors {
+		s.SetStyle(style)
+	}
+}
+
+func (ts *teescreen) ShowCursor(x, y int) {
+	ts.Screen.ShowCursor(x, y)
+	for _, s := range ts.mirrors {
+		s.ShowCursor(x, y)
+	}
+}
+
+func (ts *teescreen) HideCursor() {
+	ts.ShowCursor(-1, -1)
+}
+
+func (ts *teescreen) EnableMouse(flags ...MouseFlags) {
+	ts.Screen.EnableMouse(flags...)
+	for _, s := range ts.mirrors {
+		s.EnableMouse(flags...)
+	}
+}
+
+func (ts *teescreen) DisableMouse() {
+	ts.Screen.DisableMouse()
+	for _, s := range ts.mirrors {
+		s.DisableMouse()
+	}
+}
+
+func (ts *teescreen) EnableBlink(rate time.Duration) {
+	if bs, ok := ts.Screen.(BlinkScreen); ok {
+		bs.EnableBlink(rate)
+	}
+	for _, s := range ts.mirrors {
+		if bs, ok := s.(BlinkScreen); ok {
+			bs.EnableBlink(rate)
+		}
+	}
+}
+
+func (ts *teescreen) DisableBlink() {
+	if bs, ok := ts.Screen.(BlinkScreen); ok {
+		bs.DisableBlink()
+	}
+	for _, s := range ts.mirrors {
+		if bs, ok := s.(BlinkScreen); ok {
+			bs.DisableBlink()
+		}
+	}
+}
+
+func (ts *teescreen) Show() {
+	ts.Screen.Show()
+	for _, s := range ts.mirrors {
+		s.Show()
+	}
+}
+
+func (ts *teescreen) Sync() {
+	ts.Screen.Sync()
+	for _, s := range ts.mirrors {
+		s.Sync()
+	}
+}
+
+// Reinitialize applies to the primary only, since the mirrors are other
+// terminals.
+func (ts *teescreen) Reinitialize(term string) error {
+	if rs, ok := ts.Screen.(ReinitializeScreen); ok {
+		return rs.Reinitialize(term)
+	}
+	return nil
+}
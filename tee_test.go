@@ -0,0 +1,64 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTeeScreen(t *testing.T) {
+	Convey("Tee to several screens", t, func() {
+		primary := NewSimulationScreen("")
+		viewer := NewSimulationScreen("")
+		ts := NewTeeScreen(primary, viewer)
+		So(ts.Init(), ShouldBeNil)
+		Reset(ts.Fini)
+		viewer.Resize(40, 10)
+
+		ts.SetCell(1, 1, StyleDefault, 'x')
+		ts.SetCell(50, 1, StyleDefault, 'y')
+		ts.ShowCursor(1, 1)
+		ts.Show()
+
+		b, w, _ := primary.GetContents()
+		So(b[w+1].Runes, ShouldResemble, []rune{'x'})
+		So(b[w+50].Runes, ShouldResemble, []rune{'y'})
+		b, w, _ = viewer.GetContents()
+		So(w, ShouldEqual, 40)
+		So(b[w+1].Runes, ShouldResemble, []rune{'x'})
+		x, y, vis := viewer.GetCursor()
+		So([]int{x, y}, ShouldResemble, []int{1, 1})
+		So(vis, ShouldBeTrue)
+
+		Convey("Input comes from the primary", func() {
+			viewer.InjectKey(KeyRune, 'v', ModNone)
+			primary.InjectKey(KeyRune, 'p', ModNone)
+			So(ts.PollEvent().(*EventKey).Rune(), ShouldEqual, 'p')
+		})
+
+		Convey("Unless it is shared", func() {
+			ts.SetSharedInput(true)
+			viewer.InjectKey(KeyRune, 'v', ModNone)
+			So(ts.PollEvent().(*EventKey).Rune(), ShouldEqual, 'v')
+		})
+
+		Convey("Fini stops PollEvent", func() {
+			ts.Fini()
+			So(ts.PollEvent(), ShouldBeNil)
+		})
+	})
+}
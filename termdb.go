@@ -0,0 +1,187 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Terminals that are not in the compiled in database can be described in
+// JSON files, which hold one Terminfo after another, as mkinfo writes
+// them.  The files may be compressed with gzip.  LookupTerminfo searches
+// the files listed in $TCELLDB, which are separated as in $PATH (by
+// colons, or semicolons on Windows).  Directories may be listed too, in
+// which case their files whose names end with .json or .json.gz are
+// searched, in order of their names.  The first description found wins,
+// so site administrators can put their own definitions before the
+// standard ones.
+
+// WriteTerminfo writes the descriptions to w, in the form that
+// ReadTerminfo and LookupTerminfo read.
+func WriteTerminfo(w io.Writer, tis ...*Terminfo) error {
+	enc := json.NewEncoder(w)
+	for _, t := range tis {
+		if e := enc.Encode(t); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// ReadTerminfo reads all of the descriptions from r, which may be
+// compressed with gzip.
+func ReadTerminfo(r io.Reader) ([]*Terminfo, error) {
+	var tis []*Terminfo
+	e := readDatabase(r, func(t *Terminfo) bool {
+		tis = append(tis, t)
+		return true
+	})
+	return tis, e
+}
+
+// LoadTerminfo reads the descriptions in the named file (as ReadTerminfo
+// does), and adds them to the database with AddTerminfo.
+func LoadTerminfo(fname string) error {
+	f, e := os.Open(fname)
+	if e != nil {
+		return e
+	}
+	defer f.Close()
+	tis, e := ReadTerminfo(f)
+	if e != nil {
+		return e
+	}
+	for _, t := range tis {
+		AddTerminfo(t)
+	}
+	return nil
+}
+
+// readDatabase decodes the descriptions in r, passing each to fn until
+// it returns false.
+func readDatabase(r io.Reader, fn func(*Terminfo) bool) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 &&
+		magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, e := gzip.NewReader(br)
+		if e != nil {
+			return e
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+	d := json.NewDecoder(r)
+	for {
+		t := &Terminfo{}
+		if e := d.Decode(t); e != nil {
+			if e == io.EOF {
+				return nil
+			}
+			return e
+		}
+		if !fn(t) {
+			return nil
+		}
+	}
+}
+
+// databasePath returns the files and directories to search.
+func databasePath() []string {
+	if pth := os.Getenv("TCELLDB"); pth != "" {
+		return filepath.SplitList(pth)
+	}
+	return []string{path.Join(os.Getenv("GOPATH"), "src",
+		"github.com", "gdamore", "tcell", "database.json")}
+}
+
+// searchDatabase looks for the named terminal (which may be an alias) in
+// the files and directories of pth.  If it is not found, the error is
+// ErrNoDatabase if there were no files to search, the first error from
+// reading one if there was one, or else ErrTermNotFound.
+func searchDatabase(pth []string, name string) (*Terminfo, error) {
+	var files []string
+	for _, p := range pth {
+		fi, e := os.Stat(p)
+		switch {
+		case e != nil:
+		case fi.IsDir():
+			infos, _ := ioutil.ReadDir(p)
+			var names []string
+			for _, fi := range infos {
+				n := fi.Name()
+				if !fi.IsDir() && (strings.HasSuffix(n, ".json") ||
+					strings.HasSuffix(n, ".json.gz")) {
+					names = append(names, filepath.Join(p, n))
+				}
+			}
+			sort.Strings(names)
+			files = append(files, names...)
+		default:
+			files = append(files, p)
+		}
+	}
+	if len(files) == 0 {
+		return nil, ErrNoDatabase
+	}
+	var err error
+	for _, fname := range files {
+		t, e := searchFile(fname, name)
+		if t != nil {
+			return t, nil
+		}
+		if err == nil {
+			err = e
+		}
+	}
+	if err == nil {
+		err = ErrTermNotFound
+	}
+	return nil, err
+}
+
+// searchFile looks for the named terminal in one file.
+func searchFile(fname string, name string) (*Terminfo, error) {
+	f, e := os.Open(fname)
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+	var found *Terminfo
+	e = readDatabase(f, func(t *Terminfo) bool {
+		if t.Name == name {
+			found = t
+			return false
+		}
+		for _, a := range t.Aliases {
+			if a == name {
+				found = t
+				return false
+			}
+		}
+		return true
+	})
+	return found, e
+}
@@ -0,0 +1,855 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoPty is returned by Terminal.Start on platforms that do not have
+// pseudo-terminals.
+var ErrNoPty = errors.New("pseudo-terminals are not supported")
+
+// Terminal is a region of a Screen that shows a child process running on
+// a pseudo-terminal, as a terminal emulator would.  It can be used to
+// embed a shell, or a REPL, in a pane of an application.
+//
+// The child's output is interpreted by a parser that understands
+// the commonly used subset of the VT100 and XTerm control sequences:
+// cursor movement and addressing, erasure, insertion and deletion of
+// lines and characters, scrolling regions, the alternate screen, and SGR
+// colors and attributes.  (Colors given as RGB are shown as the nearest
+// color of the 256 color palette.)  Sequences that are not understood are
+// consumed and discarded.  The child is told that the terminal is an
+// "xterm", unless the command's environment says otherwise.
+//
+// A Terminal never reads events from the Screen; the application passes
+// the key events meant for the child to SendKey.  When the child exits,
+// an EventTerminal is posted to the Screen.
+type Terminal struct {
+	s        Screen
+	x        int
+	y        int
+	w        int
+	h        int
+	cells    []Cell
+	primary  []Cell // the primary screen, while the alternate is shown
+	cx       int
+	cy       int
+	wrapnext bool
+	style    Style
+	top      int
+	bot      int
+	savex    int
+	savey    int
+	savest   Style
+	nowrap   bool
+	appkeys  bool
+	hidden   bool
+	focus    bool
+	title    string
+	vt       vtParser
+	out      io.Writer
+	pty      *os.File
+	cmd      *exec.Cmd
+	done     chan struct{}
+	err      error
+	sync.Mutex
+}
+
+// EventTerminal is posted when the process running in a Terminal exits.
+type EventTerminal struct {
+	t    time.Time
+	term *Terminal
+	err  error
+}
+
+// When returns the time when the process exited.
+func (ev *EventTerminal) When() time.Time {
+	return ev.t
+}
+
+// Terminal returns the Terminal that the process was running in.
+func (ev *EventTerminal) Terminal() *Terminal {
+	return ev.term
+}
+
+// Err returns the error returned by waiting for the process; it is nil
+// if the process exited successfully.
+func (ev *EventTerminal) Err() error {
+	return ev.err
+}
+
+// NewTerminal returns a Terminal that occupies the region of the Screen
+// with the given origin and dimensions.  Nothing is run in it until Start
+// is called; until then, whatever is written to it with Write is shown
+// as if a process had written it.
+func NewTerminal(s Screen, x, y, width, height int) *Terminal {
+	t := &Terminal{s: s}
+	t.resize(x, y, width, height)
+	t.reset()
+	return t
+}
+
+// Start runs the command on a new pseudo-terminal, and shows its output
+// in the region.  The command's standard input, output and error are
+// all connected to the pseudo-terminal, which becomes its controlling
+// terminal.  A Terminal can only run one command.
+func (t *Terminal) Start(cmd *exec.Cmd) error {
+	t.Lock()
+	defer t.Unlock()
+	if t.cmd != nil {
+		return errors.New("terminal already started")
+	}
+	hasterm := false
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	for _, e := range cmd.Env {
+		if strings.HasPrefix(e, "TERM=") {
+			hasterm = true
+		}
+	}
+	if !hasterm {
+		cmd.Env = append(cmd.Env, "TERM=xterm")
+	}
+	pty, e := startPty(cmd, t.w, t.h)
+	if e != nil {
+		return e
+	}
+	t.cmd = cmd
+	t.pty = pty
+	t.out = pty
+	t.done = make(chan struct{})
+	go t.readLoop()
+	return nil
+}
+
+func (t *Terminal) readLoop() {
+	buf := make([]byte, 4096)
+	for {
+		n, e := t.pty.Read(buf)
+		if n > 0 {
+			t.Write(buf[:n])
+		}
+		if e != nil {
+			break
+		}
+	}
+	err := t.cmd.Wait()
+	t.Lock()
+	t.err = err
+	t.Unlock()
+	close(t.done)
+	t.s.PostEvent(&EventTerminal{t: time.Now(), term: t, err: err})
+}
+
+// Wait waits for the command started by Start to exit, and returns the
+// error (if any) from waiting for it.
+func (t *Terminal) Wait() error {
+	t.Lock()
+	done := t.done
+	t.Unlock()
+	if done == nil {
+		return errors.New("terminal not started")
+	}
+	<-done
+	t.Lock()
+	defer t.Unlock()
+	return t.err
+}
+
+// Close closes the pseudo-terminal, which normally causes the process
+// running on it to be sent a hangup signal.  It does not wait for the
+// process to exit.
+func (t *Terminal) Close() error {
+	t.Lock()
+	defer t.Unlock()
+	if t.pty == nil {
+		return nil
+	}
+	t.out = nil
+	return t.pty.Close()
+}
+
+// Title returns the window title most recently set by the process, with
+// the OSC 0 or OSC 2 sequence.
+func (t *Terminal) Title() string {
+	t.Lock()
+	defer t.Unlock()
+	return t.title
+}
+
+// SetFocus determines whether the Terminal shows its cursor on the
+// Screen.  Only the Terminal that has the keyboard focus should.
+func (t *Terminal) SetFocus(on bool) {
+	t.Lock()
+	t.focus = on
+	t.draw()
+	t.Unlock()
+	t.s.Show()
+}
+
+// Resize moves the Terminal to the given region of the Screen, and tells
+// the process about the new size.  Text that no longer fits is lost.
+func (t *Terminal) Resize(x, y, width, height int) {
+	t.Lock()
+	t.resize(x, y, width, height)
+	if t.pty != nil {
+		setPtySize(t.pty, t.w, t.h)
+	}
+	t.draw()
+	t.Unlock()
+	t.s.Show()
+}
+
+func (t *Terminal) resize(x, y, width, height int) {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	if t.primary != nil {
+		t.primary = ResizeCells(t.primary, t.w, t.h, width, height)
+	}
+	oldw, oldh := t.w, t.h
+	t.cells = ResizeCells(t.cells, oldw, oldh, width, height)
+	if width > oldw || height > oldh {
+		// ResizeCells leaves the new cells empty
+		for row := 0; row < height; row++ {
+			for col := 0; col < width; col++ {
+				if row >= oldh || col >= oldw {
+					t.cells[row*width+col].SetCell(nil, t.style)
+				}
+			}
+		}
+	}
+	t.x, t.y, t.w, t.h = x, y, width, height
+	t.top, t.bot = 0, height-1
+	t.cx = clampInt(t.cx, 0, width-1)
+	t.cy = clampInt(t.cy, 0, height-1)
+	t.wrapnext = false
+}
+
+func (t *Terminal) reset() {
+	t.style = StyleDefault
+	t.top, t.bot = 0, t.h-1
+	t.cx, t.cy = 0, 0
+	t.savex, t.savey, t.savest = 0, 0, StyleDefault
+	t.wrapnext = false
+	t.nowrap = false
+	t.appkeys = false
+	t.hidden = false
+	if t.primary != nil {
+		t.cells = t.primary
+		t.primary = nil
+	}
+	ClearCells(t.cells, t.style)
+}
+
+// Write implements io.Writer.  What is written is interpreted as output
+// from the process, and shown on the Screen.  It always consumes all of
+// its input.
+func (t *Terminal) Write(b []byte) (int, error) {
+	t.Lock()
+	t.vt.parse(b, t)
+	t.draw()
+	t.Unlock()
+	t.s.Show()
+	return len(b), nil
+}
+
+// Draw draws the contents of the Terminal on the Screen, as after the
+// application has drawn over it.  It does not call Show.
+func (t *Terminal) Draw() {
+	t.Lock()
+	InvalidateCells(t.cells)
+	t.draw()
+	t.Unlock()
+}
+
+func (t *Terminal) draw() {
+	for row := 0; row < t.h; row++ {
+		for col := 0; col < t.w; col++ {
+			c := &t.cells[row*t.w+col]
+			if c.Dirty {
+				t.s.PutCell(t.x+col, t.y+row, c)
+				c.Dirty = false
+			}
+			if c.Width == 2 {
+				col++
+			}
+		}
+	}
+	if t.focus {
+		if t.hidden {
+			t.s.HideCursor()
+		} else {
+			t.s.ShowCursor(t.x+t.cx, t.y+t.cy)
+		}
+	}
+}
+
+// cell returns the cell at the given position of the region.
+func (t *Terminal) cell(col, row int) *Cell {
+	return &t.cells[row*t.w+col]
+}
+
+func (t *Terminal) putRune(r rune) {
+	switch r {
+	case '\r':
+		t.cx = 0
+		t.wrapnext = false
+	case '\n', '\v', '\f':
+		t.linefeed()
+	case '\b':
+		if t.cx > 0 {
+			t.cx--
+		}
+		t.wrapnext = false
+	case '\t':
+		t.cx = clampInt((t.cx+8)&^7, 0, t.w-1)
+		t.wrapnext = false
+	default:
+		if r < ' ' || r == 0x7f {
+			return
+		}
+		t.printRune(r)
+	}
+}
+
+func (t *Terminal) printRune(r rune) {
+	width := runeWidth(r)
+	if width > t.w {
+		return
+	}
+	if width == 0 {
+		// combining mark, for the character before the cursor
+		col := t.cx
+		if !t.wrapnext && col > 0 {
+			col--
+		}
+		c := t.cell(col, t.cy)
+		if col > 0 && c.Width == 0 && t.cell(col-1, t.cy).Width == 2 {
+			c = t.cell(col-1, t.cy)
+		}
+		c.SetCell(append(append([]rune{}, c.Ch...), r), c.Style)
+		return
+	}
+	if t.wrapnext || (width == 2 && t.cx == t.w-1) {
+		if t.nowrap {
+			t.cx = t.w - width
+		} else {
+			t.cx = 0
+			t.linefeed()
+		}
+		t.wrapnext = false
+	}
+	t.cell(t.cx, t.cy).SetCell([]rune{r}, t.style)
+	if width == 2 {
+		c := t.cell(t.cx+1, t.cy)
+		c.SetCell(nil, t.style)
+		c.Width = 0
+	}
+	t.cx += width
+	if t.cx >= t.w {
+		t.cx = t.w - 1
+		t.wrapnext = true
+	}
+}
+
+func (t *Terminal) linefeed() {
+	t.wrapnext = false
+	if t.cy == t.bot {
+		t.scroll(t.top, t.bot, 1)
+	} else if t.cy < t.h-1 {
+		t.cy++
+	}
+}
+
+func (t *Terminal) reverseLinefeed() {
+	t.wrapnext = false
+	if t.cy == t.top {
+		t.scroll(t.top, t.bot, -1)
+	} else if t.cy > 0 {
+		t.cy--
+	}
+}
+
+// scroll moves the rows from top to bot (inclusive) up by n rows, or
+// down if n is negative, blanking the rows that are exposed.
+func (t *Terminal) scroll(top, bot, n int) {
+	rows := bot - top + 1
+	if n > rows {
+		n = rows
+	} else if n < -rows {
+		n = -rows
+	}
+	if n > 0 {
+		for row := top; row <= bot-n; row++ {
+			t.copyRow(row, row+n)
+		}
+		for row := bot - n + 1; row <= bot; row++ {
+			t.eraseRow(row, 0, t.w)
+		}
+	} else if n < 0 {
+		n = -n
+		for row := bot; row >= top+n; row-- {
+			t.copyRow(row, row-n)
+		}
+		for row := top; row < top+n; row++ {
+			t.eraseRow(row, 0, t.w)
+		}
+	}
+}
+
+func (t *Terminal) copyRow(dst, src int) {
+	for col := 0; col < t.w; col++ {
+		s := t.cell(col, src)
+		d := t.cell(col, dst)
+		d.SetCell(s.Ch, s.Style)
+		d.Width = s.Width
+	}
+}
+
+// eraseRow blanks the columns of the row from col up to, but not
+// including, end.  Erased cells take the background of the current
+// style, as in xterm.
+func (t *Terminal) eraseRow(row, col, end int) {
+	_, bg, _ := t.style.Decompose()
+	st := StyleDefault.Background(bg)
+	for ; col < end && col < t.w; col++ {
+		t.cell(col, row).SetCell(nil, st)
+	}
+}
+
+func (t *Terminal) escape(inter string, final byte) {
+	if inter != "" {
+		// character set designations and the like, ignored
+		return
+	}
+	switch final {
+	case '7':
+		t.saveCursor()
+	case '8':
+		t.restoreCursor()
+	case 'D':
+		t.linefeed()
+	case 'E':
+		t.cx = 0
+		t.linefeed()
+	case 'M':
+		t.reverseLinefeed()
+	case 'c':
+		t.reset()
+	}
+}
+
+func (t *Terminal) osc(s string) {
+	if strings.HasPrefix(s, "0;") || strings.HasPrefix(s, "2;") {
+		t.title = s[2:]
+	}
+}
+
+func (t *Terminal) saveCursor() {
+	t.savex, t.savey, t.savest = t.cx, t.cy, t.style
+}
+
+func (t *Terminal) restoreCursor() {
+	t.cx = clampInt(t.savex, 0, t.w-1)
+	t.cy = clampInt(t.savey, 0, t.h-1)
+	t.style = t.savest
+	t.wrapnext = false
+}
+
+// csiParams parses the numeric parameters of a control sequence.
+// Missing parameters are given as zero.
+func csiParams(s string) []int {
+	var params []int
+	for _, p := range strings.Split(s, ";") {
+		n, _ := strconv.Atoi(p)
+		params = append(params, n)
+	}
+	return params
+}
+
+func (t *Terminal) csi(final byte, s string) {
+	private := false
+	if strings.HasPrefix(s, "?") {
+		private = true
+		s = s[1:]
+	} else if len(s) > 0 && (s[0] < '0' || s[0] > ';') {
+		// some other private sequence (such as CSI > c), ignored
+		return
+	}
+	params := csiParams(s)
+	// arg returns the i'th parameter, or def if it is missing or zero
+	arg := func(i, def int) int {
+		if i < len(params) && params[i] != 0 {
+			return params[i]
+		}
+		return def
+	}
+	t.wrapnext = false
+	switch final {
+	case 'A':
+		t.cy = clampInt(t.cy-arg(0, 1), 0, t.h-1)
+	case 'B', 'e':
+		t.cy = clampInt(t.cy+arg(0, 1), 0, t.h-1)
+	case 'C', 'a':
+		t.cx = clampInt(t.cx+arg(0, 1), 0, t.w-1)
+	case 'D':
+		t.cx = clampInt(t.cx-arg(0, 1), 0, t.w-1)
+	case 'E':
+		t.cx = 0
+		t.cy = clampInt(t.cy+arg(0, 1), 0, t.h-1)
+	case 'F':
+		t.cx = 0
+		t.cy = clampInt(t.cy-arg(0, 1), 0, t.h-1)
+	case 'G', '`':
+		t.cx = clampInt(arg(0, 1)-1, 0, t.w-1)
+	case 'd':
+		t.cy = clampInt(arg(0, 1)-1, 0, t.h-1)
+	case 'H', 'f':
+		t.cy = clampInt(arg(0, 1)-1, 0, t.h-1)
+		t.cx = clampInt(arg(1, 1)-1, 0, t.w-1)
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			t.eraseRow(t.cy, t.cx, t.w)
+			for row := t.cy + 1; row < t.h; row++ {
+				t.eraseRow(row, 0, t.w)
+			}
+		case 1:
+			for row := 0; row < t.cy; row++ {
+				t.eraseRow(row, 0, t.w)
+			}
+			t.eraseRow(t.cy, 0, t.cx+1)
+		case 2, 3:
+			for row := 0; row < t.h; row++ {
+				t.eraseRow(row, 0, t.w)
+			}
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			t.eraseRow(t.cy, t.cx, t.w)
+		case 1:
+			t.eraseRow(t.cy, 0, t.cx+1)
+		case 2:
+			t.eraseRow(t.cy, 0, t.w)
+		}
+	case 'X':
+		t.eraseRow(t.cy, t.cx, t.cx+arg(0, 1))
+	case 'L':
+		if t.cy >= t.top && t.cy <= t.bot {
+			t.scroll(t.cy, t.bot, -arg(0, 1))
+		}
+	case 'M':
+		if t.cy >= t.top && t.cy <= t.bot {
+			t.scroll(t.cy, t.bot, arg(0, 1))
+		}
+	case 'S':
+		t.scroll(t.top, t.bot, arg(0, 1))
+	case 'T':
+		t.scroll(t.top, t.bot, -arg(0, 1))
+	case '@':
+		t.shiftRow(arg(0, 1))
+	case 'P':
+		t.shiftRow(-arg(0, 1))
+	case 'r':
+		top := arg(0, 1) - 1
+		bot := arg(1, t.h) - 1
+		if top < bot && bot < t.h {
+			t.top, t.bot = top, bot
+			t.cx, t.cy = 0, 0
+		}
+	case 's':
+		t.saveCursor()
+	case 'u':
+		t.restoreCursor()
+	case 'm':
+		t.style = sgrStyle(t.style, StyleDefault, params)
+	case 'h', 'l':
+		if private {
+			for _, p := range params {
+				t.mode(p, final == 'h')
+			}
+		}
+	case 'n':
+		if !private && arg(0, 0) == 6 {
+			t.reply("\x1b[" + strconv.Itoa(t.cy+1) + ";" +
+				strconv.Itoa(t.cx+1) + "R")
+		} else if !private && arg(0, 0) == 5 {
+			t.reply("\x1b[0n")
+		}
+	case 'c':
+		if !private && arg(0, 0) == 0 {
+			// a VT100 with advanced video
+			t.reply("\x1b[?1;2c")
+		}
+	}
+}
+
+// shiftRow inserts n blank characters at the cursor, moving the rest of
+// the row right, or deletes -n characters, moving it left.
+func (t *Terminal) shiftRow(n int) {
+	row := t.cy
+	if n > 0 {
+		for col := t.w - 1; col >= t.cx+n; col-- {
+			s := t.cell(col-n, row)
+			t.cell(col, row).SetCell(s.Ch, s.Style)
+		}
+		t.eraseRow(row, t.cx, t.cx+n)
+	} else if n < 0 {
+		n = -n
+		for col := t.cx; col < t.w-n; col++ {
+			s := t.cell(col+n, row)
+			t.cell(col, row).SetCell(s.Ch, s.Style)
+		}
+		t.eraseRow(row, clampInt(t.w-n, t.cx, t.w), t.w)
+	}
+}
+
+func (t *Terminal) mode(p int, on bool) {
+	switch p {
+	case 1:
+		t.appkeys = on
+	case 7:
+		t.nowrap = !on
+	case 25:
+		t.hidden = !on
+	case 47, 1047, 1049:
+		if on == (t.primary != nil) {
+			return
+		}
+		if p == 1049 && on {
+			t.saveCursor()
+		}
+		if on {
+			t.primary = t.cells
+			t.cells = make([]Cell, t.w*t.h)
+			ClearCells(t.cells, StyleDefault)
+		} else {
+			t.cells = t.primary
+			t.primary = nil
+			InvalidateCells(t.cells)
+		}
+		if p == 1049 && !on {
+			t.restoreCursor()
+		}
+	}
+}
+
+// sgrStyle returns the style that results from applying the SGR
+// parameters to style.  Resets, of the whole style or of its colors,
+// go back to base.
+func sgrStyle(style, base Style, params []int) Style {
+	for i := 0; i < len(params); i++ {
+		n := params[i]
+		switch {
+		case n == 0:
+			style = base
+		case n == 1:
+			style = style.Bold(true)
+		case n == 2:
+			style = style.Dim(true)
+		case n == 4:
+			style = style.Underline(true)
+		case n == 5:
+			style = style.Blink(true)
+		case n == 7:
+			style = style.Reverse(true)
+		case n == 8:
+			style = style.Invisible(true)
+		case n == 22:
+			style = style.Bold(false).Dim(false)
+		case n == 24:
+			style = style.Underline(false)
+		case n == 25:
+			style = style.Blink(false)
+		case n == 27:
+			style = style.Reverse(false)
+		case n == 28:
+			style = style.Invisible(false)
+		case n >= 30 && n <= 37:
+			style = style.Foreground(ColorBlack + Color(n-30))
+		case n == 38, n == 48:
+			var c Color
+			c, i = sgrColor(params, i)
+			if n == 38 {
+				style = style.Foreground(c)
+			} else {
+				style = style.Background(c)
+			}
+		case n == 39:
+			fg, _, _ := base.Decompose()
+			style = style.Foreground(fg)
+		case n >= 40 && n <= 47:
+			style = style.Background(ColorBlack + Color(n-40))
+		case n == 49:
+			_, bg, _ := base.Decompose()
+			style = style.Background(bg)
+		case n >= 90 && n <= 97:
+			style = style.Foreground(ColorGrey + Color(n-90))
+		case n >= 100 && n <= 107:
+			style = style.Background(ColorGrey + Color(n-100))
+		}
+	}
+	return style
+}
+
+// sgrColor decodes the extended color that starts at params[i], which
+// is 38 or 48, and returns it with the index of its last parameter.
+func sgrColor(params []int, i int) (Color, int) {
+	switch {
+	case i+2 < len(params) && params[i+1] == 5:
+		return ColorBlack + Color(clampInt(params[i+2], 0, 255)), i + 2
+	case i+4 < len(params) && params[i+1] == 2:
+		r := clampInt(params[i+2], 0, 255)
+		g := clampInt(params[i+3], 0, 255)
+		b := clampInt(params[i+4], 0, 255)
+		return NewRGBColor(r, g, b), i + 4
+	}
+	return ColorDefault, len(params)
+}
+
+func (t *Terminal) reply(s string) {
+	if t.out != nil {
+		io.WriteString(t.out, s)
+	}
+}
+
+// SendKey sends the key to the process, encoded as an xterm would send
+// it.  Keys that an xterm cannot send are ignored.
+func (t *Terminal) SendKey(ev *EventKey) error {
+	t.Lock()
+	s := t.keyString(ev)
+	out := t.out
+	t.Unlock()
+	if s == "" || out == nil {
+		return nil
+	}
+	_, e := io.WriteString(out, s)
+	return e
+}
+
+// SendString sends the string to the process, as if it had been typed.
+func (t *Terminal) SendString(s string) error {
+	t.Lock()
+	out := t.out
+	t.Unlock()
+	if out == nil {
+		return nil
+	}
+	_, e := io.WriteString(out, s)
+	return e
+}
+
+// vtCursorKeys are the keys that xterm sends as CSI (or, in application
+// mode, SS3) followed by a letter.
+var vtCursorKeys = map[Key]byte{
+	KeyUp:    'A',
+	KeyDown:  'B',
+	KeyRight: 'C',
+	KeyLeft:  'D',
+	KeyHome:  'H',
+	KeyEnd:   'F',
+	KeyF1:    'P',
+	KeyF2:    'Q',
+	KeyF3:    'R',
+	KeyF4:    'S',
+}
+
+// vtTildeKeys are the keys that xterm sends as CSI, a number, and a
+// tilde.
+var vtTildeKeys = map[Key]int{
+	KeyInsert: 2,
+	KeyDelete: 3,
+	KeyPgUp:   5,
+	KeyPgDn:   6,
+	KeyF5:     15,
+	KeyF6:     17,
+	KeyF7:     18,
+	KeyF8:     19,
+	KeyF9:     20,
+	KeyF10:    21,
+	KeyF11:    23,
+	KeyF12:    24,
+}
+
+func (t *Terminal) keyString(ev *EventKey) string {
+	mod := ev.Mod()
+	// the xterm modifier parameter
+	xmod := 1
+	if mod&ModShift != 0 {
+		xmod++
+	}
+	if mod&ModAlt != 0 {
+		xmod += 2
+	}
+	if mod&ModCtrl != 0 {
+		xmod += 4
+	}
+	prefix := ""
+	if mod&ModAlt != 0 {
+		prefix = "\x1b"
+	}
+	key := ev.Key()
+	switch {
+	case key == KeyRune:
+		return prefix + string(ev.Rune())
+	case key == KeyBacktab:
+		return "\x1b[Z"
+	case key < KeyRune:
+		// the control keys, which are their ASCII values
+		return prefix + string(rune(key))
+	}
+	if c, ok := vtCursorKeys[key]; ok {
+		switch {
+		case xmod > 1:
+			return "\x1b[1;" + strconv.Itoa(xmod) + string(c)
+		case t.appkeys || key >= KeyF1:
+			return "\x1bO" + string(c)
+		}
+		return "\x1b[" + string(c)
+	}
+	if n, ok := vtTildeKeys[key]; ok {
+		s := "\x1b[" + strconv.Itoa(n)
+		if xmod > 1 {
+			s += ";" + strconv.Itoa(xmod)
+		}
+		return s + "~"
+	}
+	return ""
+}
+
+func clampInt(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
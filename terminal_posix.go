@@ -0,0 +1,108 @@
+// +build !windows,!nacl,!plan9,!js
+
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// #if defined(__linux__)
+// #define _GNU_SOURCE
+// #endif
+// #include <stdlib.h>
+// #include <string.h>
+// #include <fcntl.h>
+// #include <unistd.h>
+// #include <sys/ioctl.h>
+//
+// int openpt(char *name, int len) {
+//	int fd;
+//	char *s;
+//	if ((fd = posix_openpt(O_RDWR | O_NOCTTY)) < 0) {
+//		return (-1);
+//	}
+//	if ((grantpt(fd) < 0) || (unlockpt(fd) < 0) ||
+//	    ((s = ptsname(fd)) == NULL) || (strlen(s) >= len)) {
+//		close(fd);
+//		return (-1);
+//	}
+//	strcpy(name, s);
+//	return (fd);
+// }
+//
+// int setwinsize(int fd, int cols, int rows) {
+// #if defined TIOCSWINSZ
+//	struct winsize w;
+//	memset(&w, 0, sizeof (w));
+//	w.ws_col = cols;
+//	w.ws_row = rows;
+//	return (ioctl(fd, TIOCSWINSZ, &w));
+// #else
+//	return (-1);
+// #endif
+// }
+import "C"
+
+// startPty starts the command with a new pseudo-terminal as its standard
+// input, output and error, and controlling terminal.  It returns the
+// master side, from which the command's output is read.
+func startPty(cmd *exec.Cmd, w, h int) (*os.File, error) {
+	var name [256]C.char
+	fd, e := C.openpt(&name[0], C.int(len(name)))
+	if fd < 0 {
+		return nil, e
+	}
+	// non-blocking, so that Close interrupts a pending Read
+	syscall.SetNonblock(int(fd), true)
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	setPtySize(master, w, h)
+
+	slave, e := os.OpenFile(C.GoString(&name[0]),
+		os.O_RDWR|syscall.O_NOCTTY, 0)
+	if e != nil {
+		master.Close()
+		return nil, e
+	}
+	defer slave.Close()
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	if e = cmd.Start(); e != nil {
+		master.Close()
+		return nil, e
+	}
+	return master, nil
+}
+
+// setPtySize tells the pseudo-terminal its size, which sends SIGWINCH
+// to the process running on it.
+func setPtySize(pty *os.File, w, h int) {
+	// Fd would put the file back into blocking mode
+	if rc, e := pty.SyscallConn(); e == nil {
+		rc.Control(func(fd uintptr) {
+			C.setwinsize(C.int(fd), C.int(w), C.int(h))
+		})
+	}
+}
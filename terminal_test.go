@@ -0,0 +1,132 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTerminal(t *testing.T) {
+	Convey("Terminal emulation", t, WithScreen(t, "", func(s SimulationScreen) {
+		vt := NewTerminal(s, 2, 1, 6, 3)
+		row := func(y int) string {
+			return regionText(s, 2, 1+y, 6)
+		}
+
+		Convey("Text wraps, and scrolls", func() {
+			fmt.Fprint(vt, "abcdefgh\r\nij\r\nkl")
+			So(row(0), ShouldEqual, "gh    ")
+			So(row(1), ShouldEqual, "ij    ")
+			So(row(2), ShouldEqual, "kl    ")
+		})
+
+		Convey("Cursor addressing and erasure", func() {
+			fmt.Fprint(vt, "xxxxxx\x1b[2;3Hab\x1b[1;4H\x1b[K\x1b[3;1HQ")
+			So(row(0), ShouldEqual, "xxx   ")
+			So(row(1), ShouldEqual, "  ab  ")
+			So(row(2), ShouldEqual, "Q     ")
+		})
+
+		Convey("Insert and delete lines and characters", func() {
+			fmt.Fprint(vt, "one\r\ntwo\r\nthree\x1b[2;1H\x1b[L")
+			So(row(1), ShouldEqual, "      ")
+			So(row(2), ShouldEqual, "two   ")
+			fmt.Fprint(vt, "\x1b[1;1H\x1b[2P")
+			So(row(0), ShouldEqual, "e     ")
+			fmt.Fprint(vt, "\x1b[3@")
+			So(row(0), ShouldEqual, "   e  ")
+		})
+
+		Convey("Scrolling regions", func() {
+			fmt.Fprint(vt, "top\x1b[2;3r\x1b[2;1Ha\r\nb\r\nc")
+			So(row(0), ShouldEqual, "top   ")
+			So(row(1), ShouldEqual, "b     ")
+			So(row(2), ShouldEqual, "c     ")
+		})
+
+		Convey("The alternate screen is restored", func() {
+			fmt.Fprint(vt, "main\x1b[?1049h\x1b[2J\x1b[HALT")
+			So(row(0), ShouldEqual, "ALT   ")
+			fmt.Fprint(vt, "\x1b[?1049l")
+			So(row(0), ShouldEqual, "main  ")
+		})
+
+		Convey("Colors and attributes", func() {
+			fmt.Fprint(vt, "\x1b[1;31mR\x1b[38;5;200mP\x1b[48;2;0;0;255mB\x1b[0mN")
+			b, pw, _ := s.GetContents()
+			fg, _, attr := b[pw+2].Style.Decompose()
+			So(fg, ShouldEqual, ColorRed)
+			So(attr&AttrBold, ShouldNotEqual, 0)
+			fg, _, _ = b[pw+3].Style.Decompose()
+			So(fg, ShouldEqual, ColorBlack+200)
+			_, bg, _ := b[pw+4].Style.Decompose()
+			So(bg, ShouldEqual, ColorBlack+21)
+			So(b[pw+5].Style, ShouldEqual, StyleDefault)
+		})
+
+		Convey("The title is recorded", func() {
+			fmt.Fprint(vt, "\x1b]2;hello\a")
+			So(vt.Title(), ShouldEqual, "hello")
+		})
+
+		Convey("Cursor position reports are answered", func() {
+			buf := &bytes.Buffer{}
+			vt.out = buf
+			fmt.Fprint(vt, "ab\x1b[6n")
+			So(buf.String(), ShouldEqual, "\x1b[1;3R")
+		})
+
+		Convey("Keys are encoded as an xterm sends them", func() {
+			buf := &bytes.Buffer{}
+			vt.out = buf
+			vt.SendKey(NewEventKey(KeyRune, 'x', ModAlt))
+			vt.SendKey(NewEventKey(KeyUp, 0, ModNone))
+			vt.SendKey(NewEventKey(KeyUp, 0, ModCtrl))
+			vt.SendKey(NewEventKey(KeyF5, 0, ModNone))
+			vt.SendKey(NewEventKey(KeyCtrlC, 0, ModCtrl))
+			fmt.Fprint(vt, "\x1b[?1h")
+			vt.SendKey(NewEventKey(KeyLeft, 0, ModNone))
+			So(buf.String(), ShouldEqual,
+				"\x1bx\x1b[A\x1b[1;5A\x1b[15~\x03\x1bOD")
+		})
+	}))
+}
+
+func TestTerminalPty(t *testing.T) {
+	Convey("Running a process on a pty", t, WithScreen(t, "", func(s SimulationScreen) {
+		sh, e := exec.LookPath("sh")
+		if e != nil {
+			return
+		}
+		vt := NewTerminal(s, 0, 0, 20, 2)
+		e = vt.Start(exec.Command(sh, "-c", "stty size; exit 3"))
+		if e == ErrNoPty {
+			return
+		}
+		So(e, ShouldBeNil)
+		So(vt.Wait(), ShouldNotBeNil)
+		So(regionText(s, 0, 0, 20), ShouldEqual, "2 20                ")
+		ev := s.PollEvent()
+		tev, ok := ev.(*EventTerminal)
+		So(ok, ShouldBeTrue)
+		So(tev.Terminal(), ShouldEqual, vt)
+		vt.Close()
+	}))
+}
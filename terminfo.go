@@ -16,11 +16,9 @@ package tcell
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
-	"os"
-	"path"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -141,48 +139,91 @@ type Terminfo struct {
 	AltChars     string   `json:"acsc,omitempty"`   // acsc
 	EnterAcs     string   `json:"smacs,omitempty"`  // smacs
 	ExitAcs      string   `json:"rmacs,omitempty"`  // rmacs
+	UnderlineX   string   `json:"Smulx,omitempty"`  // Smulx
+	SetUlColor   string   `json:"Setulc,omitempty"` // Setulc
+
+	// These are extensions, for terminals that can set colors as RGB
+	// values, and that support bracketed paste.  The RGB forms take
+	// the red, green and blue components as three parameters.
+	SetFgRGB     string `json:"setrgbf,omitempty"` // setrgbf
+	SetBgRGB     string `json:"setrgbb,omitempty"` // setrgbb
+	EnablePaste  string `json:"BE,omitempty"`      // BE
+	DisablePaste string `json:"BD,omitempty"`      // BD
+	PasteStart   string `json:"PS,omitempty"`      // PS
+	PasteEnd     string `json:"PE,omitempty"`      // PE
+
+	// InitColor redefines a palette color, for terminals that can
+	// change their colors (ccc).  It takes the color number, and the
+	// red, green and blue components on a scale of 0 to 1000.
+	// ResetColors restores the original palette.
+	InitColor   string `json:"initc,omitempty"` // initc
+	ResetColors string `json:"oc,omitempty"`    // oc
+
+	// XOnXOff is set for terminals that use XON/XOFF flow control, and
+	// so need no padding, except where it is mandatory.
+	XOnXOff bool `json:"xon,omitempty"` // xon
 }
 
-type stack []string
+// stackElem is a value on the TParm stack.  Numbers are kept as such, so
+// that arithmetic on them needs no conversion to and from strings.
+type stackElem struct {
+	s     string
+	i     int
+	isInt bool
+}
+
+func (e stackElem) String() string {
+	if e.isInt {
+		return strconv.Itoa(e.i)
+	}
+	return e.s
+}
+
+func (e stackElem) Int() int {
+	if e.isInt {
+		return e.i
+	}
+	i, _ := strconv.Atoi(e.s)
+	return i
+}
+
+type stack []stackElem
 
 func (st stack) Push(v string) stack {
-	return append(st, v)
+	return append(st, stackElem{s: v})
 }
 
-func (st stack) Pop() (string, stack) {
-	v := ""
+func (st stack) pop() (stackElem, stack) {
+	var e stackElem
 	if len(st) > 0 {
-		v = st[len(st)-1]
+		e = st[len(st)-1]
 		st = st[:len(st)-1]
 	}
-	return v, st
+	return e, st
 }
 
 func (st stack) PopInt() (int, stack) {
-	v := ""
-	v, st = st.Pop()
-	i, _ := strconv.Atoi(v)
-	return i, st
+	e, st := st.pop()
+	return e.Int(), st
 }
 
 func (st stack) PopBool() (bool, stack) {
-	v := ""
-	v, st = st.Pop()
-	if v == "1" {
-		return true, st
+	e, st := st.pop()
+	if e.isInt {
+		return e.i == 1, st
 	}
-	return false, st
+	return e.s == "1", st
 }
 
 func (st stack) PushInt(i int) stack {
-	return st.Push(strconv.Itoa(i))
+	return append(st, stackElem{i: i, isInt: true})
 }
 
 func (st stack) PushBool(i bool) stack {
 	if i {
-		return st.Push("1")
+		return st.PushInt(1)
 	}
-	return st.Push("0")
+	return st.PushInt(0)
 }
 
 func nextch(s string, index int) (byte, int) {
@@ -194,21 +235,27 @@ func nextch(s string, index int) (byte, int) {
 }
 
 // static vars
-var svars [26]string
+var svars [26]stackElem
 
 // TParm takes a terminfo parameterized string, such as setaf or cup, and
 // evaluates the string, and returns the result with the parameter
 // applied.
 func (t *Terminfo) TParm(s string, p ...int) string {
-	var stk stack
-	var a, b string
+	var stkbuf [8]stackElem
+	var outbuf [32]byte
+	var numbuf [20]byte
+	var a, b stackElem
 	var ai, bi int
 	var ab bool
-	var dvars [26]string
+	var dvars [26]stackElem
 	var params [9]int
 
-	buf := bytes.NewBufferString(s)
-	out := &bytes.Buffer{}
+	// The stack and output start out in local storage, which is enough
+	// for nearly all strings, so that evaluation only allocates the
+	// result.
+	stk := stack(stkbuf[:0])
+	buf := strings.NewReader(s)
+	out := bytes.NewBuffer(outbuf[:0])
 
 	// make sure we always have 9 parameters -- makes it easier
 	// later to skip checks
@@ -248,32 +295,40 @@ func (t *Terminfo) TParm(s string, p ...int) string {
 			// NB: these, and 'd' below are special cased for
 			// efficiency.  They could be handled by the richer
 			// format support below, less efficiently.
-			a, stk = stk.Pop()
-			out.WriteString(a)
+			a, stk = stk.pop()
+			if a.isInt {
+				out.Write(strconv.AppendInt(numbuf[:0], int64(a.i), 10))
+			} else {
+				out.WriteString(a.s)
+			}
 
 		case 'd':
 			ai, stk = stk.PopInt()
-			out.WriteString(strconv.Itoa(ai))
+			out.Write(strconv.AppendInt(numbuf[:0], int64(ai), 10))
 
-		case '0', '1', '2', '3', '4', 'x', 'X', 'o', ':':
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '.',
+			'x', 'X', 'o', ':':
+			// printf style, %[[:]flags][width[.precision]]conv
 			if ch == ':' {
 				ch, _ = buf.ReadByte()
 			}
-			f := string(ch)
+			f := "%"
 			for ch == '+' || ch == '-' || ch == '#' || ch == ' ' {
 				f += string(ch)
+				ch, _ = buf.ReadByte()
 			}
 			for (ch >= '0' && ch <= '9') || ch == '.' {
-				ch, _ = buf.ReadByte()
 				f += string(ch)
+				ch, _ = buf.ReadByte()
 			}
+			f += string(ch)
 			switch ch {
 			case 'd', 'x', 'X', 'o':
 				ai, stk = stk.PopInt()
 				out.WriteString(fmt.Sprintf(f, ai))
 			case 'c', 's':
-				a, stk = stk.Pop()
-				out.WriteString(fmt.Sprintf(f, a))
+				a, stk = stk.pop()
+				out.WriteString(fmt.Sprintf(f, a.String()))
 			}
 
 		case 'p': // push parameter
@@ -288,17 +343,17 @@ func (t *Terminfo) TParm(s string, p ...int) string {
 		case 'P': // pop & store variable
 			ch, _ = buf.ReadByte()
 			if ch >= 'A' && ch <= 'Z' {
-				svars[int(ch-'A')], stk = stk.Pop()
+				svars[int(ch-'A')], stk = stk.pop()
 			} else if ch >= 'a' && ch <= 'z' {
-				dvars[int(ch-'a')], stk = stk.Pop()
+				dvars[int(ch-'a')], stk = stk.pop()
 			}
 
 		case 'g': // recall & push variable
 			ch, _ = buf.ReadByte()
 			if ch >= 'A' && ch <= 'Z' {
-				stk = stk.Push(svars[int(ch-'A')])
+				stk = append(stk, svars[int(ch-'A')])
 			} else if ch >= 'a' && ch <= 'z' {
-				stk = stk.Push(dvars[int(ch-'a')])
+				stk = append(stk, dvars[int(ch-'a')])
 			}
 
 		case '\'': // push(char)
@@ -318,8 +373,8 @@ func (t *Terminfo) TParm(s string, p ...int) string {
 			stk = stk.PushInt(ai)
 
 		case 'l': // push(strlen(pop))
-			a, stk = stk.Pop()
-			stk = stk.PushInt(len(a))
+			a, stk = stk.pop()
+			stk = stk.PushInt(len(a.String()))
 
 		case '+':
 			bi, stk = stk.PopInt()
@@ -378,9 +433,13 @@ func (t *Terminfo) TParm(s string, p ...int) string {
 			stk = stk.PushBool(ai != 0)
 
 		case '=': // numeric compare or string compare
-			b, stk = stk.Pop()
-			a, stk = stk.Pop()
-			stk = stk.PushBool(a == b)
+			b, stk = stk.pop()
+			a, stk = stk.pop()
+			if a.isInt && b.isInt {
+				stk = stk.PushBool(a.i == b.i)
+			} else {
+				stk = stk.PushBool(a.String() == b.String())
+			}
 
 		case '>': // greater than, numeric
 			bi, stk = stk.PopInt()
@@ -461,6 +520,14 @@ func (t *Terminfo) TParm(s string, p ...int) string {
 	return out.String()
 }
 
+// TPuts emits the string to the writer, replacing any padding that it
+// calls for ($<n>) with enough pad characters to take n milliseconds to
+// send at the given baud rate.  The delay may have a tenth of a
+// millisecond (as in $<2.5>), and may be followed by * to have it scale
+// with the number of lines affected (which we take to be one), and by /
+// to make it mandatory.  Terminals with XON/XOFF flow control only get
+// mandatory padding.  A baud rate of zero, or a terminal without a pad
+// character, gets no padding at all.
 func (t *Terminfo) TPuts(w io.Writer, s string, baud int) {
 	for {
 		beg := strings.Index(s, "$<")
@@ -479,32 +546,41 @@ func (t *Terminfo) TPuts(w io.Writer, s string, baud int) {
 		}
 		val := s[:end]
 		s = s[end+1:]
-		padus := 0
-		unit := 1000
+		tenths := 0 // of a millisecond
+		digits := 0
 		dot := false
+		mandatory := false
 	loop:
 		for i := range val {
-			switch val[i] {
-			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-				padus *= 10
-				padus += int(val[i] - '0')
+			switch c := val[i]; {
+			case c >= '0' && c <= '9':
 				if dot {
-					unit *= 10
-				}
-			case '.':
-				if !dot {
-					dot = true
-				} else {
-					break loop
+					if digits > 0 {
+						// only tenths count
+						continue
+					}
+					digits++
 				}
+				tenths = tenths*10 + int(c-'0')
+			case c == '.' && !dot:
+				dot = true
+			case c == '*':
+			case c == '/':
+				mandatory = true
 			default:
 				break loop
 			}
 		}
-		cnt := int(((baud / 8) * padus) / unit)
-		for cnt > 0 {
+		if !dot || digits == 0 {
+			tenths *= 10
+		}
+		if baud <= 0 || t.PadChar == "" || (t.XOnXOff && !mandatory) {
+			continue
+		}
+		// Like curses, we reckon on nine bits to the character: seven
+		// data bits, a parity bit, and a stop bit.
+		for cnt := baud * tenths / 90000; cnt > 0; cnt-- {
 			io.WriteString(w, t.PadChar)
-			cnt--
 		}
 	}
 }
@@ -515,6 +591,22 @@ func (t *Terminfo) TGoto(col, row int) string {
 	return t.TParm(t.SetCursor, row, col)
 }
 
+// GetString returns the value of the string capability with the given
+// terminfo name, such as "smcup", and whether the terminal has it.  Only
+// the capabilities that Terminfo has fields for are known.
+func (t *Terminfo) GetString(name string) (string, bool) {
+	v := reflect.ValueOf(t).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == name && f.Type.Kind() == reflect.String {
+			s := v.Field(i).String()
+			return s, s != ""
+		}
+	}
+	return "", false
+}
+
 // Color returns a string corresponding to the given foreground and background
 // colors.  Either fg or bg can be set to -1 to elide.
 func (t *Terminfo) TColor(fg, bg Color) string {
@@ -566,32 +658,13 @@ func AddTerminfo(t *Terminfo) {
 	dblock.Unlock()
 }
 
-func loadFromFile(fname string, term string) (*Terminfo, error) {
-	if f, e := os.Open(fname); e != nil {
-		return nil, ErrNoDatabase
-	} else {
-		d := json.NewDecoder(f)
-		for {
-			t := &Terminfo{}
-			if e := d.Decode(t); e != nil {
-				if e == io.EOF {
-					return nil, ErrTermNotFound
-				}
-				return nil, e
-			}
-			if t.Name == term {
-				return t, nil
-			}
-		}
-	}
-}
-
 // LookupTerminfo attemps to find a definition for the named $TERM.
 // It first looks in the builtin database, which should cover just about
 // everyone.  If it can't find one there, then it will attempt to read
-// one from the JSON file located in either $TCELLDB, or in this package's
-// source directory.  (XXX: Perhaps move that to $HOME/.tcelldb or somesuch
-// instead?  What about somewhere in /etc?)
+// one from the JSON files listed in $TCELLDB, or else from the one in
+// this package's source directory.  (See ReadTerminfo.)  Failing that,
+// names ending with -256color or -direct are taken to be those of a known
+// terminal with more colors.  (See deriveTerminfo.)
 func LookupTerminfo(name string) (*Terminfo, error) {
 	dblock.Lock()
 	initDB()
@@ -600,29 +673,14 @@ func LookupTerminfo(name string) (*Terminfo, error) {
 
 	if t == nil {
 		var e error
-		// Load the database located here.  Its expected that TCELLSDB
-		// points either to a single JSON file, or to a directory of
-		// of files all of which should be loaded.
-		if pth := os.Getenv("TCELLDB"); pth != "" {
-			t, e = loadFromFile(pth, name)
-		} else {
-			pth = path.Join(os.Getenv("GOPATH"), "src",
-				"github.com", "gdamore", "tcell",
-				"database.json")
-			t, e = loadFromFile(pth, name)
-
-		}
-		if t == nil {
-			return nil, e
-		}
-		if t != nil {
-			dblock.Lock()
-			terminfos[name] = t
-			dblock.Unlock()
+		if t, e = searchDatabase(databasePath(), name); t == nil {
+			if t = deriveTerminfo(name); t == nil {
+				return nil, e
+			}
 		}
-	}
-	if t == nil {
-		return nil, ErrTermNotFound
+		dblock.Lock()
+		terminfos[name] = t
+		dblock.Unlock()
 	}
 	return t, nil
 }
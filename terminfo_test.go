@@ -16,6 +16,11 @@ package tcell
 
 import (
 	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -86,5 +91,111 @@ func TestTerminfo(t *testing.T) {
 			s := string(buf.Bytes())
 			So(s, ShouldEqual, "\x1b2ms")
 		})
+
+		Convey("Padding forms", func() {
+			pad := func(s string, baud int) string {
+				buf := bytes.NewBuffer(nil)
+				ti.TPuts(buf, s, baud)
+				return buf.String()
+			}
+			So(pad("a$<10>b", 9000), ShouldEqual, "a"+strings.Repeat("\x00", 10)+"b")
+			So(pad("$<2.5*/>", 36000), ShouldEqual, strings.Repeat("\x00", 10))
+			So(pad("$<2.55>", 36000), ShouldEqual, strings.Repeat("\x00", 10))
+			So(pad("$<5>", 0), ShouldEqual, "")
+			So(pad("$<5", 9600), ShouldEqual, "$<5")
+
+			xon := *ti
+			xon.XOnXOff = true
+			buf := bytes.NewBuffer(nil)
+			xon.TPuts(buf, "$<5>x$<5/>", 18000)
+			So(buf.String(), ShouldEqual, "x"+strings.Repeat("\x00", 10))
+
+			nopad := *ti
+			nopad.PadChar = ""
+			buf.Reset()
+			nopad.TPuts(buf, "$<5/>", 18000)
+			So(buf.String(), ShouldEqual, "")
+		})
+	})
+}
+
+func TestModernTerminals(t *testing.T) {
+	Convey("Builtin entries for modern terminals", t, func() {
+		for _, name := range []string{"alacritty", "contour-latest",
+			"foot", "xterm-kitty", "tmux-256color", "screen-256color",
+			"wezterm"} {
+			ti, e := LookupTerminfo(name)
+			So(e, ShouldBeNil)
+			So(ti.Colors, ShouldEqual, 256)
+			So(ti.Mouse, ShouldNotEqual, "")
+		}
+
+		ti, e := LookupTerminfo("foot-direct")
+		So(e, ShouldBeNil)
+		So(ti.Colors, ShouldEqual, 256)
+		So(ti.TParm(ti.SetFg, 200), ShouldEqual, "\x1b[38;5;200m")
+		So(ti.TParm(ti.SetFgRGB, 51, 102, 153), ShouldEqual,
+			"\x1b[38;2;51;102;153m")
+		So(ti.EnablePaste, ShouldEqual, "\x1b[?2004h")
+		So(ti.PasteStart, ShouldEqual, "\x1b[200~")
+	})
+}
+
+func TestTerminfoDatabase(t *testing.T) {
+	Convey("Terminal databases in files", t, func() {
+		dir, e := ioutil.TempDir("", "tcell")
+		So(e, ShouldBeNil)
+		env := os.Getenv("TCELLDB")
+		Reset(func() {
+			os.Setenv("TCELLDB", env)
+			os.RemoveAll(dir)
+		})
+		a := &Terminfo{Name: "tcell-test-a", Aliases: []string{"tcell-test-alias"},
+			Colors: 8, Clear: "\x1b[H\x1b[2J"}
+		b := &Terminfo{Name: "tcell-test-b", Colors: 256}
+
+		var buf bytes.Buffer
+		So(WriteTerminfo(&buf, a, b), ShouldBeNil)
+		tis, e := ReadTerminfo(&buf)
+		So(e, ShouldBeNil)
+		So(tis, ShouldResemble, []*Terminfo{a, b})
+
+		So(ioutil.WriteFile(filepath.Join(dir, "a.json"),
+			[]byte(`{"name":"tcell-test-a","colors":16}`), 0644), ShouldBeNil)
+		f, e := os.Create(filepath.Join(dir, "b.json.gz"))
+		So(e, ShouldBeNil)
+		gz := gzip.NewWriter(f)
+		So(WriteTerminfo(gz, a, b), ShouldBeNil)
+		gz.Close()
+		f.Close()
+
+		Convey("Files and directories are searched in order", func() {
+			os.Setenv("TCELLDB", filepath.Join(dir, "none.json")+
+				string(filepath.ListSeparator)+dir)
+			ti, e := searchDatabase(databasePath(), "tcell-test-a")
+			So(e, ShouldBeNil)
+			So(ti.Colors, ShouldEqual, 16)
+			ti, e = searchDatabase(databasePath(), "tcell-test-alias")
+			So(e, ShouldBeNil)
+			So(ti.Name, ShouldEqual, "tcell-test-a")
+			So(ti.Colors, ShouldEqual, 8)
+			ti, e = LookupTerminfo("tcell-test-b")
+			So(e, ShouldBeNil)
+			So(ti.Colors, ShouldEqual, 256)
+			_, e = searchDatabase(databasePath(), "tcell-test-c")
+			So(e, ShouldEqual, ErrTermNotFound)
+		})
+
+		Convey("Missing files are no database", func() {
+			_, e := searchDatabase([]string{filepath.Join(dir, "none.json")}, "xterm")
+			So(e, ShouldEqual, ErrNoDatabase)
+		})
+
+		Convey("Files can be loaded", func() {
+			So(LoadTerminfo(filepath.Join(dir, "b.json.gz")), ShouldBeNil)
+			ti, e := LookupTerminfo("tcell-test-alias")
+			So(e, ShouldBeNil)
+			So(ti.Clear, ShouldEqual, a.Clear)
+		})
 	})
 }
@@ -0,0 +1,347 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"sync"
+	"time"
+)
+
+// NewTiledScreen returns a Screen that spans several Screens (heads),
+// placed side by side from left to right, as for a status wall made up of
+// several terminals.  The application draws on one logical screen, as
+// wide as all of the heads together, and as tall as the shortest of them;
+// each cell is drawn on the head that it falls on.  Events from all of
+// the heads are delivered, with mouse positions translated to the logical
+// screen.  When a head is resized, the layout is redone, and an
+// EventResize with the new logical size is delivered.
+//
+// The cursor is shown on whichever head it falls on.  Colors returns the
+// fewest colors of any head, and CharacterSet that of the first.  Init
+// and Fini apply to all of the heads.
+func NewTiledScreen(heads ...Screen) Screen {
+	return &tiledscreen{heads: heads}
+}
+
+type tiledscreen struct {
+	heads  []Screen
+	offs   []int // the logical column at which each head starts
+	w      int
+	h      int
+	evq    chan Event
+	filter eventFilter
+	quit   chan struct{}
+	once   sync.Once
+
+	sync.Mutex
+}
+
+func (ts *tiledscreen) Init() error {
+	for i, s := range ts.heads {
+		if e := s.Init(); e != nil {
+			for _, s := range ts.heads[:i] {
+				s.Fini()
+			}
+			return e
+		}
+	}
+	ts.evq = make(chan Event, 10)
+	ts.quit = make(chan struct{})
+	ts.layout()
+	for i, s := range ts.heads {
+		go ts.pump(i, s)
+	}
+	return nil
+}
+
+// layout places the heads, from their current sizes, and reports whether
+// the size of the logical screen changed.
+func (ts *tiledscreen) layout() bool {
+	ts.Lock()
+	defer ts.Unlock()
+	ow, oh := ts.w, ts.h
+	ts.offs = ts.offs[:0]
+	ts.w, ts.h = 0, 0
+	for i, s := range ts.heads {
+		w, h := s.Size()
+		ts.offs = append(ts.offs, ts.w)
+		ts.w += w
+		if i == 0 || h < ts.h {
+			ts.h = h
+		}
+	}
+	return ts.w != ow || ts.h != oh
+}
+
+// head returns the head that the logical column falls on, and the column
+// within that head, or nil if there is none.
+func (ts *tiledscreen) head(x int) (Screen, int) {
+	ts.Lock()
+	defer ts.Unlock()
+	if x < 0 || x >= ts.w {
+		return nil, 0
+	}
+	for i := len(ts.offs) - 1; i >= 0; i-- {
+		if x >= ts.offs[i] {
+			return ts.heads[i], x - ts.offs[i]
+		}
+	}
+	return nil, 0
+}
+
+// pump passes on the events of a head.
+func (ts *tiledscreen) pump(i int, s Screen) {
+	for {
+		ev := s.PollEvent()
+		if ev == nil {
+			return
+		}
+		switch e := ev.(type) {
+		case *EventResize:
+			if !ts.layout() {
+				continue
+			}
+			ev = NewEventResize(ts.Size())
+		case *EventMouse:
+			ts.Lock()
+			m := *e
+			m.x += ts.offs[i]
+			m.pix = false
+			ts.Unlock()
+			ev = &m
+		}
+		select {
+		case ts.evq <- ev:
+		case <-ts.quit:
+			return
+		}
+	}
+}
+
+func (ts *tiledscreen) Fini() {
+	ts.once.Do(func() {
+		if ts.quit != nil {
+			close(ts.quit)
+		}
+	})
+	for _, s := range ts.heads {
+		s.Fini()
+	}
+}
+
+func (ts *tiledscreen) PollEvent() Event {
+	return ts.filter.poll(ts.quit, ts.evq)
+}
+
+func (ts *tiledscreen) SetEventFilter(f EventFilter) {
+	ts.filter.set(f)
+}
+
+func (ts *tiledscreen) SetIdleTimeout(d time.Duration) {
+	ts.filter.setIdle(d)
+}
+
+func (ts *tiledscreen) PostEvent(ev Event) {
+	select {
+	case ts.evq <- ev:
+	default:
+		// drop the event on the floor
+	}
+}
+
+func (ts *tiledscreen) Size() (int, int) {
+	ts.Lock()
+	defer ts.Unlock()
+	return ts.w, ts.h
+}
+
+func (ts *tiledscreen) SetCell(x, y int, style Style, ch ...rune) {
+	if s, hx := ts.head(x); s != nil {
+		s.SetCell(hx, y, style, ch...)
+	}
+}
+
+func (ts *tiledscreen) PutCell(x, y int, cell *Cell) {
+	if s, hx := ts.head(x); s != nil {
+		s.PutCell(hx, y, cell)
+	}
+}
+
+func (ts *tiledscreen) GetCell(x, y int) *Cell {
+	if s, hx := ts.head(x); s != nil {
+		return s.GetCell(hx, y)
+	}
+	return nil
+}
+
+func (ts *tiledscreen) Contents() *Contents {
+	ts.Lock()
+	offs := append([]int(nil), ts.offs...)
+	c := newContents(nil, ts.w, ts.h, -1, -1)
+	ts.Unlock()
+	for i, s := range ts.heads {
+		if i >= len(offs) {
+			break
+		}
+		hc := screenContents(s)
+		for y := 0; y < hc.Height && y < c.Height; y++ {
+			for x := 0; x < hc.Width && offs[i]+x < c.Width; x++ {
+				c.Cells[(y*c.Width)+offs[i]+x] = hc.Cells[(y*hc.Width)+x]
+			}
+		}
+		if hc.CursorX >= 0 && hc.CursorY < c.Height {
+			c.CursorX, c.CursorY = offs[i]+hc.CursorX, hc.CursorY
+		}
+	}
+	return c
+}
+
+func (ts *tiledscreen) ShowCursor(x, y int) {
+	on, hx := ts.head(x)
+	for _, s := range ts.heads {
+		if s == on {
+			s.ShowCursor(hx, y)
+		} else {
+			s.HideCursor()
+		}
+	}
+}
+
+func (ts *tiledscreen) HideCursor() {
+	ts.ShowCursor(-1, -1)
+}
+
+func (ts *tiledscreen) ExternalWriter(x, y, width, height int) *RegionWriter {
+	return NewRegionWriter(ts, x, y, width, height, StyleDefault)
+}
+
+func (ts *tiledscreen) Colors() int {
+	colors := 0
+	for i, s := range ts.heads {
+		if c := s.Colors(); i == 0 || c < colors {
+			colors = c
+		}
+	}
+	return colors
+}
+
+// HasMouse is true if any of the heads has a mouse, as any of them can be
+// clicked on.  Likewise for keys.
+func (ts *tiledscreen) HasMouse() bool {
+	for _, s := range ts.heads {
+		if cs, ok := s.(CapabilityScreen); !ok || cs.HasMouse() {
+			return true
+		}
+	}
+	return false
+}
+
+func (ts *tiledscreen) HasKey(k Key) bool {
+	for _, s := range ts.heads {
+		if cs, ok := s.(CapabilityScreen); !ok || cs.HasKey(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ts *tiledscreen) ColorModel() ColorModel {
+	model := ColorModelNone
+	for i, s := range ts.heads {
+		if m := colorModelOf(s); i == 0 || m < model {
+			model = m
+		}
+	}
+	return model
+}
+
+func (ts *tiledscreen) DefaultColors() bool {
+	for _, s := range ts.heads {
+		if cs, ok := s.(ColorModelScreen); ok && !cs.DefaultColors() {
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *tiledscreen) CharacterSet() string {
+	if len(ts.heads) == 0 {
+		return "UTF-8"
+	}
+	return ts.heads[0].CharacterSet()
+}
+
+func (ts *tiledscreen) Reinitialize(term string) error {
+	for _, s := range ts.heads {
+		if rs, ok := s.(ReinitializeScreen); ok {
+			if e := rs.Reinitialize(term); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (ts *tiledscreen) Clear() {
+	for _, s := range ts.heads {
+		s.Clear()
+	}
+}
+
+func (ts *tiledscreen) SetStyle(style Style) {
+	for _, s := range ts.heads {
+		s.SetStyle(style)
+	}
+}
+
+func (ts *tiledscreen) EnableMouse(flags ...MouseFlags) {
+	for _, s := range ts.heads {
+		s.EnableMouse(flags...)
+	}
+}
+
+func (ts *tiledscreen) DisableMouse() {
+	for _, s := range ts.heads {
+		s.DisableMouse()
+	}
+}
+
+func (ts *tiledscreen) EnableBlink(rate time.Duration) {
+	for _, s := range ts.heads {
+		if bs, ok := s.(BlinkScreen); ok {
+			bs.EnableBlink(rate)
+		}
+	}
+}
+
+func (ts *tiledscreen) DisableBlink() {
+	for _, s := range ts.heads {
+		if bs, ok := s.(BlinkScreen); ok {
+			bs.DisableBlink()
+		}
+	}
+}
+
+func (ts *tiledscreen) Show() {
+	for _, s := range ts.heads {
+		s.Show()
+	}
+}
+
+func (ts *tiledscreen) Sync() {
+	for _, s := range ts.heads {
+		s.Sync()
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTiledScreen(t *testing.T) {
+	Convey("Screens side by side", t, func() {
+		left := NewSimulationScreen("")
+		right := NewSimulationScreen("")
+		ts := NewTiledScreen(left, right)
+		So(ts.Init(), ShouldBeNil)
+		Reset(ts.Fini)
+
+		w, h := ts.Size()
+		So(w, ShouldEqual, 160)
+		So(h, ShouldEqual, 25)
+
+		ts.SetCell(85, 2, StyleDefault, 'x')
+		ts.SetCell(160, 2, StyleDefault, 'y')
+		ts.ShowCursor(85, 2)
+		ts.Show()
+		b, bw, _ := right.GetContents()
+		So(b[2*bw+5].Runes, ShouldResemble, []rune{'x'})
+		So(ts.GetCell(85, 2).Ch, ShouldResemble, []rune{'x'})
+		So(ts.GetCell(160, 2), ShouldBeNil)
+		x, y, vis := right.GetCursor()
+		So([]int{x, y}, ShouldResemble, []int{5, 2})
+		So(vis, ShouldBeTrue)
+		_, _, vis = left.GetCursor()
+		So(vis, ShouldBeFalse)
+		c := ts.(ContentsScreen).Contents()
+		So(c.Cell(85, 2).Ch, ShouldResemble, []rune{'x'})
+		So([]int{c.CursorX, c.CursorY}, ShouldResemble, []int{85, 2})
+		cm := ts.(ColorModelScreen)
+		So(cm.ColorModel(), ShouldEqual, ColorModel256)
+		So(cm.DefaultColors(), ShouldBeTrue)
+
+		Convey("Mouse positions are translated", func() {
+			right.InjectMouse(1, 3, Button1, ModNone)
+			ev := ts.PollEvent().(*EventMouse)
+			x, y := ev.Position()
+			So([]int{x, y}, ShouldResemble, []int{81, 3})
+		})
+
+		Convey("Resizing a head changes the layout", func() {
+			left.Resize(70, 20)
+			left.Show()
+			ev := ts.PollEvent().(*EventResize)
+			w, h := ev.Size()
+			So([]int{w, h}, ShouldResemble, []int{150, 20})
+			ts.SetCell(75, 0, StyleDefault, 'z')
+			So(right.GetCell(5, 0).Ch, ShouldResemble, []rune{'z'})
+		})
+
+		Convey("Fini stops PollEvent", func() {
+			ts.Fini()
+			So(ts.PollEvent(), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"strings"
+)
+
+// Transform is a mapping of the cell grid that the application draws
+// on to the physical display, for displays that are mounted sideways or
+// upside down, or are viewed in a mirror.  The mapping is done entirely
+// by the renderer; applications see a normal screen (with the width and
+// height exchanged by the rotations of 90 and 270 degrees), and mouse
+// positions are mapped back to it.  Note that characters themselves are
+// not rotated, as that is up to the display, and that double width
+// characters cannot be shown sideways, so they are replaced by "?" when
+// the display is rotated by 90 or 270 degrees.
+//
+// The initial transform for terminals can be set with the TCELL_TRANSFORM
+// environment variable, using the names "rotate90", "rotate180",
+// "rotate270" and "mirror".
+type Transform int
+
+const (
+	// TransformNone displays the grid as is.
+	TransformNone Transform = iota
+
+	// TransformRotate90 rotates the grid clockwise by 90 degrees, so
+	// that the top row of the grid is shown in the rightmost column.
+	TransformRotate90
+
+	// TransformRotate180 rotates the grid by 180 degrees.
+	TransformRotate180
+
+	// TransformRotate270 rotates the grid clockwise by 270 degrees, so
+	// that the top row of the grid is shown in the leftmost column.
+	TransformRotate270
+
+	// TransformMirror reverses the grid from left to right.
+	TransformMirror
+)
+
+// parseTransform returns the transform with the given name, as used for
+// TCELL_TRANSFORM.  Unknown names select TransformNone.
+func parseTransform(name string) Transform {
+	switch strings.ToLower(name) {
+	case "rotate90":
+		return TransformRotate90
+	case "rotate180":
+		return TransformRotate180
+	case "rotate270":
+		return TransformRotate270
+	case "mirror":
+		return TransformMirror
+	}
+	return TransformNone
+}
+
+// String returns the name of the transform, as used for TCELL_TRANSFORM.
+func (tr Transform) String() string {
+	switch tr {
+	case TransformRotate90:
+		return "rotate90"
+	case TransformRotate180:
+		return "rotate180"
+	case TransformRotate270:
+		return "rotate270"
+	case TransformMirror:
+		return "mirror"
+	}
+	return "none"
+}
+
+// swaps returns true if the transform exchanges width and height.
+func (tr Transform) swaps() bool {
+	return tr == TransformRotate90 || tr == TransformRotate270
+}
+
+// apply maps the position x, y on a grid of size w, h to its position on
+// the physical display.
+func (tr Transform) apply(x, y, w, h int) (int, int) {
+	switch tr {
+	case TransformRotate90:
+		return h - 1 - y, x
+	case TransformRotate180:
+		return w - 1 - x, h - 1 - y
+	case TransformRotate270:
+		return y, w - 1 - x
+	case TransformMirror:
+		return w - 1 - x, y
+	}
+	return x, y
+}
+
+// invert maps the position px, py on the physical display back to the
+// position on the grid of size w, h.
+func (tr Transform) invert(px, py, w, h int) (int, int) {
+	switch tr {
+	case TransformRotate90:
+		return py, h - 1 - px
+	case TransformRotate180:
+		return w - 1 - px, h - 1 - py
+	case TransformRotate270:
+		return w - 1 - py, px
+	case TransformMirror:
+		return w - 1 - px, py
+	}
+	return px, py
+}
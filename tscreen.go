@@ -17,10 +17,13 @@ package tcell
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"image"
 	"io"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"golang.org/x/text/transform"
@@ -34,31 +37,212 @@ import (
 // For terminals that do not support dynamic resize events, the $LINES
 // $COLUMNS environment variables can be set to the actual window size,
 // otherwise defaults taken from the terminal database are used.
+//
+// If $TERM is not set, or names a terminal that is not known, then the
+// terminal named by $TCELL_FORCE_TERM is used in its place, if that is
+// set; "xterm" is a good choice for most modern emulators, and "vt100"
+// for anything else.  This lets programs start on hosts whose terminal
+// database is missing or incomplete.  Without it, an error is returned.
+//
+// The descriptions of the Linux console and of screen are upgraded to
+// 256 colors where the terminal is known to support them (see
+// upgrade.go), unless $TCELL_UPGRADE_TERM is set to 0.  Setting it to 1
+// upgrades the Linux console even when it cannot be known to support
+// them, as when it is reached over ssh.
+//
+// Setting $TCELL_TRUECOLOR, $TCELL_ALTSCREEN or $TCELL_MOUSE to on or off
+// overrides what the description says about those capabilities.  (See
+// override.go.)
+//
+// Terminals that cannot address the cursor (such as "dumb", and hardcopy
+// terminals) are drawn a line at a time.  (See drawLines.)  Setting
+// $TCELL_LINE_MODE to 1 does the same on any terminal, which can help
+// with serial consoles that scramble cursor motions.
+//
+// Padding is sent as the terminal description calls for it, at the baud
+// rate of the tty.  Setting $TCELL_PADDING to 0 turns it off, for local
+// terminal emulators that have descriptions meant for hardware terminals.
+//
+// Images (see ImageScreen) are shown with the kitty graphics protocol, or
+// as iTerm2 inline images, on terminals known to support them, from $TERM,
+// $KITTY_WINDOW_ID and $TERM_PROGRAM.  Setting $TCELL_IMAGES to kitty,
+// iterm2, or none overrides this.
+//
+// Output sent with WritePassthrough is wrapped for tmux or GNU screen when
+// running inside them (see passthrough.go), unless $TCELL_PASSTHROUGH is
+// set to 0.
+//
+// The character set is that of the locale (see parseLocale), unless
+// $TCELL_CHARSET names another, for terminals that are set up
+// differently from the host.
+//
+// The screen does its input and output through /dev/tty, not through
+// the standard input and output, so it works even when those are
+// redirected, as for an interactive filter (ls | pick > choice).
+//
+// Some of this can be decided by the program instead; see
+// NewTerminfoScreenWithOptions.
 func NewTerminfoScreen() (Screen, error) {
-	ti, e := LookupTerminfo(os.Getenv("TERM"))
+	return NewTerminfoScreenWithOptions(ScreenOptions{})
+}
+
+// NewTerminfoScreenWithOptions is NewTerminfoScreen, but with the given
+// options taking precedence over the environment.
+func NewTerminfoScreenWithOptions(opts ScreenOptions) (Screen, error) {
+	if e := opts.check(); e != nil {
+		return nil, e
+	}
+	term := opts.Term
+	if term == "" {
+		term = os.Getenv("TERM")
+	}
+	ti, e := lookupTermWithFallback(term)
 	if e != nil {
 		return nil, e
 	}
-	t := &tScreen{ti: ti}
+	ti = overrideTerminfo(upgradeTerminfo(ti))
+	w, h := ti.Columns, ti.Lines
+	// environment overrides
+	if i, _ := strconv.Atoi(os.Getenv("LINES")); i != 0 {
+		h = i
+	}
+	if i, _ := strconv.Atoi(os.Getenv("COLUMNS")); i != 0 {
+		w = i
+	}
+	t := newTScreen(ti, w, h)
+	t.mux = detectMultiplexer(term)
+	t.images.proto = detectImages(term)
+	t.setOptions(opts)
+	return t, nil
+}
 
-	t.keys = make(map[Key][]byte)
-	if len(ti.Mouse) > 0 {
+// newTScreen returns a tScreen for the terminal, with the size to use
+// until the tty reports its own.  (Zero means 80 by 24.)
+func newTScreen(ti *Terminfo, w, h int) *tScreen {
+	t := &tScreen{ti: ti, w: w, h: h, fini: true}
+
+	t.input = newInputParser(ti)
+	t.input.postfn = t.PostEvent
+	t.input.altgr = parseAltGrMode(os.Getenv("TCELL_ALTGR"))
+	if mouseSupport && len(ti.Mouse) > 0 {
 		t.mouse = []byte(ti.Mouse)
 	}
-	t.prepareKeys()
 	t.buildAcsMap()
-	t.w = ti.Columns
-	t.h = ti.Lines
 	t.sigwinch = make(chan os.Signal, 1)
-	// environment overrides
-	if i, _ := strconv.Atoi(os.Getenv("LINES")); i != 0 {
-		t.h = i
+	t.xform = parseTransform(os.Getenv("TCELL_TRANSFORM"))
+	t.nocolor = noColorEnv()
+	t.fullsgr = os.Getenv("TCELL_FULL_SGR") == "1"
+	t.linemode = ti.SetCursor == "" || os.Getenv("TCELL_LINE_MODE") == "1"
+	t.nopad = os.Getenv("TCELL_PADDING") == "0"
+	if t.w <= 0 {
+		t.w = 80
 	}
-	if i, _ := strconv.Atoi(os.Getenv("COLUMNS")); i != 0 {
-		t.w = i
+	if t.h <= 0 {
+		t.h = 24
+	}
+	if t.xform.swaps() {
+		t.w, t.h = t.h, t.w
 	}
 
-	return t, nil
+	return t
+}
+
+// setOptions applies the options that are not handled by Init.
+func (t *tScreen) setOptions(opts ScreenOptions) {
+	t.opts = opts
+	t.opts.setEscapeDelay(t.input)
+	t.mouseon = opts.Mouse
+	t.mousef = opts.MouseFlags
+	if opts.NoColor {
+		t.nocolor = true
+	}
+}
+
+// lookupTermWithFallback looks up the terminal, falling back to the
+// one named by TCELL_FORCE_TERM if it is not known.  The error returned
+// is the one for the original terminal, since that is what the user
+// needs to fix.
+func lookupTermWithFallback(term string) (*Terminfo, error) {
+	ti, e := LookupTerminfo(term)
+	if e == nil {
+		return ti, nil
+	}
+	if force := os.Getenv("TCELL_FORCE_TERM"); force != "" {
+		if fb, _ := LookupTerminfo(force); fb != nil {
+			return fb, nil
+		}
+	}
+	return nil, e
+}
+
+// TtyScreen is implemented by Screens that drive a tty device directly,
+// such as the one returned by NewTerminfoScreen.  Applications can use
+// a type assertion to obtain it.  It is intended for advanced integrations
+// (ioctl based features, custom signal handling, and so forth) that need
+// to cooperate with tcell rather than fight it.  Most applications should
+// never need this.
+type TtyScreen interface {
+	// Tty returns the files used for input and output.  These are only
+	// valid between Init and Fini.  Callers must not close them, nor
+	// should they read from the input, since that would steal events.
+	Tty() (in *os.File, out *os.File)
+
+	// SavedTermios returns a copy of the raw terminal modes (a struct
+	// termios on POSIX systems) that were in effect before Init, and
+	// which will be restored by Fini.  It returns nil if there are none.
+	SavedTermios() []byte
+
+	// SetOutputTranslation controls the output post-processing done by
+	// the tty driver.  When on, OPOST and ONLCR are set, so that a bare
+	// newline written to the tty also returns the carriage, as it would
+	// on a terminal in its normal (cooked) mode.  This is convenient for
+	// applications that intermix raw writes with cell rendering.  When
+	// off, which is the default, bytes are delivered to the device
+	// unaltered, as printers and plotters sharing the tty may require.
+	// Cell rendering works either way.  This may be called before Init,
+	// in which case the setting takes effect when the screen starts.
+	SetOutputTranslation(on bool) error
+
+	// SetRecorder starts recording everything that is sent to (and
+	// received from) the terminal with the Recorder, or stops recording
+	// if it is nil.  If
+	// the screen is running, it is redrawn in full by the next Show,
+	// so that the recording is complete on its own.  The Recorder is
+	// not closed by Fini; call its Flush and Err methods to make sure
+	// that everything was written.
+	SetRecorder(r *Recorder)
+
+	// SetTransform changes how the screen is mapped onto the display.
+	// If this changes the size of the screen, an EventResize is posted.
+	// The whole screen is redrawn by the next Show.
+	SetTransform(tr Transform)
+
+	// Tput sends the string capability with the given terminfo name
+	// (such as "smcup"), with the parameters applied as by TParm.  It
+	// returns ErrNoCapability if the terminal does not have it.  See
+	// WriteRaw.
+	Tput(name string, params ...int) error
+
+	// WriteRaw sends the bytes to the terminal as they are, for escape
+	// sequences (custom OSC sequences and the like) that tcell does not
+	// model.  They are written in order with tcell's own output, so they
+	// never split a sequence of it.  Afterwards, tcell assumes nothing
+	// about the position of the cursor or the current attributes, so
+	// writes that change them do no harm, but changes to anything else
+	// (such as the scrolling region, or the contents of the display) are
+	// the caller's to undo.  Nothing is written if the screen is not
+	// running.
+	WriteRaw(b []byte)
+
+	// WritePassthrough is like WriteRaw, but is for sequences meant for
+	// the outer terminal when running inside tmux or GNU screen, which
+	// would otherwise drop them.  There, the bytes are wrapped in the
+	// multiplexer's passthrough envelope; elsewhere, they are written as
+	// they are.  Sequences sent through GNU screen should be terminated
+	// with BEL rather than ST.
+	WritePassthrough(b []byte)
+
+	Screen
 }
 
 // tScreen represents a screen backed by a terminfo implementation.
@@ -70,187 +254,258 @@ type tScreen struct {
 	in       *os.File
 	out      *os.File
 	curstyle Style
+	fullsgr  bool
+	sgrfull  bytes.Buffer // scratch space for sgr
+	sgrdiff  bytes.Buffer
+	cellbuf  []byte // scratch space for drawCell
+	gotos    paramCache
+	fgs      paramCache
+	bgs      paramCache
+	linemode bool
+	linelen  []int
+	palette  bool // the palette was changed
 	style    Style
 	evch     chan Event
+	filter   eventFilter
+	redraw   redrawer
 	sigwinch chan os.Signal
 	quit     chan struct{}
 	indoneq  chan struct{}
-	keys     map[Key][]byte
+	input    *InputParser
 	cx       int
 	cy       int
 	mouse    []byte
-	cells    []Cell
+	cells    cellGrid
+	drawc    Cell // scratch space for drawing cells
+	damage   rowDamage
 	clear    bool
 	cursorx  int
 	cursory  int
 	tiosp    *termiosPrivate
 	baud     int
-	wasbtn   bool
+	nopad    bool
+	serial   *SerialConfig
+	frames   frameLimiter
 	acs      map[rune]string
 	charset  string
 	encoder  transform.Transformer
-	decoder  transform.Transformer
+	blinkoff bool
+	blinkq   chan struct{}
+	blinkdur time.Duration
+	blinkrun bool
+	mouseon  bool
+	mousef   MouseFlags
+	onlcr    bool
+	stats    renderStats
+	xform    Transform
+	rec      *Recorder
+	nocolor  bool
+	mux      multiplexer
+	remote   *remoteConn
+	distance ColorDistance
+	opts     ScreenOptions
+	colormap []Color
+	images   screenImages
+	obuf     bytes.Buffer // output waiting to be written to the tty
+	wbuf     []byte       // output being written, owned by the writer
+	writing  bool         // a goroutine is writing to the tty
+	wdone    *sync.Cond   // signalled when writing is done
 
 	sync.Mutex
 }
 
+// Init may be called again after Fini, as after suspending the program;
+// while the screen is initialized, it does nothing.
 func (t *tScreen) Init() error {
-	t.evch = make(chan Event, 10)
+	t.Lock()
+	running := !t.fini
+	t.Unlock()
+	if running {
+		return nil
+	}
+
+	t.evch = make(chan Event, t.opts.queueLen())
 	t.indoneq = make(chan struct{})
 	t.charset = "UTF-8"
 
 	t.charset = t.getCharset()
+	if cs := os.Getenv("TCELL_CHARSET"); cs != "" {
+		t.charset = cs
+	}
+	if t.serial != nil {
+		t.charset = t.serial.Charset
+	}
+	if t.remote != nil {
+		t.charset = "UTF-8"
+	}
+	t.charset = canonicalCharset(t.charset)
+	if e := t.input.setCharset(t.charset); e != nil {
+		return e
+	}
 	switch t.charset {
 	case "UTF-8", "US-ASCII":
 		t.encoder = nil
-		t.decoder = nil
 	default:
-		if enc := GetEncoding(t.charset); enc != nil {
-			t.encoder = enc.NewEncoder()
-			t.decoder = enc.NewDecoder()
-		} else {
-			return errors.New("no support for charset " + t.charset)
-		}
+		t.encoder = GetEncoding(t.charset).NewEncoder()
 	}
 	ti := t.ti
 
-	if e := t.termioInit(); e != nil {
+	if t.remote != nil {
+		t.remoteInit()
+	} else if e := t.termioInit(); e != nil {
 		return e
 	}
 
-	t.TPuts(ti.EnterCA)
+	if !t.opts.NoAltScreen {
+		t.TPuts(ti.EnterCA)
+	}
 	t.TPuts(ti.EnterKeypad)
+	t.TPuts(ti.EnablePaste)
 	t.TPuts(ti.HideCursor)
 	t.TPuts(ti.Clear)
+	var pending []byte
+	if os.Getenv("TCELL_PROBE_WIDTH") == "1" && t.remote == nil {
+		pending = t.probeWidths()
+	}
+	t.TPuts(colorSchemeOn)
+	t.TPuts(colorSchemeQuery)
+	if os.Getenv("TCELL_PROBE_DEVICE") == "1" {
+		t.input.probing = true
+		t.TPuts(deviceQueries)
+	}
 
 	t.quit = make(chan struct{})
 	t.cx = -1
 	t.cy = -1
 	t.style = StyleDefault
+	t.curstyle = styleInvalid
 
-	t.cells = ResizeCells(nil, 0, 0, t.w, t.h)
+	t.cells.resize(t.w, t.h)
 	t.cursorx = -1
 	t.cursory = -1
+	t.input.buf.Reset()
 
 	t.Lock()
 	t.fini = false
+	if t.mouseon {
+		t.enableMouse(t.mousef)
+	}
+	if ti.Blink == "" {
+		// No native blink, so do it ourselves.
+		t.enableBlink(DefaultBlinkRate)
+	}
 	t.Unlock()
+	t.flush()
+	if len(pending) > 0 {
+		t.scanInput(pending, false)
+	}
 	go t.inputLoop()
 
 	return nil
 }
 
-func (t *tScreen) prepareKey(key Key, val string) {
-	if val != "" {
-		t.keys[key] = []byte(val)
+// probeWidths measures the widths that the terminal uses for the classes
+// of characters on which terminals disagree, and calibrates ours to
+// match.  (See width.go.)  Each sample is drawn on the second row, and
+// the cursor position requested; terminals that do not answer within a
+// second are left alone.  Any other input that arrives meanwhile is
+// returned, for the input parser.
+func (t *tScreen) probeWidths() []byte {
+	samples := []rune{probeAmbiguous, probeEmoji}
+	const row = 1
+	if t.charset != "UTF-8" || t.h <= row {
+		return nil
 	}
+	for _, r := range samples {
+		t.TPuts(t.ti.TGoto(0, row))
+		t.TPuts(string(r))
+		t.TPuts("\x1b[6n")
+	}
+	t.flush()
+	var got []byte
+	chunk := make([]byte, 128)
+	for try := 0; try < 10; try++ {
+		// Reads time out after 100 msec.  (See termioInit.)
+		n, e := t.in.Read(chunk)
+		got = append(got, chunk[:n]...)
+		if cols, _ := parseCursorReports(got, row); len(cols) >= len(samples) {
+			break
+		}
+		if e != nil && e != io.EOF {
+			break
+		}
+	}
+	cols, rest := parseCursorReports(got, row)
+	if len(cols) == len(samples) {
+		calibrateWidths(cols[0], cols[1])
+	}
+	t.TPuts(t.ti.Clear)
+	return rest
 }
 
-func (t *tScreen) prepareKeys() {
-	ti := t.ti
-	t.prepareKey(KeyBackspace, ti.KeyBackspace)
-	t.prepareKey(KeyF1, ti.KeyF1)
-	t.prepareKey(KeyF2, ti.KeyF2)
-	t.prepareKey(KeyF3, ti.KeyF3)
-	t.prepareKey(KeyF4, ti.KeyF4)
-	t.prepareKey(KeyF5, ti.KeyF5)
-	t.prepareKey(KeyF6, ti.KeyF6)
-	t.prepareKey(KeyF7, ti.KeyF7)
-	t.prepareKey(KeyF8, ti.KeyF8)
-	t.prepareKey(KeyF9, ti.KeyF9)
-	t.prepareKey(KeyF10, ti.KeyF10)
-	t.prepareKey(KeyF11, ti.KeyF11)
-	t.prepareKey(KeyF12, ti.KeyF12)
-	t.prepareKey(KeyF13, ti.KeyF13)
-	t.prepareKey(KeyF14, ti.KeyF14)
-	t.prepareKey(KeyF15, ti.KeyF15)
-	t.prepareKey(KeyF16, ti.KeyF16)
-	t.prepareKey(KeyF17, ti.KeyF17)
-	t.prepareKey(KeyF18, ti.KeyF18)
-	t.prepareKey(KeyF19, ti.KeyF19)
-	t.prepareKey(KeyF20, ti.KeyF20)
-	t.prepareKey(KeyF21, ti.KeyF21)
-	t.prepareKey(KeyF22, ti.KeyF22)
-	t.prepareKey(KeyF23, ti.KeyF23)
-	t.prepareKey(KeyF24, ti.KeyF24)
-	t.prepareKey(KeyF25, ti.KeyF25)
-	t.prepareKey(KeyF26, ti.KeyF26)
-	t.prepareKey(KeyF27, ti.KeyF27)
-	t.prepareKey(KeyF28, ti.KeyF28)
-	t.prepareKey(KeyF29, ti.KeyF29)
-	t.prepareKey(KeyF30, ti.KeyF30)
-	t.prepareKey(KeyF31, ti.KeyF31)
-	t.prepareKey(KeyF32, ti.KeyF32)
-	t.prepareKey(KeyF33, ti.KeyF33)
-	t.prepareKey(KeyF34, ti.KeyF34)
-	t.prepareKey(KeyF35, ti.KeyF35)
-	t.prepareKey(KeyF36, ti.KeyF36)
-	t.prepareKey(KeyF37, ti.KeyF37)
-	t.prepareKey(KeyF38, ti.KeyF38)
-	t.prepareKey(KeyF39, ti.KeyF39)
-	t.prepareKey(KeyF40, ti.KeyF40)
-	t.prepareKey(KeyF41, ti.KeyF41)
-	t.prepareKey(KeyF42, ti.KeyF42)
-	t.prepareKey(KeyF43, ti.KeyF43)
-	t.prepareKey(KeyF44, ti.KeyF44)
-	t.prepareKey(KeyF45, ti.KeyF45)
-	t.prepareKey(KeyF46, ti.KeyF46)
-	t.prepareKey(KeyF47, ti.KeyF47)
-	t.prepareKey(KeyF48, ti.KeyF48)
-	t.prepareKey(KeyF49, ti.KeyF49)
-	t.prepareKey(KeyF50, ti.KeyF50)
-	t.prepareKey(KeyF51, ti.KeyF51)
-	t.prepareKey(KeyF52, ti.KeyF52)
-	t.prepareKey(KeyF53, ti.KeyF53)
-	t.prepareKey(KeyF54, ti.KeyF54)
-	t.prepareKey(KeyF55, ti.KeyF55)
-	t.prepareKey(KeyF56, ti.KeyF56)
-	t.prepareKey(KeyF57, ti.KeyF57)
-	t.prepareKey(KeyF58, ti.KeyF58)
-	t.prepareKey(KeyF59, ti.KeyF59)
-	t.prepareKey(KeyF60, ti.KeyF60)
-	t.prepareKey(KeyF61, ti.KeyF61)
-	t.prepareKey(KeyF62, ti.KeyF62)
-	t.prepareKey(KeyF63, ti.KeyF63)
-	t.prepareKey(KeyF64, ti.KeyF64)
-	t.prepareKey(KeyInsert, ti.KeyInsert)
-	t.prepareKey(KeyDelete, ti.KeyDelete)
-	t.prepareKey(KeyHome, ti.KeyHome)
-	t.prepareKey(KeyEnd, ti.KeyEnd)
-	t.prepareKey(KeyUp, ti.KeyUp)
-	t.prepareKey(KeyDown, ti.KeyDown)
-	t.prepareKey(KeyLeft, ti.KeyLeft)
-	t.prepareKey(KeyRight, ti.KeyRight)
-	t.prepareKey(KeyPgUp, ti.KeyPgUp)
-	t.prepareKey(KeyPgDn, ti.KeyPgDn)
-	t.prepareKey(KeyHelp, ti.KeyHelp)
-	t.prepareKey(KeyPrint, ti.KeyPrint)
-	t.prepareKey(KeyCancel, ti.KeyCancel)
-	t.prepareKey(KeyExit, ti.KeyExit)
-	t.prepareKey(KeyBacktab, ti.KeyBacktab)
-}
-
+// Fini may be called more than once, and before Init; only the first
+// call after Init does anything.  It returns once the input goroutines
+// have finished, and the terminal has been restored.
 func (t *tScreen) Fini() {
-	ti := t.ti
 	t.Lock()
-	t.w = 0
-	t.h = 0
+	if t.fini {
+		t.Unlock()
+		return
+	}
+	ti := t.ti
 	t.fini = true
-	t.Unlock()
+	t.frames.stop()
+	if t.blinkq != nil {
+		close(t.blinkq)
+		t.blinkq = nil
+		t.blinkrun = false
+	}
 	t.TPuts(ti.ShowCursor)
 	t.TPuts(ti.AttrOff)
-	t.TPuts(ti.Clear)
-	t.TPuts(ti.ExitCA)
+	t.dropImages(t.images.removeAll())
+	if t.opts.NoAltScreen {
+		// leave the drawing, with the cursor below it
+		_, ph := t.physSize()
+		t.TPuts(ti.TGoto(0, ph-1))
+		t.TPuts("\r\n")
+	} else {
+		t.TPuts(ti.Clear)
+	}
+	if t.palette {
+		t.TPuts(paletteResetString(ti))
+		t.palette = false
+	}
+	if !t.opts.NoAltScreen {
+		t.TPuts(ti.ExitCA)
+	}
 	t.TPuts(ti.ExitKeypad)
-	t.TPuts(ti.TParm(ti.MouseMode, 0))
+	t.TPuts(ti.DisablePaste)
+	t.TPuts(colorSchemeOff)
+	t.disableMouse()
+	if t.rec != nil {
+		t.rec.Flush()
+	}
+	t.cells = cellGrid{}
+	t.curstyle = styleInvalid
+	t.clear = false
+	t.Unlock()
+
+	// everything must reach the terminal before its modes are restored
+	t.flush()
+	t.Lock()
+	for t.writing {
+		t.waitWriter()
+	}
+	t.Unlock()
+
 	if t.quit != nil {
 		close(t.quit)
 	}
-
-	t.cells = nil
-	t.curstyle = Style(-1)
-	t.clear = false
-	t.termioFini()
+	if t.remote != nil {
+		<-t.indoneq
+	} else {
+		t.termioFini()
+	}
 }
 
 func (t *tScreen) SetStyle(style Style) {
@@ -265,7 +520,8 @@ func (t *tScreen) Clear() {
 
 	t.Lock()
 	if !t.fini {
-		ClearCells(t.cells, t.style)
+		t.cells.clear(t.style)
+		t.damage.all()
 	}
 	t.Unlock()
 }
@@ -277,8 +533,11 @@ func (t *tScreen) SetCell(x, y int, style Style, ch ...rune) {
 		t.Unlock()
 		return
 	}
-	cell := &t.cells[(y*t.w)+x]
-	cell.SetCell(ch, style)
+	i := (y * t.w) + x
+	t.cells.put(i, ch, style)
+	if t.cells.dirty[i] {
+		t.damage.touch(y)
+	}
 	t.Unlock()
 }
 
@@ -288,9 +547,19 @@ func (t *tScreen) PutCell(x, y int, cell *Cell) {
 		t.Unlock()
 		return
 	}
-	cp := &t.cells[(y*t.w)+x]
-	cp.PutStyle(cell.Style)
-	cp.PutChars(cell.Ch)
+	i := (y * t.w) + x
+	t.cells.put(i, cell.Ch, cell.Style)
+	if t.cells.dirty[i] {
+		t.damage.touch(y)
+	}
+	t.Unlock()
+}
+
+func (t *tScreen) PutCells(x, y, w, h int, cells []Cell) {
+	t.Lock()
+	if !t.fini {
+		t.cells.putCells(x, y, w, h, cells, t.damage.touch)
+	}
 	t.Unlock()
 }
 
@@ -300,9 +569,15 @@ func (t *tScreen) GetCell(x, y int) *Cell {
 		t.Unlock()
 		return nil
 	}
-	cell := t.cells[(y*t.w)+x]
+	cell := t.cells.cell((y * t.w) + x)
 	t.Unlock()
-	return &cell
+	return cell
+}
+
+func (t *tScreen) Contents() *Contents {
+	t.Lock()
+	defer t.Unlock()
+	return t.cells.contents(t.cursorx, t.cursory)
 }
 
 func (t *tScreen) encodeRune(r rune, buf []byte) []byte {
@@ -323,10 +598,9 @@ func (t *tScreen) encodeRune(r rune, buf []byte) []byte {
 		return buf
 	}
 
-	nb := make([]byte, 6)
-	ob := make([]byte, 6)
-	num := utf8.EncodeRune(ob, r)
-	ob = ob[:num]
+	var nbuf, obuf [utf8.UTFMax * 2]byte
+	nb := nbuf[:]
+	ob := obuf[:utf8.EncodeRune(obuf[:], r)]
 	t.encoder.Reset()
 	dst, _, err := t.encoder.Transform(nb, ob, true)
 	if err == nil {
@@ -337,7 +611,7 @@ func (t *tScreen) encodeRune(r rune, buf []byte) []byte {
 			// elide combining
 			if len(buf) == 0 {
 				if acs, ok := t.acs[r]; ok {
-					buf = append(buf, []byte(acs)...)
+					buf = append(buf, acs...)
 				} else {
 					buf = append(buf, '?')
 				}
@@ -351,45 +625,33 @@ func (t *tScreen) encodeRune(r rune, buf []byte) []byte {
 	return buf
 }
 
+// drawCell draws the cell at the given physical position.
 func (t *tScreen) drawCell(x, y int, cell *Cell) {
 	// XXX: check for hazeltine not being able to display ~
 
-	ti := t.ti
+	pw, _ := t.physSize()
 
 	if t.cy != y || t.cx != x {
-		t.TPuts(ti.TGoto(x, y))
+		t.TPuts(t.tgoto(x, y))
 	}
 	style := cell.Style
 	if style == StyleDefault {
 		style = t.style
 	}
+	blank := false
+	if t.blinkq != nil && isBlink(style) {
+		// Software blink; the terminal must not see the attribute.
+		t.startBlink()
+		blank = t.blinkoff
+		style = style.Blink(false)
+	}
+	if isInvisible(style) {
+		blank = true
+		style = style.Invisible(false)
+	}
+	style = t.matchStyle(style)
 	if style != t.curstyle {
-		fg, bg, attrs := style.Decompose()
-
-		t.TPuts(ti.AttrOff)
-		if attrs&AttrBold != 0 {
-			t.TPuts(ti.Bold)
-		}
-		if attrs&AttrUnderline != 0 {
-			t.TPuts(ti.Underline)
-		}
-		if attrs&AttrReverse != 0 {
-			t.TPuts(ti.Reverse)
-		}
-		if attrs&AttrBlink != 0 {
-			t.TPuts(ti.Blink)
-		}
-		if attrs&AttrDim != 0 {
-			t.TPuts(ti.Dim)
-		}
-		if fg != ColorDefault {
-			c := int(fg) - 1
-			t.TPuts(ti.TParm(ti.SetFg, c))
-		}
-		if bg != ColorDefault {
-			c := int(bg) - 1
-			t.TPuts(ti.TParm(ti.SetBg, c))
-		}
+		t.TPuts(t.sgr(t.curstyle, style))
 		t.curstyle = style
 	}
 	// now emit runes - taking care to not overrun width with a
@@ -397,52 +659,57 @@ func (t *tScreen) drawCell(x, y int, cell *Cell) {
 	// character followed up by any residual combing characters
 
 	width := int(cell.Width)
-	var str string
+	buf := t.cellbuf[:0]
 
 	switch t.charset {
 	case "UTF-8":
 		if len(cell.Ch) == 0 {
-			str = " "
 			width = 1
-		} else {
-			str = string(cell.Ch)
+		}
+		var rb [utf8.UTFMax]byte
+		for _, r := range cell.Ch {
+			n := utf8.EncodeRune(rb[:], r)
+			buf = append(buf, rb[:n]...)
 		}
 	default:
 		// Non-Unicode systems.  Make do.
-		switch len(cell.Ch) {
-		case 0:
-			str = " "
+		if len(cell.Ch) == 0 {
 			width = 1
-		default:
-			buf := make([]byte, 0, 6)
-
-			for _, r := range cell.Ch {
-				buf = t.encodeRune(r, buf)
-			}
-			if len(buf) == 0 {
-				str = " "
-			} else {
-				str = string(buf)
-			}
 		}
-		if cell.Width > 1 && str == "?" {
+		for _, r := range cell.Ch {
+			buf = t.encodeRune(r, buf)
+		}
+		if cell.Width > 1 && len(buf) == 1 && buf[0] == '?' {
 			// No FullWidth character support
-			if x < t.w-1 {
-				str = "? "
+			if x < pw-1 {
+				buf = append(buf, ' ')
 				width = 2
 			} else {
-				str = "?"
 				width = 1
 			}
 		}
 	}
+	if len(buf) == 0 {
+		buf = append(buf, ' ')
+	}
 
-	if width == 2 && x >= t.w-1 {
+	if width == 2 && x >= pw-1 {
 		// too wide to fit; emit space instead
 		width = 1
-		str = " "
+		buf = append(buf[:0], ' ')
+	}
+	if blank {
+		// invisible, or blinking cell in the hidden phase
+		buf = append(buf[:0], ' ')
+		if width == 2 {
+			buf = append(buf, ' ')
+		}
+	}
+	t.obuf.Write(buf)
+	if t.rec != nil {
+		t.rec.Write(buf)
 	}
-	io.WriteString(t.out, str)
+	t.cellbuf = buf
 	t.cy = y
 	t.cx = x + width
 }
@@ -467,8 +734,9 @@ func (t *tScreen) showCursor() {
 		t.TPuts(t.ti.HideCursor)
 		return
 	}
+	x, y = t.xform.apply(x, y, t.w, t.h)
 	if t.cx != x || t.cy != y {
-		t.TPuts(t.ti.TGoto(x, y))
+		t.TPuts(t.tgoto(x, y))
 	}
 	t.TPuts(t.ti.ShowCursor)
 	t.cx = x
@@ -476,18 +744,88 @@ func (t *tScreen) showCursor() {
 }
 
 func (t *tScreen) TPuts(s string) {
-	t.ti.TPuts(t.out, s, t.baud)
+	baud := t.baud
+	if t.nopad {
+		baud = 0
+	}
+	t.ti.TPuts(&t.obuf, s, baud)
+	if t.rec != nil {
+		t.ti.TPuts(t.rec, s, 0)
+	}
 }
 
 func (t *tScreen) Show() {
 	t.Lock()
 	if !t.fini {
+		t.resize()
+		if t.frames.ready(t.showFrame) {
+			t.draw()
+			t.frames.drawn()
+		} else if !t.linemode && !t.clear {
+			// cursor motion is not held back, even when cells are
+			t.showCursor()
+		}
+	}
+	t.Unlock()
+	t.flush()
+}
+
+// showFrame draws the frame that Show scheduled.  (See FrameRateScreen.)
+func (t *tScreen) showFrame() {
+	t.Lock()
+	if !t.fini && t.frames.pending() {
 		t.resize()
 		t.draw()
+		t.frames.drawn()
+	}
+	t.Unlock()
+	t.flush()
+}
+
+func (t *tScreen) SetFrameRate(fps int) {
+	t.Lock()
+	t.frames.setRate(fps)
+	t.Unlock()
+}
+
+// flush writes the pending output to the tty.  Output is only ever
+// buffered while the lock is held; the write itself is done without it,
+// so that a slow (or stopped) terminal blocks the goroutine that is
+// writing, but not those that are merely updating cells.  Only one
+// goroutine writes at a time.  If another one is already writing, we
+// leave our output to it, since it checks for more output each time a
+// write completes, and so need not wait.  Must be called without the
+// lock held.
+func (t *tScreen) flush() {
+	t.Lock()
+	if t.writing {
+		t.Unlock()
+		return
+	}
+	t.writing = true
+	for t.obuf.Len() > 0 {
+		t.wbuf = append(t.wbuf[:0], t.obuf.Bytes()...)
+		t.obuf.Reset()
+		t.Unlock()
+		t.output().Write(t.wbuf)
+		t.Lock()
+	}
+	t.writing = false
+	if t.wdone != nil {
+		t.wdone.Broadcast()
 	}
 	t.Unlock()
 }
 
+// waitWriter waits for the goroutine that is writing to the tty to
+// finish.  Must be called with the lock held.
+func (t *tScreen) waitWriter() {
+	if t.wdone == nil {
+		t.wdone = sync.NewCond(&t.Mutex)
+	}
+	t.wdone.Wait()
+}
+
 func (t *tScreen) clearScreen() {
 	t.TPuts(t.ti.Clear)
 	t.clear = false
@@ -499,6 +837,31 @@ func (t *tScreen) hideCursor() {
 }
 
 func (t *tScreen) draw() {
+	t.stats.begin(&t.cells, t.clear)
+	defer t.stats.end()
+	if t.rec != nil {
+		// each update is a frame of the recording
+		defer t.rec.Flush()
+	}
+
+	t.damage.fit(t.h)
+	if t.clear {
+		t.damage.all()
+	}
+
+	if t.linemode {
+		t.drawLines()
+		return
+	}
+
+	if !t.clear && !t.damage.dirty(&t.cells) && !t.images.pending() {
+		// Only the cursor may have moved.  Just put it where it
+		// belongs, without disturbing anything else.  This keeps
+		// typing latency to a minimum.
+		t.showCursor()
+		return
+	}
+
 	// clobber cursor position, because we're gonna change it all
 	t.cx = -1
 	t.cy = -1
@@ -508,91 +871,646 @@ func (t *tScreen) draw() {
 
 	if t.clear {
 		t.clearScreen()
+		t.images.unplace()
+	} else {
+		t.dropImages(t.images.overwritten(&t.cells))
 	}
 
 	for row := 0; row < t.h; row++ {
+		if !t.damage[row] {
+			continue
+		}
 		for col := 0; col < t.w; col++ {
-			cell := &t.cells[(row*t.w)+col]
-			if !cell.Dirty {
+			i := (row * t.w) + col
+			if !t.cells.dirty[i] {
+				if t.cells.width[i] > 1 && !t.xform.swaps() {
+					// leave the cell it covers alone
+					col++
+				}
 				continue
 			}
-			t.drawCell(col, row, cell)
-			if cell.Width > 1 {
+			t.cells.load(i, &t.drawc)
+			if t.drawMapped(col, row, &t.drawc) && col+1 < t.w {
+				t.cells.drawnWide(i)
 				col++
+			} else {
+				t.cells.drawn(i)
 			}
-			cell.Dirty = false
 		}
+		t.damage[row] = false
 	}
 
+	t.placeImages()
+
 	// restore the cursor
 	t.showCursor()
 }
 
-func (t *tScreen) EnableMouse() {
-	if len(t.mouse) != 0 {
-		t.TPuts(t.ti.TParm(t.ti.MouseMode, 1))
+// placeImages places the images that are waiting to be shown.
+func (t *tScreen) placeImages() {
+	for _, im := range t.images.list {
+		if !im.placed {
+			t.TPuts(t.ti.TGoto(im.x, im.y))
+			t.TPuts(t.images.place(im))
+			// not all terminals leave the cursor alone
+			t.cx, t.cy = -1, -1
+			im.placed = true
+		}
 	}
 }
 
-func (t *tScreen) DisableMouse() {
-	if len(t.mouse) != 0 {
-		t.TPuts(t.ti.TParm(t.ti.MouseMode, 0))
+// dropImages erases the images, and arranges for the cells that they
+// covered to be drawn again.
+func (t *tScreen) dropImages(ims []*screenImage) {
+	for _, im := range ims {
+		t.TPuts(t.images.erase(im))
+		if t.cells.mainc == nil {
+			continue
+		}
+		for row := im.y; row < im.y+im.h && row < t.h; row++ {
+			for col := im.x; col < im.x+im.w && col < t.w; col++ {
+				t.cells.touch(row*t.w + col)
+			}
+			t.damage.touch(row)
+		}
 	}
 }
 
-func (t *tScreen) Size() (int, int) {
+func (t *tScreen) PutImage(x, y, w, h int, img image.Image) (int, error) {
 	t.Lock()
-	w, h := t.w, t.h
+	if t.fini || t.images.proto == imageNone || t.linemode || t.xform != TransformNone {
+		t.Unlock()
+		return 0, ErrNoImages
+	}
+	if w <= 0 || h <= 0 || x < 0 || y < 0 || x >= t.w || y >= t.h {
+		t.Unlock()
+		return 0, errors.New("image outside of the screen")
+	}
+	im, s, e := t.images.add(x, y, w, h, img)
+	if e != nil {
+		t.Unlock()
+		return 0, e
+	}
+	t.TPuts(s)
 	t.Unlock()
-	return w, h
+	t.flush()
+	return im.id, nil
 }
 
-func (t *tScreen) resize() {
-	var ev Event
-	if w, h, e := t.getWinSize(); e == nil {
-		if w != t.w || h != t.h {
-			ev = NewEventResize(w, h)
-			t.cx = -1
-			t.cy = -1
+func (t *tScreen) DeleteImage(id int) {
+	t.Lock()
+	if !t.fini {
+		if im := t.images.remove(id); im != nil {
+			t.dropImages([]*screenImage{im})
+		}
+	}
+	t.Unlock()
+	t.flush()
+}
 
-			t.cells = ResizeCells(t.cells, t.w, t.h, w, h)
-			t.w = w
-			t.h = h
+// drawLines draws the screen on a terminal that cannot address the
+// cursor.  Rows with any changes are redrawn in full, from top to bottom,
+// using only carriage returns and line feeds to move.  Where a row above
+// the current one must be redrawn, we go up with cuu1 if the terminal has
+// it, and otherwise just start a new line, so that on a printer each
+// update appears below the last.  Rows are not drawn past their last
+// non-blank cell, which also keeps terminals with automatic margins from
+// wrapping, but they are padded to cover what was drawn there before.
+// The cursor is left wherever drawing ended.
+func (t *tScreen) drawLines() {
+	ti := t.ti
+	if t.clear {
+		t.clearScreen()
+		t.cx = -1
+		t.cy = -1
+		t.linelen = nil
+	}
+	if len(t.linelen) != t.h {
+		t.linelen = make([]int, t.h)
+	}
+	for row := 0; row < t.h; row++ {
+		base := row * t.w
+		if !t.damage[row] || !t.cells.anyDirty(row) {
+			t.damage[row] = false
+			continue
+		}
+		switch {
+		case t.cy >= 0 && row >= t.cy:
+			for y := t.cy; y < row; y++ {
+				t.TPuts("\n")
+			}
+		case t.cy >= 0 && ti.CursorUp1 != "":
+			for y := row; y < t.cy; y++ {
+				t.TPuts(ti.CursorUp1)
+			}
+		case t.cy >= 0 || t.cx > 0:
+			t.TPuts("\n")
+		}
+		t.TPuts("\r")
+		t.cx = 0
+		t.cy = row
 
-			InvalidateCells(t.cells)
+		n := 0
+		for col := 0; col < t.w; col++ {
+			t.cells.load(base+col, &t.drawc)
+			if !isBlank(&t.drawc) {
+				n = col + 1
+			}
+			if t.drawc.Width > 1 {
+				n++
+				col++
+			}
 		}
-	}
-	if ev != nil {
-		t.PostEvent(ev)
+		end := n
+		if end < t.linelen[row] {
+			end = t.linelen[row]
+		}
+		if end > t.w-1 {
+			end = t.w - 1
+		}
+		for col := 0; col < end; col++ {
+			t.cells.load(base+col, &t.drawc)
+			t.drawCell(col, row, &t.drawc)
+			if t.drawc.Width > 1 {
+				col++
+			}
+		}
+		for col := 0; col < t.w; col++ {
+			if t.cells.width[base+col] > 1 && col+1 < t.w {
+				t.cells.drawnWide(base + col)
+				col++
+			} else {
+				t.cells.drawn(base + col)
+			}
+		}
+		t.damage[row] = false
+		t.linelen[row] = n
 	}
 }
 
-func (t *tScreen) Colors() int {
-	// this doesn't change, no need for lock
-	return t.ti.Colors
+// isBlank reports whether the cell shows nothing but a space, in the
+// default style.
+func isBlank(cell *Cell) bool {
+	if cell.Style != StyleDefault {
+		return false
+	}
+	return len(cell.Ch) == 0 || (len(cell.Ch) == 1 && cell.Ch[0] == ' ')
 }
 
-func (t *tScreen) PollEvent() Event {
-	select {
-	case <-t.quit:
-		return nil
-	case ev := <-t.evch:
-		return ev
+// These are the private modes that select the extended mouse reporting
+// encodings.  The urxvt encoding (1015) lifts the 223 column limit of the
+// legacy X11 encoding, for terminals that lack SGR (1006) reporting; where
+// both are enabled, SGR is preferred by the terminal.  SGR-Pixels (1016)
+// reports positions in pixels rather than cells.  These are not described
+// by terminfo, but terminals that do not know them just ignore them.
+const (
+	mouseUrxvtOn   = "\x1b[?1015h"
+	mouseUrxvtOff  = "\x1b[?1015l"
+	mousePixelsOn  = "\x1b[?1016h"
+	mousePixelsOff = "\x1b[?1016l"
+)
+
+func (t *tScreen) EnableMouse(flags ...MouseFlags) {
+	var f MouseFlags
+	for _, fl := range flags {
+		f |= fl
 	}
+	t.Lock()
+	t.mouseon = true
+	t.mousef = f
+	t.enableMouse(f)
+	t.Unlock()
+	t.flush()
 }
 
-// bulidAcsMap builds a map of characters that we translate from Unicode to
-// alternate character encodings.  To do this, we use the standard VT100 ACS
-// maps.  This is only done if the terminal lacks support for Unicode; we
-// always prefer to emit Unicode glyphs when we are able.
+func (t *tScreen) enableMouse(f MouseFlags) {
+	if len(t.mouse) == 0 {
+		return
+	}
+	t.input.SetCellPixels(0, 0)
+	if f&MousePixels != 0 {
+		// We can only translate pixel positions to cells if we
+		// know how big the cells are.
+		w, h := t.physSize()
+		if pw, ph, e := t.pixSize(); e == nil && pw > 0 && ph > 0 {
+			t.input.SetCellPixels(pw/w, ph/h)
+		}
+	}
+	t.TPuts(t.ti.TParm(t.ti.MouseMode, 1))
+	t.TPuts(mouseUrxvtOn)
+	if t.input.mousepix {
+		t.TPuts(mousePixelsOn)
+	} else {
+		t.TPuts(mousePixelsOff)
+	}
+}
 
-type acsMap struct {
-	utf   rune   // UTF-8 glyph
-	vt100 rune   // VT100 name
-	ascii string // ASCII default
+func (t *tScreen) DisableMouse() {
+	t.Lock()
+	t.mouseon = false
+	t.disableMouse()
+	t.Unlock()
+	t.flush()
 }
 
-func (t *tScreen) buildAcsMap() {
+func (t *tScreen) disableMouse() {
+	if len(t.mouse) != 0 {
+		t.TPuts(t.ti.TParm(t.ti.MouseMode, 0))
+		t.TPuts(mouseUrxvtOff)
+		t.TPuts(mousePixelsOff)
+		t.input.SetCellPixels(0, 0)
+	}
+}
+
+func (t *tScreen) EnableBlink(rate time.Duration) {
+	t.Lock()
+	if !t.fini {
+		t.enableBlink(rate)
+	}
+	t.Unlock()
+}
+
+func (t *tScreen) enableBlink(rate time.Duration) {
+	if rate <= 0 {
+		rate = DefaultBlinkRate
+	}
+	if t.blinkq != nil {
+		close(t.blinkq)
+	}
+	t.blinkq = make(chan struct{})
+	t.blinkdur = rate
+	t.blinkrun = false
+	t.blinkoff = false
+	t.cells.invalidateBlink(t.style)
+	t.damage.all()
+}
+
+// startBlink starts the software blink loop, when a blinking cell is
+// drawn, unless it is already running.
+func (t *tScreen) startBlink() {
+	if t.blinkrun {
+		return
+	}
+	t.blinkrun = true
+	q := t.blinkq
+	go blinkLoop(t.blinkdur, q, func() bool { return t.blink(q) })
+}
+
+func (t *tScreen) DisableBlink() {
+	t.Lock()
+	if t.blinkq != nil {
+		close(t.blinkq)
+		t.blinkq = nil
+		t.blinkrun = false
+		t.blinkoff = false
+		t.cells.invalidateBlink(t.style)
+		t.damage.all()
+	}
+	t.Unlock()
+}
+
+// blink toggles the software blink phase, and redraws the blinking
+// cells.  Cells that are dirty are left for the next Show, so that we
+// don't expose partial updates made by the application.  It returns
+// false, stopping the loop, once no cell blinks; drawing one starts it
+// again.
+func (t *tScreen) blink(q chan struct{}) bool {
+	t.Lock()
+	if t.fini || t.blinkq != q || t.linemode {
+		// (In line mode, we cannot go back to redraw the cells.)
+		t.Unlock()
+		return false
+	}
+	if !t.cells.anyBlink(t.style) {
+		t.blinkrun = false
+		t.blinkoff = false
+		t.Unlock()
+		return false
+	}
+	t.blinkoff = !t.blinkoff
+
+	t.cx = -1
+	t.cy = -1
+	t.hideCursor()
+	for row := 0; row < t.h; row++ {
+		for col := 0; col < t.w; col++ {
+			i := (row * t.w) + col
+			style := t.cells.style[i]
+			if style == StyleDefault {
+				style = t.style
+			}
+			if t.cells.dirty[i] || !isBlink(style) {
+				continue
+			}
+			t.cells.load(i, &t.drawc)
+			if t.drawMapped(col, row, &t.drawc) {
+				col++
+			}
+		}
+	}
+	t.showCursor()
+	if t.rec != nil {
+		t.rec.Flush()
+	}
+	t.Unlock()
+	t.flush()
+	return true
+}
+
+func (t *tScreen) Size() (int, int) {
+	t.Lock()
+	w, h := t.w, t.h
+	t.Unlock()
+	return w, h
+}
+
+// physSize returns the size of the physical display, which differs from
+// that of the screen if the transform rotates it sideways.
+func (t *tScreen) physSize() (int, int) {
+	if t.xform.swaps() {
+		return t.h, t.w
+	}
+	return t.w, t.h
+}
+
+// drawMapped draws the cell at the given screen position, wherever the
+// transform puts it on the display.  It returns true if the cell is
+// a double width one that also covered the next cell.
+func (t *tScreen) drawMapped(x, y int, cell *Cell) bool {
+	px, py := t.xform.apply(x, y, t.w, t.h)
+	if cell.Width < 2 || t.xform == TransformNone {
+		t.drawCell(px, py, cell)
+		return cell.Width > 1
+	}
+	switch t.xform {
+	case TransformRotate180, TransformMirror:
+		// Reversed, so the character starts in the column where
+		// the cell that it covers ends up.
+		if px > 0 {
+			t.drawCell(px-1, py, cell)
+		} else {
+			t.drawCell(px, py, &Cell{Ch: []rune{' '}, Width: 1,
+				Style: cell.Style})
+		}
+		return true
+	}
+	// Sideways, where a double width character cannot go.
+	t.drawCell(px, py, &Cell{Ch: []rune{'?'}, Width: 1, Style: cell.Style})
+	return false
+}
+
+func (t *tScreen) SetNoColor(on bool) {
+	t.Lock()
+	defer t.Unlock()
+	t.nocolor = on
+	t.curstyle = styleInvalid
+	t.clear = true
+	t.cells.invalidate()
+}
+
+func (t *tScreen) Tput(name string, params ...int) error {
+	t.Lock()
+	s, ok := t.ti.GetString(name)
+	t.Unlock()
+	if !ok {
+		return ErrNoCapability
+	}
+	if len(params) > 0 {
+		s = t.ti.TParm(s, params...)
+	}
+	t.writeRaw(func() { t.TPuts(s) })
+	return nil
+}
+
+func (t *tScreen) WriteRaw(b []byte) {
+	t.writeRaw(func() {
+		t.obuf.Write(b)
+		if t.rec != nil {
+			t.rec.Write(b)
+		}
+	})
+}
+
+func (t *tScreen) WritePassthrough(b []byte) {
+	t.WriteRaw(wrapPassthrough(t.mux, b))
+}
+
+// writeRaw queues the output done by fn, and forgets the state of the
+// terminal that it may have changed.
+func (t *tScreen) writeRaw(fn func()) {
+	t.Lock()
+	if t.fini {
+		t.Unlock()
+		return
+	}
+	fn()
+	t.curstyle = styleInvalid
+	t.cx = -1
+	t.cy = -1
+	t.Unlock()
+	t.flush()
+}
+
+func (t *tScreen) SetTransform(tr Transform) {
+	t.Lock()
+	defer t.Unlock()
+	if tr == t.xform {
+		return
+	}
+	w, h := t.physSize()
+	t.xform = tr
+	if tr.swaps() {
+		w, h = h, w
+	}
+	if w != t.w || h != t.h {
+		if t.cells.mainc != nil {
+			t.cells.resize(w, h)
+		}
+		t.w, t.h = w, h
+		t.PostEvent(NewEventResize(w, h))
+	}
+	t.cx = -1
+	t.cy = -1
+	t.clear = true
+	t.cells.invalidate()
+	t.damage.all()
+}
+
+func (t *tScreen) resize() {
+	var ev *EventResize
+	var ow, oh int
+	if w, h, e := t.winSize(); e == nil {
+		if t.xform.swaps() {
+			w, h = h, w
+		}
+		if w != t.w || h != t.h {
+			ev = NewEventResize(w, h)
+			ow, oh = t.w, t.h
+			t.cx = -1
+			t.cy = -1
+
+			t.cells.resize(w, h)
+			t.w = w
+			t.h = h
+			if t.rec != nil {
+				t.rec.Resize(w, h)
+			}
+
+			t.cells.invalidate()
+			t.damage.all()
+			t.dropImages(t.images.removeAll())
+		}
+	}
+	opw, oph := t.input.winpw, t.input.winph
+	if pw, ph, e := t.pixSize(); e == nil {
+		t.syncInput()
+		t.input.setPixelSize(pw, ph, false)
+	}
+	pw, ph := t.input.winpw, t.input.winph
+	if ev != nil {
+		if pw > 0 && ph > 0 {
+			ev.SetPixelSize(pw, ph)
+		}
+		t.PostEvent(ev)
+		t.redraw.exposed(t.PostEvent, ow, oh, t.w, t.h)
+	} else if pw != opw || ph != oph {
+		// only the pixel size has changed
+		ev = NewEventResize(t.w, t.h)
+		ev.SetPixelSize(pw, ph)
+		t.PostEvent(ev)
+	}
+}
+
+func (t *tScreen) PixelSize() (int, int, bool) {
+	t.Lock()
+	defer t.Unlock()
+	pw, ph := t.input.winpw, t.input.winph
+	return pw, ph, pw > 0 && ph > 0
+}
+
+func (t *tScreen) SetColorDistance(d ColorDistance) {
+	t.Lock()
+	t.distance = d
+	t.colormap = nil
+	t.cells.invalidate()
+	t.damage.all()
+	t.Unlock()
+}
+
+// matchStyle replaces the colors of the style that the terminal lacks
+// with the nearest that it has.  Colors of the 256 color palette are
+// matched to the first 16, and those of the first 16 to the first 8, as
+// the terminal has them.  (That is the usual standard, so we take the
+// terminal to show them as xterm does.)  RGB colors are kept if the
+// terminal can show them, and otherwise replaced by the palette colors
+// that look most like them.  Without colors, none are kept.
+func (t *tScreen) matchStyle(style Style) Style {
+	if t.nocolor {
+		return style.colorless()
+	}
+	if p := style.palette(); p != style && !t.trueColor() {
+		style = p
+	}
+	n := t.ti.Colors
+	if n <= 0 || n >= 256 {
+		return style
+	}
+	if t.colormap == nil {
+		if n > 16 {
+			n = 16
+		}
+		t.colormap = matchColors(ansiRGB[:n], t.distance)
+	}
+	match := func(c Color) Color {
+		switch {
+		case c.IsRGB(), int(c) <= t.ti.Colors:
+			return c
+		case int(c) < len(t.colormap):
+			return t.colormap[c]
+		}
+		return ColorDefault
+	}
+	fg, bg, _ := style.Decompose()
+	_, uc := style.DecomposeUnderline()
+	return style.Foreground(match(fg)).Background(match(bg)).
+		UnderlineColor(match(uc))
+}
+
+func (t *tScreen) ExternalWriter(x, y, width, height int) *RegionWriter {
+	return NewRegionWriter(t, x, y, width, height, StyleDefault)
+}
+
+func (t *tScreen) Colors() int {
+	// this only changes with Reinitialize
+	t.Lock()
+	defer t.Unlock()
+	if t.nocolor {
+		return 0
+	}
+	if t.ti.Colors > 0 && t.trueColor() {
+		return 1 << 24
+	}
+	return t.ti.Colors
+}
+
+func (t *tScreen) ColorModel() ColorModel {
+	return paletteModel(t.Colors())
+}
+
+// DefaultColors is true if the terminal can reset its colors, which it
+// does with sgr0, as it has no other way to get back to its defaults.
+func (t *tScreen) DefaultColors() bool {
+	t.Lock()
+	defer t.Unlock()
+	return t.ti.AttrOff != ""
+}
+
+// trueColor reports whether the terminal can show RGB colors, either by
+// its description or by its answer to our query, unless that has been
+// overridden with $TCELL_TRUECOLOR.  Callers hold the lock.
+func (t *tScreen) trueColor() bool {
+	_, forced := envSwitch("TCELL_TRUECOLOR")
+	return t.ti.SetFgRGB != "" || (t.input.devattr.TrueColor && !forced)
+}
+
+func (t *tScreen) PollEvent() Event {
+	return t.filter.poll(t.quit, t.evch)
+}
+
+func (t *tScreen) SetEventFilter(f EventFilter) {
+	t.filter.set(f)
+}
+
+func (t *tScreen) SetIdleTimeout(d time.Duration) {
+	t.filter.setIdle(d)
+}
+
+func (t *tScreen) SetSize(w, h int) {
+	t.Lock()
+	if t.fini || t.linemode || w <= 0 || h <= 0 {
+		t.Unlock()
+		return
+	}
+	if t.xform.swaps() {
+		w, h = h, w
+	}
+	fmt.Fprintf(&t.obuf, setSizeString, h, w)
+	t.Unlock()
+	t.flush()
+}
+
+// bulidAcsMap builds a map of characters that we translate from Unicode to
+// alternate character encodings.  To do this, we use the standard VT100 ACS
+// maps.  This is only done if the terminal lacks support for Unicode; we
+// always prefer to emit Unicode glyphs when we are able.
+
+type acsMap struct {
+	utf   rune   // UTF-8 glyph
+	vt100 rune   // VT100 name
+	ascii string // ASCII default
+}
+
+func (t *tScreen) buildAcsMap() {
 	acsstr := t.ti.AltChars
 	vtNames := []acsMap{
 		{RuneSterling, '}', "f"},
@@ -656,412 +1574,389 @@ func (t *tScreen) PostEvent(ev Event) {
 	}
 }
 
-func (t *tScreen) postMouseEvent(x, y, btn int) {
-
-	// XTerm mouse events only report at most one button at a time,
-	// which may include a wheel button.  Wheel motion events are
-	// reported as single impulses, while other button events are reported
-	// as separate press & release events.
-
-	button := ButtonNone
-	mod := ModNone
-
-	// Mouse wheel has bit 6 set, no release events.  It should be noted
-	// that wheel events are sometimes misdelivered as mouse button events
-	// during a click-drag, so we debounce these, considering them to be
-	// button press events unless we see an intervening release event.
-	switch btn & 0x43 {
-	case 0:
-		button = Button1
-		t.wasbtn = true
-	case 1:
-		button = Button2
-		t.wasbtn = true
-	case 2:
-		button = Button3
-		t.wasbtn = true
-	case 3:
-		button = ButtonNone
-		t.wasbtn = false
-	case 0x40:
-		if !t.wasbtn {
-			button = WheelUp
-		} else {
-			button = Button1
-		}
-	case 0x41:
-		if !t.wasbtn {
-			button = WheelDown
-		} else {
-			button = Button2
+// inputLoop parses the input read by readInput.  When a read leaves a
+// partial sequence (such as a lone ESC) waiting for the rest, and
+// nothing more arrives within the escape delay, what we have is
+// delivered as is.  It starts with the timer running, in case Init left
+// anything waiting.
+func (t *tScreen) inputLoop() {
+	defer close(t.indoneq)
+	chunks := make(chan []byte)
+	go t.readInput(chunks)
+	expire := time.After(t.input.escdelay)
+	for {
+		select {
+		case <-t.quit:
+			// wait for readInput to finish, unless it already has
+			if chunks != nil {
+				for _ = range chunks {
+				}
+			}
+			return
+		case <-t.sigwinch:
+			t.Lock()
+			t.resize()
+			t.Unlock()
+		case b, ok := <-chunks:
+			if !ok {
+				// the tty is gone; wait for Fini
+				chunks = nil
+				continue
+			}
+			expire = nil
+			if t.scanInput(b, false) {
+				expire = time.After(t.input.escdelay)
+			}
+		case <-expire:
+			expire = nil
+			t.scanInput(nil, true)
 		}
 	}
+}
 
-	if btn&0x4 != 0 {
-		mod |= ModShift
-	}
-	if btn&0x8 != 0 {
-		mod |= ModMeta
-	}
-	if btn&0x10 != 0 {
-		mod |= ModCtrl
-	}
-
-	// Some terminals will report mouse coordinates outside the
-	// screen, especially with click-drag events.  Clip the coordinates
-	// to the screen in that case.
-	if x < 0 {
-		x = 0
-	}
-	if x > t.w-1 {
-		x = t.w - 1
-	}
-	if y < 0 {
-		y = 0
+// readInput reads the terminal, and passes on what it reads, until Fini
+// or an error.  Reads time out every so often (see termioInit), so that
+// we notice Fini promptly.
+func (t *tScreen) readInput(chunks chan<- []byte) {
+	defer close(chunks)
+	if t.remote != nil {
+		t.readRemote(chunks)
+		return
 	}
-	if y > t.h-1 {
-		y = t.h - 1
+	for {
+		select {
+		case <-t.quit:
+			return
+		default:
+		}
+		chunk := make([]byte, 128)
+		n, e := t.in.Read(chunk)
+		switch e {
+		case io.EOF:
+			continue
+		case nil:
+		default:
+			return
+		}
+		select {
+		case chunks <- chunk[:n]:
+		case <-t.quit:
+			return
+		}
 	}
-	ev := NewEventMouse(x, y, button, mod)
-	t.PostEvent(ev)
 }
 
-// parseSgrMouse attempts to locate an SGR mouse record at the start of the
-// buffer.  It returns true, true if it found one, and the associated bytes
-// be removed from the buffer.  It returns true, false if the buffer might
-// contain such an event, but more bytes are necessary (partial match), and
-// false, false if the content is definitely *not* an SGR mouse record.
-func (t *tScreen) parseSgrMouse(buf *bytes.Buffer) (bool, bool) {
-
-	b := buf.Bytes()
-
-	var x, y, btn, state int
-	dig := false
-	neg := false
-	i := 0
-	val := 0
-
-	for i = range b {
-		switch b[i] {
-		case '\x1b':
-			if state != 0 {
-				return false, false
-			}
-			state = 1
-
-		case '\x9b':
-			if state != 0 {
-				return false, false
-			}
-			state = 2
-
-		case '[':
-			if state != 1 {
-				return false, false
-			}
-			state = 2
-
-		case '<':
-			if state != 2 {
-				return false, false
-			}
-			val = 0
-			dig = false
-			neg = false
-			state = 3
-
-		case '-':
-			if state != 3 || state != 4 || state != 5 {
-				return false, false
-			}
-			if dig || neg {
-				return false, false
-			}
-			neg = true // stay in state
-
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			if state != 3 && state != 4 && state != 5 {
-				return false, false
-			}
-			val *= 10
-			val += int(b[i] - '0')
-			dig = true // stay in state
-
-		case ';':
-			if neg {
-				val = -val
-			}
-			switch state {
-			case 3:
-				btn, val = val, 0
-				neg, dig, state = false, false, 4
-			case 4:
-				x, val = val, 0
-				neg, dig, state = false, false, 5
-			default:
-				return false, false
-			}
-
-		case 'm', 'M':
-			if state != 5 {
-				return false, false
-			}
-			if neg {
-				val = -val
-			}
-			y = val
-
-			// We don't care about the motion bit
-			btn &^= 32
-			if b[i] == 'm' {
-				// mouse release, clear all buttons
-				btn |= 3
-				btn &^= 0x40
-			}
-			// consume the event bytes
-			for i >= 0 {
-				buf.ReadByte()
-				i--
-			}
-			t.postMouseEvent(x, y, btn)
-			return true, true
+// scanInput parses the input for events, or if expire is true, delivers
+// whatever partial input is still waiting for the rest of a sequence.
+// It returns true if partial input remains.  The lock keeps Reinitialize
+// from changing the key codes and terminal capabilities underneath us.
+func (t *tScreen) scanInput(b []byte, expire bool) bool {
+	t.Lock()
+	defer t.Unlock()
+	t.syncInput()
+	if expire {
+		t.input.Expire()
+	} else {
+		if t.rec != nil {
+			t.rec.Input(b)
 		}
+		t.input.Feed(b)
 	}
-
-	// incomplete & inconclusve at this point
-	return true, false
+	return t.input.buf.Len() > 0
 }
 
-// parseXtermMouse is like parseSgrMouse, but it parses a legacy
-// X11 mouse record.
-func (t *tScreen) parseXtermMouse(buf *bytes.Buffer) (bool, bool) {
+// syncInput brings the geometry used by the input parser to place mouse
+// events up to date with that of the screen.  Must be called with the
+// lock held.
+func (t *tScreen) syncInput() {
+	t.input.SetSize(t.w, t.h)
+	t.input.xform = t.xform
+}
 
-	b := buf.Bytes()
+func (t *tScreen) Sync() {
+	t.Lock()
+	t.resize()
+	t.clear = true
+	t.cells.invalidate()
+	t.damage.all()
+	t.draw()
+	t.frames.drawn()
+	t.redraw.all(t.PostEvent, t.w, t.h)
+	t.Unlock()
+	t.flush()
+}
 
-	state := 0
-	btn := 0
-	x := 0
-	y := 0
+func (t *tScreen) Reinitialize(term string) error {
+	ti, e := LookupTerminfo(term)
+	if e != nil {
+		return e
+	}
+	ti = overrideTerminfo(upgradeTerminfo(ti))
+	t.Lock()
+	defer t.flush()
+	defer t.Unlock()
+
+	// The old terminal is gone, so there is no point in trying to
+	// undo its modes.  Just set up the new one from scratch.
+	t.ti = ti
+	t.mouse = nil
+	if mouseSupport && len(ti.Mouse) > 0 {
+		t.mouse = []byte(ti.Mouse)
+	}
+	t.input.setTerminfo(ti)
+	t.buildAcsMap()
+	t.colormap = nil
 
-	for i := range b {
-		switch state {
-		case 0:
-			switch b[i] {
-			case '\x1b':
-				state = 1
-			case '\x9b':
-				state = 2
-			default:
-				return false, false
-			}
-		case 1:
-			if b[i] != '[' {
-				return false, false
-			}
-			state = 2
-		case 2:
-			if b[i] != 'M' {
-				return false, false
-			}
-			state++
-		case 3:
-			btn = int(b[i])
-			state++
-		case 4:
-			x = int(b[i]) - 32 - 1
-			state++
-		case 5:
-			y = int(b[i]) - 32 - 1
-			for i >= 0 {
-				buf.ReadByte()
-				i--
-			}
-			t.postMouseEvent(x, y, btn)
-			return true, true
-		}
+	if t.fini {
+		return nil
+	}
+	if !t.opts.NoAltScreen {
+		t.TPuts(ti.EnterCA)
+	}
+	t.TPuts(ti.EnterKeypad)
+	t.TPuts(ti.EnablePaste)
+	t.TPuts(ti.HideCursor)
+	t.TPuts(colorSchemeOn)
+	t.TPuts(colorSchemeQuery)
+	if t.mouseon {
+		t.enableMouse(t.mousef)
+	}
+	if ti.Blink == "" && t.blinkq == nil {
+		t.enableBlink(DefaultBlinkRate)
 	}
-	return true, false
+	t.curstyle = styleInvalid
+	t.cx = -1
+	t.cy = -1
+	t.resize()
+	t.clear = true
+	t.cells.invalidate()
+	t.damage.all()
+	t.draw()
+	t.frames.drawn()
+	t.redraw.all(t.PostEvent, t.w, t.h)
+	return nil
 }
 
-func (t *tScreen) parseFunctionKey(buf *bytes.Buffer) (bool, bool) {
-	b := buf.Bytes()
-	partial := false
-	for k, esc := range t.keys {
-		if bytes.HasPrefix(b, esc) {
-			// matched
-			var r rune
-			if len(esc) == 1 {
-				r = rune(b[0])
-			}
-			ev := NewEventKey(k, r, ModNone)
-			t.PostEvent(ev)
-			for i := 0; i < len(esc); i++ {
-				buf.ReadByte()
-			}
-			return true, true
-		}
-		if bytes.HasPrefix(esc, b) {
-			partial = true
-		}
+func (t *tScreen) SetPaletteColor(c Color, r, g, b uint8) error {
+	t.Lock()
+	if t.fini {
+		t.Unlock()
+		return nil
 	}
-	return partial, false
+	if c <= ColorDefault || int(c) > t.ti.Colors {
+		t.Unlock()
+		return ErrNoPalette
+	}
+	t.TPuts(paletteString(t.ti, int(c-1), r, g, b))
+	t.palette = true
+	t.Unlock()
+	t.flush()
+	return nil
 }
 
-func (t *tScreen) parseRune(buf *bytes.Buffer) (bool, bool) {
-	b := buf.Bytes()
-	if b[0] >= ' ' && b[0] <= 0x7F {
-		// printable ASCII easy to deal with -- no encodings
-		ev := NewEventKey(KeyRune, rune(b[0]), ModNone)
-		t.PostEvent(ev)
-		buf.ReadByte()
-		return true, true
+func (t *tScreen) ResetPalette() {
+	t.Lock()
+	if !t.fini && t.palette {
+		t.TPuts(paletteResetString(t.ti))
+		t.palette = false
 	}
+	t.Unlock()
+	t.flush()
+}
 
-	if b[0] < 0x80 {
-		// No encodings start with low numbered values
-		return false, false
+func (t *tScreen) ProbeDeviceAttributes() {
+	t.Lock()
+	if t.fini {
+		t.Unlock()
+		return
 	}
+	t.input.probing = true
+	t.TPuts(deviceQueries)
+	t.Unlock()
+	t.flush()
+}
 
-	switch t.charset {
-	case "UTF-8":
-		if utf8.FullRune(b) {
-			r, _, e := buf.ReadRune()
-			if e == nil {
-				ev := NewEventKey(KeyRune, r, ModNone)
-				t.PostEvent(ev)
-				return true, true
-			}
-		}
-	case "US-ASCII":
-		// ASCII cannot generate this, so most likely it was
-		// entered as an Alt sequence
-		ev := NewEventKey(KeyRune, rune(b[0]-128), ModAlt)
-		t.PostEvent(ev)
-		buf.ReadByte()
-		return true, true
+func (t *tScreen) HasMouse() bool {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.mouse) > 0
+}
 
-	default:
-		utfb := make([]byte, 12)
-		for l := 1; l <= len(b); l++ {
-			t.decoder.Reset()
-			nout, nin, _ := t.decoder.Transform(utfb, b[:l], true)
-			if nout != 0 {
-				if r, _ := utf8.DecodeRune(utfb[:nout]); r != utf8.RuneError {
-					ev := NewEventKey(KeyRune, r, ModNone)
-					t.PostEvent(ev)
-				}
-				for eat := 0; eat < nin; eat++ {
-					buf.ReadByte()
-				}
-				return true, true
-			}
-		}
-	}
-	// Looks like potential escape
-	return true, false
+func (t *tScreen) HasKey(k Key) bool {
+	t.Lock()
+	defer t.Unlock()
+	return t.input.hasKey(k)
 }
 
-func (t *tScreen) scanInput(buf *bytes.Buffer, expire bool) {
+func (t *tScreen) GetCursorPosition() (int, int, error) {
+	// The report is sent by the input loop, which may be waiting for
+	// the lock, so it must not be held here.
+	ch := make(chan [2]int, 1)
+	t.Lock()
+	if t.fini {
+		t.Unlock()
+		return -1, -1, ErrNoCursorPosition
+	}
+	t.input.cursorq = append(t.input.cursorq, ch)
+	t.TPuts(cursorQuery)
+	quit := t.quit
+	t.Unlock()
+	t.flush()
+	select {
+	case pos := <-ch:
+		return pos[0], pos[1], nil
+	case <-time.After(cursorReportTimeout):
+	case <-quit:
+	}
+	t.Lock()
+	t.input.cancelCursorReport(ch)
+	t.Unlock()
+	return -1, -1, ErrNoCursorPosition
+}
 
-	for {
-		b := buf.Bytes()
-		if len(b) == 0 {
-			buf.Reset()
-			return
-		}
+func (t *tScreen) DeviceAttributes() DeviceAttributes {
+	t.Lock()
+	defer t.Unlock()
+	return t.input.devattr
+}
 
-		partials := 0
+func (t *tScreen) EnableRedrawEvents(on bool) {
+	t.Lock()
+	t.redraw.on = on
+	t.redraw.all(t.PostEvent, t.w, t.h)
+	t.Unlock()
+}
 
-		if part, comp := t.parseRune(buf); comp {
-			continue
-		} else if part {
-			partials++
-		}
+func (t *tScreen) EnableRenderStats(on bool) {
+	t.Lock()
+	t.stats.enable(on)
+	t.Unlock()
+}
 
-		if part, comp := t.parseFunctionKey(buf); comp {
-			continue
-		} else if part {
-			partials++
-		}
+func (t *tScreen) RenderStats() RenderStats {
+	t.Lock()
+	defer t.Unlock()
+	return t.stats.stats
+}
 
-		// Only parse mouse records if this term claims to have
-		// mouse support
+func (t *tScreen) SetAllocBudget(allocs uint64) {
+	t.Lock()
+	t.stats.budget = allocs
+	t.Unlock()
+}
 
-		if t.ti.Mouse != "" {
-			if part, comp := t.parseXtermMouse(buf); comp {
-				continue
-			} else if part {
-				partials++
-			}
+func (t *tScreen) Tty() (*os.File, *os.File) {
+	t.Lock()
+	in, out := t.in, t.out
+	t.Unlock()
+	return in, out
+}
 
-			if part, comp := t.parseSgrMouse(buf); comp {
-				continue
-			} else if part {
-				partials++
-			}
-		}
+func (t *tScreen) SavedTermios() []byte {
+	t.Lock()
+	defer t.Unlock()
+	return t.savedTermios()
+}
 
-		if partials == 0 || expire {
-			// Nothing was going to match, or we timed out
-			// waiting for more data -- just deliver the characters
-			// to the app & let them sort it out.  Possibly we should only
-			// do this for control characters such like ESC.
-			by, _ := buf.ReadByte()
-			ev := NewEventKey(KeyRune, rune(by), ModNone)
-			t.PostEvent(ev)
-			continue
-		}
+func (t *tScreen) SetOutputTranslation(on bool) error {
+	t.Lock()
+	defer t.Unlock()
+	t.onlcr = on
+	return t.setOutputPost()
+}
 
-		// well we have some partial data, wait until we get
-		// some more
-		break
+func (t *tScreen) SetRecorder(r *Recorder) {
+	t.Lock()
+	defer t.Unlock()
+	t.rec = r
+	if r == nil {
+		return
+	}
+	r.Resize(t.w, t.h)
+	if !t.fini && t.cells.mainc != nil {
+		t.clear = true
+		t.curstyle = styleInvalid
+		t.cells.invalidate()
+		t.damage.all()
 	}
 }
 
-func (t *tScreen) inputLoop() {
-	buf := &bytes.Buffer{}
-
-	chunk := make([]byte, 128)
-	for {
-		select {
-		case <-t.quit:
-			close(t.indoneq)
-			return
-		case <-t.sigwinch:
-			t.Lock()
-			t.resize()
-			t.Unlock()
-			continue
-		default:
+func (t *tScreen) Features() []Feature {
+	t.Lock()
+	defer t.Unlock()
+	ti := t.ti
+	fs := newFeatureSet()
+	if t.nocolor {
+		fs.set(FeatureColor, false, "turned off")
+	} else if ti.Colors > 0 {
+		fs.set(FeatureColor, true, fmt.Sprintf("%d colors", ti.Colors))
+		if t.trueColor() {
+			fs.set(FeatureTrueColor, true, "")
+		} else {
+			fs.set(FeatureTrueColor, false,
+				fmt.Sprintf("%d color palette", ti.Colors))
 		}
-		n, e := t.in.Read(chunk)
-		switch e {
-		case io.EOF:
-			// If we timeout waiting for more bytes, then it's
-			// time to give up on it.  Even at 300 baud it takes
-			// less than 0.5 ms to transmit a whole byte.
-			if buf.Len() > 0 {
-				t.scanInput(buf, true)
-			}
-			continue
-		case nil:
-		default:
-			close(t.indoneq)
-			return
+	}
+	fs.setFlag(FeatureBold, ti.Bold)
+	fs.setFlag(FeatureDim, ti.Dim)
+	fs.setFlag(FeatureUnderline, ti.Underline)
+	if ti.UnderlineX != "" {
+		fs.set(FeatureUnderlineStyles, true, "")
+	} else if ti.Underline != "" {
+		fs.set(FeatureUnderlineStyles, false, "single underline")
+	}
+	fs.setFlag(FeatureUnderlineColor, ti.SetUlColor)
+	fs.setFlag(FeatureReverse, ti.Reverse)
+	if t.blinkq != nil {
+		fs.set(FeatureBlink, true, "software")
+	} else if ti.Blink != "" {
+		fs.set(FeatureBlink, true, "native")
+	}
+	switch {
+	case t.charset == "UTF-8":
+		fs.set(FeatureUnicode, true, t.charset)
+		fs.set(FeatureLineDrawing, true, "unicode")
+	case ti.EnterAcs != "" && ti.AltChars != "":
+		fs.set(FeatureUnicode, false, t.charset)
+		fs.set(FeatureLineDrawing, true, "alternate character set")
+	default:
+		fs.set(FeatureUnicode, false, t.charset)
+		fs.set(FeatureLineDrawing, false, "ascii")
+	}
+	switch {
+	case !mouseSupport:
+		fs.set(FeatureMouse, false, "not built in")
+	case len(t.mouse) == 0:
+	case !t.mouseon:
+		fs.set(FeatureMouse, false, "off")
+		fs.set(FeatureMousePixels, false, "off")
+	default:
+		fs.set(FeatureMouse, true, "SGR, urxvt or X11 reports")
+		if t.input.mousepix {
+			fs.set(FeatureMousePixels, true, "SGR-Pixels")
+		} else {
+			fs.set(FeatureMousePixels, false, "cell positions")
 		}
-		buf.Write(chunk[:n])
-		// Now we need to parse the input buffer for events
-		t.scanInput(buf, false)
 	}
-}
-
-func (t *tScreen) Sync() {
-	t.Lock()
-	t.resize()
-	t.clear = true
-	InvalidateCells(t.cells)
-	t.draw()
-	t.Unlock()
+	if ti.EnablePaste != "" {
+		fs.set(FeaturePaste, true, "bracketed")
+	} else {
+		fs.set(FeaturePaste, false, "guessed from timing")
+	}
+	fs.set(FeatureComposition, false, "by the terminal")
+	fs.set(FeatureResize, true, "")
+	if t.input.scheme != ColorSchemeUnknown {
+		fs.set(FeatureColorScheme, true, t.input.scheme.String())
+	} else {
+		fs.set(FeatureColorScheme, false, "no report from terminal")
+	}
+	fs.set(FeatureTransform, t.xform != TransformNone, t.xform.String())
+	if t.rec != nil {
+		fs.set(FeatureRecording, true, "")
+	} else {
+		fs.set(FeatureRecording, false, "off")
+	}
+	return fs.list()
 }
 
 func (t *tScreen) CharacterSet() string {
@@ -1,4 +1,4 @@
-// +build !windows,!nacl,!plan9
+// +build !windows,!nacl,!plan9,!js
 
 // Copyright 2015 The TCell Authors
 //
@@ -19,8 +19,8 @@ package tcell
 import (
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
+	"unsafe"
 )
 
 // #include <termios.h>
@@ -40,6 +40,20 @@ import (
 // #endif
 // }
 //
+// int getpixsize(int fd, int *width, int *height) {
+// #if defined TIOCGWINSZ
+//	struct winsize w;
+//	if (ioctl(fd, TIOCGWINSZ, &w) < 0) {
+//		return (-1);
+//	}
+//	*width = w.ws_xpixel;
+//	*height = w.ws_ypixel;
+//	return (0);
+// #else
+//	return (-1);
+// #endif
+// }
+//
 // int getbaud(struct termios *tios) {
 //     switch (cfgetospeed(tios)) {
 // #ifdef B0
@@ -117,6 +131,99 @@ import (
 //	}
 //	return (0);
 // }
+//
+// int setbaud(struct termios *tios, int baud) {
+//	speed_t speed;
+//	switch (baud) {
+// #ifdef B50
+//	case 50: speed = B50; break;
+// #endif
+// #ifdef B75
+//	case 75: speed = B75; break;
+// #endif
+// #ifdef B110
+//	case 110: speed = B110; break;
+// #endif
+// #ifdef B134
+//	case 134: speed = B134; break;
+// #endif
+// #ifdef B150
+//	case 150: speed = B150; break;
+// #endif
+// #ifdef B200
+//	case 200: speed = B200; break;
+// #endif
+// #ifdef B300
+//	case 300: speed = B300; break;
+// #endif
+// #ifdef B600
+//	case 600: speed = B600; break;
+// #endif
+// #ifdef B1200
+//	case 1200: speed = B1200; break;
+// #endif
+// #ifdef B1800
+//	case 1800: speed = B1800; break;
+// #endif
+// #ifdef B2400
+//	case 2400: speed = B2400; break;
+// #endif
+// #ifdef B4800
+//	case 4800: speed = B4800; break;
+// #endif
+// #ifdef B9600
+//	case 9600: speed = B9600; break;
+// #endif
+// #ifdef B19200
+//	case 19200: speed = B19200; break;
+// #endif
+// #ifdef B38400
+//	case 38400: speed = B38400; break;
+// #endif
+// #ifdef B57600
+//	case 57600: speed = B57600; break;
+// #endif
+// #ifdef B76800
+//	case 76800: speed = B76800; break;
+// #endif
+// #ifdef B115200
+//	case 115200: speed = B115200; break;
+// #endif
+// #ifdef B153600
+//	case 153600: speed = B153600; break;
+// #endif
+// #ifdef B230400
+//	case 230400: speed = B230400; break;
+// #endif
+// #ifdef B307200
+//	case 307200: speed = B307200; break;
+// #endif
+// #ifdef B460800
+//	case 460800: speed = B460800; break;
+// #endif
+// #ifdef B921600
+//	case 921600: speed = B921600; break;
+// #endif
+//	default: return (-1);
+//	}
+//	if (cfsetispeed(tios, speed) < 0 || cfsetospeed(tios, speed) < 0) {
+//		return (-1);
+//	}
+//	return (0);
+// }
+//
+// int setrtscts(struct termios *tios, int on) {
+// #if defined CRTSCTS
+//	if (on) {
+//		tios->c_cflag |= CRTSCTS;
+//	} else {
+//		tios->c_cflag &= ~CRTSCTS;
+//	}
+//	return (0);
+// #else
+//	return (on ? -1 : 0);
+// #endif
+// }
 import "C"
 
 type termiosPrivate struct {
@@ -129,11 +236,22 @@ func (t *tScreen) termioInit() error {
 	var newtios C.struct_termios
 	var fd C.int
 
-	if t.in, e = os.OpenFile("/dev/tty", os.O_RDONLY, 0); e != nil {
-		goto failed
-	}
-	if t.out, e = os.OpenFile("/dev/tty", os.O_WRONLY, 0); e != nil {
-		goto failed
+	// We always use the controlling terminal, rather than stdin and
+	// stdout, which may be pipes or files.  (Unless we were given a
+	// serial line to drive, or another tty in the options.)
+	if t.serial != nil {
+		if t.in, t.out, e = openSerial(t.serial.Device); e != nil {
+			goto failed
+		}
+	} else if t.opts.In != nil {
+		t.in, t.out = t.opts.In, t.opts.Out
+	} else {
+		if t.in, e = os.OpenFile("/dev/tty", os.O_RDONLY, 0); e != nil {
+			goto failed
+		}
+		if t.out, e = os.OpenFile("/dev/tty", os.O_WRONLY, 0); e != nil {
+			goto failed
+		}
 	}
 
 	t.tiosp = &termiosPrivate{}
@@ -148,6 +266,9 @@ func (t *tScreen) termioInit() error {
 		C.ISTRIP | C.INLCR | C.IGNCR |
 		C.ICRNL | C.IXON
 	newtios.c_oflag &^= C.OPOST
+	if t.onlcr {
+		newtios.c_oflag |= C.OPOST | C.ONLCR
+	}
 	newtios.c_lflag &^= C.ECHO | C.ECHONL | C.ICANON |
 		C.ISIG | C.IEXTEN
 	newtios.c_cflag &^= C.CSIZE | C.PARENB
@@ -161,6 +282,13 @@ func (t *tScreen) termioInit() error {
 	newtios.c_cc[C.VMIN] = 0
 	newtios.c_cc[C.VTIME] = 1
 
+	if t.serial != nil {
+		if e = setSerial(&newtios, t.serial); e != nil {
+			goto failed
+		}
+		t.baud = int(C.getbaud(&newtios))
+	}
+
 	if rv, e = C.tcsetattr(fd, C.TCSANOW|C.TCSAFLUSH, &newtios); rv != 0 {
 		goto failed
 	}
@@ -168,6 +296,9 @@ func (t *tScreen) termioInit() error {
 	signal.Notify(t.sigwinch, syscall.SIGWINCH)
 
 	if w, h, e := t.getWinSize(); e == nil && w != 0 && h != 0 {
+		if t.xform.swaps() {
+			w, h = h, w
+		}
 		t.w = w
 		t.h = h
 	}
@@ -175,13 +306,65 @@ func (t *tScreen) termioInit() error {
 	return nil
 
 failed:
-	if t.in != nil {
-		t.in.Close()
+	t.closeTty()
+	return e
+}
+
+// openSerial opens the serial device for input and output.  It is
+// opened without waiting for carrier, and without it becoming our
+// controlling terminal.
+func openSerial(dev string) (*os.File, *os.File, error) {
+	fd, e := syscall.Open(dev, syscall.O_RDWR|syscall.O_NOCTTY|
+		syscall.O_NONBLOCK, 0)
+	if e != nil {
+		return nil, nil, &os.PathError{Op: "open", Path: dev, Err: e}
 	}
-	if t.out != nil {
-		t.out.Close()
+	// The input loop relies on VTIME, not on non-blocking reads.
+	if e = syscall.SetNonblock(fd, false); e != nil {
+		syscall.Close(fd)
+		return nil, nil, &os.PathError{Op: "open", Path: dev, Err: e}
 	}
-	return e
+	ofd, e := syscall.Dup(fd)
+	if e != nil {
+		syscall.Close(fd)
+		return nil, nil, &os.PathError{Op: "dup", Path: dev, Err: e}
+	}
+	return os.NewFile(uintptr(fd), dev), os.NewFile(uintptr(ofd), dev), nil
+}
+
+// setSerial applies the line settings of the serial configuration.
+func setSerial(tios *C.struct_termios, cfg *SerialConfig) error {
+	if C.setbaud(tios, C.int(cfg.Baud)) != 0 {
+		return ErrSerialConfig
+	}
+	tios.c_cflag &^= C.CSIZE | C.PARENB | C.PARODD | C.CSTOPB
+	tios.c_cflag |= C.CREAD | C.CLOCAL
+	if cfg.DataBits == 7 {
+		tios.c_cflag |= C.CS7
+	} else {
+		tios.c_cflag |= C.CS8
+	}
+	switch cfg.Parity {
+	case ParityEven:
+		tios.c_cflag |= C.PARENB
+	case ParityOdd:
+		tios.c_cflag |= C.PARENB | C.PARODD
+	}
+	if cfg.StopBits == 2 {
+		tios.c_cflag |= C.CSTOPB
+	}
+	tios.c_iflag &^= C.IXON | C.IXOFF
+	hw := C.int(0)
+	switch cfg.Flow {
+	case FlowXonXoff:
+		tios.c_iflag |= C.IXON | C.IXOFF
+	case FlowHardware:
+		hw = 1
+	}
+	if C.setrtscts(tios, hw) != 0 {
+		return ErrSerialConfig
+	}
+	return nil
 }
 
 func (t *tScreen) termioFini() {
@@ -193,6 +376,16 @@ func (t *tScreen) termioFini() {
 	if t.out != nil {
 		fd := C.int(t.out.Fd())
 		C.tcsetattr(fd, C.TCSANOW|C.TCSAFLUSH, &t.tiosp.tios)
+	}
+	t.closeTty()
+}
+
+// closeTty closes the tty, unless it was given to us.
+func (t *tScreen) closeTty() {
+	if t.opts.In != nil {
+		return
+	}
+	if t.out != nil {
 		t.out.Close()
 	}
 	if t.in != nil {
@@ -200,30 +393,49 @@ func (t *tScreen) termioFini() {
 	}
 }
 
-func (t *tScreen) getCharset() string {
-	// Let's also determine the character set.  This can help us later.
-	// Per POSIX, we search for LC_ALL first, then LC_CTYPE, and
-	// finally LANG.  First one set wins.
-	locale := ""
-	if locale = os.Getenv("LC_ALL"); locale == "" {
-		if locale = os.Getenv("LC_CTYPE"); locale == "" {
-			locale = os.Getenv("LANG")
-		}
+func (t *tScreen) savedTermios() []byte {
+	if t.tiosp == nil {
+		return nil
 	}
-	if locale == "POSIX" || locale == "C" {
-		return "US-ASCII"
+	return C.GoBytes(unsafe.Pointer(&t.tiosp.tios),
+		C.int(unsafe.Sizeof(t.tiosp.tios)))
+}
+
+// setOutputPost applies the output translation setting to the tty,
+// if it is open.  Otherwise, it will be applied by termioInit.
+func (t *tScreen) setOutputPost() error {
+	var tios C.struct_termios
+
+	if t.out == nil || t.tiosp == nil {
+		return nil
+	}
+	fd := C.int(t.out.Fd())
+	if rv, e := C.tcgetattr(fd, &tios); rv != 0 {
+		return e
 	}
-	if i := strings.IndexRune(locale, '@'); i >= 0 {
-		locale = locale[:i]
+	if t.onlcr {
+		tios.c_oflag |= C.OPOST | C.ONLCR
+	} else {
+		tios.c_oflag &^= C.OPOST
 	}
-	if i := strings.IndexRune(locale, '.'); i >= 0 {
-		locale = locale[i+1:]
+	if rv, e := C.tcsetattr(fd, C.TCSADRAIN, &tios); rv != 0 {
+		return e
 	}
-	if locale == "" {
-		return "UTF-8"
+	return nil
+}
+
+func (t *tScreen) getCharset() string {
+	return localeCharset()
+}
+
+// getPixelSize returns the size of the window in pixels.  Many terminals
+// do not report this, in which case the values will be zero.
+func (t *tScreen) getPixelSize() (int, int, error) {
+	var px, py C.int
+	if r, e := C.getpixsize(C.int(t.out.Fd()), &px, &py); r != 0 {
+		return 0, 0, e
 	}
-	// XXX: add support for aliases
-	return locale
+	return int(px), int(py), nil
 }
 
 func (t *tScreen) getWinSize() (int, int, error) {
@@ -1,4 +1,4 @@
-// +build nacl plan9
+// +build nacl plan9 js
 
 // Copyright 2015 The TCell Authors
 //
@@ -35,6 +35,18 @@ func (t *tScreen) getCharset() string {
 	return ""
 }
 
+func (t *tScreen) savedTermios() []byte {
+	return nil
+}
+
+func (t *tScreen) setOutputPost() error {
+	return errors.New("no termios support on this platform")
+}
+
+func (t *tScreen) getPixelSize() (int, int, error) {
+	return 0, 0, errors.New("no termios support on this platform")
+}
+
 func (t *tScreen) getWinSize() (int, int, error) {
 	return 0, 0, errors.New("no termios support on this platform")
 }
@@ -0,0 +1,1171 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newTestTScreen returns a tScreen without any actual tty.  Its input
+// parser queues events, rather than posting them, so that tests can
+// retrieve them with scanEvents.
+func newTestTScreen(term string) *tScreen {
+	ti, e := LookupTerminfo(term)
+	So(e, ShouldBeNil)
+	t := &tScreen{ti: ti, w: 80, h: 24, charset: "UTF-8"}
+	t.input = newInputParser(ti)
+	t.syncInput()
+	return t
+}
+
+// newTestOutput sends the output of ts to a temporary file.  The
+// function returned flushes ts, and returns what was written since it
+// was last called.
+func newTestOutput(ts *tScreen) func() string {
+	f, e := ioutil.TempFile("", "tcell")
+	So(e, ShouldBeNil)
+	Reset(func() {
+		f.Close()
+		os.Remove(f.Name())
+	})
+	ts.out = f
+	return func() string {
+		ts.flush()
+		b, e := ioutil.ReadFile(f.Name())
+		So(e, ShouldBeNil)
+		f.Truncate(0)
+		f.Seek(0, 0)
+		return string(b)
+	}
+}
+
+func TestReinitialize(t *testing.T) {
+	Convey("Reinitialize with a new terminal type", t, func() {
+		ts := newTestTScreen("xterm")
+		ts.fini = true
+		So(len(scanKeys(ts.input, "\x1b[a")), ShouldNotEqual, 1)
+
+		So(ts.Reinitialize("rxvt"), ShouldBeNil)
+		So(ts.ti.Name, ShouldStartWith, "rxvt")
+		evs := scanKeys(ts.input, "\x1b[a")
+		So(len(evs), ShouldEqual, 1)
+		So(evs[0].Key(), ShouldEqual, KeyUp)
+		So(evs[0].Mod(), ShouldEqual, ModShift)
+
+		Convey("Unknown terminals are refused", func() {
+			So(ts.Reinitialize("no-such-terminal"), ShouldNotBeNil)
+			So(ts.ti.Name, ShouldStartWith, "rxvt")
+		})
+
+		Convey("Tee screens reinitialize the primary", func() {
+			tee := NewTeeScreen(ts, NewSimulationScreen(""))
+			rs, ok := tee.(ReinitializeScreen)
+			So(ok, ShouldBeTrue)
+			So(rs.Reinitialize("xterm"), ShouldBeNil)
+			So(ts.ti.Name, ShouldStartWith, "xterm")
+		})
+	})
+}
+
+func TestForceTerm(t *testing.T) {
+	Convey("Fallback for unknown terminals", t, func() {
+		old := os.Getenv("TCELL_FORCE_TERM")
+		defer os.Setenv("TCELL_FORCE_TERM", old)
+
+		os.Setenv("TCELL_FORCE_TERM", "")
+		_, e := lookupTermWithFallback("no-such-terminal")
+		So(e, ShouldNotBeNil)
+
+		os.Setenv("TCELL_FORCE_TERM", "vt100")
+		ti, e := lookupTermWithFallback("no-such-terminal")
+		So(e, ShouldBeNil)
+		So(ti.Name, ShouldEqual, "vt100")
+
+		ti, e = lookupTermWithFallback("xterm")
+		So(e, ShouldBeNil)
+		So(ti.Name, ShouldEqual, "xterm")
+
+		os.Setenv("TCELL_FORCE_TERM", "no-such-fallback")
+		_, e = lookupTermWithFallback("no-such-terminal")
+		So(e, ShouldNotBeNil)
+	})
+}
+
+func TestUpgradeTerminfo(t *testing.T) {
+	Convey("Upgrading terminal descriptions", t, func() {
+		env := map[string]string{}
+		for _, k := range []string{"TCELL_UPGRADE_TERM", "TMUX", "COLORTERM"} {
+			env[k] = os.Getenv(k)
+			os.Setenv(k, "")
+		}
+		release, console := kernelRelease, localConsole
+		Reset(func() {
+			for k, v := range env {
+				os.Setenv(k, v)
+			}
+			kernelRelease, localConsole = release, console
+		})
+		localConsole = func() bool { return true }
+		linux, e := LookupTerminfo("linux")
+		So(e, ShouldBeNil)
+		screen, e := LookupTerminfo("screen")
+		So(e, ShouldBeNil)
+
+		Convey("Linux console on a recent kernel", func() {
+			kernelRelease = func() string { return "5.15.0-91-generic" }
+			ti := upgradeTerminfo(linux)
+			So(ti.Colors, ShouldEqual, 256)
+			So(ti.TParm(ti.SetFg, 3), ShouldEqual, "\x1b[33m")
+			So(ti.TParm(ti.SetFg, 12), ShouldEqual, "\x1b[38;5;12m")
+			So(ti.SetFgRGB, ShouldNotEqual, "")
+			So(linux.Colors, ShouldEqual, 8)
+
+			os.Setenv("TCELL_UPGRADE_TERM", "0")
+			So(upgradeTerminfo(linux), ShouldEqual, linux)
+		})
+
+		Convey("Linux console of another machine", func() {
+			kernelRelease = func() string { return "5.15.0-91-generic" }
+			localConsole = func() bool { return false }
+			So(upgradeTerminfo(linux), ShouldEqual, linux)
+
+			os.Setenv("TCELL_UPGRADE_TERM", "1")
+			kernelRelease = func() string { return "" }
+			So(upgradeTerminfo(linux).Colors, ShouldEqual, 256)
+		})
+
+		Convey("Linux console on an old kernel", func() {
+			kernelRelease = func() string { return "3.10.0-1160.el7" }
+			So(upgradeTerminfo(linux), ShouldEqual, linux)
+			kernelRelease = func() string { return "" }
+			So(upgradeTerminfo(linux), ShouldEqual, linux)
+		})
+
+		Convey("Screen inside tmux", func() {
+			So(upgradeTerminfo(screen), ShouldEqual, screen)
+			os.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+			So(upgradeTerminfo(screen).Name, ShouldEqual, "screen-256color")
+		})
+	})
+}
+
+func TestDeriveTerminfo(t *testing.T) {
+	Convey("Deriving terminals with more colors", t, func() {
+		env := os.Getenv("TCELLDB")
+		os.Setenv("TCELLDB", os.DevNull+".none")
+		Reset(func() {
+			os.Setenv("TCELLDB", env)
+		})
+
+		ti, e := LookupTerminfo("vt100-256color")
+		So(e, ShouldBeNil)
+		So(ti.Name, ShouldEqual, "vt100-256color")
+		So(ti.Colors, ShouldEqual, 256)
+		So(ti.TParm(ti.SetFg, 200), ShouldEqual, "\x1b[38;5;200m")
+		So(ti.SetFgRGB, ShouldEqual, "")
+
+		ti, e = LookupTerminfo("xterm-direct")
+		So(e, ShouldBeNil)
+		So(ti.Colors, ShouldEqual, 256)
+		So(ti.TParm(ti.SetBgRGB, 1, 2, 3), ShouldEqual, "\x1b[48;2;1;2;3m")
+		xterm, _ := LookupTerminfo("xterm")
+		So(xterm.Colors, ShouldEqual, 8)
+
+		_, e = LookupTerminfo("no-such-terminal-256color")
+		So(e, ShouldNotBeNil)
+		_, e = LookupTerminfo("-direct")
+		So(e, ShouldNotBeNil)
+	})
+}
+
+func TestOverrideTerminfo(t *testing.T) {
+	Convey("Overriding capabilities", t, func() {
+		vars := []string{"TCELL_TRUECOLOR", "TCELL_ALTSCREEN", "TCELL_MOUSE"}
+		env := map[string]string{}
+		for _, k := range vars {
+			env[k] = os.Getenv(k)
+			os.Setenv(k, "")
+		}
+		Reset(func() {
+			for k, v := range env {
+				os.Setenv(k, v)
+			}
+		})
+		xterm, e := LookupTerminfo("xterm")
+		So(e, ShouldBeNil)
+		vt100, e := LookupTerminfo("vt100")
+		So(e, ShouldBeNil)
+
+		Convey("Nothing changes by default", func() {
+			So(overrideTerminfo(xterm), ShouldEqual, xterm)
+			os.Setenv("TCELL_MOUSE", "maybe")
+			So(overrideTerminfo(xterm), ShouldEqual, xterm)
+		})
+
+		Convey("Capabilities can be turned off", func() {
+			for _, k := range vars {
+				os.Setenv(k, "off")
+			}
+			ti := overrideTerminfo(xterm)
+			So(ti.SetFgRGB, ShouldEqual, "")
+			So(ti.EnterCA, ShouldEqual, "")
+			So(ti.ExitCA, ShouldEqual, "")
+			So(ti.Mouse, ShouldEqual, "")
+			So(xterm.Mouse, ShouldNotEqual, "")
+		})
+
+		Convey("Capabilities can be turned on", func() {
+			for _, k := range vars {
+				os.Setenv(k, "on")
+			}
+			ti := overrideTerminfo(vt100)
+			So(ti.TParm(ti.SetFgRGB, 1, 2, 3), ShouldEqual, "\x1b[38;2;1;2;3m")
+			So(ti.EnterCA, ShouldEqual, "\x1b[?1049h")
+			So(ti.Mouse, ShouldEqual, "\x1b[M")
+			So(ti.TParm(ti.MouseMode, 1), ShouldEqual,
+				"\x1b[?1000h\x1b[?1003h\x1b[?1006h")
+			So(overrideTerminfo(xterm).EnterCA, ShouldEqual, xterm.EnterCA)
+		})
+	})
+}
+
+func TestStyledUnderline(t *testing.T) {
+	Convey("Styled underlines", t, func() {
+		ts := newTestTScreen("xterm-256color")
+		output := newTestOutput(ts)
+		ts.curstyle = styleInvalid
+		cell := &Cell{Ch: []rune{'x'}, Width: 1}
+		cell.Style = StyleDefault.UnderlineStyle(UnderlineCurly).
+			UnderlineColor(ColorRed)
+
+		Convey("Plain underline without Smulx", func() {
+			ts.drawCell(0, 0, cell)
+			out := output()
+			So(out, ShouldContainSubstring, "\x1b[4m")
+			So(out, ShouldNotContainSubstring, "58:5")
+		})
+
+		Convey("Curly red underline with Smulx and Setulc", func() {
+			ti := *ts.ti
+			ti.UnderlineX = "\x1b[4:%p1%dm"
+			ti.SetUlColor = "\x1b[58:2::%p1%dm"
+			ts.ti = &ti
+			ts.drawCell(0, 0, cell)
+			out := output()
+			So(out, ShouldContainSubstring, "\x1b[4:3m")
+			So(out, ShouldContainSubstring, "\x1b[58:5:1m")
+		})
+	})
+}
+
+func TestLineMode(t *testing.T) {
+	Convey("Terminals without cursor addressing", t, func() {
+		ts := newTestTScreen("dumb")
+		output := newTestOutput(ts)
+		ts.linemode = true
+		ts.cx, ts.cy = -1, -1
+		ts.cells.resize(ts.w, ts.h)
+		ts.cells.clear(StyleDefault)
+		for i := range ts.cells.dirty {
+			ts.cells.dirty[i] = false
+		}
+
+		ts.SetCell(0, 0, StyleDefault, 'a')
+		ts.SetCell(2, 1, StyleDefault, 'b')
+		ts.Show()
+		So(output(), ShouldEqual, "\ra\n\r  b")
+
+		Convey("Lines further down are reached with line feeds", func() {
+			ts.SetCell(0, 3, StyleDefault, 'c')
+			ts.Show()
+			So(output(), ShouldEqual, "\n\n\rc")
+		})
+
+		Convey("Going back up starts a new line", func() {
+			ts.SetCell(0, 0, StyleDefault, ' ')
+			ts.Show()
+			So(output(), ShouldEqual, "\n\r ")
+		})
+
+		Convey("Unless the terminal can move up", func() {
+			ti := *ts.ti
+			ti.CursorUp1 = "\x1b[A"
+			ts.ti = &ti
+			ts.SetCell(1, 0, StyleDefault, 'z')
+			ts.Show()
+			So(output(), ShouldEqual, "\x1b[A\raz")
+		})
+	})
+}
+
+func TestPalette(t *testing.T) {
+	Convey("Palette colors", t, func() {
+		xt, e := LookupTerminfo("xterm-256color")
+		So(e, ShouldBeNil)
+		So(paletteString(xt, 1, 0xff, 0x80, 0x01),
+			ShouldEqual, "\x1b]4;1;rgb:FF/80/01\x1b\\")
+		So(paletteString(xt, 200, 0xfe, 0, 0x7f),
+			ShouldEqual, "\x1b]4;200;rgb:FE/00/7F\x1b\\")
+		So(paletteResetString(xt), ShouldEqual, "\x1b]104\a")
+
+		linux, e := LookupTerminfo("linux")
+		So(e, ShouldBeNil)
+		So(paletteString(linux, 12, 0x12, 0x34, 0x56),
+			ShouldEqual, "\x1b]Pc123456")
+
+		vt, e := LookupTerminfo("vt100")
+		So(e, ShouldBeNil)
+		So(paletteString(vt, 3, 1, 2, 3),
+			ShouldEqual, "\x1b]4;3;rgb:01/02/03\x1b\\")
+		So(paletteResetString(vt), ShouldEqual, "\x1b]104\x1b\\")
+
+		Convey("Only colors in the palette can be set", func() {
+			ts := newTestTScreen("xterm")
+			newTestOutput(ts)
+			So(ts.SetPaletteColor(ColorDefault, 0, 0, 0), ShouldEqual, ErrNoPalette)
+			So(ts.SetPaletteColor(ColorGrey, 0, 0, 0), ShouldEqual, ErrNoPalette)
+			So(ts.palette, ShouldBeFalse)
+			So(ts.SetPaletteColor(ColorWhite, 0xee, 0xee, 0xee), ShouldBeNil)
+			So(ts.palette, ShouldBeTrue)
+			ts.ResetPalette()
+			So(ts.palette, ShouldBeFalse)
+		})
+	})
+}
+
+func TestNoColor(t *testing.T) {
+	Convey("Drawing without colors", t, func() {
+		ts := newTestTScreen("xterm-256color")
+		output := newTestOutput(ts)
+		ts.curstyle = styleInvalid
+		cell := &Cell{Ch: []rune{'x'}, Width: 1}
+		cell.Style = StyleDefault.Foreground(ColorRed).
+			Background(ColorBlue).Bold(true).Reverse(true)
+
+		Convey("Keeps the attributes", func() {
+			ts.SetNoColor(true)
+			ts.drawCell(0, 0, cell)
+			out := output()
+			So(out, ShouldContainSubstring, ts.ti.Bold)
+			So(out, ShouldContainSubstring, ts.ti.Reverse)
+			So(out, ShouldNotContainSubstring,
+				ts.ti.TParm(ts.ti.SetFg, int(ColorRed)-1))
+			So(out, ShouldNotContainSubstring,
+				ts.ti.TParm(ts.ti.SetBg, int(ColorBlue)-1))
+			So(ts.Colors(), ShouldEqual, 0)
+		})
+
+		Convey("Can be turned back off", func() {
+			ts.SetNoColor(true)
+			ts.SetNoColor(false)
+			ts.drawCell(0, 0, cell)
+			So(output(), ShouldContainSubstring,
+				ts.ti.TParm(ts.ti.SetFg, int(ColorRed)-1))
+			So(ts.Colors(), ShouldEqual, 256)
+		})
+
+		Convey("Is chosen by NO_COLOR", func() {
+			old := os.Getenv("NO_COLOR")
+			Reset(func() {
+				os.Setenv("NO_COLOR", old)
+			})
+			os.Setenv("NO_COLOR", "1")
+			So(noColorEnv(), ShouldBeTrue)
+			os.Setenv("NO_COLOR", "")
+			So(noColorEnv(), ShouldBeFalse)
+		})
+	})
+}
+
+func TestMinimalSGR(t *testing.T) {
+	Convey("Style changes send only the difference", t, func() {
+		ts := newTestTScreen("xterm-256color")
+		ti := *ts.ti
+		ti.Dim = "\x1b[2m"
+		ts.ti = &ti
+		red := StyleDefault.Foreground(ColorRed)
+		bold := red.Bold(true)
+
+		So(ts.sgr(styleInvalid, red), ShouldEqual, "\x1b(B\x1b[m\x1b[31m")
+		So(ts.sgr(red, bold), ShouldEqual, "\x1b[1m")
+		So(ts.sgr(bold, red), ShouldEqual, "\x1b[22m")
+		So(ts.sgr(bold.Dim(true), red.Dim(true)), ShouldEqual, "\x1b[22m\x1b[2m")
+		So(ts.sgr(red, StyleDefault), ShouldEqual, "\x1b[39m")
+		So(ts.sgr(red, red.Background(ColorBlue).Reverse(true)),
+			ShouldEqual, "\x1b[7m\x1b[44m")
+
+		Convey("Unless the full form is shorter", func() {
+			all := bold.Underline(true).Reverse(true).Blink(true).
+				Background(ColorBlue)
+			So(ts.sgr(all, StyleDefault), ShouldEqual, "\x1b(B\x1b[m")
+		})
+
+		Convey("Or full SGR output is requested", func() {
+			ts.fullsgr = true
+			So(ts.sgr(red, bold), ShouldEqual, "\x1b(B\x1b[m\x1b[1m\x1b[31m")
+		})
+	})
+}
+
+func TestParamCache(t *testing.T) {
+	Convey("Cached motion and colors match TParm", t, func() {
+		ts := newTestTScreen("xterm-256color")
+		ti := ts.ti
+		for _, c := range []Color{ColorRed, Color(200), Color(1000)} {
+			So(ts.setFg(c), ShouldEqual, ti.TParm(ti.SetFg, int(c)-1))
+			So(ts.setBg(c), ShouldEqual, ti.TParm(ti.SetBg, int(c)-1))
+			// and again, from the cache
+			So(ts.setFg(c), ShouldEqual, ti.TParm(ti.SetFg, int(c)-1))
+		}
+		So(ts.tgoto(3, 2), ShouldEqual, "\x1b[3;4H")
+		So(ts.tgoto(3, 2), ShouldEqual, "\x1b[3;4H")
+		So(ts.tgoto(ts.w+5, 2), ShouldEqual, ti.TGoto(ts.w+5, 2))
+
+		Convey("And follow changes to the terminfo", func() {
+			nti := *ts.ti
+			nti.SetCursor = "\x1b[%i%p1%d;%p2%df"
+			ts.ti = &nti
+			So(ts.tgoto(3, 2), ShouldEqual, "\x1b[3;4f")
+		})
+
+		Convey("And the size of the screen", func() {
+			ts.w, ts.h = ts.w+1, ts.h+1
+			So(ts.tgoto(ts.w-1, ts.h-1), ShouldEqual,
+				ti.TGoto(ts.w-1, ts.h-1))
+		})
+	})
+}
+
+func TestTransform(t *testing.T) {
+	Convey("Display transforms", t, func() {
+		Convey("Mappings are inverted", func() {
+			for _, tr := range []Transform{TransformNone,
+				TransformRotate90, TransformRotate180,
+				TransformRotate270, TransformMirror} {
+				px, py := tr.apply(3, 1, 10, 4)
+				x, y := tr.invert(px, py, 10, 4)
+				So(x, ShouldEqual, 3)
+				So(y, ShouldEqual, 1)
+			}
+			px, py := TransformRotate90.apply(0, 0, 10, 4)
+			So(px, ShouldEqual, 3)
+			So(py, ShouldEqual, 0)
+		})
+
+		Convey("Rotation exchanges width and height", func() {
+			ts := newTestTScreen("xterm")
+			ts.SetTransform(TransformRotate90)
+			w, h := ts.Size()
+			So(w, ShouldEqual, 24)
+			So(h, ShouldEqual, 80)
+
+			// clicking at the top right of the display is
+			// the top left of the screen
+			ts.syncInput()
+			evs := scanMouse(ts.input, "\x1b[<0;80;1M")
+			So(len(evs), ShouldEqual, 1)
+			x, y := evs[0].Position()
+			So(x, ShouldEqual, 0)
+			So(y, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestTScreenFeatures(t *testing.T) {
+	Convey("Terminal feature report", t, func() {
+		ts := newTestTScreen("xterm-256color")
+		ts.mouse = []byte(ts.ti.Mouse)
+		fs := make(map[string]Feature)
+		for _, f := range ts.Features() {
+			fs[f.Name] = f
+		}
+		So(fs[FeatureColor].Mode, ShouldEqual, "256 colors")
+		So(fs[FeatureBlink].Mode, ShouldEqual, "native")
+		So(fs[FeatureLineDrawing].Mode, ShouldEqual, "unicode")
+		So(fs[FeatureMouse].Mode, ShouldEqual, "off")
+		So(fs[FeatureColorScheme].Active, ShouldBeFalse)
+
+		ts.scanInput([]byte("\x1b[?997;2n"), false)
+		ts.EnableMouse()
+		fs = make(map[string]Feature)
+		for _, f := range ts.Features() {
+			fs[f.Name] = f
+		}
+		So(fs[FeatureMouse].Active, ShouldBeTrue)
+		So(fs[FeatureColorScheme].Mode, ShouldEqual, "light")
+	})
+}
+
+func TestTScreenColorModel(t *testing.T) {
+	Convey("Terminal color models", t, func() {
+		env := os.Getenv("TCELL_TRUECOLOR")
+		os.Setenv("TCELL_TRUECOLOR", "")
+		Reset(func() {
+			os.Setenv("TCELL_TRUECOLOR", env)
+		})
+		for term, model := range map[string]ColorModel{
+			"vt100":          ColorModelNone,
+			"xterm":          ColorModel8,
+			"xterm-256color": ColorModel256,
+		} {
+			ts := newTestTScreen(term)
+			So(ts.ColorModel(), ShouldEqual, model)
+			So(ts.DefaultColors(), ShouldBeTrue)
+		}
+
+		os.Setenv("TCELL_TRUECOLOR", "on")
+		ts := newTestTScreen("xterm-256color")
+		ts.ti = overrideTerminfo(ts.ti)
+		So(ts.ColorModel(), ShouldEqual, ColorModelDirect)
+		So(ts.Colors(), ShouldEqual, 1<<24)
+		So(ColorModelDirect.String(), ShouldEqual, "direct")
+		for _, f := range ts.Features() {
+			if f.Name == FeatureTrueColor {
+				So(f.Active, ShouldBeTrue)
+			}
+		}
+
+		ts.SetNoColor(true)
+		So(ts.ColorModel(), ShouldEqual, ColorModelNone)
+		So(ts.Colors(), ShouldEqual, 0)
+	})
+}
+
+func TestHasKey(t *testing.T) {
+	Convey("Keys and mouse of terminals", t, func() {
+		ts := newTestTScreen("xterm")
+		ts.mouse = []byte(ts.ti.Mouse)
+		So(ts.HasMouse(), ShouldBeTrue)
+		for _, k := range []Key{KeyRune, KeyEnter, KeyCtrlA, KeyF1, KeyF12, KeyInsert} {
+			So(ts.HasKey(k), ShouldBeTrue)
+		}
+
+		ts = newTestTScreen("vt100")
+		So(ts.HasMouse(), ShouldBeFalse)
+		So(ts.HasKey(KeyF10), ShouldBeTrue)
+		So(ts.HasKey(KeyF11), ShouldBeFalse)
+		So(ts.HasKey(KeyInsert), ShouldBeFalse)
+	})
+}
+
+func TestSetSize(t *testing.T) {
+	Convey("Asking for a new size", t, func() {
+		ts := newTestTScreen("xterm")
+		output := newTestOutput(ts)
+		ts.SetSize(100, 40)
+		ts.SetSize(0, 40)
+		So(output(), ShouldEqual, "\x1b[8;40;100t")
+	})
+}
+
+func TestWideUncovered(t *testing.T) {
+	Convey("A cell covered by a wide character is redrawn", t, func() {
+		ts := newTestTScreen("xterm")
+		output := newTestOutput(ts)
+		ts.cells.resize(ts.w, ts.h)
+		ts.Clear()
+		ts.draw()
+		output()
+
+		ts.SetCell(0, 0, StyleDefault, 'a')
+		ts.SetCell(1, 0, StyleDefault, 'b')
+		ts.draw()
+		So(output(), ShouldContainSubstring, "ab")
+
+		ts.SetCell(0, 0, StyleDefault, '世')
+		ts.SetCell(2, 0, StyleDefault, 'b')
+		ts.draw()
+		So(output(), ShouldContainSubstring, "世b")
+
+		ts.SetCell(0, 0, StyleDefault, 'a')
+		ts.SetCell(2, 0, StyleDefault, ' ')
+		ts.draw()
+		So(output(), ShouldContainSubstring, "ab ")
+		So(ts.GetCell(1, 0).Dirty, ShouldBeFalse)
+	})
+}
+
+func TestRowDamage(t *testing.T) {
+	Convey("Only changed rows are looked at", t, func() {
+		ts := newTestTScreen("xterm")
+		newTestOutput(ts)
+		ts.cells.resize(ts.w, ts.h)
+		ts.Clear()
+		ts.draw()
+		So(ts.damage, ShouldHaveLength, ts.h)
+		So(ts.damage.dirty(&ts.cells), ShouldBeFalse)
+
+		ts.SetCell(5, 3, StyleDefault, 'x')
+		ts.SetCell(6, 3, StyleDefault, ' ')
+		ts.PutCell(0, 20, &Cell{Ch: []rune{'y'}})
+		for row := range ts.damage {
+			So(ts.damage[row], ShouldEqual, row == 3 || row == 20)
+		}
+		ts.draw()
+		So(ts.damage.dirty(&ts.cells), ShouldBeFalse)
+		So(ts.GetCell(5, 3).Dirty, ShouldBeFalse)
+		So(ts.GetCell(0, 20).Dirty, ShouldBeFalse)
+
+		Convey("Clearing marks every row", func() {
+			ts.Clear()
+			for row := range ts.damage {
+				So(ts.damage[row], ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestPadding(t *testing.T) {
+	Convey("Padding can be turned off", t, func() {
+		ts := newTestTScreen("adm3a")
+		ts.baud = 9600
+		ts.TPuts(ts.ti.Clear)
+		So(ts.obuf.String(), ShouldEqual, "\x1a\x00")
+
+		ts.obuf.Reset()
+		ts.nopad = true
+		ts.TPuts(ts.ti.Clear)
+		So(ts.obuf.String(), ShouldEqual, "\x1a")
+	})
+}
+
+func TestSerialConfig(t *testing.T) {
+	Convey("Serial configurations are checked", t, func() {
+		good := SerialConfig{Device: "/dev/ttyS0", Term: "vt100", Baud: 9600}
+		s, e := NewSerialScreen(good)
+		So(e, ShouldBeNil)
+		ts := s.(*tScreen)
+		So(ts.serial.Charset, ShouldEqual, "US-ASCII")
+		w, h := ts.Size()
+		So(w, ShouldEqual, 80)
+		So(h, ShouldEqual, 24)
+
+		bad := []func(*SerialConfig){
+			func(c *SerialConfig) { c.Device = "" },
+			func(c *SerialConfig) { c.Baud = 0 },
+			func(c *SerialConfig) { c.DataBits = 6 },
+			func(c *SerialConfig) { c.StopBits = 3 },
+			func(c *SerialConfig) { c.Parity = ParityOdd + 1 },
+			func(c *SerialConfig) { c.Flow = -1 },
+		}
+		for _, f := range bad {
+			cfg := good
+			f(&cfg)
+			_, e := NewSerialScreen(cfg)
+			So(e, ShouldEqual, ErrSerialConfig)
+		}
+
+		cfg := good
+		cfg.Term = "no-such-terminal"
+		_, e = NewSerialScreen(cfg)
+		So(e, ShouldNotBeNil)
+	})
+}
+
+func TestCygwinPtyName(t *testing.T) {
+	Convey("Cygwin pty pipes are recognized", t, func() {
+		So(isCygwinPtyName(`\msys-1888ae32e00d56aa-pty0-from-master`), ShouldBeTrue)
+		So(isCygwinPtyName(`\cygwin-e022582115c10879-pty4-to-master`), ShouldBeTrue)
+		So(isCygwinPtyName(`\msys-1888ae32e00d56aa-pipe-0x12`), ShouldBeFalse)
+		So(isCygwinPtyName(`\Device\NamedPipe\other`), ShouldBeFalse)
+	})
+}
+
+func TestFrameRate(t *testing.T) {
+	Convey("Frames are limited", t, func() {
+		ts := newTestTScreen("xterm")
+		output := newTestOutput(ts)
+		ts.cells.resize(ts.w, ts.h)
+		ts.EnableRenderStats(true)
+		ts.SetFrameRate(10)
+
+		// the clock only moves when we move it, and scheduled frames
+		// are drawn when we draw them
+		clock := time.Now()
+		var frame func()
+		ts.frames.now = func() time.Time { return clock }
+		ts.frames.after = func(d time.Duration, fn func()) *time.Timer {
+			So(d, ShouldEqual, 100*time.Millisecond)
+			frame = fn
+			return time.NewTimer(time.Hour)
+		}
+
+		ts.SetCell(0, 0, StyleDefault, 'a')
+		ts.Show()
+		So(ts.RenderStats().Frames, ShouldEqual, 1)
+
+		// these are folded into one frame, drawn later
+		ts.SetCell(0, 0, StyleDefault, 'b')
+		ts.Show()
+		ts.SetCell(1, 0, StyleDefault, 'c')
+		ts.Show()
+		So(ts.RenderStats().Frames, ShouldEqual, 1)
+		So(ts.GetCell(1, 0).Dirty, ShouldBeTrue)
+
+		clock = clock.Add(100 * time.Millisecond)
+		So(frame, ShouldNotBeNil)
+		frame()
+		So(ts.RenderStats().Frames, ShouldEqual, 2)
+		So(ts.GetCell(1, 0).Dirty, ShouldBeFalse)
+
+		clock = clock.Add(100 * time.Millisecond)
+		ts.SetCell(2, 0, StyleDefault, 'd')
+		ts.Show()
+		So(ts.RenderStats().Frames, ShouldEqual, 3)
+		ts.SetCell(3, 0, StyleDefault, 'e')
+		ts.Show()
+		So(ts.frames.pending(), ShouldBeTrue)
+
+		Convey("The cursor is not held back", func() {
+			output()
+			ts.ShowCursor(5, 2)
+			ts.Show()
+			So(output(), ShouldContainSubstring, ts.tgoto(5, 2))
+			So(ts.frames.pending(), ShouldBeTrue)
+			So(ts.GetCell(3, 0).Dirty, ShouldBeTrue)
+		})
+
+		Convey("Sync is not held back", func() {
+			ts.Sync()
+			So(ts.RenderStats().Frames, ShouldEqual, 4)
+			So(ts.frames.pending(), ShouldBeFalse)
+		})
+
+		Convey("Without a limit, every Show draws", func() {
+			ts.SetFrameRate(0)
+			ts.Show()
+			So(ts.RenderStats().Frames, ShouldEqual, 4)
+			ts.SetCell(4, 0, StyleDefault, 'f')
+			ts.Show()
+			So(ts.RenderStats().Frames, ShouldEqual, 5)
+		})
+	})
+}
+
+func TestInvisibleDraw(t *testing.T) {
+	Convey("Invisible cells are drawn blank", t, func() {
+		ts := newTestTScreen("xterm")
+		ts.curstyle = StyleDefault
+		ts.cx, ts.cy = 0, 0
+		ts.drawCell(0, 0, &Cell{Ch: []rune{'s'}, Width: 1,
+			Style: StyleDefault.Invisible(true)})
+		So(ts.obuf.String(), ShouldEqual, " ")
+	})
+}
+
+func TestFiniIdempotent(t *testing.T) {
+	Convey("Fini before Init, and twice, does nothing", t, func() {
+		ti, e := LookupTerminfo("xterm")
+		So(e, ShouldBeNil)
+		ts := newTScreen(ti, 0, 0)
+		So(ts.fini, ShouldBeTrue)
+		ts.Fini()
+		ts.Fini()
+		So(ts.fini, ShouldBeTrue)
+		So(ts.obuf.Len(), ShouldEqual, 0)
+	})
+	Convey("Init on a running screen does nothing", t, func() {
+		ts := newTestTScreen("xterm")
+		So(ts.Init(), ShouldBeNil)
+		So(ts.evch, ShouldBeNil)
+	})
+}
+
+func TestWriteRaw(t *testing.T) {
+	Convey("Raw output", t, func() {
+		ts := newTestTScreen("xterm")
+		ts.writing = true // keep the output in obuf
+		ts.curstyle = StyleDefault
+		ts.cx, ts.cy = 3, 4
+
+		Convey("Capabilities are looked up by name", func() {
+			So(ts.Tput("cup", 2, 5), ShouldBeNil)
+			So(ts.obuf.String(), ShouldEqual, "\x1b[3;6H")
+			So(ts.Tput("no-such-cap"), ShouldEqual, ErrNoCapability)
+		})
+
+		Convey("The cursor and style are forgotten", func() {
+			ts.WriteRaw([]byte("\x1b]777;notify;hi\a"))
+			So(ts.obuf.String(), ShouldEqual, "\x1b]777;notify;hi\a")
+			So(ts.cx, ShouldEqual, -1)
+			So(ts.curstyle, ShouldEqual, styleInvalid)
+		})
+
+		Convey("Nothing is written before Init", func() {
+			ts.fini = true
+			ts.WriteRaw([]byte("x"))
+			So(ts.obuf.Len(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestPassthrough(t *testing.T) {
+	Convey("Passthrough for multiplexers", t, func() {
+		for _, v := range []string{"TMUX", "STY", "TCELL_PASSTHROUGH"} {
+			defer os.Setenv(v, os.Getenv(v))
+			os.Setenv(v, "")
+		}
+		So(detectMultiplexer("xterm-256color"), ShouldEqual, muxNone)
+		So(detectMultiplexer("screen-256color"), ShouldEqual, muxScreen)
+		So(detectMultiplexer("tmux-256color"), ShouldEqual, muxTmux)
+		os.Setenv("TMUX", "/tmp/tmux-0/default,1,0")
+		So(detectMultiplexer("screen"), ShouldEqual, muxTmux)
+		os.Setenv("TCELL_PASSTHROUGH", "0")
+		So(detectMultiplexer("screen"), ShouldEqual, muxNone)
+
+		osc := []byte("\x1b]52;c;aGk=\x1b\\")
+		So(string(wrapPassthrough(muxNone, osc)), ShouldEqual, string(osc))
+		So(string(wrapPassthrough(muxTmux, osc)), ShouldEqual,
+			"\x1bPtmux;\x1b\x1b]52;c;aGk=\x1b\x1b\\\x1b\\")
+		long := bytes.Repeat([]byte("x"), screenPassthroughMax+1)
+		So(string(wrapPassthrough(muxScreen, long)), ShouldEqual,
+			"\x1bP"+string(long[1:])+"\x1b\\\x1bPx\x1b\\")
+
+		ts := newTestTScreen("screen")
+		ts.writing = true
+		ts.mux = muxTmux
+		ts.WritePassthrough([]byte("\x1b[5 q"))
+		So(ts.obuf.String(), ShouldEqual, "\x1bPtmux;\x1b\x1b[5 q\x1b\\")
+	})
+}
+
+func TestImages(t *testing.T) {
+	if !imageSupport {
+		t.Skip("built with tcell_noimage")
+	}
+	Convey("Kitty graphics", t, func() {
+		ts := newTestTScreen("xterm")
+		ts.writing = true // keep the output in obuf
+		ts.cells.resize(ts.w, ts.h)
+		ts.draw()
+		ts.obuf.Reset()
+		img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+
+		_, e := ts.PutImage(1, 1, 4, 2, img)
+		So(e, ShouldEqual, ErrNoImages)
+
+		ts.images.proto = imageKitty
+		id, e := ts.PutImage(1, 1, 4, 2, img)
+		So(e, ShouldBeNil)
+		So(ts.obuf.String(), ShouldStartWith, "\x1b_Ga=t,f=100,q=2,i=1,m=0;")
+		ts.obuf.Reset()
+
+		ts.draw()
+		So(ts.obuf.String(), ShouldContainSubstring,
+			"\x1b[2;2H\x1b_Ga=p,q=2,C=1,i=1,c=4,r=2\x1b\\")
+		ts.obuf.Reset()
+
+		Convey("Drawing elsewhere leaves it", func() {
+			ts.SetCell(0, 0, StyleDefault, 'x')
+			ts.draw()
+			So(ts.obuf.String(), ShouldNotContainSubstring, "\x1b_G")
+			So(ts.images.list, ShouldHaveLength, 1)
+		})
+
+		Convey("Drawing over it removes it", func() {
+			ts.SetCell(4, 2, StyleDefault, 'x')
+			ts.draw()
+			So(ts.obuf.String(), ShouldContainSubstring, "\x1b_Ga=d,d=I,q=2,i=1\x1b\\")
+			So(ts.images.list, ShouldBeEmpty)
+		})
+
+		Convey("It can be deleted", func() {
+			ts.DeleteImage(id)
+			So(ts.obuf.String(), ShouldEqual, "\x1b_Ga=d,d=I,q=2,i=1\x1b\\")
+			ts.obuf.Reset()
+			ts.DeleteImage(id)
+			So(ts.obuf.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Drawing over it redraws the rest", func() {
+			ts.SetCell(4, 2, StyleDefault, 'x')
+			ts.draw()
+			So(ts.GetCell(1, 1).Dirty, ShouldBeFalse)
+			So(ts.damage.dirty(&ts.cells), ShouldBeFalse)
+		})
+
+		Convey("iTerm2 images are sent to place them", func() {
+			ts.DeleteImage(id)
+			ts.draw()
+			ts.images.proto = imageITerm
+			_, e := ts.PutImage(0, 0, 2, 1, img)
+			So(e, ShouldBeNil)
+			ts.obuf.Reset()
+			ts.draw()
+			So(ts.obuf.String(), ShouldContainSubstring,
+				"\x1b]1337;File=inline=1;size=")
+			So(ts.obuf.String(), ShouldContainSubstring,
+				";width=2;height=1;preserveAspectRatio=0;doNotMoveCursor=1:")
+			ts.obuf.Reset()
+			ts.SetCell(1, 0, StyleDefault, 'x')
+			ts.draw()
+			So(ts.obuf.String(), ShouldNotContainSubstring, "1337")
+			So(ts.images.list, ShouldBeEmpty)
+		})
+
+		Convey("Large images are sent in chunks", func() {
+			s := kittyTransmit(7, make([]byte, 4000))
+			So(strings.Count(s, "\x1b_G"), ShouldEqual, 2)
+			So(s, ShouldContainSubstring, "i=7,m=1;")
+			So(s, ShouldContainSubstring, "\x1b_Gm=0;")
+		})
+	})
+}
+
+// pipeConn is a connection for a remote screen, whose input is written
+// to the pipe, and whose output is kept.
+type pipeConn struct {
+	*io.PipeReader
+	out bytes.Buffer
+	sync.Mutex
+}
+
+func (pc *pipeConn) Write(b []byte) (int, error) {
+	pc.Lock()
+	defer pc.Unlock()
+	return pc.out.Write(b)
+}
+
+func (pc *pipeConn) output() string {
+	pc.Lock()
+	defer pc.Unlock()
+	return pc.out.String()
+}
+
+func TestRemoteScreen(t *testing.T) {
+	Convey("Remote screens", t, func() {
+		pr, pw := io.Pipe()
+		conn := &pipeConn{PipeReader: pr}
+		var mu sync.Mutex
+		w, h := 40, 10
+		size := func() (int, int) {
+			mu.Lock()
+			defer mu.Unlock()
+			return w, h
+		}
+		s, e := NewRemoteScreen(conn, "xterm", size)
+		So(e, ShouldBeNil)
+		So(s.Init(), ShouldBeNil)
+		cols, rows := s.Size()
+		So(cols, ShouldEqual, 40)
+		So(rows, ShouldEqual, 10)
+		So(conn.output(), ShouldContainSubstring, "\x1b[?1049h")
+
+		Convey("Input is read from the connection", func() {
+			pw.Write([]byte("q"))
+			ev, ok := s.PollEvent().(*EventKey)
+			So(ok, ShouldBeTrue)
+			So(ev.Rune(), ShouldEqual, 'q')
+			s.Fini()
+		})
+
+		Convey("The cursor position is reported", func() {
+			pw.Write([]byte("\x1b[1;2R"))
+			_, ok := s.PollEvent().(*EventKey)
+			So(ok, ShouldBeTrue)
+			go func() {
+				for !strings.Contains(conn.output(), cursorQuery) {
+					time.Sleep(time.Millisecond)
+				}
+				pw.Write([]byte("\x1b[3;7R"))
+			}()
+			cs := s.(CursorPositionScreen)
+			x, y, e := cs.GetCursorPosition()
+			So(e, ShouldBeNil)
+			So([]int{x, y}, ShouldResemble, []int{6, 2})
+			s.Fini()
+			_, _, e = cs.GetCursorPosition()
+			So(e, ShouldEqual, ErrNoCursorPosition)
+		})
+
+		Convey("Window changes are resizes", func() {
+			mu.Lock()
+			w, h = 60, 20
+			mu.Unlock()
+			s.WindowChanged()
+			ev, ok := s.PollEvent().(*EventResize)
+			So(ok, ShouldBeTrue)
+			cols, rows := ev.Size()
+			So(cols, ShouldEqual, 60)
+			So(rows, ShouldEqual, 20)
+			s.Fini()
+		})
+
+		Convey("Fini does not wait for input, which is kept", func() {
+			s.Fini()
+			go pw.Write([]byte("x"))
+			So(s.Init(), ShouldBeNil)
+			ev, ok := s.PollEvent().(*EventKey)
+			So(ok, ShouldBeTrue)
+			So(ev.Rune(), ShouldEqual, 'x')
+			s.Fini()
+		})
+
+		Convey("A lost connection is an error", func() {
+			pw.CloseWithError(io.ErrClosedPipe)
+			ev := s.PollEvent()
+			err, ok := ev.(*EventError)
+			So(ok, ShouldBeTrue)
+			So(err.Error(), ShouldEqual, io.ErrClosedPipe.Error())
+			s.Fini()
+		})
+	})
+}
+
+func TestColorMatching(t *testing.T) {
+	Convey("Colors the terminal lacks are matched", t, func() {
+		ts := newTestTScreen("xterm") // 8 colors
+		style := StyleDefault.Foreground(Color(197)).
+			Background(ColorBrightBlue).UnderlineColor(Color(22))
+		fg, bg, _ := ts.matchStyle(style).Decompose()
+		_, uc := ts.matchStyle(style).DecomposeUnderline()
+		So(fg, ShouldEqual, ColorRed)
+		So(bg, ShouldEqual, ColorBlue)
+		So(uc, ShouldEqual, ColorBlue)
+		So(ts.matchStyle(StyleDefault.Foreground(ColorWhite)),
+			ShouldEqual, StyleDefault.Foreground(ColorWhite))
+
+		Convey("With the chosen distance", func() {
+			ts.SetColorDistance(func(r1, g1, b1, r2, g2, b2 int) int { return 0 })
+			fg, _, _ := ts.matchStyle(style).Decompose()
+			So(fg, ShouldEqual, ColorBlack)
+		})
+
+		Convey("But not on terminals with all of them", func() {
+			ts := newTestTScreen("xterm-256color")
+			So(ts.matchStyle(style), ShouldEqual, style)
+		})
+	})
+}
+
+func TestTrueColor(t *testing.T) {
+	Convey("RGB colors", t, func() {
+		ts := newTestTScreen("xterm-256color")
+		output := newTestOutput(ts)
+		ts.curstyle = styleInvalid
+		cell := &Cell{Ch: []rune{'x'}, Width: 1}
+		cell.Style = StyleDefault.Foreground(NewHexColor(0xff0000)).
+			Background(NewHexColor(0x005f87)).Underline(true).
+			UnderlineColor(NewHexColor(0x102030))
+		ti := *ts.ti
+		ti.SetUlColor = "\x1b[58:2::%p1%{65536}%/%d:%p1%{256}%/%{255}%&%d:%p1%{255}%&%dm"
+		ts.ti = &ti
+
+		Convey("Are shown as palette colors without RGB support", func() {
+			ts.drawCell(0, 0, cell)
+			out := output()
+			So(out, ShouldContainSubstring, ti.TParm(ti.SetFg, 9))
+			So(out, ShouldContainSubstring, ti.TParm(ti.SetBg, 24))
+			So(out, ShouldContainSubstring, "\x1b[58:5:")
+			So(out, ShouldNotContainSubstring, ";2;")
+		})
+
+		Convey("Are sent as they are with RGB support", func() {
+			ti.SetFgRGB = xtermSetFgRGB
+			ti.SetBgRGB = xtermSetBgRGB
+			ts.drawCell(0, 0, cell)
+			out := output()
+			So(out, ShouldContainSubstring, "\x1b[38;2;255;0;0m")
+			So(out, ShouldContainSubstring, "\x1b[48;2;0;95;135m")
+			So(out, ShouldContainSubstring, "\x1b[58:2::16:32:48m")
+		})
+
+		Convey("Are sent when the terminal says it has them", func() {
+			ts.input.devattr.TrueColor = true
+			ts.drawCell(0, 0, cell)
+			So(output(), ShouldContainSubstring, "\x1b[38;2;255;0;0m")
+		})
+	})
+}
+
+func TestScreenOptions(t *testing.T) {
+	Convey("Screen options", t, func() {
+		Convey("Must be valid", func() {
+			_, e := NewTerminfoScreenWithOptions(ScreenOptions{In: os.Stdin})
+			So(e, ShouldEqual, ErrScreenOptions)
+			_, e = NewTerminfoScreenWithOptions(ScreenOptions{EventQueue: -1})
+			So(e, ShouldEqual, ErrScreenOptions)
+		})
+
+		Convey("Choose the terminal and escape delay", func() {
+			s, e := NewTerminfoScreenWithOptions(ScreenOptions{
+				Term:     "rxvt",
+				EscDelay: -1,
+				NoColor:  true,
+			})
+			So(e, ShouldBeNil)
+			ts := s.(*tScreen)
+			So(ts.ti.Name, ShouldStartWith, "rxvt")
+			So(ts.input.escdelay, ShouldEqual, 0)
+			So(s.Colors(), ShouldEqual, 0)
+		})
+
+		Convey("Apply to Init and Fini", func() {
+			pr, pw := io.Pipe()
+			defer pw.Close()
+			conn := &pipeConn{PipeReader: pr}
+			s, e := NewRemoteScreen(conn, "xterm",
+				func() (int, int) { return 80, 24 })
+			So(e, ShouldBeNil)
+			ts := s.(*tScreen)
+			ts.setOptions(ScreenOptions{
+				NoAltScreen: true,
+				Mouse:       true,
+				EventQueue:  3,
+			})
+			So(s.Init(), ShouldBeNil)
+			So(cap(ts.evch), ShouldEqual, 3)
+			So(conn.output(), ShouldNotContainSubstring, ts.ti.EnterCA)
+			So(conn.output(), ShouldContainSubstring,
+				ts.ti.TParm(ts.ti.MouseMode, 1))
+			s.Fini()
+			So(conn.output(), ShouldNotContainSubstring, ts.ti.ExitCA)
+			So(conn.output(), ShouldContainSubstring, "\x1b[24;1H\r\n")
+		})
+	})
+}
+
+// BenchmarkTScreenDraw measures drawing screens full of changing content
+// (as a busy dashboard has) to a terminal.
+func BenchmarkTScreenDraw(b *testing.B) {
+	ti, e := LookupTerminfo("xterm-256color")
+	if e != nil {
+		b.Fatal(e)
+	}
+	ts := &tScreen{ti: ti, w: 200, h: 60, charset: "UTF-8"}
+	ts.input = newInputParser(ti)
+	ts.cells.resize(ts.w, ts.h)
+	ts.curstyle = styleInvalid
+	sp := NewStressPattern(1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		sp.Draw(ts)
+		ts.obuf.Reset()
+		b.StartTimer()
+		ts.draw()
+	}
+}
@@ -19,7 +19,9 @@ package tcell
 // On win32 we don't have support for termios.  We probably could, and
 // may should, in a cygwin type environment.  Its not clear how to make
 // this all work nicely with both cygwin and Windows console, so we
-// decline to do so here.
+// decline to do so here.  (The line discipline of a cygwin pty belongs to
+// the cygwin runtime, which native programs cannot get at; see
+// ErrCygwinPty.)
 
 import (
 	"errors"
@@ -34,6 +36,18 @@ func (t *tScreen) termioFini() {
 	return
 }
 
+func (t *tScreen) savedTermios() []byte {
+	return nil
+}
+
+func (t *tScreen) setOutputPost() error {
+	return errors.New("no termios on Windows")
+}
+
+func (t *tScreen) getPixelSize() (int, int, error) {
+	return 0, 0, errors.New("no temrios on Windows")
+}
+
 func (t *tScreen) getWinSize() (int, int, error) {
 	return 0, 0, errors.New("no temrios on Windows")
 }
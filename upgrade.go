@@ -0,0 +1,174 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Some terminal descriptions undersell the terminals that use them most
+// often.  The Linux console has supported the 256 color and direct color
+// SGR forms since kernel 3.16, but its terminfo entry still reports 8
+// colors, as it must for older kernels.  And TERM=screen is what tmux
+// sets by default, although tmux always supports 256 colors; likewise
+// GNU screen, when run within a terminal that advertises more colors
+// through $COLORTERM.  For these, upgradeTerminfo returns an upgraded
+// description.  Setting $TCELL_UPGRADE_TERM to 0 disables this.
+//
+// The kernel that matters is that of the console the user is at, which
+// is only known to be the one we run on if our terminal is one of its
+// virtual consoles.  (With TERM=linux over ssh, say, the console is that
+// of some other machine.)  Setting $TCELL_UPGRADE_TERM to 1 upgrades the
+// Linux console entry anyway, for users who know that their console is
+// recent enough.
+
+// kernelRelease returns the release of the running Linux kernel, or the
+// empty string if it is not Linux.  Tests replace it.
+var kernelRelease = func() string {
+	b, e := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if e != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// localConsole reports whether the controlling terminal of the process
+// (the one /dev/tty opens) is a virtual console of the running Linux
+// kernel, such as /dev/tty1.  Tests replace it.
+var localConsole = func() bool {
+	b, e := ioutil.ReadFile("/proc/self/stat")
+	if e != nil {
+		return false
+	}
+	// the command name, in parentheses, may contain anything, so the
+	// fields that follow it are counted from the last parenthesis
+	s := string(b)
+	i := strings.LastIndex(s, ")")
+	if i < 0 {
+		return false
+	}
+	// state, ppid, pgrp, session, tty_nr
+	f := strings.Fields(s[i+1:])
+	if len(f) < 5 {
+		return false
+	}
+	dev, e := strconv.Atoi(f[4])
+	if e != nil {
+		return false
+	}
+	major := (dev >> 8) & 0xfff
+	minor := (dev & 0xff) | ((dev >> 12) & 0xfff00)
+	// the virtual consoles are tty1 through tty63, with major number 4
+	return major == 4 && minor >= 1 && minor <= 63
+}
+
+// upgradeTerminfo returns a better description of the terminal, if we
+// know that it can do more than its entry says, or else ti itself.
+func upgradeTerminfo(ti *Terminfo) *Terminfo {
+	force := false
+	switch os.Getenv("TCELL_UPGRADE_TERM") {
+	case "0":
+		return ti
+	case "1":
+		force = true
+	}
+	switch ti.Name {
+	case "linux":
+		if force || (localConsole() && kernelAtLeast(kernelRelease(), 3, 16)) {
+			return linux256(ti)
+		}
+	case "screen":
+		if os.Getenv("TMUX") != "" || os.Getenv("COLORTERM") != "" {
+			if up, e := LookupTerminfo("screen-256color"); e == nil {
+				return up
+			}
+		}
+	}
+	return ti
+}
+
+// linux256 returns a copy of the Linux console entry that uses 256
+// colors.  The console approximates these (and direct colors) with its
+// 16 color palette.  The bright colors are selected with 38;5 rather
+// than 90-97, since kernels that understand the former may not
+// understand the latter.
+func linux256(ti *Terminfo) *Terminfo {
+	up := *ti
+	up.Colors = 256
+	up.SetFg = "\x1b[%?%p1%{8}%<%t3%p1%d%e38;5;%p1%d%;m"
+	up.SetBg = "\x1b[%?%p1%{8}%<%t4%p1%d%e48;5;%p1%d%;m"
+	up.SetFgRGB = "\x1b[38;2;%p1%d;%p2%d;%p3%dm"
+	up.SetBgRGB = "\x1b[48;2;%p1%d;%p2%d;%p3%dm"
+	return &up
+}
+
+// The 256 color forms of setaf and setab, as xterm-256color has them.
+const (
+	xtermSetFg256 = "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m"
+	xtermSetBg256 = "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m"
+)
+
+// deriveTerminfo returns a description for a terminal that is missing
+// from the database, but whose name is that of one that is there, with
+// -256color or -direct added, as users (and terminals) often set $TERM.
+// The description is a copy of the known one, with the usual xterm
+// sequences for 256 colors (if it has fewer), and for -direct, for RGB
+// colors as well.  It returns nil if the name has neither suffix, or the
+// terminal is not known.
+func deriveTerminfo(name string) *Terminfo {
+	var base string
+	direct := false
+	switch {
+	case strings.HasSuffix(name, "-256color"):
+		base = strings.TrimSuffix(name, "-256color")
+	case strings.HasSuffix(name, "-direct"):
+		base = strings.TrimSuffix(name, "-direct")
+		direct = true
+	default:
+		return nil
+	}
+	ti, e := LookupTerminfo(base)
+	if e != nil {
+		return nil
+	}
+	up := *ti
+	up.Name = name
+	up.Aliases = nil
+	if up.Colors < 256 {
+		up.Colors = 256
+		up.SetFg, up.SetBg = xtermSetFg256, xtermSetBg256
+	}
+	if direct {
+		up.SetFgRGB, up.SetBgRGB = xtermSetFgRGB, xtermSetBgRGB
+	}
+	return &up
+}
+
+// kernelAtLeast reports whether the kernel release (such as
+// "5.15.0-91-generic") is at least major.minor.
+func kernelAtLeast(release string, major, minor int) bool {
+	f := strings.FieldsFunc(release, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if len(f) < 2 || !strings.HasPrefix(release, f[0]) {
+		return false
+	}
+	maj, _ := strconv.Atoi(f[0])
+	min, _ := strconv.Atoi(f[1])
+	return maj > major || (maj == major && min >= minor)
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// The widths of most characters are well defined, but there are two
+// classes on which terminals disagree: characters of ambiguous East Asian
+// width (such as ① or many of the box drawing characters), which are wide
+// on terminals set up for CJK use, and emoji, which terminals that predate
+// Unicode 9 treat as narrow.  When the widths that we assume differ from
+// those used by the terminal, borders and columns end up misaligned.
+//
+// If the TCELL_PROBE_WIDTH environment variable is set to 1, then the
+// terminal screen measures how the terminal advances the cursor for a
+// sample of each class during Init, and the widths of all characters of
+// that class are calibrated accordingly.
+
+// widthCal holds the calibrated widths, or 0 where we go by the tables.
+var widthCal struct {
+	ambiguous int
+	emoji     int
+	sync.RWMutex
+}
+
+var (
+	narrowCond = &runewidth.Condition{EastAsianWidth: false}
+	wideCond   = &runewidth.Condition{EastAsianWidth: true}
+)
+
+// These are the samples used to probe each class.
+const (
+	probeAmbiguous = '①' // circled digit one
+	probeEmoji     = '\U0001F600'
+)
+
+// runeWidth returns the width of the rune, taking the calibration into
+// account.
+func runeWidth(r rune) int {
+	w := runewidth.RuneWidth(r)
+	widthCal.RLock()
+	amb, emoji := widthCal.ambiguous, widthCal.emoji
+	widthCal.RUnlock()
+	switch {
+	case amb != 0 && isAmbiguousWidth(r):
+		return amb
+	case emoji != 0 && w == 2 && r >= 0x1F000 && r <= 0x1FAFF:
+		return emoji
+	}
+	return w
+}
+
+func isAmbiguousWidth(r rune) bool {
+	return narrowCond.RuneWidth(r) == 1 && wideCond.RuneWidth(r) == 2
+}
+
+// calibrateWidths sets the widths to use for each class; a width other
+// than 1 or 2 leaves the class as it is.
+func calibrateWidths(ambiguous, emoji int) {
+	widthCal.Lock()
+	if ambiguous == 1 || ambiguous == 2 {
+		widthCal.ambiguous = ambiguous
+	}
+	if emoji == 1 || emoji == 2 {
+		widthCal.emoji = emoji
+	}
+	widthCal.Unlock()
+}
+
+// parseCursorReports extracts the cursor position reports, CSI row ; col
+// R, that are for the given row (counted from 0) from the input.  It
+// returns the columns reported, counted from 0, and the rest of the
+// input, which is left for the input parser.  Reports for other rows
+// are left alone, as CSI 1 ; 2 R is also Shift-F3 on some terminals.
+func parseCursorReports(b []byte, row int) ([]int, []byte) {
+	var cols []int
+	var rest []byte
+	prefix := []byte("\x1b[" + strconv.Itoa(row+1) + ";")
+	for len(b) > 0 {
+		i := bytes.Index(b, prefix)
+		if i < 0 {
+			break
+		}
+		j := i + len(prefix)
+		k := j
+		for k < len(b) && b[k] >= '0' && b[k] <= '9' {
+			k++
+		}
+		if k == j || k == len(b) || b[k] != 'R' {
+			rest = append(rest, b[:j]...)
+			b = b[j:]
+			continue
+		}
+		col, _ := strconv.Atoi(string(b[j:k]))
+		cols = append(cols, col-1)
+		rest = append(rest, b[:i]...)
+		b = b[k+1:]
+	}
+	return cols, append(rest, b...)
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcell
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWidthCalibration(t *testing.T) {
+	Convey("Cursor reports are extracted from input", t, func() {
+		cols, rest := parseCursorReports(
+			[]byte("a\x1b[2;2R\x1b[1;2Rb\x1b[2;3R"), 1)
+		So(cols, ShouldResemble, []int{1, 2})
+		So(string(rest), ShouldEqual, "a\x1b[1;2Rb")
+
+		cols, rest = parseCursorReports([]byte("\x1b[2;"), 1)
+		So(len(cols), ShouldEqual, 0)
+		So(string(rest), ShouldEqual, "\x1b[2;")
+	})
+
+	Convey("Calibration changes the widths of a class", t, func() {
+		Reset(func() {
+			widthCal.Lock()
+			widthCal.ambiguous, widthCal.emoji = 0, 0
+			widthCal.Unlock()
+		})
+		So(runeWidth(probeAmbiguous), ShouldEqual, 1)
+		So(runeWidth(probeEmoji), ShouldEqual, 2)
+
+		calibrateWidths(2, 1)
+		So(runeWidth('②'), ShouldEqual, 2)
+		So(runeWidth('\U0001F680'), ShouldEqual, 1)
+		So(runeWidth('a'), ShouldEqual, 1)
+		So(runeWidth('中'), ShouldEqual, 2)
+
+		c := &Cell{}
+		c.PutChar('①')
+		So(c.Width, ShouldEqual, 2)
+
+		calibrateWidths(0, 5)
+		So(runeWidth('②'), ShouldEqual, 2)
+	})
+}